@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+
+	"go-git-demo/ants-demo/workpool"
+)
+
+// autoscaleExample 演示根据耗时和积压自动调整协程池容量
+// 模拟的下游同时只能处理6个请求，超过后每个请求都会变慢，固定的大容量只会让所有请求一起变慢
+func autoscaleExample() {
+	pool, err := ants.NewPool(2)
+	if err != nil {
+		log.Fatalf("创建协程池失败: %v", err)
+	}
+	defer pool.Release()
+
+	scaler, err := workpool.NewAutoscaler(pool, workpool.AutoscaleConfig{
+		Min:           2,
+		Max:           20,
+		TargetLatency: 60 * time.Millisecond,
+		Increase:      2,
+	})
+	if err != nil {
+		log.Fatalf("创建Autoscaler失败: %v", err)
+	}
+
+	var mu sync.Mutex
+	inflight := 0
+	downstream := func() {
+		mu.Lock()
+		inflight++
+		slowdown := float64(inflight) / 6
+		mu.Unlock()
+		if slowdown < 1 {
+			slowdown = 1
+		}
+		time.Sleep(time.Duration(float64(40*time.Millisecond) * slowdown))
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+	}
+
+	// 前2秒每8ms到达一个请求（125/s），之后停止
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(8 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := scaler.Submit(downstream); err != nil {
+						log.Printf("提交任务失败: %v", err)
+					}
+				}()
+			}
+		}
+	}()
+
+	fmt.Println("🚀 前2秒持续有请求，之后空闲1秒，每200ms调整一次容量")
+	for i := 0; i < 15; i++ {
+		if i == 10 {
+			close(stop)
+		}
+		time.Sleep(200 * time.Millisecond)
+		m := scaler.Tick()
+		fmt.Printf("   容量 %2d -> %-2d %-8s 排队:%-4d 吞吐:%4.0f/s 平均耗时:%s\n",
+			m.Capacity, m.NewCapacity, m.Reason, m.Queued, m.Throughput, m.AvgLatency.Round(time.Millisecond))
+	}
+	wg.Wait()
+}
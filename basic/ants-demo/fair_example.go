@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+
+	"go-git-demo/ants-demo/workpool"
+)
+
+// fairSchedulerExample 演示优先级和租户公平调度
+// 租户noisy一次提交了30个任务，quiet只提交了3个；直接提交到协程池时quiet要等noisy的任务全部执行完
+func fairSchedulerExample() {
+	pool, err := ants.NewPool(2)
+	if err != nil {
+		log.Fatalf("创建协程池失败: %v", err)
+	}
+	defer pool.Release()
+
+	s := workpool.NewFairScheduler(pool, workpool.WithMaxQueue(50))
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var order []string
+	submit := func(tenant string, priority workpool.Priority, n int) {
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			label := fmt.Sprintf("%s-%d", tenant, i)
+			err := s.Submit(tenant, priority, func() {
+				defer wg.Done()
+				time.Sleep(20 * time.Millisecond)
+				mu.Lock()
+				order = append(order, label)
+				mu.Unlock()
+			})
+			if err != nil {
+				log.Printf("提交任务失败: %v", err)
+				wg.Done()
+			}
+		}
+	}
+
+	fmt.Println("🚀 noisy提交30个普通任务，quiet提交3个普通任务，alert提交1个高优先级任务")
+	submit("noisy", workpool.Normal, 30)
+	submit("quiet", workpool.Normal, 3)
+	submit("alert", workpool.High, 1)
+	wg.Wait()
+
+	fmt.Printf("📋 前10个执行的任务: %v\n", order[:10])
+	fmt.Println("📊 各租户统计:")
+	for _, st := range s.Stats() {
+		fmt.Printf("   %-6s %-6s 提交:%-3d 执行:%-3d 平均等待:%-8s 最长等待:%s\n",
+			st.Tenant, st.Priority, st.Submitted, st.Dispatched,
+			st.AvgWait.Round(time.Millisecond), st.MaxWait.Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+
+	"go-git-demo/ants-demo/workpool"
+)
+
+// futureExample 演示带返回值的Future和出错即停的任务组
+func futureExample() {
+	pool, err := ants.NewPool(4)
+	if err != nil {
+		log.Fatalf("创建协程池失败: %v", err)
+	}
+	defer pool.Release()
+
+	// 并发计算几个数的平方，按提交顺序拿到结果
+	var futures []*workpool.Future[int]
+	for i := 1; i <= 5; i++ {
+		n := i
+		futures = append(futures, workpool.Submit(pool, func(ctx context.Context) (int, error) {
+			time.Sleep(time.Duration(6-n) * 10 * time.Millisecond)
+			return n * n, nil
+		}))
+	}
+	squares, err := workpool.AwaitAll(context.Background(), futures...)
+	fmt.Printf("📦 AwaitAll 结果: %v, 错误: %v\n", squares, err)
+
+	// 同时请求多个副本，取最先成功的一个
+	replica := func(name string, d time.Duration, fail bool) func(ctx context.Context) (string, error) {
+		return func(ctx context.Context) (string, error) {
+			select {
+			case <-time.After(d):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+			if fail {
+				return "", fmt.Errorf("%s 不可用", name)
+			}
+			return name, nil
+		}
+	}
+	i, name, err := workpool.AwaitAny(context.Background(),
+		workpool.Submit(pool, replica("replica-a", 50*time.Millisecond, false)),
+		workpool.Submit(pool, replica("replica-b", 10*time.Millisecond, true)),
+		workpool.Submit(pool, replica("replica-c", 20*time.Millisecond, false)),
+	)
+	fmt.Printf("🏁 AwaitAny 第%d个最先成功: %s, 错误: %v\n", i, name, err)
+
+	// 任务中的panic变成带调用栈的错误
+	_, err = workpool.Submit(pool, func(ctx context.Context) (int, error) {
+		var items []int
+		return items[3], nil
+	}).Await(context.Background())
+	var pe *workpool.PanicError
+	if errors.As(err, &pe) {
+		fmt.Printf("💥 任务panic: %v（调用栈%d字节）\n", pe.Value, len(pe.Stack))
+	}
+
+	// 任务组：第3个任务失败后取消其余任务，之后的Go不再提交
+	g, ctx := workpool.NewGroup(context.Background(), pool)
+	for i := 1; i <= 10; i++ {
+		n := i
+		if !g.Go(func(ctx context.Context) error {
+			if n == 3 {
+				return fmt.Errorf("任务%d处理失败", n)
+			}
+			select {
+			case <-time.After(100 * time.Millisecond):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}) {
+			fmt.Printf("⛔ 任务组已经失败，任务%d没有提交\n", n)
+			break
+		}
+		if n == 3 {
+			<-ctx.Done()
+		}
+	}
+	fmt.Printf("🧯 Group.Wait 返回: %v\n", g.Wait())
+}
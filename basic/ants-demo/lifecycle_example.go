@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+
+	"go-git-demo/ants-demo/workpool"
+)
+
+// gracefulShutdownExample 演示优雅关闭：停止接收任务，执行完排队的任务，超过截止时间的任务列入报告
+func gracefulShutdownExample() {
+	pool, err := ants.NewPool(2)
+	if err != nil {
+		log.Fatalf("创建协程池失败: %v", err)
+	}
+	// 协程池交给Lifecycle管理，不再defer pool.Release()
+	lc := workpool.NewLifecycle(pool)
+
+	lc.SubmitNamed("export-report", func() {
+		select {
+		case <-time.After(5 * time.Second):
+			fmt.Println("   export-report 完成")
+		case <-lc.Context().Done():
+			fmt.Println("   export-report 收到取消，保存进度后退出")
+		}
+	})
+	for i := 1; i <= 6; i++ {
+		id := fmt.Sprintf("email-%d", i)
+		lc.SubmitNamed(id, func() {
+			time.Sleep(100 * time.Millisecond)
+			fmt.Printf("   %s 发送完成\n", id)
+		})
+	}
+
+	// 服务中ShutdownOnSignal等待SIGINT/SIGTERM，这里用50ms后结束的ctx代替信号
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	fmt.Println("🛑 50ms后开始关闭，最多等待400ms")
+	report, err := lc.ShutdownOnSignal(ctx, 400*time.Millisecond)
+
+	if err := lc.Submit(func() {}); err != nil {
+		fmt.Printf("🚫 关闭后提交任务: %v\n", err)
+	}
+	fmt.Printf("📋 关闭期间执行完 %d 个任务, 错误: %v\n", report.Drained, err)
+	for _, task := range report.Abandoned {
+		fmt.Printf("   被放弃: %-14s 状态: %-8s 已等待: %s\n", task.ID, task.State, time.Since(task.Since).Round(10*time.Millisecond))
+	}
+	time.Sleep(100 * time.Millisecond) // 等被放弃的任务打印退出信息
+}
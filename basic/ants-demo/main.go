@@ -32,6 +32,36 @@ func main() {
 	fmt.Println(strings.Repeat("=", 50))
 	poolConfigExample()
 
+	// 示例5: 优先级和租户公平调度
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("示例5: 优先级和租户公平调度")
+	fmt.Println(strings.Repeat("=", 50))
+	fairSchedulerExample()
+
+	// 示例6: 带返回值的Future和任务组
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("示例6: 带返回值的Future和任务组")
+	fmt.Println(strings.Repeat("=", 50))
+	futureExample()
+
+	// 示例7: 根据耗时和积压自动调整容量
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("示例7: 根据耗时和积压自动调整容量")
+	fmt.Println(strings.Repeat("=", 50))
+	autoscaleExample()
+
+	// 示例8: 优雅关闭
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("示例8: 优雅关闭")
+	fmt.Println(strings.Repeat("=", 50))
+	gracefulShutdownExample()
+
+	// 示例9: 多阶段流水线
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("示例9: 多阶段流水线")
+	fmt.Println(strings.Repeat("=", 50))
+	pipelineExample()
+
 	// 第三部分：综合示例
 	fmt.Println("\n" + strings.Repeat("=", 50))
 	fmt.Println("第三部分：综合并发安全性验证")
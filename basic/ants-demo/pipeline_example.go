@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-git-demo/ants-demo/workpool/pipeline"
+)
+
+type order struct {
+	ID     int
+	User   string
+	Amount float64
+}
+
+// pipelineExample 演示多阶段流水线：解析 -> 查询汇率换算 -> 按顺序写入
+// 每个阶段有自己的协程池，格式错误的行进入死信，空行被过滤
+func pipelineExample() {
+	lines := []string{
+		"1,alice,12.5", "2,bob,8", "", "3,carol,abc", "4,dave,99.9",
+		"5,erin,3.2", "6,frank,-1", "7,grace,42", "8,heidi,7.7",
+	}
+
+	p := pipeline.New(context.Background(), pipeline.WithDeadLetter(func(d pipeline.DeadLetter) {
+		fmt.Printf("   ☠️  死信 [%s] %v: %v\n", d.Stage, d.Item, d.Err)
+	}))
+
+	src := pipeline.FromSlice(p, "csv", lines)
+	parsed := pipeline.Map(src, "parse", func(ctx context.Context, line string) (order, error) {
+		if line == "" {
+			return order{}, pipeline.ErrSkip
+		}
+		fields := strings.Split(line, ",")
+		id, _ := strconv.Atoi(fields[0])
+		amount, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return order{}, err
+		}
+		return order{ID: id, User: fields[1], Amount: amount}, nil
+	}, pipeline.WithWorkers(2), pipeline.WithOrdered())
+
+	// 查询汇率是慢操作，用更多worker；前后两个阶段都WithOrdered，写入顺序与输入一致
+	converted := pipeline.Map(parsed, "convert", func(ctx context.Context, o order) (order, error) {
+		time.Sleep(time.Duration(10+o.ID*7%30) * time.Millisecond)
+		if o.Amount < 0 {
+			return order{}, errors.New("金额不能为负数")
+		}
+		o.Amount *= 7.1
+		return o, nil
+	}, pipeline.WithWorkers(4), pipeline.WithOrdered())
+
+	pipeline.Sink(converted, "write", func(ctx context.Context, o order) error {
+		fmt.Printf("   ✍️  写入订单 %d %-6s ¥%.2f\n", o.ID, o.User, o.Amount)
+		return nil
+	}, pipeline.WithWorkers(1))
+
+	if err := p.Wait(); err != nil {
+		fmt.Printf("❌ 流水线失败: %v\n", err)
+	}
+	fmt.Println("📊 各阶段统计:")
+	for _, st := range p.Stats() {
+		fmt.Printf("   %-8s 输入:%-3d 输出:%-3d 跳过:%-3d 失败:%d\n", st.Name, st.In, st.Out, st.Skipped, st.Failed)
+	}
+}
@@ -0,0 +1,257 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBadConfig 自动扩缩容配置不合法
+var ErrBadConfig = errors.New("workpool: invalid autoscale config")
+
+// Tunable 可以在运行中调整容量的协程池，*ants.Pool实现了这个接口
+// 注意ants对预分配（PreAlloc）和不限容量的协程池调用Tune不生效
+type Tunable interface {
+	Submitter
+	Cap() int
+	Tune(size int)
+}
+
+// AutoscaleConfig 自动扩缩容配置
+type AutoscaleConfig struct {
+	Min int // 最小容量，默认1
+	Max int // 最大容量，必须设置
+
+	// TargetLatency 任务执行耗时的目标值，一个周期内的平均耗时超过它认为下游已经过载，
+	// 容量按Backoff乘性减小；0表示不根据耗时调整
+	TargetLatency time.Duration
+
+	Interval time.Duration // Run的调整周期，默认1秒
+	Increase int           // 有积压时每个周期增加的容量，默认1
+	Backoff  float64       // 耗时超标时容量乘以这个系数，取值(0,1)，默认0.75
+
+	// QueueLen 返回协程池外部排队的任务数，例如FairScheduler各队列的Queued之和，可以为nil
+	QueueLen func() int
+
+	Now func() time.Time // 时钟，默认time.Now，测试时替换
+}
+
+// AutoscaleMetrics 一个调整周期内的统计和调整结果
+type AutoscaleMetrics struct {
+	Capacity    int           // 调整前的容量
+	NewCapacity int           // 调整后的容量
+	Reason      string        // latency、saturated、backlog、idle或steady
+	Queued      int           // 周期结束时等待执行的任务数
+	PeakRunning int           // 周期内同时执行的最大任务数
+	Completed   int           // 周期内完成的任务数
+	Rejected    int           // 周期内提交失败的任务数，例如非阻塞协程池已满
+	Throughput  float64       // 每秒完成的任务数
+	AvgLatency  time.Duration // 周期内完成的任务的平均执行耗时，不含排队时间
+}
+
+// Autoscaler 根据任务耗时、积压和吞吐量调整协程池容量，算法是AIMD：
+//   - 平均耗时超过TargetLatency：容量乘以Backoff（乘性减小）
+//   - 有任务在排队或者被拒绝：容量加Increase（加性增长）；但上个周期刚增加过容量、
+//     吞吐量却没有相应提高时，说明瓶颈不在协程数，撤销上次增加的容量
+//   - 没有积压且同时执行的任务不到容量的一半：容量减Increase，空闲时慢慢回到Min
+//
+// 容量减小后，之前已经开始的任务的耗时不再计入，避免同一次过载连续触发多次减小
+type Autoscaler struct {
+	pool Tunable
+	cfg  AutoscaleConfig
+
+	mu          sync.Mutex
+	pending     int // 通过Submit提交但还没开始执行
+	running     int
+	peak        int
+	completed   int
+	rejected    int
+	latencySum  time.Duration
+	latencyN    int
+	epoch       uint64 // 每次减小容量加1
+	windowStart time.Time
+
+	// 上个周期因为积压增加了容量时，grewFrom是增加前的容量和吞吐量
+	grew           bool
+	grewFrom       int
+	grewThroughput float64
+}
+
+// NewAutoscaler 创建Autoscaler，pool当前的容量超出[Min, Max]时立即调整到边界
+func NewAutoscaler(pool Tunable, cfg AutoscaleConfig) (*Autoscaler, error) {
+	if cfg.Min == 0 {
+		cfg.Min = 1
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.Increase == 0 {
+		cfg.Increase = 1
+	}
+	if cfg.Backoff == 0 {
+		cfg.Backoff = 0.75
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	switch {
+	case cfg.Min < 1 || cfg.Max < cfg.Min:
+		return nil, fmt.Errorf("%w: need 1 <= min <= max, got min=%d max=%d", ErrBadConfig, cfg.Min, cfg.Max)
+	case cfg.Backoff <= 0 || cfg.Backoff >= 1:
+		return nil, fmt.Errorf("%w: backoff %v not in (0, 1)", ErrBadConfig, cfg.Backoff)
+	case cfg.Increase < 0 || cfg.Interval < 0 || cfg.TargetLatency < 0:
+		return nil, fmt.Errorf("%w: increase, interval and target latency must not be negative", ErrBadConfig)
+	}
+
+	a := &Autoscaler{pool: pool, cfg: cfg, windowStart: cfg.Now()}
+	if c := a.clamp(pool.Cap()); c != pool.Cap() {
+		pool.Tune(c)
+	}
+	return a, nil
+}
+
+// Submit 提交任务并统计排队和执行耗时
+// 阻塞模式的ants协程池已满时Submit会阻塞，阻塞期间任务计入Queued
+func (a *Autoscaler) Submit(task func()) error {
+	if task == nil {
+		return ErrBadRequest
+	}
+	a.mu.Lock()
+	a.pending++
+	a.mu.Unlock()
+
+	err := a.pool.Submit(func() {
+		a.mu.Lock()
+		a.pending--
+		a.mu.Unlock()
+		a.Wrap(task)()
+	})
+	if err != nil {
+		a.mu.Lock()
+		a.pending--
+		a.rejected++
+		a.mu.Unlock()
+	}
+	return err
+}
+
+// Wrap 返回统计执行耗时的任务，用于不经过Submit提交到协程池的任务，
+// 例如交给FairScheduler的任务，此时排队数通过AutoscaleConfig.QueueLen提供
+func (a *Autoscaler) Wrap(task func()) func() {
+	return func() {
+		start, epoch := a.begin()
+		defer a.end(start, epoch)
+		task()
+	}
+}
+
+func (a *Autoscaler) begin() (time.Time, uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.running++
+	if a.running > a.peak {
+		a.peak = a.running
+	}
+	return a.cfg.Now(), a.epoch
+}
+
+func (a *Autoscaler) end(start time.Time, epoch uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.running--
+	a.completed++
+	if epoch == a.epoch {
+		a.latencySum += a.cfg.Now().Sub(start)
+		a.latencyN++
+	}
+}
+
+// Tick 结束当前统计周期，计算新容量并调用pool.Tune，返回这个周期的统计
+// Run按Interval调用Tick，测试中可以配合假时钟直接调用
+func (a *Autoscaler) Tick() AutoscaleMetrics {
+	queueLen := 0
+	if a.cfg.QueueLen != nil {
+		queueLen = a.cfg.QueueLen()
+	}
+
+	a.mu.Lock()
+	now := a.cfg.Now()
+	m := AutoscaleMetrics{
+		Capacity:    a.pool.Cap(),
+		Queued:      a.pending + queueLen,
+		PeakRunning: a.peak,
+		Completed:   a.completed,
+		Rejected:    a.rejected,
+	}
+	if elapsed := now.Sub(a.windowStart); elapsed > 0 {
+		m.Throughput = float64(a.completed) / elapsed.Seconds()
+	}
+	if a.latencyN > 0 {
+		m.AvgLatency = a.latencySum / time.Duration(a.latencyN)
+	}
+	m.NewCapacity, m.Reason = a.decide(m)
+	if m.NewCapacity < m.Capacity {
+		a.epoch++
+	}
+	a.grew = m.Reason == "backlog" && m.NewCapacity > m.Capacity
+	a.grewFrom, a.grewThroughput = m.Capacity, m.Throughput
+
+	a.windowStart = now
+	a.peak = a.running
+	a.completed, a.rejected = 0, 0
+	a.latencySum, a.latencyN = 0, 0
+	a.mu.Unlock()
+
+	if m.NewCapacity != m.Capacity {
+		a.pool.Tune(m.NewCapacity)
+	}
+	return m
+}
+
+// decide 计算新容量，调用方必须持有a.mu
+func (a *Autoscaler) decide(m AutoscaleMetrics) (int, string) {
+	c := m.Capacity
+	switch {
+	case a.cfg.TargetLatency > 0 && a.latencyN > 0 && m.AvgLatency > a.cfg.TargetLatency:
+		n := int(float64(c) * a.cfg.Backoff)
+		if n >= c {
+			n = c - 1
+		}
+		return a.clamp(n), "latency"
+	case m.Queued > 0 || m.Rejected > 0:
+		// 容量从grewFrom增加到c，吞吐量至少要按比例提高一半才算有效
+		if a.grew && m.Throughput < a.grewThroughput*(1+float64(c-a.grewFrom)/float64(a.grewFrom)/2) {
+			return a.clamp(a.grewFrom), "saturated"
+		}
+		return a.clamp(c + a.cfg.Increase), "backlog"
+	case m.PeakRunning*2 < c:
+		return a.clamp(c - a.cfg.Increase), "idle"
+	}
+	return a.clamp(c), "steady"
+}
+
+func (a *Autoscaler) clamp(n int) int {
+	if n < a.cfg.Min {
+		return a.cfg.Min
+	}
+	if n > a.cfg.Max {
+		return a.cfg.Max
+	}
+	return n
+}
+
+// Run 每隔Interval调整一次容量，直到ctx结束
+func (a *Autoscaler) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.Tick()
+		}
+	}
+}
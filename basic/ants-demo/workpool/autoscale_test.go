@@ -0,0 +1,281 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// simPool 只记录容量的协程池，任务由simulation驱动执行
+type simPool struct {
+	capacity int
+	tunes    int
+}
+
+func (p *simPool) Submit(task func()) error { return errors.New("simPool: use simulation") }
+func (p *simPool) Cap() int                 { return p.capacity }
+func (p *simPool) Tune(size int)            { p.capacity = size; p.tunes++ }
+
+// simulation 用离散时间模拟一个容量有限的下游：同时执行的任务超过limit后，
+// 每个任务的耗时按 base * 并发数/limit 变长，吞吐量不再增加
+type simulation struct {
+	t     *testing.T
+	clock *fakeClock
+	pool  *simPool
+	a     *Autoscaler
+
+	limit   int
+	base    time.Duration
+	queued  int
+	credit  float64
+	running []simTask
+}
+
+type simTask struct {
+	start, end time.Time
+	epoch      uint64
+}
+
+func newSimulation(t *testing.T, limit int, base time.Duration, cfg AutoscaleConfig) *simulation {
+	t.Helper()
+	s := &simulation{t: t, clock: newFakeClock(), pool: &simPool{capacity: 1}, limit: limit, base: base}
+	cfg.Now = s.clock.Now
+	cfg.QueueLen = func() int { return s.queued }
+	a, err := NewAutoscaler(s.pool, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.a = a
+	return s
+}
+
+// run 以每秒rate个任务的速度到达，模拟d时间，每隔interval调用一次Tick，返回每次Tick的统计
+func (s *simulation) run(d, interval time.Duration, rate float64) []AutoscaleMetrics {
+	const dt = 10 * time.Millisecond
+	var out []AutoscaleMetrics
+	for elapsed := time.Duration(0); elapsed < d; elapsed += dt {
+		s.clock.Advance(dt)
+		now := s.clock.Now()
+
+		kept := s.running[:0]
+		for _, task := range s.running {
+			if task.end.After(now) {
+				kept = append(kept, task)
+				continue
+			}
+			s.a.end(task.start, task.epoch)
+		}
+		s.running = kept
+
+		s.credit += rate * dt.Seconds()
+		for ; s.credit >= 1; s.credit-- {
+			s.queued++
+		}
+		for s.queued > 0 && len(s.running) < s.pool.Cap() {
+			s.queued--
+			start, epoch := s.a.begin()
+			slowdown := float64(len(s.running)+1) / float64(s.limit)
+			if slowdown < 1 {
+				slowdown = 1
+			}
+			latency := time.Duration(float64(s.base) * slowdown)
+			s.running = append(s.running, simTask{start: start, end: start.Add(latency), epoch: epoch})
+		}
+
+		if (elapsed+dt)%interval == 0 {
+			m := s.a.Tick()
+			if m.NewCapacity < 1 || m.NewCapacity > s.a.cfg.Max {
+				s.t.Fatalf("capacity %d out of bounds", m.NewCapacity)
+			}
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func capRange(ms []AutoscaleMetrics) (lo, hi int) {
+	lo, hi = ms[0].NewCapacity, ms[0].NewCapacity
+	for _, m := range ms {
+		if m.NewCapacity < lo {
+			lo = m.NewCapacity
+		}
+		if m.NewCapacity > hi {
+			hi = m.NewCapacity
+		}
+	}
+	return lo, hi
+}
+
+func TestAutoscalerOverloadedDownstream(t *testing.T) {
+	// 下游同时只能处理8个任务，每个50ms，最大吞吐量160/s；到达速度300/s，任务会一直积压
+	// 一味增加容量只会让每个任务变慢，耗时目标80ms对应的并发约为12
+	sim := newSimulation(t, 8, 50*time.Millisecond, AutoscaleConfig{
+		Max:           64,
+		TargetLatency: 80 * time.Millisecond,
+		Increase:      2,
+	})
+	ms := sim.run(20*time.Second, 500*time.Millisecond, 300)
+
+	lo, hi := capRange(ms[len(ms)/2:])
+	if lo < 6 || hi > 14 {
+		t.Fatalf("capacity after warm-up in [%d, %d], want within [6, 14]", lo, hi)
+	}
+	var throughput float64
+	for _, m := range ms[len(ms)/2:] {
+		throughput += m.Throughput
+	}
+	if avg := throughput / float64(len(ms)-len(ms)/2); avg < 130 {
+		t.Fatalf("average throughput = %.0f/s, want close to 160/s", avg)
+	}
+}
+
+func TestAutoscalerGrowsThenShrinks(t *testing.T) {
+	// 60/s的负载需要大约3个并发，负载停止后容量回到Min
+	sim := newSimulation(t, 8, 50*time.Millisecond, AutoscaleConfig{
+		Min:           2,
+		Max:           32,
+		TargetLatency: 80 * time.Millisecond,
+	})
+	ms := sim.run(10*time.Second, 500*time.Millisecond, 60)
+	lo, hi := capRange(ms[len(ms)/2:])
+	if lo < 3 || hi > 8 {
+		t.Fatalf("capacity under load in [%d, %d], want within [3, 8]", lo, hi)
+	}
+	if sim.queued > 5 {
+		t.Fatalf("%d tasks still queued", sim.queued)
+	}
+	for _, m := range ms {
+		if m.Reason == "latency" {
+			t.Fatalf("latency backoff at %+v, downstream was never overloaded", m)
+		}
+	}
+
+	ms = sim.run(10*time.Second, 500*time.Millisecond, 0)
+	if last := ms[len(ms)-1]; last.NewCapacity != 2 || last.Reason != "idle" {
+		t.Fatalf("idle capacity = %d (%s), want 2", last.NewCapacity, last.Reason)
+	}
+}
+
+func TestAutoscalerStopsGrowingWhenThroughputFlat(t *testing.T) {
+	// 不设耗时目标，下游的吞吐量在8个并发时达到上限160/s，任务一直积压
+	// 只看积压会一直涨到Max；增加容量后吞吐量不涨时应该退回去
+	sim := newSimulation(t, 8, 50*time.Millisecond, AutoscaleConfig{Max: 64})
+	ms := sim.run(20*time.Second, 500*time.Millisecond, 300)
+
+	lo, hi := capRange(ms[len(ms)/2:])
+	if lo < 6 || hi > 12 {
+		t.Fatalf("capacity after warm-up in [%d, %d], want within [6, 12]", lo, hi)
+	}
+	saturated := 0
+	for _, m := range ms {
+		if m.Reason == "saturated" {
+			saturated++
+			if m.NewCapacity >= m.Capacity {
+				t.Fatalf("saturated tick did not undo growth: %+v", m)
+			}
+		}
+	}
+	if saturated == 0 {
+		t.Fatal("no saturated tick, throughput was never consulted")
+	}
+}
+
+func TestAutoscalerRespectsMax(t *testing.T) {
+	// 不设耗时目标时只要有积压就增长，但不超过Max
+	sim := newSimulation(t, 1000, 10*time.Millisecond, AutoscaleConfig{Max: 10, Increase: 4})
+	ms := sim.run(5*time.Second, 100*time.Millisecond, 5000)
+	if last := ms[len(ms)-1]; last.NewCapacity != 10 || sim.pool.Cap() != 10 {
+		t.Fatalf("capacity = %d, want 10", last.NewCapacity)
+	}
+	if sim.pool.tunes != 3 {
+		t.Fatalf("Tune called %d times, want 3 (1→5→9→10)", sim.pool.tunes)
+	}
+}
+
+func TestAutoscalerBackoffOncePerOverload(t *testing.T) {
+	clock := newFakeClock()
+	pool := &simPool{capacity: 16}
+	a, err := NewAutoscaler(pool, AutoscaleConfig{Max: 16, TargetLatency: time.Second, Now: clock.Now})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 16个慢任务在减小容量前开始，跨越两个周期才结束
+	type started struct {
+		at    time.Time
+		epoch uint64
+	}
+	var tasks []started
+	for i := 0; i < 16; i++ {
+		at, epoch := a.begin()
+		tasks = append(tasks, started{at, epoch})
+	}
+	clock.Advance(2 * time.Second)
+	for _, task := range tasks[:8] {
+		a.end(task.at, task.epoch)
+	}
+	if m := a.Tick(); m.NewCapacity != 12 || m.Reason != "latency" || m.AvgLatency != 2*time.Second {
+		t.Fatalf("first tick = %+v, want backoff to 12", m)
+	}
+
+	// 剩下的任务也很慢，但它们是减小容量之前开始的，不应再次触发减小
+	clock.Advance(2 * time.Second)
+	for _, task := range tasks[8:] {
+		a.end(task.at, task.epoch)
+	}
+	if m := a.Tick(); m.Reason == "latency" || m.Completed != 8 {
+		t.Fatalf("second tick = %+v, want no second backoff", m)
+	}
+}
+
+func TestAutoscalerConfig(t *testing.T) {
+	for _, cfg := range []AutoscaleConfig{
+		{},
+		{Min: 5, Max: 4},
+		{Max: 4, Backoff: 1.5},
+		{Max: 4, Increase: -1},
+	} {
+		if _, err := NewAutoscaler(&simPool{capacity: 1}, cfg); !errors.Is(err, ErrBadConfig) {
+			t.Errorf("NewAutoscaler(%+v) error = %v, want ErrBadConfig", cfg, err)
+		}
+	}
+
+	pool := &simPool{capacity: 100}
+	if _, err := NewAutoscaler(pool, AutoscaleConfig{Min: 2, Max: 8}); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Cap() != 8 {
+		t.Fatalf("initial capacity = %d, want clamped to 8", pool.Cap())
+	}
+}
+
+func TestAutoscalerWithAntsPool(t *testing.T) {
+	pool := newTestPool(t, 1)
+	a, err := NewAutoscaler(pool, AutoscaleConfig{Max: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gate := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.Submit(func() { <-gate })
+		}()
+	}
+	// 容量为1，一个任务在执行，另外两个阻塞在Submit中
+	deadline := time.Now().Add(time.Second)
+	for pool.Waiting() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	m := a.Tick()
+	if m.Queued != 2 || m.PeakRunning != 1 || m.Reason != "backlog" || pool.Cap() != 2 {
+		t.Fatalf("tick = %+v, cap = %d", m, pool.Cap())
+	}
+	close(gate)
+	wg.Wait()
+}
@@ -0,0 +1,447 @@
+// Package workpool 在ants协程池之上提供常用的调度能力：
+// 按优先级和租户公平地分发任务，以及带返回值的Future和出错即停的任务组。
+package workpool
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+var (
+	ErrClosed     = errors.New("workpool: closed")
+	ErrQueueFull  = errors.New("workpool: tenant queue is full")
+	ErrBadRequest = errors.New("workpool: invalid task")
+)
+
+// Priority 任务优先级，数值越大越先执行
+type Priority int
+
+const (
+	Low Priority = iota
+	Normal
+	High
+)
+
+func (p Priority) String() string {
+	switch p {
+	case Low:
+		return "low"
+	case Normal:
+		return "normal"
+	case High:
+		return "high"
+	}
+	return fmt.Sprintf("priority(%d)", int(p))
+}
+
+// FairScheduler 放在ants.Pool前面的调度器
+//
+// 任务先进入调度器自己的队列，只有协程池有空闲容量时才交给协程池，
+// 这样执行顺序由调度器决定，而不是协程池内部的先来先服务：
+//   - 不同优先级之间严格按优先级，高优先级队列不为空时不会执行低优先级任务
+//   - 同一优先级内按租户做加权公平排队（WFQ），任务多的租户不会饿死其他租户
+type FairScheduler struct {
+	pool           *ants.Pool
+	now            func() time.Time
+	maxQueue       int
+	statsRetention time.Duration
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	levels    map[Priority]*fairLevel
+	weights   map[string]int
+	stats     map[statsKey]*queueStats
+	lastSweep time.Time
+	inflight  int
+	seq       uint64
+	closed    bool
+	done      chan struct{}
+}
+
+// FairOption 调度器选项
+type FairOption func(*FairScheduler)
+
+// WithClock 设置时钟，测试中用来控制等待时间的统计
+func WithClock(now func() time.Time) FairOption {
+	return func(s *FairScheduler) { s.now = now }
+}
+
+// WithMaxQueue 限制每个租户在每个优先级上排队的任务数，超过时Submit返回ErrQueueFull，0表示不限制
+func WithMaxQueue(n int) FairOption {
+	return func(s *FairScheduler) { s.maxQueue = n }
+}
+
+// WithStatsRetention 没有排队的任务、空闲超过d的租户的统计被删除，默认10分钟
+// 统计按租户和优先级保存，租户ID很多时靠它限制统计占用的内存
+func WithStatsRetention(d time.Duration) FairOption {
+	return func(s *FairScheduler) { s.statsRetention = d }
+}
+
+// WithTenantWeights 设置租户权重，未设置的租户权重为1
+func WithTenantWeights(weights map[string]int) FairOption {
+	return func(s *FairScheduler) {
+		for tenant, w := range weights {
+			s.weights[tenant] = w
+		}
+	}
+}
+
+// fairLevel 一个优先级上的公平队列
+// 每个任务入队时计算开始标签 start = max(虚拟时间, 租户上一个任务的结束标签)（租户没有排队的任务时为0），
+// 结束标签 finish = start + 1/权重；出队时选结束标签最小的任务，虚拟时间推进到它的开始标签
+type fairLevel struct {
+	vtime   float64
+	tenants map[string]*tenantQueue
+}
+
+type tenantQueue struct {
+	items      []*fairTask
+	lastFinish float64
+}
+
+type fairTask struct {
+	tenant     string
+	priority   Priority
+	run        func()
+	abort      func(err error) // 可以为nil，任务被丢弃时调用
+	start      float64
+	finish     float64
+	seq        uint64
+	enqueuedAt time.Time
+}
+
+type statsKey struct {
+	tenant   string
+	priority Priority
+}
+
+type queueStats struct {
+	queued     int
+	submitted  int64
+	dispatched int64
+	rejected   int64
+	failed     int64
+	totalWait  time.Duration
+	maxWait    time.Duration
+	lastActive time.Time // 最近一次提交或分发的时间
+}
+
+// QueueStats 一个租户在一个优先级上的统计
+type QueueStats struct {
+	Tenant     string
+	Priority   Priority
+	Queued     int   // 当前排队的任务数
+	Submitted  int64 // 累计提交
+	Dispatched int64 // 累计交给协程池
+	Rejected   int64 // 因为队列满被拒绝
+	Failed     int64 // 交给协程池失败，例如协程池已经关闭
+	AvgWait    time.Duration
+	MaxWait    time.Duration
+}
+
+// NewFairScheduler 创建调度器并启动分发goroutine，pool的生命周期仍由调用方管理
+func NewFairScheduler(pool *ants.Pool, opts ...FairOption) *FairScheduler {
+	s := &FairScheduler{
+		pool:           pool,
+		now:            time.Now,
+		statsRetention: 10 * time.Minute,
+		levels:         make(map[Priority]*fairLevel),
+		weights:        make(map[string]int),
+		stats:          make(map[statsKey]*queueStats),
+		done:           make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.lastSweep = s.now()
+	go s.dispatch()
+	return s
+}
+
+// SetWeight 设置租户权重，对之后入队的任务生效
+func (s *FairScheduler) SetWeight(tenant string, weight int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.weights[tenant] = weight
+}
+
+// Submit 把任务放入tenant在priority上的队列
+func (s *FairScheduler) Submit(tenant string, priority Priority, task func()) error {
+	return s.submit(tenant, priority, task, nil)
+}
+
+// submit abort不为nil时，任务被Close丢弃或者交给协程池失败后调用abort
+func (s *FairScheduler) submit(tenant string, priority Priority, task func(), abort func(error)) error {
+	if task == nil {
+		return ErrBadRequest
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+
+	s.sweepStats()
+	st := s.statsFor(tenant, priority)
+	st.lastActive = s.now()
+	level := s.levels[priority]
+	if level == nil {
+		level = &fairLevel{tenants: make(map[string]*tenantQueue)}
+		s.levels[priority] = level
+	}
+	q := level.tenants[tenant]
+	if q == nil {
+		q = &tenantQueue{}
+		level.tenants[tenant] = q
+	}
+	if s.maxQueue > 0 && len(q.items) >= s.maxQueue {
+		st.rejected++
+		return fmt.Errorf("%w: %s has %d %s tasks queued", ErrQueueFull, tenant, len(q.items), priority)
+	}
+
+	weight := s.weights[tenant]
+	if weight <= 0 {
+		weight = 1
+	}
+	start := level.vtime
+	if q.lastFinish > start {
+		start = q.lastFinish
+	}
+	s.seq++
+	t := &fairTask{
+		tenant:     tenant,
+		priority:   priority,
+		run:        task,
+		abort:      abort,
+		start:      start,
+		finish:     start + 1/float64(weight),
+		seq:        s.seq,
+		enqueuedAt: s.now(),
+	}
+	q.lastFinish = t.finish
+	q.items = append(q.items, t)
+
+	st.queued++
+	st.submitted++
+	s.cond.Signal()
+	return nil
+}
+
+// ForTenant 把一个租户和优先级适配成AbortSubmitter，可以和Submit、Group一起使用；
+// Close丢弃排队的任务时，对应的Future和Group以ErrClosed结束
+func (s *FairScheduler) ForTenant(tenant string, priority Priority) AbortSubmitter {
+	return tenantSubmitter{s, tenant, priority}
+}
+
+type tenantSubmitter struct {
+	s        *FairScheduler
+	tenant   string
+	priority Priority
+}
+
+func (t tenantSubmitter) Submit(task func()) error {
+	return t.s.submit(t.tenant, t.priority, task, nil)
+}
+
+func (t tenantSubmitter) SubmitAbortable(task func(), abort func(err error)) error {
+	return t.s.submit(t.tenant, t.priority, task, abort)
+}
+
+// Stats 返回每个租户在每个优先级上的统计，按租户、优先级从高到低排序
+func (s *FairScheduler) Stats() []QueueStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweepStats()
+	out := make([]QueueStats, 0, len(s.stats))
+	for k, st := range s.stats {
+		qs := QueueStats{
+			Tenant:     k.tenant,
+			Priority:   k.priority,
+			Queued:     st.queued,
+			Submitted:  st.submitted,
+			Dispatched: st.dispatched,
+			Rejected:   st.rejected,
+			Failed:     st.failed,
+			MaxWait:    st.maxWait,
+		}
+		if st.dispatched > 0 {
+			qs.AvgWait = st.totalWait / time.Duration(st.dispatched)
+		}
+		out = append(out, qs)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Tenant != out[j].Tenant {
+			return out[i].Tenant < out[j].Tenant
+		}
+		return out[i].Priority > out[j].Priority
+	})
+	return out
+}
+
+// Close 停止接收任务和分发，丢弃还在排队的任务并返回丢弃的数量
+// 通过ForTenant提交的Future和Group任务以ErrClosed结束；已经交给协程池的任务不受影响
+func (s *FairScheduler) Close() int {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return 0
+	}
+	s.closed = true
+	var dropped []*fairTask
+	for p, level := range s.levels {
+		for tenant, q := range level.tenants {
+			dropped = append(dropped, q.items...)
+			s.statsFor(tenant, p).queued -= len(q.items)
+		}
+	}
+	s.levels = make(map[Priority]*fairLevel)
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	<-s.done
+	for _, t := range dropped {
+		if t.abort != nil {
+			t.abort(ErrClosed)
+		}
+	}
+	return len(dropped)
+}
+
+// statsFor 调用方必须持有s.mu
+func (s *FairScheduler) statsFor(tenant string, priority Priority) *queueStats {
+	k := statsKey{tenant, priority}
+	st := s.stats[k]
+	if st == nil {
+		st = &queueStats{}
+		s.stats[k] = st
+	}
+	return st
+}
+
+// sweepStats 删除空闲超过statsRetention的统计，每个statsRetention最多扫描一次，调用方必须持有s.mu
+func (s *FairScheduler) sweepStats() {
+	now := s.now()
+	if now.Sub(s.lastSweep) < s.statsRetention {
+		return
+	}
+	s.lastSweep = now
+	for k, st := range s.stats {
+		if st.queued == 0 && now.Sub(st.lastActive) >= s.statsRetention {
+			delete(s.stats, k)
+		}
+	}
+}
+
+// dispatch 协程池有空闲容量时取出下一个任务交给协程池
+func (s *FairScheduler) dispatch() {
+	defer close(s.done)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		var t *fairTask
+		for !s.closed {
+			if s.hasCapacity() {
+				if t = s.next(); t != nil {
+					break
+				}
+			}
+			s.cond.Wait()
+		}
+		if s.closed {
+			return
+		}
+
+		now := s.now()
+		wait := now.Sub(t.enqueuedAt)
+		st := s.statsFor(t.tenant, t.priority)
+		st.lastActive = now
+		st.queued--
+		st.dispatched++
+		st.totalWait += wait
+		if wait > st.maxWait {
+			st.maxWait = wait
+		}
+		s.inflight++
+
+		// Submit在协程池满时会阻塞，这里已经确认有空闲容量，释放锁后再提交
+		s.mu.Unlock()
+		err := s.pool.Submit(func() {
+			defer s.finish()
+			t.run()
+		})
+		if err != nil && t.abort != nil {
+			t.abort(fmt.Errorf("submit: %w", err))
+		}
+		s.mu.Lock()
+		if err != nil {
+			s.inflight--
+			st.failed++
+		}
+	}
+}
+
+func (s *FairScheduler) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight--
+	s.cond.Signal()
+}
+
+// hasCapacity 协程池容量可以通过Tune调整，每次分发前重新读取，调用方必须持有s.mu
+func (s *FairScheduler) hasCapacity() bool {
+	c := s.pool.Cap()
+	return c <= 0 || s.inflight < c
+}
+
+// next 从最高的非空优先级中取出结束标签最小的任务，调用方必须持有s.mu
+func (s *FairScheduler) next() *fairTask {
+	priorities := make([]Priority, 0, len(s.levels))
+	for p := range s.levels {
+		priorities = append(priorities, p)
+	}
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] > priorities[j] })
+
+	for _, p := range priorities {
+		level := s.levels[p]
+		var best *tenantQueue
+		for _, q := range level.tenants {
+			if len(q.items) == 0 {
+				continue
+			}
+			if best == nil || less(q.items[0], best.items[0]) {
+				best = q
+			}
+		}
+		if best == nil {
+			continue
+		}
+		t := best.items[0]
+		best.items[0] = nil
+		best.items = best.items[1:]
+		level.vtime = t.start
+		// 队列空了就删除，否则见过的每个租户都会一直占用内存；
+		// 租户再次提交时和新租户一样从当前虚拟时间开始排队
+		if len(best.items) == 0 {
+			delete(level.tenants, t.tenant)
+		}
+		return t
+	}
+	return nil
+}
+
+// less 结束标签小的优先，相同时先提交的优先
+func less(a, b *fairTask) bool {
+	if a.finish != b.finish {
+		return a.finish < b.finish
+	}
+	return a.seq < b.seq
+}
@@ -0,0 +1,325 @@
+package workpool
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func newTestPool(t *testing.T, size int) *ants.Pool {
+	t.Helper()
+	pool, err := ants.NewPool(size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Release)
+	return pool
+}
+
+// recorder 按执行顺序记录任务标签
+type recorder struct {
+	mu    sync.Mutex
+	order []string
+	wg    sync.WaitGroup
+}
+
+func (r *recorder) task(label string) func() {
+	r.wg.Add(1)
+	return func() {
+		defer r.wg.Done()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.order = append(r.order, label)
+	}
+}
+
+func (r *recorder) String() string {
+	r.wg.Wait()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.order, "")
+}
+
+// blockPool 提交一个阻塞任务占满容量为1的协程池，返回放行函数
+// 放行前提交的任务都留在调度器的队列中，放行后按调度顺序逐个执行
+func blockPool(t *testing.T, s *FairScheduler) func() {
+	t.Helper()
+	gate := make(chan struct{})
+	started := make(chan struct{})
+	if err := s.Submit("gate", High, func() {
+		close(started)
+		<-gate
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	return func() { close(gate) }
+}
+
+func TestFairSchedulerPriority(t *testing.T) {
+	s := NewFairScheduler(newTestPool(t, 1))
+	defer s.Close()
+	release := blockPool(t, s)
+
+	rec := &recorder{}
+	for _, p := range []Priority{Low, Normal, High, Low, High} {
+		label := map[Priority]string{Low: "l", Normal: "n", High: "h"}[p]
+		if err := s.Submit("a", p, rec.task(label)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	release()
+
+	if got := rec.String(); got != "hhnll" {
+		t.Fatalf("order = %s, want hhnll", got)
+	}
+}
+
+func TestFairSchedulerTenantFairness(t *testing.T) {
+	s := NewFairScheduler(newTestPool(t, 1))
+	defer s.Close()
+	release := blockPool(t, s)
+
+	// 噪声租户a先提交了8个任务，b和c随后各提交2个
+	rec := &recorder{}
+	for i := 0; i < 8; i++ {
+		s.Submit("a", Normal, rec.task("a"))
+	}
+	for i := 0; i < 2; i++ {
+		s.Submit("b", Normal, rec.task("b"))
+		s.Submit("c", Normal, rec.task("c"))
+	}
+	release()
+
+	if got := rec.String(); got != "abcabcaaaaaa" {
+		t.Fatalf("order = %s, want abcabcaaaaaa", got)
+	}
+}
+
+func TestFairSchedulerWeights(t *testing.T) {
+	s := NewFairScheduler(newTestPool(t, 1), WithTenantWeights(map[string]int{"a": 2}))
+	defer s.Close()
+	release := blockPool(t, s)
+
+	rec := &recorder{}
+	for i := 0; i < 6; i++ {
+		s.Submit("a", Normal, rec.task("a"))
+		s.Submit("b", Normal, rec.task("b"))
+	}
+	release()
+
+	// 权重2:1，在b用完之前a执行的次数是b的两倍
+	got := rec.String()
+	if a := strings.Count(got[:9], "a"); a != 6 {
+		t.Fatalf("order = %s, a ran %d of the first 9 tasks, want 6", got, a)
+	}
+}
+
+func TestFairSchedulerIdleTenantNoBurst(t *testing.T) {
+	s := NewFairScheduler(newTestPool(t, 1))
+	defer s.Close()
+	release := blockPool(t, s)
+
+	// b空闲期间a一直在执行，b不能因此攒下"额度"一次执行多个任务，
+	// 它的任务从当前虚拟时间开始排，与a交替执行；
+	// a的队列排空后已经删除，两个租户都从当前虚拟时间开始，先提交的a先执行
+	rec := &recorder{}
+	for i := 0; i < 4; i++ {
+		s.Submit("a", Normal, rec.task("a"))
+	}
+	release()
+	if got := rec.String(); got != "aaaa" {
+		t.Fatalf("order = %s", got)
+	}
+
+	release = blockPool(t, s)
+	rec = &recorder{}
+	for i := 0; i < 3; i++ {
+		s.Submit("a", Normal, rec.task("a"))
+		s.Submit("b", Normal, rec.task("b"))
+	}
+	release()
+	if got := rec.String(); got != "ababab" {
+		t.Fatalf("order = %s, want ababab", got)
+	}
+}
+
+func TestFairSchedulerDeletesDrainedTenants(t *testing.T) {
+	s := NewFairScheduler(newTestPool(t, 1))
+	defer s.Close()
+	release := blockPool(t, s)
+
+	rec := &recorder{}
+	for i := 0; i < 100; i++ {
+		s.Submit(fmt.Sprintf("tenant-%d", i), Normal, rec.task("."))
+	}
+	release()
+	rec.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p, level := range s.levels {
+		if n := len(level.tenants); n != 0 {
+			t.Errorf("%s level still has %d tenant queues", p, n)
+		}
+	}
+}
+
+func TestFairSchedulerAgesOutIdleStats(t *testing.T) {
+	clock := newFakeClock()
+	s := NewFairScheduler(newTestPool(t, 1), WithClock(clock.Now), WithStatsRetention(time.Minute))
+	defer s.Close()
+	release := blockPool(t, s)
+
+	rec := &recorder{}
+	for i := 0; i < 1000; i++ {
+		s.Submit(fmt.Sprintf("tenant-%d", i), Normal, rec.task("."))
+	}
+	s.Submit("busy", Low, rec.task("."))
+	clock.Advance(30 * time.Second)
+	// 没有超过保留时间，空闲租户的统计仍然可以查询
+	if n := len(s.Stats()); n != 1002 {
+		t.Fatalf("stats entries = %d, want 1002", n)
+	}
+	release()
+	rec.wg.Wait()
+
+	clock.Advance(time.Minute)
+	s.Submit("new", Normal, rec.task("."))
+	rec.wg.Wait()
+	stats := statsByKey(s.Stats())
+	if len(stats) != 1 || stats["new/normal"].Submitted != 1 {
+		t.Fatalf("stats after retention = %v, want only new/normal", stats)
+	}
+}
+
+func TestFairSchedulerStats(t *testing.T) {
+	clock := newFakeClock()
+	s := NewFairScheduler(newTestPool(t, 1), WithClock(clock.Now), WithMaxQueue(2))
+	defer s.Close()
+	release := blockPool(t, s)
+
+	rec := &recorder{}
+	s.Submit("a", Normal, rec.task("a"))
+	s.Submit("a", Normal, rec.task("a"))
+	if err := s.Submit("a", Normal, func() {}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Submit over limit error = %v, want ErrQueueFull", err)
+	}
+	s.Submit("b", Low, rec.task("b"))
+
+	stats := statsByKey(s.Stats())
+	if st := stats["a/normal"]; st.Queued != 2 || st.Submitted != 2 || st.Rejected != 1 {
+		t.Fatalf("a/normal = %+v", st)
+	}
+
+	clock.Advance(3 * time.Second)
+	release()
+	rec.wg.Wait()
+
+	stats = statsByKey(s.Stats())
+	a, b := stats["a/normal"], stats["b/low"]
+	if a.Queued != 0 || a.Dispatched != 2 || a.AvgWait != 3*time.Second || a.MaxWait != 3*time.Second {
+		t.Fatalf("a/normal = %+v", a)
+	}
+	if b.Dispatched != 1 || b.MaxWait != 3*time.Second {
+		t.Fatalf("b/low = %+v", b)
+	}
+}
+
+func statsByKey(stats []QueueStats) map[string]QueueStats {
+	m := make(map[string]QueueStats)
+	for _, st := range stats {
+		m[st.Tenant+"/"+st.Priority.String()] = st
+	}
+	return m
+}
+
+func TestFairSchedulerClose(t *testing.T) {
+	s := NewFairScheduler(newTestPool(t, 1))
+	release := blockPool(t, s)
+
+	for i := 0; i < 3; i++ {
+		s.Submit("a", Normal, func() { t.Error("queued task ran after Close") })
+	}
+	if dropped := s.Close(); dropped != 3 {
+		t.Fatalf("dropped = %d, want 3", dropped)
+	}
+	release()
+	if err := s.Submit("a", Normal, func() {}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Submit after Close error = %v, want ErrClosed", err)
+	}
+}
+
+func TestFairSchedulerConcurrent(t *testing.T) {
+	pool := newTestPool(t, 4)
+	s := NewFairScheduler(pool)
+	defer s.Close()
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	var wg sync.WaitGroup
+	for tenant := 0; tenant < 5; tenant++ {
+		wg.Add(1)
+		go func(tenant string) {
+			defer wg.Done()
+			for i := 0; i < 40; i++ {
+				wg.Add(1)
+				s.Submit(tenant, Priority(i%3), func() {
+					defer wg.Done()
+					mu.Lock()
+					running++
+					if running > peak {
+						peak = running
+					}
+					mu.Unlock()
+					time.Sleep(100 * time.Microsecond)
+					mu.Lock()
+					running--
+					mu.Unlock()
+				})
+			}
+		}(string(rune('a' + tenant)))
+	}
+	wg.Wait()
+
+	if peak > pool.Cap() {
+		t.Fatalf("peak concurrency = %d, want <= %d", peak, pool.Cap())
+	}
+	var dispatched int64
+	for _, st := range s.Stats() {
+		dispatched += st.Dispatched
+		if st.Queued != 0 {
+			t.Errorf("%s/%s still has %d queued", st.Tenant, st.Priority, st.Queued)
+		}
+	}
+	if dispatched != 200 {
+		t.Fatalf("dispatched = %d, want 200", dispatched)
+	}
+}
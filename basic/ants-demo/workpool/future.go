@@ -0,0 +1,258 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// Submitter 可以提交无返回值任务的协程池，*ants.Pool实现了这个接口
+type Submitter interface {
+	Submit(task func()) error
+}
+
+// AbortSubmitter 先排队再交给协程池的Submitter，例如FairScheduler.ForTenant和Lifecycle
+//
+// 排队的任务可能不执行就被丢弃（Close、Shutdown，或者交给协程池失败），
+// 这时调用abort而不是task，Future和Group借此以错误完成，不会一直等待。
+// SubmitAbortable返回错误时两个函数都不会被调用；返回nil时task和abort恰好调用其中一个
+type AbortSubmitter interface {
+	Submitter
+	SubmitAbortable(task func(), abort func(err error)) error
+}
+
+// submit pool实现了AbortSubmitter时传入abort
+func submit(pool Submitter, task func(), abort func(err error)) error {
+	if p, ok := pool.(AbortSubmitter); ok {
+		return p.SubmitAbortable(task, abort)
+	}
+	return pool.Submit(task)
+}
+
+// PanicError 任务panic时返回的错误，Stack为panic发生时的调用栈
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v\n%s", e.Value, e.Stack)
+}
+
+// Future 在协程池中执行的任务的结果
+type Future[T any] struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	val    T
+	err    error
+}
+
+// Submit 把fn提交到pool，返回可以等待结果的Future
+func Submit[T any](pool Submitter, fn func(ctx context.Context) (T, error)) *Future[T] {
+	return SubmitContext(context.Background(), pool, fn)
+}
+
+// SubmitContext 与Submit相同，ctx取消时传给fn的ctx也会取消；
+// 任务开始执行前ctx已经取消时不再执行fn，直接以ctx.Err()完成；
+// pool实现了AbortSubmitter时，排队的任务被丢弃后以丢弃的原因（例如ErrClosed）完成
+func SubmitContext[T any](ctx context.Context, pool Submitter, fn func(ctx context.Context) (T, error)) *Future[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	f := &Future[T]{done: make(chan struct{}), cancel: cancel}
+	var zero T
+
+	err := submit(pool, func() {
+		var val T
+		var err error
+		defer func() {
+			if r := recover(); r != nil {
+				err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+			f.complete(val, err)
+		}()
+		if err = ctx.Err(); err == nil {
+			val, err = fn(ctx)
+		}
+	}, func(err error) {
+		f.complete(zero, err)
+	})
+	if err != nil {
+		f.complete(zero, fmt.Errorf("submit: %w", err))
+	}
+	return f
+}
+
+func (f *Future[T]) complete(val T, err error) {
+	f.val, f.err = val, err
+	f.cancel()
+	close(f.done)
+}
+
+// Done 任务完成时关闭
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Cancel 取消传给任务的ctx，任务是否提前结束取决于它是否响应ctx
+func (f *Future[T]) Cancel() {
+	f.cancel()
+}
+
+// Await 等待任务完成并返回结果；ctx先结束时返回ctx.Err()，任务继续执行
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// AwaitAll 等待所有任务，按提交顺序返回结果
+// 任何一个任务失败或者ctx结束时取消其余任务并返回错误，类似Promise.all
+func AwaitAll[T any](ctx context.Context, futures ...*Future[T]) ([]T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		i   int
+		err error
+	}
+	results := make(chan result, len(futures))
+	for i, f := range futures {
+		go func(i int, f *Future[T]) {
+			_, err := f.Await(ctx)
+			results <- result{i, err}
+		}(i, f)
+	}
+
+	vals := make([]T, len(futures))
+	for range futures {
+		r := <-results
+		if r.err != nil {
+			cancelAll(futures)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("task %d: %w", r.i, r.err)
+		}
+		vals[r.i] = futures[r.i].val
+	}
+	return vals, nil
+}
+
+// AwaitAny 返回第一个成功的任务的下标和结果，并取消其余任务，类似Promise.any
+// 所有任务都失败时返回合并后的错误
+func AwaitAny[T any](ctx context.Context, futures ...*Future[T]) (int, T, error) {
+	var zero T
+	if len(futures) == 0 {
+		return -1, zero, errors.New("no futures")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		i   int
+		val T
+		err error
+	}
+	results := make(chan result, len(futures))
+	for i, f := range futures {
+		go func(i int, f *Future[T]) {
+			val, err := f.Await(ctx)
+			results <- result{i, val, err}
+		}(i, f)
+	}
+
+	errs := make([]error, len(futures))
+	for range futures {
+		r := <-results
+		if r.err == nil {
+			cancelAll(futures)
+			return r.i, r.val, nil
+		}
+		if ctx.Err() != nil {
+			cancelAll(futures)
+			return -1, zero, ctx.Err()
+		}
+		errs[r.i] = fmt.Errorf("task %d: %w", r.i, r.err)
+	}
+	return -1, zero, errors.Join(errs...)
+}
+
+func cancelAll[T any](futures []*Future[T]) {
+	for _, f := range futures {
+		f.Cancel()
+	}
+}
+
+// Group 类似errgroup.Group，任务在协程池中执行
+// 第一个任务失败后取消Group的ctx，之后的Go不再提交新任务
+type Group struct {
+	pool   Submitter
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+}
+
+// NewGroup 创建Group，返回的ctx在第一个任务失败或Wait返回时取消
+func NewGroup(ctx context.Context, pool Submitter) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{pool: pool, ctx: ctx, cancel: cancel}, ctx
+}
+
+// Go 提交任务，Group已经失败时不提交并返回false
+// 提交失败（例如协程池已关闭）和排队的任务被AbortSubmitter丢弃也视为任务失败
+func (g *Group) Go(fn func(ctx context.Context) error) bool {
+	if g.ctx.Err() != nil {
+		g.fail(context.Cause(g.ctx))
+		return false
+	}
+
+	g.wg.Add(1)
+	err := submit(g.pool, func() {
+		defer g.wg.Done()
+		var err error
+		defer func() {
+			if r := recover(); r != nil {
+				err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+			if err != nil {
+				g.fail(err)
+			}
+		}()
+		// 排队期间Group已经失败或者父ctx已经取消
+		if err = context.Cause(g.ctx); err == nil {
+			err = fn(g.ctx)
+		}
+	}, func(err error) {
+		g.fail(err)
+		g.wg.Done()
+	})
+	if err != nil {
+		g.fail(fmt.Errorf("submit: %w", err))
+		g.wg.Done()
+		return false
+	}
+	return true
+}
+
+// Wait 等待所有已提交的任务结束，返回第一个错误
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+	return g.err
+}
+
+// fail 记录第一个错误并取消ctx，之后的错误被忽略
+func (g *Group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel(err)
+	})
+}
@@ -0,0 +1,294 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+var errBoom = errors.New("boom")
+
+// sleepThen 等待d后返回v，ctx取消时提前返回
+func sleepThen[T any](d time.Duration, v T, err error) func(context.Context) (T, error) {
+	return func(ctx context.Context) (T, error) {
+		select {
+		case <-time.After(d):
+			return v, err
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+func TestFutureAwait(t *testing.T) {
+	pool := newTestPool(t, 2)
+	f := Submit(pool, func(ctx context.Context) (int, error) { return 42, nil })
+	if v, err := f.Await(context.Background()); v != 42 || err != nil {
+		t.Fatalf("Await = (%d, %v), want (42, nil)", v, err)
+	}
+
+	f = Submit(pool, func(ctx context.Context) (int, error) { return 0, errBoom })
+	if _, err := f.Await(context.Background()); !errors.Is(err, errBoom) {
+		t.Fatalf("Await error = %v, want errBoom", err)
+	}
+
+	// Await的ctx结束时只是不再等待
+	slow := Submit(pool, sleepThen(time.Hour, 1, nil))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := slow.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Await error = %v, want DeadlineExceeded", err)
+	}
+
+	// Cancel取消任务自己的ctx
+	slow.Cancel()
+	if _, err := slow.Await(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Await after Cancel error = %v, want Canceled", err)
+	}
+}
+
+func TestFuturePanic(t *testing.T) {
+	pool := newTestPool(t, 1)
+	f := Submit(pool, func(ctx context.Context) (string, error) {
+		var m map[string]int
+		m["x"] = 1
+		return "unreachable", nil
+	})
+	_, err := f.Await(context.Background())
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error = %v, want *PanicError", err)
+	}
+	if !strings.Contains(string(pe.Stack), "TestFuturePanic") {
+		t.Fatalf("stack does not point at the task:\n%s", pe.Stack)
+	}
+
+	// panic之后协程池仍然可用
+	if v, err := Submit(pool, func(ctx context.Context) (int, error) { return 1, nil }).Await(context.Background()); v != 1 || err != nil {
+		t.Fatalf("pool unusable after panic: (%d, %v)", v, err)
+	}
+}
+
+func TestFutureSubmitErrors(t *testing.T) {
+	pool, err := ants.NewPool(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Release()
+	if _, err := Submit(pool, sleepThen(0, 1, nil)).Await(context.Background()); !errors.Is(err, ants.ErrPoolClosed) {
+		t.Fatalf("error = %v, want ErrPoolClosed", err)
+	}
+
+	// 提交前ctx已经取消时不执行任务
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var ran atomic.Bool
+	f := SubmitContext(ctx, newTestPool(t, 1), func(ctx context.Context) (int, error) {
+		ran.Store(true)
+		return 1, nil
+	})
+	if _, err := f.Await(context.Background()); !errors.Is(err, context.Canceled) || ran.Load() {
+		t.Fatalf("error = %v, ran = %v, want Canceled without running", err, ran.Load())
+	}
+}
+
+func TestAwaitAll(t *testing.T) {
+	pool := newTestPool(t, 4)
+	futures := []*Future[int]{
+		Submit(pool, sleepThen(30*time.Millisecond, 1, nil)),
+		Submit(pool, sleepThen(10*time.Millisecond, 2, nil)),
+		Submit(pool, sleepThen(20*time.Millisecond, 3, nil)),
+	}
+	vals, err := AwaitAll(context.Background(), futures...)
+	if err != nil || len(vals) != 3 || vals[0] != 1 || vals[1] != 2 || vals[2] != 3 {
+		t.Fatalf("AwaitAll = (%v, %v), want ([1 2 3], nil)", vals, err)
+	}
+
+	// 一个失败时其余任务被取消
+	futures = []*Future[int]{
+		Submit(pool, sleepThen(time.Hour, 1, nil)),
+		Submit(pool, sleepThen(10*time.Millisecond, 0, errBoom)),
+	}
+	if _, err := AwaitAll(context.Background(), futures...); !errors.Is(err, errBoom) {
+		t.Fatalf("AwaitAll error = %v, want errBoom", err)
+	}
+	if _, err := futures[0].Await(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("remaining task error = %v, want Canceled", err)
+	}
+}
+
+func TestAwaitAny(t *testing.T) {
+	pool := newTestPool(t, 4)
+	futures := []*Future[string]{
+		Submit(pool, sleepThen(time.Hour, "slow", nil)),
+		Submit(pool, sleepThen(5*time.Millisecond, "", errBoom)),
+		Submit(pool, sleepThen(20*time.Millisecond, "fast", nil)),
+	}
+	i, v, err := AwaitAny(context.Background(), futures...)
+	if i != 2 || v != "fast" || err != nil {
+		t.Fatalf("AwaitAny = (%d, %q, %v), want (2, fast, nil)", i, v, err)
+	}
+	if _, err := futures[0].Await(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("slow task error = %v, want Canceled", err)
+	}
+
+	futures = []*Future[string]{
+		Submit(pool, sleepThen(0, "", errBoom)),
+		Submit(pool, sleepThen(0, "", errors.New("bang"))),
+	}
+	if _, _, err := AwaitAny(context.Background(), futures...); !errors.Is(err, errBoom) || !strings.Contains(err.Error(), "bang") {
+		t.Fatalf("AwaitAny error = %v, want both errors", err)
+	}
+}
+
+func TestGroupStopsAfterFirstFailure(t *testing.T) {
+	pool := newTestPool(t, 2)
+	g, ctx := NewGroup(context.Background(), pool)
+
+	var ran atomic.Int64
+	g.Go(func(ctx context.Context) error {
+		ran.Add(1)
+		return errBoom
+	})
+	<-ctx.Done()
+
+	if g.Go(func(ctx context.Context) error {
+		ran.Add(1)
+		return nil
+	}) {
+		t.Fatal("Go after failure should not submit")
+	}
+	if err := g.Wait(); !errors.Is(err, errBoom) {
+		t.Fatalf("Wait = %v, want errBoom", err)
+	}
+	if n := ran.Load(); n != 1 {
+		t.Fatalf("ran = %d, want 1", n)
+	}
+	if !errors.Is(context.Cause(ctx), errBoom) {
+		t.Fatalf("ctx cause = %v, want errBoom", context.Cause(ctx))
+	}
+}
+
+func TestGroupCancelsRunningTasks(t *testing.T) {
+	pool := newTestPool(t, 4)
+	g, _ := NewGroup(context.Background(), pool)
+
+	for i := 0; i < 3; i++ {
+		g.Go(func(ctx context.Context) error {
+			_, err := sleepThen(time.Hour, 0, nil)(ctx)
+			return err
+		})
+	}
+	g.Go(func(ctx context.Context) error { panic("bad input") })
+
+	err := g.Wait()
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "bad input" {
+		t.Fatalf("Wait = %v, want the panic", err)
+	}
+}
+
+func TestGroupSuccess(t *testing.T) {
+	pool := newTestPool(t, 3)
+	g, _ := NewGroup(context.Background(), pool)
+	var sum atomic.Int64
+	for i := 1; i <= 10; i++ {
+		i := i
+		g.Go(func(ctx context.Context) error {
+			sum.Add(int64(i))
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil || sum.Load() != 55 {
+		t.Fatalf("Wait = %v, sum = %d, want nil and 55", err, sum.Load())
+	}
+}
+
+func TestFutureWithFairScheduler(t *testing.T) {
+	s := NewFairScheduler(newTestPool(t, 2))
+	defer s.Close()
+
+	f := Submit(s.ForTenant("a", High), func(ctx context.Context) (string, error) { return "ok", nil })
+	if v, err := f.Await(context.Background()); v != "ok" || err != nil {
+		t.Fatalf("Await = (%q, %v)", v, err)
+	}
+	if st := s.Stats(); len(st) != 1 || st[0].Tenant != "a" || st[0].Dispatched != 1 {
+		t.Fatalf("stats = %+v", st)
+	}
+}
+
+func TestFairSchedulerCloseAbortsQueuedFutures(t *testing.T) {
+	s := NewFairScheduler(newTestPool(t, 1))
+	tenant := s.ForTenant("a", Normal)
+
+	// 占住唯一的worker，之后的任务都在调度器中排队
+	started, release := make(chan struct{}), make(chan struct{})
+	blocker := Submit(tenant, func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	queued := []*Future[int]{
+		Submit(tenant, func(ctx context.Context) (int, error) { return 2, nil }),
+		Submit(tenant, func(ctx context.Context) (int, error) { return 3, nil }),
+	}
+	g, _ := NewGroup(context.Background(), tenant)
+	var ran atomic.Bool
+	g.Go(func(ctx context.Context) error {
+		ran.Store(true)
+		return nil
+	})
+
+	if n := s.Close(); n != 3 {
+		t.Fatalf("Close dropped %d tasks, want 3", n)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i, f := range queued {
+		if _, err := f.Await(ctx); !errors.Is(err, ErrClosed) {
+			t.Errorf("queued future %d error = %v, want ErrClosed", i, err)
+		}
+	}
+	waited := make(chan error, 1)
+	go func() { waited <- g.Wait() }()
+	select {
+	case err := <-waited:
+		if !errors.Is(err, ErrClosed) || ran.Load() {
+			t.Errorf("Group.Wait = %v, ran = %v, want ErrClosed and not run", err, ran.Load())
+		}
+	case <-ctx.Done():
+		t.Fatal("Group.Wait blocked after Close")
+	}
+
+	// 已经交给协程池的任务正常完成
+	close(release)
+	if v, err := blocker.Await(ctx); v != 1 || err != nil {
+		t.Fatalf("running future = (%d, %v), want (1, nil)", v, err)
+	}
+}
+
+func TestFairSchedulerAbortsOnPoolSubmitError(t *testing.T) {
+	pool := newTestPool(t, 1)
+	s := NewFairScheduler(pool)
+	defer s.Close()
+	pool.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	f := Submit(s.ForTenant("a", Normal), func(ctx context.Context) (int, error) { return 1, nil })
+	if _, err := f.Await(ctx); !errors.Is(err, ants.ErrPoolClosed) {
+		t.Fatalf("Await error = %v, want ants.ErrPoolClosed", err)
+	}
+	g, _ := NewGroup(ctx, s.ForTenant("a", Normal))
+	g.Go(func(ctx context.Context) error { return nil })
+	if err := g.Wait(); !errors.Is(err, ants.ErrPoolClosed) {
+		t.Fatalf("Group.Wait = %v, want ants.ErrPoolClosed", err)
+	}
+}
@@ -0,0 +1,265 @@
+package workpool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+// TaskState 被放弃的任务所处的状态
+type TaskState string
+
+const (
+	TaskQueued   TaskState = "queued"   // 还在排队，没有执行
+	TaskRejected TaskState = "rejected" // 交给协程池失败（例如协程池被直接释放），没有执行
+	TaskRunning  TaskState = "running"  // 正在执行，Shutdown不再等待它
+)
+
+// TaskInfo 被放弃的任务
+type TaskInfo struct {
+	ID    string
+	State TaskState
+	Since time.Time // 排队的任务是入队时间，交给协程池失败和执行中的任务是交给协程池的时间
+}
+
+// ShutdownReport Shutdown的结果
+type ShutdownReport struct {
+	Drained   int        // 开始关闭之后执行完的任务数
+	Abandoned []TaskInfo // 没有执行完的任务：排队的在前，然后是交给协程池失败的，最后是执行中的
+}
+
+// Lifecycle 给ants.Pool加上优雅关闭
+//
+// defer pool.Release()不等待任何任务：阻塞在Submit中的任务可能执行也可能不执行，
+// 正在执行的任务被直接丢下。Lifecycle在协程池前面维护自己的队列，
+// Shutdown时停止接收任务，继续执行排队的任务，在截止时间内等待所有任务结束，
+// 最后释放协程池并报告哪些任务没有执行完
+type Lifecycle struct {
+	pool   *ants.Pool
+	now    func() time.Time
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	queue     []*lifecycleTask
+	running   map[uint64]*lifecycleTask
+	rejected  []*lifecycleTask // 交给协程池失败的任务，Shutdown时报告
+	rejectErr error            // 第一次交给协程池失败的错误
+	seq       uint64
+	draining  bool
+	stopped   bool
+	drained   int
+	idle      chan struct{} // 关闭中队列和执行中的任务都为空时关闭
+	done      chan struct{}
+}
+
+type lifecycleTask struct {
+	seq   uint64
+	id    string
+	run   func()
+	abort func(err error) // 可以为nil，任务没有执行就被丢弃时调用
+	since time.Time
+}
+
+// NewLifecycle 接管pool的生命周期，之后应该通过Shutdown而不是pool.Release释放
+func NewLifecycle(pool *ants.Pool) *Lifecycle {
+	l := &Lifecycle{
+		pool:    pool,
+		now:     time.Now,
+		running: make(map[uint64]*lifecycleTask),
+		idle:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	l.ctx, l.cancel = context.WithCancel(context.Background())
+	l.cond = sync.NewCond(&l.mu)
+	go l.dispatch()
+	return l
+}
+
+// Context Shutdown放弃等待时取消，长时间运行的任务应该检查它并尽快退出
+func (l *Lifecycle) Context() context.Context {
+	return l.ctx
+}
+
+// Submit 提交任务，开始关闭后返回ErrClosed，任务ID按提交顺序生成
+func (l *Lifecycle) Submit(task func()) error {
+	return l.SubmitNamed("", task)
+}
+
+// SubmitNamed 提交任务，id出现在ShutdownReport中，为空时按提交顺序生成
+func (l *Lifecycle) SubmitNamed(id string, task func()) error {
+	return l.submit(id, task, nil)
+}
+
+// SubmitAbortable 实现AbortSubmitter：Shutdown丢弃排队的任务时以ErrClosed调用abort，
+// 交给协程池失败时以提交的错误调用abort
+func (l *Lifecycle) SubmitAbortable(task func(), abort func(err error)) error {
+	return l.submit("", task, abort)
+}
+
+func (l *Lifecycle) submit(id string, task func(), abort func(error)) error {
+	if task == nil {
+		return ErrBadRequest
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.draining {
+		return ErrClosed
+	}
+	l.seq++
+	if id == "" {
+		id = fmt.Sprintf("task-%d", l.seq)
+	}
+	l.queue = append(l.queue, &lifecycleTask{seq: l.seq, id: id, run: task, abort: abort, since: l.now()})
+	l.cond.Signal()
+	return nil
+}
+
+// Shutdown 停止接收任务，执行完排队的任务并等待执行中的任务，然后释放协程池
+// ctx结束时不再等待：排队的任务不再执行，取消Context()，返回的报告中列出被放弃的任务，
+// 错误包装ctx.Err()；没有超时但有交给协程池失败的任务时，错误包装第一次提交失败的错误；
+// 重复调用返回ErrClosed
+func (l *Lifecycle) Shutdown(ctx context.Context) (ShutdownReport, error) {
+	l.mu.Lock()
+	if l.draining {
+		l.mu.Unlock()
+		return ShutdownReport{}, ErrClosed
+	}
+	l.draining = true
+	l.checkIdle()
+	l.mu.Unlock()
+
+	select {
+	case <-l.idle:
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	l.stopped = true
+	report := ShutdownReport{Drained: l.drained}
+	for _, t := range l.queue {
+		report.Abandoned = append(report.Abandoned, TaskInfo{ID: t.id, State: TaskQueued, Since: t.since})
+	}
+	for _, t := range l.rejected {
+		report.Abandoned = append(report.Abandoned, TaskInfo{ID: t.id, State: TaskRejected, Since: t.since})
+	}
+	var running []*lifecycleTask
+	for _, t := range l.running {
+		running = append(running, t)
+	}
+	sort.Slice(running, func(i, j int) bool { return running[i].seq < running[j].seq })
+	for _, t := range running {
+		report.Abandoned = append(report.Abandoned, TaskInfo{ID: t.id, State: TaskRunning, Since: t.since})
+	}
+	dropped := l.queue
+	l.queue = nil
+	cause := ctx.Err()
+	if cause == nil {
+		cause = l.rejectErr
+	}
+	l.cond.Broadcast()
+	l.mu.Unlock()
+
+	l.cancel()
+	<-l.done
+	l.pool.Release()
+	for _, t := range dropped {
+		if t.abort != nil {
+			t.abort(ErrClosed)
+		}
+	}
+
+	if len(report.Abandoned) > 0 {
+		return report, fmt.Errorf("workpool: shutdown abandoned %d tasks: %w", len(report.Abandoned), cause)
+	}
+	return report, nil
+}
+
+// ShutdownOnSignal 阻塞直到收到信号（默认SIGINT和SIGTERM）或者ctx结束，然后在timeout内Shutdown
+// 服务的main中通常这样使用：go func() { report, err := l.ShutdownOnSignal(ctx, 30*time.Second) ... }()
+func (l *Lifecycle) ShutdownOnSignal(ctx context.Context, timeout time.Duration, sigs ...os.Signal) (ShutdownReport, error) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	sigCtx, stop := signal.NotifyContext(ctx, sigs...)
+	<-sigCtx.Done()
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return l.Shutdown(shutdownCtx)
+}
+
+// checkIdle 关闭中没有任务时通知Shutdown，调用方必须持有l.mu
+func (l *Lifecycle) checkIdle() {
+	if l.draining && !l.stopped && len(l.queue) == 0 && len(l.running) == 0 {
+		close(l.idle)
+		l.stopped = true
+	}
+}
+
+// dispatch 协程池有空闲容量时按FIFO把任务交给协程池
+func (l *Lifecycle) dispatch() {
+	defer close(l.done)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for {
+		for !l.stopped && (len(l.queue) == 0 || !l.hasCapacity()) {
+			l.cond.Wait()
+		}
+		if l.stopped {
+			return
+		}
+
+		t := l.queue[0]
+		l.queue[0] = nil
+		l.queue = l.queue[1:]
+		t.since = l.now()
+		l.running[t.seq] = t
+
+		l.mu.Unlock()
+		err := l.pool.Submit(func() {
+			defer l.finish(t)
+			t.run()
+		})
+		if err != nil && t.abort != nil {
+			t.abort(fmt.Errorf("submit: %w", err))
+		}
+		l.mu.Lock()
+		if err != nil {
+			// 协程池已经被直接释放，任务不会执行，Shutdown时作为被放弃的任务报告
+			delete(l.running, t.seq)
+			l.rejected = append(l.rejected, t)
+			if l.rejectErr == nil {
+				l.rejectErr = err
+			}
+			l.checkIdle()
+		}
+	}
+}
+
+func (l *Lifecycle) finish(t *lifecycleTask) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.running, t.seq)
+	if l.draining {
+		l.drained++
+	}
+	l.checkIdle()
+	l.cond.Signal()
+}
+
+// hasCapacity 调用方必须持有l.mu
+func (l *Lifecycle) hasCapacity() bool {
+	c := l.pool.Cap()
+	return c <= 0 || len(l.running) < c
+}
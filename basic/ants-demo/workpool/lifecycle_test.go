@@ -0,0 +1,208 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+func TestLifecycleDrains(t *testing.T) {
+	pool, err := ants.NewPool(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLifecycle(pool)
+
+	var ran atomic.Int64
+	for i := 0; i < 6; i++ {
+		if err := l.Submit(func() {
+			time.Sleep(10 * time.Millisecond)
+			ran.Add(1)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	report, err := l.Shutdown(ctx)
+	if err != nil || len(report.Abandoned) != 0 {
+		t.Fatalf("Shutdown = (%+v, %v), want everything drained", report, err)
+	}
+	if ran.Load() != 6 || report.Drained != 6 {
+		t.Fatalf("ran = %d, drained = %d, want 6", ran.Load(), report.Drained)
+	}
+	if !pool.IsClosed() {
+		t.Fatal("pool not released")
+	}
+	if err := l.Submit(func() {}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Submit after Shutdown error = %v, want ErrClosed", err)
+	}
+	if _, err := l.Shutdown(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("second Shutdown error = %v, want ErrClosed", err)
+	}
+}
+
+func TestLifecycleDeadline(t *testing.T) {
+	pool, err := ants.NewPool(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLifecycle(pool)
+
+	started := make(chan struct{})
+	stuck := make(chan struct{})
+	l.SubmitNamed("export", func() {
+		close(started)
+		select {
+		case <-l.Context().Done():
+		case <-time.After(time.Minute):
+		}
+		close(stuck)
+	})
+	<-started
+	for _, id := range []string{"email-1", "email-2"} {
+		l.SubmitNamed(id, func() { t.Errorf("queued task ran after the deadline") })
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	report, err := l.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown error = %v, want DeadlineExceeded", err)
+	}
+
+	want := []TaskInfo{{ID: "email-1", State: TaskQueued}, {ID: "email-2", State: TaskQueued}, {ID: "export", State: TaskRunning}}
+	if len(report.Abandoned) != len(want) {
+		t.Fatalf("abandoned = %+v, want %v", report.Abandoned, want)
+	}
+	for i, task := range report.Abandoned {
+		if task.ID != want[i].ID || task.State != want[i].State || task.Since.IsZero() {
+			t.Fatalf("abandoned[%d] = %+v, want %+v", i, task, want[i])
+		}
+	}
+
+	// 被放弃的任务通过Context()得知需要退出
+	select {
+	case <-stuck:
+	case <-time.After(time.Second):
+		t.Fatal("running task did not observe Context() cancellation")
+	}
+}
+
+func TestLifecycleShutdownOnSignal(t *testing.T) {
+	pool, err := ants.NewPool(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLifecycle(pool)
+	l.Submit(func() { time.Sleep(10 * time.Millisecond) })
+
+	// ctx结束和收到信号一样触发关闭
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.ShutdownOnSignal(ctx, time.Second)
+		done <- err
+	}()
+	select {
+	case <-done:
+		t.Fatal("ShutdownOnSignal returned before the signal")
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("ShutdownOnSignal error = %v", err)
+	}
+	if !pool.IsClosed() {
+		t.Fatal("pool not released")
+	}
+}
+
+func TestLifecycleWithFutures(t *testing.T) {
+	pool, err := ants.NewPool(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLifecycle(pool)
+	f := Submit(l, func(ctx context.Context) (int, error) { return 7, nil })
+	if v, err := f.Await(context.Background()); v != 7 || err != nil {
+		t.Fatalf("Await = (%d, %v)", v, err)
+	}
+	if _, err := l.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Submit(l, func(ctx context.Context) (int, error) { return 0, nil }).Await(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Submit after Shutdown error = %v, want ErrClosed", err)
+	}
+}
+
+func TestLifecycleReportsRejectedTasks(t *testing.T) {
+	pool, err := ants.NewPool(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLifecycle(pool)
+	// 绕过Lifecycle直接释放协程池，之后交给协程池的任务都会失败
+	pool.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	f := Submit(l, func(ctx context.Context) (int, error) { return 1, nil })
+	if _, err := f.Await(ctx); !errors.Is(err, ants.ErrPoolClosed) {
+		t.Fatalf("Await error = %v, want ants.ErrPoolClosed", err)
+	}
+	l.SubmitNamed("report", func() { t.Error("task ran on a released pool") })
+
+	report, err := l.Shutdown(ctx)
+	if !errors.Is(err, ants.ErrPoolClosed) {
+		t.Fatalf("Shutdown error = %v, want ants.ErrPoolClosed", err)
+	}
+	want := []TaskInfo{{ID: "task-1", State: TaskRejected}, {ID: "report", State: TaskRejected}}
+	if len(report.Abandoned) != len(want) {
+		t.Fatalf("abandoned = %+v, want %v", report.Abandoned, want)
+	}
+	for i, task := range report.Abandoned {
+		if task.ID != want[i].ID || task.State != want[i].State {
+			t.Fatalf("abandoned[%d] = %+v, want %+v", i, task, want[i])
+		}
+	}
+}
+
+func TestLifecycleDeadlineAbortsQueuedFutures(t *testing.T) {
+	pool, err := ants.NewPool(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLifecycle(pool)
+
+	started := make(chan struct{})
+	Submit(l, func(ctx context.Context) (int, error) {
+		close(started)
+		<-l.Context().Done()
+		return 0, nil
+	})
+	<-started
+	queued := Submit(l, func(ctx context.Context) (int, error) { return 1, nil })
+	g, _ := NewGroup(context.Background(), l)
+	g.Go(func(ctx context.Context) error { return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := l.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown error = %v, want DeadlineExceeded", err)
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second)
+	defer waitCancel()
+	if _, err := queued.Await(waitCtx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("queued future error = %v, want ErrClosed", err)
+	}
+	if err := g.Wait(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Group.Wait = %v, want ErrClosed", err)
+	}
+}
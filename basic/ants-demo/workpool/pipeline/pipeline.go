@@ -0,0 +1,371 @@
+// Package pipeline 在ants协程池之上组装多步骤的批处理流水线：
+// Source -> Stage -> Stage -> Sink，每个阶段有自己的协程池和有界的输出通道。
+//
+// 下游处理不过来时输出通道被填满，上游的worker阻塞在发送上，协程池被占满后上游停止读取输入，
+// 压力一级一级传回Source，内存占用有上限。单个数据出错时交给死信处理函数，
+// 没有设置死信处理函数时第一个错误取消整个流水线。
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+
+	"github.com/panjf2000/ants/v2"
+
+	"go-git-demo/ants-demo/workpool"
+)
+
+// ErrSkip Map函数返回ErrSkip时丢弃这个数据，不算错误，用来实现过滤
+var ErrSkip = errors.New("pipeline: skip item")
+
+// DeadLetter 处理失败的数据
+type DeadLetter struct {
+	Stage string // 出错的阶段
+	Item  any    // 这个阶段的输入
+	Err   error  // 阶段函数返回的错误，panic时是*workpool.PanicError
+}
+
+// Pipeline 一条流水线，通过From、Map和Sink添加阶段，Wait等待结束
+type Pipeline struct {
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	deadLetter func(DeadLetter)
+
+	wg      sync.WaitGroup
+	dlMu    sync.Mutex
+	errOnce sync.Once
+	err     error
+
+	mu     sync.Mutex
+	stages []*stageStats
+}
+
+// Option 流水线选项
+type Option func(*Pipeline)
+
+// WithDeadLetter 设置死信处理函数，不会被并发调用
+// 设置后单个数据出错不影响流水线继续运行
+func WithDeadLetter(fn func(DeadLetter)) Option {
+	return func(p *Pipeline) { p.deadLetter = fn }
+}
+
+// New 创建流水线，ctx取消时整个流水线停止
+func New(ctx context.Context, opts ...Option) *Pipeline {
+	p := &Pipeline{}
+	p.ctx, p.cancel = context.WithCancelCause(ctx)
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Wait 等待所有阶段结束，返回导致流水线停止的第一个错误
+// 父ctx被取消时返回context.Cause(ctx)
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.errOnce.Do(func() {
+		if p.ctx.Err() != nil {
+			p.err = context.Cause(p.ctx)
+		}
+	})
+	p.cancel(nil)
+	return p.err
+}
+
+// fail 记录第一个错误并取消流水线
+func (p *Pipeline) fail(err error) {
+	p.errOnce.Do(func() {
+		p.err = err
+		p.cancel(err)
+	})
+}
+
+// StageStats 一个阶段的统计
+type StageStats struct {
+	Name    string
+	In      int64 // 读入的数据数
+	Out     int64 // 成功输出的数据数
+	Skipped int64 // 返回ErrSkip的数据数
+	Failed  int64 // 出错的数据数
+}
+
+type stageStats struct {
+	name                     string
+	in, out, skipped, failed atomic.Int64
+}
+
+// Stats 按添加顺序返回每个阶段的统计
+func (p *Pipeline) Stats() []StageStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]StageStats, len(p.stages))
+	for i, st := range p.stages {
+		out[i] = StageStats{
+			Name:    st.name,
+			In:      st.in.Load(),
+			Out:     st.out.Load(),
+			Skipped: st.skipped.Load(),
+			Failed:  st.failed.Load(),
+		}
+	}
+	return out
+}
+
+func (p *Pipeline) addStage(name string) *stageStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := &stageStats{name: name}
+	p.stages = append(p.stages, st)
+	return st
+}
+
+// itemError 处理单个数据出错：有死信处理函数时交给它，否则停止流水线
+func (p *Pipeline) itemError(stage string, item any, err error) {
+	if p.deadLetter == nil {
+		p.fail(fmt.Errorf("stage %s: %w", stage, err))
+		return
+	}
+	p.dlMu.Lock()
+	defer p.dlMu.Unlock()
+	p.deadLetter(DeadLetter{Stage: stage, Item: item, Err: err})
+}
+
+// Stream 一个阶段的输出，作为下一个阶段的输入，只能被一个阶段消费
+type Stream[T any] struct {
+	p  *Pipeline
+	ch <-chan T
+}
+
+// StageOption 阶段选项
+type StageOption func(*stageConfig)
+
+type stageConfig struct {
+	workers int
+	buffer  int
+	ordered bool
+}
+
+// WithWorkers 阶段协程池的大小，默认runtime.NumCPU()
+func WithWorkers(n int) StageOption {
+	return func(c *stageConfig) { c.workers = n }
+}
+
+// WithBuffer 输出通道的容量，默认等于worker数
+func WithBuffer(n int) StageOption {
+	return func(c *stageConfig) { c.buffer = n }
+}
+
+// WithOrdered 按输入顺序输出，默认谁先处理完谁先输出
+// 前面的数据处理得慢时后面处理完的数据在内存中等待，最多等待workers+buffer个
+func WithOrdered() StageOption {
+	return func(c *stageConfig) { c.ordered = true }
+}
+
+func newStageConfig(opts []StageOption) stageConfig {
+	c := stageConfig{workers: runtime.NumCPU(), buffer: -1}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.workers < 1 {
+		c.workers = 1
+	}
+	if c.buffer < 0 {
+		c.buffer = c.workers
+	}
+	return c
+}
+
+// From 添加数据源，src通过emit逐个发出数据，emit返回错误时（流水线已经停止）src应该尽快返回
+// src返回的错误（ctx的错误除外）会停止流水线
+func From[T any](p *Pipeline, name string, src func(ctx context.Context, emit func(T) error) error, opts ...StageOption) *Stream[T] {
+	cfg := newStageConfig(opts)
+	st := p.addStage(name)
+	out := make(chan T, cfg.buffer)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(out)
+		emit := func(v T) error {
+			select {
+			case out <- v:
+				st.out.Add(1)
+				return nil
+			case <-p.ctx.Done():
+				return context.Cause(p.ctx)
+			}
+		}
+		if err := src(p.ctx, emit); err != nil && p.ctx.Err() == nil {
+			p.fail(fmt.Errorf("source %s: %w", name, err))
+		}
+	}()
+	return &Stream[T]{p: p, ch: out}
+}
+
+// FromSlice 把切片作为数据源
+func FromSlice[T any](p *Pipeline, name string, items []T, opts ...StageOption) *Stream[T] {
+	return From(p, name, func(ctx context.Context, emit func(T) error) error {
+		for _, v := range items {
+			if err := emit(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, opts...)
+}
+
+type result[In, Out any] struct {
+	seq uint64
+	in  In
+	out Out
+	err error
+}
+
+// Map 添加一个阶段，在自己的协程池中对每个输入调用fn
+// fn返回ErrSkip时丢弃数据，返回其他错误或者panic时交给死信处理函数
+func Map[In, Out any](in *Stream[In], name string, fn func(ctx context.Context, v In) (Out, error), opts ...StageOption) *Stream[Out] {
+	p := in.p
+	cfg := newStageConfig(opts)
+	st := p.addStage(name)
+	out := make(chan Out, cfg.buffer)
+
+	pool, err := ants.NewPool(cfg.workers)
+	if err != nil {
+		p.fail(fmt.Errorf("stage %s: %w", name, err))
+		close(out)
+		return &Stream[Out]{p: p, ch: out}
+	}
+
+	// window限制有序模式下已经读入但还没有输出的数据数，避免一个慢数据导致后面的结果无限堆积
+	var window chan struct{}
+	if cfg.ordered {
+		window = make(chan struct{}, cfg.workers+cfg.buffer)
+	}
+	results := make(chan result[In, Out], cfg.workers)
+
+	p.wg.Add(2)
+	go func() {
+		defer p.wg.Done()
+		var tasks sync.WaitGroup
+		defer func() {
+			tasks.Wait()
+			close(results)
+		}()
+
+		for seq := uint64(0); ; seq++ {
+			var v In
+			select {
+			case item, ok := <-in.ch:
+				if !ok {
+					return
+				}
+				v = item
+			case <-p.ctx.Done():
+				return
+			}
+			st.in.Add(1)
+			if window != nil {
+				select {
+				case window <- struct{}{}:
+				case <-p.ctx.Done():
+					return
+				}
+			}
+
+			seq := seq
+			tasks.Add(1)
+			err := pool.Submit(func() {
+				defer tasks.Done()
+				r := result[In, Out]{seq: seq, in: v}
+				r.out, r.err = call(p.ctx, fn, v)
+				select {
+				case results <- r:
+				case <-p.ctx.Done():
+				}
+			})
+			if err != nil {
+				tasks.Done()
+				p.fail(fmt.Errorf("stage %s: %w", name, err))
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer p.wg.Done()
+		defer pool.Release()
+		defer close(out)
+
+		emit := func(r result[In, Out]) {
+			if window != nil {
+				defer func() { <-window }()
+			}
+			switch {
+			case r.err == nil:
+				select {
+				case out <- r.out:
+					st.out.Add(1)
+				case <-p.ctx.Done():
+				}
+			case errors.Is(r.err, ErrSkip):
+				st.skipped.Add(1)
+			default:
+				st.failed.Add(1)
+				p.itemError(name, r.in, r.err)
+			}
+		}
+
+		if !cfg.ordered {
+			for r := range results {
+				emit(r)
+			}
+			return
+		}
+		pending := make(map[uint64]result[In, Out])
+		next := uint64(0)
+		for r := range results {
+			pending[r.seq] = r
+			for {
+				r, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				emit(r)
+				next++
+			}
+		}
+	}()
+	return &Stream[Out]{p: p, ch: out}
+}
+
+// Sink 添加最后一个阶段，在自己的协程池中对每个输入调用fn，出错的数据交给死信处理函数
+// 需要按顺序写入时让上一个阶段WithOrdered，Sink使用WithWorkers(1)
+func Sink[T any](in *Stream[T], name string, fn func(ctx context.Context, v T) error, opts ...StageOption) {
+	done := Map(in, name, func(ctx context.Context, v T) (struct{}, error) {
+		return struct{}{}, fn(ctx, v)
+	}, append(opts, WithBuffer(0))...)
+
+	p := in.p
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for range done.ch {
+		}
+	}()
+}
+
+// call 调用fn并把panic转换成*workpool.PanicError
+func call[In, Out any](ctx context.Context, fn func(context.Context, In) (Out, error), v In) (out Out, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &workpool.PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn(ctx, v)
+}
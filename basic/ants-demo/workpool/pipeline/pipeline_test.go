@@ -0,0 +1,253 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-git-demo/ants-demo/workpool"
+)
+
+func numbers(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i + 1
+	}
+	return out
+}
+
+// jitter 随机休眠，让worker乱序完成
+func jitter() {
+	time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+}
+
+// collector 按到达顺序收集Sink的输入
+type collector[T any] struct {
+	mu    sync.Mutex
+	items []T
+}
+
+func (c *collector[T]) sink(ctx context.Context, v T) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = append(c.items, v)
+	return nil
+}
+
+func TestPipelineOrdered(t *testing.T) {
+	p := New(context.Background())
+	src := FromSlice(p, "numbers", numbers(200))
+	squared := Map(src, "square", func(ctx context.Context, v int) (int, error) {
+		jitter()
+		return v * v, nil
+	}, WithWorkers(8), WithOrdered())
+	text := Map(squared, "format", func(ctx context.Context, v int) (string, error) {
+		jitter()
+		return strconv.Itoa(v), nil
+	}, WithWorkers(4), WithBuffer(2), WithOrdered())
+	out := &collector[string]{}
+	Sink(text, "collect", out.sink, WithWorkers(1))
+
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.items) != 200 {
+		t.Fatalf("got %d items, want 200", len(out.items))
+	}
+	for i, s := range out.items {
+		if want := strconv.Itoa((i + 1) * (i + 1)); s != want {
+			t.Fatalf("items[%d] = %s, want %s", i, s, want)
+		}
+	}
+}
+
+func TestPipelineUnordered(t *testing.T) {
+	p := New(context.Background())
+	doubled := Map(FromSlice(p, "numbers", numbers(100)), "double", func(ctx context.Context, v int) (int, error) {
+		jitter()
+		return v * 2, nil
+	}, WithWorkers(8))
+	out := &collector[int]{}
+	Sink(doubled, "collect", out.sink, WithWorkers(3))
+
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(out.items)
+	for i, v := range out.items {
+		if v != (i+1)*2 {
+			t.Fatalf("sorted items[%d] = %d, want %d", i, v, (i+1)*2)
+		}
+	}
+	if len(out.items) != 100 {
+		t.Fatalf("got %d items, want 100", len(out.items))
+	}
+}
+
+func TestPipelineDeadLetter(t *testing.T) {
+	var dead []DeadLetter
+	p := New(context.Background(), WithDeadLetter(func(d DeadLetter) { dead = append(dead, d) }))
+
+	parsed := Map(FromSlice(p, "lines", []string{"1", "x", "3", "", "5", "boom", "7"}), "parse",
+		func(ctx context.Context, s string) (int, error) {
+			switch s {
+			case "":
+				return 0, ErrSkip
+			case "boom":
+				panic("corrupt record")
+			}
+			return strconv.Atoi(s)
+		}, WithWorkers(2), WithOrdered())
+	out := &collector[int]{}
+	Sink(parsed, "store", func(ctx context.Context, v int) error {
+		if v == 5 {
+			return errors.New("duplicate key")
+		}
+		return out.sink(ctx, v)
+	}, WithWorkers(1))
+
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(out.items) != "[1 3 7]" {
+		t.Fatalf("stored = %v, want [1 3 7]", out.items)
+	}
+
+	sort.Slice(dead, func(i, j int) bool { return fmt.Sprint(dead[i].Item) < fmt.Sprint(dead[j].Item) })
+	if len(dead) != 3 {
+		t.Fatalf("dead letters = %+v, want 3", dead)
+	}
+	if dead[0].Stage != "store" || dead[0].Item != 5 {
+		t.Fatalf("dead[0] = %+v, want store/5", dead[0])
+	}
+	var pe *workpool.PanicError
+	if dead[1].Stage != "parse" || dead[1].Item != "boom" || !errors.As(dead[1].Err, &pe) {
+		t.Fatalf("dead[1] = %+v, want the parse panic", dead[1])
+	}
+	var ne *strconv.NumError
+	if dead[2].Stage != "parse" || dead[2].Item != "x" || !errors.As(dead[2].Err, &ne) {
+		t.Fatalf("dead[2] = %+v, want parse/x", dead[2])
+	}
+
+	stats := p.Stats()
+	want := []StageStats{
+		{Name: "lines", Out: 7},
+		{Name: "parse", In: 7, Out: 4, Skipped: 1, Failed: 2},
+		{Name: "store", In: 4, Out: 3, Failed: 1},
+	}
+	if fmt.Sprint(stats) != fmt.Sprint(want) {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+// endless 不停发出递增整数的数据源，返回发出的数量
+func endless(p *Pipeline, opts ...StageOption) (*Stream[int], *atomic.Int64) {
+	var emitted atomic.Int64
+	s := From(p, "endless", func(ctx context.Context, emit func(int) error) error {
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+			emitted.Add(1)
+		}
+	}, opts...)
+	return s, &emitted
+}
+
+func TestPipelineErrorStopsEverything(t *testing.T) {
+	p := New(context.Background())
+	src, emitted := endless(p, WithBuffer(1))
+	checked := Map(src, "check", func(ctx context.Context, v int) (int, error) {
+		if v == 50 {
+			return 0, errors.New("bad record")
+		}
+		return v, nil
+	}, WithWorkers(2))
+	Sink(checked, "discard", func(ctx context.Context, v int) error { return nil }, WithWorkers(2))
+
+	done := make(chan error, 1)
+	go func() { done <- p.Wait() }()
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "stage check: bad record" {
+			t.Fatalf("Wait = %v, want stage check: bad record", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pipeline did not stop after the first error")
+	}
+	if n := emitted.Load(); n > 100 {
+		t.Fatalf("source emitted %d items after the failure", n)
+	}
+}
+
+func TestPipelineBackpressure(t *testing.T) {
+	p := New(context.Background())
+	src, emitted := endless(p, WithBuffer(2))
+	mapped := Map(src, "identity", func(ctx context.Context, v int) (int, error) { return v, nil },
+		WithWorkers(4), WithBuffer(2), WithOrdered())
+
+	// 慢Sink：源头发出的数据比Sink处理的多出的部分被各级缓冲区和worker限制住
+	var sunk atomic.Int64
+	var maxLead int64
+	Sink(mapped, "slow", func(ctx context.Context, v int) error {
+		time.Sleep(200 * time.Microsecond)
+		n := sunk.Add(1)
+		if lead := emitted.Load() - n; lead > maxLead {
+			maxLead = lead
+		}
+		if n == 300 {
+			return errors.New("stop")
+		}
+		return nil
+	}, WithWorkers(1))
+
+	if err := p.Wait(); err == nil {
+		t.Fatal("Wait = nil, want the stop error")
+	}
+	// source缓冲2 + source阻塞中的1 + identity窗口6 + results中的4 + Sink缓冲和worker
+	if maxLead > 20 {
+		t.Fatalf("source ran %d items ahead of the sink, want bounded by the buffers", maxLead)
+	}
+}
+
+func TestPipelineParentCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx)
+	src, _ := endless(p)
+	Sink(src, "discard", func(ctx context.Context, v int) error {
+		if v == 10 {
+			cancel()
+		}
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- p.Wait() }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Wait = %v, want Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pipeline did not stop after cancel")
+	}
+}
+
+func TestPipelineSourceError(t *testing.T) {
+	p := New(context.Background())
+	src := From(p, "db", func(ctx context.Context, emit func(int) error) error {
+		emit(1)
+		return errors.New("connection reset")
+	})
+	Sink(src, "discard", func(ctx context.Context, v int) error { return nil })
+	if err := p.Wait(); err == nil || err.Error() != "source db: connection reset" {
+		t.Fatalf("Wait = %v, want source db: connection reset", err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-git-demo/cron/scheduler"
+)
+
+// SimpleJob 与simple_job_example.go中的任务相同，计数只保存在内存中，
+// 重启后清零；执行历史由调度器保存在状态文件中
+type SimpleJob struct {
+	Name    string
+	Message string
+	Counter int
+}
+
+func (j *SimpleJob) Run() {
+	j.Counter++
+	fmt.Printf("[%s] 任务 '%s' 第 %d 次执行: %s\n",
+		time.Now().Format("15:04:05"), j.Name, j.Counter, j.Message)
+}
+
+// flakyJob 模拟不稳定的外部调用，有一半的概率失败
+func flakyJob(ctx context.Context) error {
+	if rand.Intn(2) == 0 {
+		return errors.New("upstream unavailable")
+	}
+	fmt.Printf("[%s] 同步任务完成\n", time.Now().Format("15:04:05"))
+	return nil
+}
+
+// slowJob 耗时比执行间隔和超时时间都长
+func slowJob(ctx context.Context) error {
+	select {
+	case <-time.After(8 * time.Second):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func main() {
+	statePath := flag.String("state", "scheduler_state.json", "状态文件路径")
+	duration := flag.Duration("duration", 20*time.Second, "运行时长")
+	adminAddr := flag.String("admin", ":8080", "管理接口监听地址，为空时不启动")
+	lockDir := flag.String("lock-dir", "", "锁目录，多个实例指向同一个目录时每次执行只在一个实例上运行")
+	calendarPath := flag.String("calendar", "cron/scheduler-demo/holidays.json", "节假日日历文件")
+	flag.Parse()
+
+	store, err := scheduler.NewFileStore(*statePath)
+	if err != nil {
+		log.Fatalf("打开状态文件失败: %v", err)
+	}
+	opts := []scheduler.Option{scheduler.WithRegistry(newRegistry())}
+	if *lockDir != "" {
+		locker, err := scheduler.NewFileLocker(*lockDir)
+		if err != nil {
+			log.Fatalf("打开锁目录失败: %v", err)
+		}
+		opts = append(opts, scheduler.WithLocker(locker, time.Minute))
+	}
+	cal, err := scheduler.LoadCalendar(*calendarPath)
+	if err != nil {
+		log.Fatalf("加载日历失败: %v", err)
+	}
+	opts = append(opts, scheduler.WithCalendar(cal))
+	s := scheduler.New(store, opts...)
+
+	jobs := []struct {
+		name string
+		spec string
+		job  scheduler.Job
+		opts scheduler.JobOptions
+	}{
+		{"greeting", "*/5 * * * * *", scheduler.CronJob(&SimpleJob{Name: "问候任务", Message: "Hello, World!"}),
+			scheduler.JobOptions{CatchUp: scheduler.CatchUpSkip}},
+		{"report", "*/10 * * * * *", scheduler.CronJob(&SimpleJob{Name: "报表任务", Message: "生成报表"}),
+			scheduler.JobOptions{CatchUp: scheduler.CatchUpOnce}},
+		{"billing", "*/15 * * * * *", scheduler.CronJob(&SimpleJob{Name: "计费任务", Message: "结算账单"}),
+			scheduler.JobOptions{CatchUp: scheduler.CatchUpAll}},
+		// 失败后最多重试3次，间隔200ms、400ms
+		{"sync", "*/3 * * * * *", scheduler.JobFunc(flakyJob),
+			scheduler.JobOptions{Retry: scheduler.RetryPolicy{MaxAttempts: 3, Backoff: 200 * time.Millisecond}}},
+		// 每次最多执行5秒，上一次还没结束时跳过
+		{"export", "*/2 * * * * *", scheduler.JobFunc(slowJob),
+			scheduler.JobOptions{Overlap: scheduler.OverlapSkip, Timeout: 5 * time.Second}},
+		// 每月最后一个工作日北京时间18:00发工资，跳过周末和节假日
+		{"payroll", "0 0 18 * * *", scheduler.CronJob(&SimpleJob{Name: "发薪任务", Message: "发放工资"}),
+			scheduler.JobOptions{CatchUp: scheduler.CatchUpOnce, TimeZone: "Asia/Shanghai", Calendar: cal.Name, Days: scheduler.LastBusinessDay}},
+	}
+	for _, j := range jobs {
+		if err := s.Add(j.name, j.spec, j.job, j.opts); err != nil {
+			log.Fatalf("添加任务失败: %v", err)
+		}
+	}
+
+	// 恢复之前通过管理接口添加的任务
+	if err := s.Restore(); err != nil {
+		log.Printf("恢复任务失败: %v", err)
+	}
+
+	fmt.Println("启动调度器，先处理停机期间错过的执行...")
+	if err := s.Start(); err != nil {
+		log.Fatalf("启动失败: %v", err)
+	}
+	if info, err := s.Entry("payroll"); err == nil {
+		fmt.Printf("发薪任务下次执行: %s\n", info.Next.In(time.FixedZone("CST", 8*3600)).Format("2006-01-02 15:04 MST"))
+	}
+	fmt.Printf("程序将运行%s，停止后等待一段时间再次运行可以看到补偿执行\n", *duration)
+	fmt.Println("========================")
+
+	if *adminAddr != "" {
+		srv := &http.Server{Addr: *adminAddr, Handler: scheduler.NewAdminHandler(s)}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("管理接口启动失败: %v", err)
+			}
+		}()
+		defer srv.Close()
+		fmt.Printf("管理接口: http://localhost%s/jobs\n", *adminAddr)
+	}
+
+	time.Sleep(*duration)
+	s.Stop()
+
+	for _, name := range []string{"sync", "export"} {
+		fmt.Printf("\n任务 %s 最近5次执行:\n", name)
+		history, _ := s.History(name, scheduler.HistoryQuery{Limit: 5})
+		for _, r := range history {
+			line := fmt.Sprintf("  %s %-7s 尝试%d次 耗时%-6s %s",
+				r.ScheduledAt.Format("15:04:05"), r.Status, r.Attempts, r.Duration().Round(time.Millisecond), r.Error)
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+	}
+}
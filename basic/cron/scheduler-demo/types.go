@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-git-demo/cron/scheduler"
+)
+
+// LogJob 与simple_job_example.go中的LogJob相同，参数来自管理接口的JSON
+type LogJob struct {
+	LogLevel string `json:"level"`
+	Message  string `json:"message"`
+}
+
+func (l *LogJob) Run(ctx context.Context) error {
+	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	fmt.Printf("[%s] [%s] %s\n", timestamp, l.LogLevel, l.Message)
+	return nil
+}
+
+// CounterJob 与simple_job_example.go中的CounterJob相同
+type CounterJob struct {
+	Name  string `json:"name"`
+	count int
+}
+
+func (c *CounterJob) Run(ctx context.Context) error {
+	c.count++
+	fmt.Printf("计数器 %s: %d\n", c.Name, c.count)
+	return nil
+}
+
+// newRegistry 注册可以通过管理接口创建的任务类型
+func newRegistry() *scheduler.Registry {
+	r := scheduler.NewRegistry()
+	r.Register("log", func(params json.RawMessage) (scheduler.Job, error) {
+		j := &LogJob{LogLevel: "INFO"}
+		if err := json.Unmarshal(params, j); err != nil {
+			return nil, err
+		}
+		if j.Message == "" {
+			return nil, errors.New("message is required")
+		}
+		return j, nil
+	})
+	r.Register("counter", func(params json.RawMessage) (scheduler.Job, error) {
+		j := &CounterJob{}
+		if err := json.Unmarshal(params, j); err != nil {
+			return nil, err
+		}
+		if j.Name == "" {
+			return nil, errors.New("name is required")
+		}
+		return j, nil
+	})
+	return r
+}
@@ -0,0 +1,309 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AdminHandler 调度器的HTTP管理接口
+//
+//	GET    /types                 已注册的任务类型
+//	GET    /jobs                  所有任务
+//	POST   /jobs                  通过注册的任务类型添加任务
+//	GET    /jobs/{name}           单个任务
+//	DELETE /jobs/{name}           删除任务
+//	POST   /jobs/{name}/pause     暂停
+//	POST   /jobs/{name}/resume    恢复
+//	POST   /jobs/{name}/trigger   立即执行一次
+//	GET    /jobs/{name}/history   执行历史，支持status、since、until（RFC3339）和limit参数
+type AdminHandler struct {
+	s *Scheduler
+}
+
+func NewAdminHandler(s *Scheduler) *AdminHandler {
+	return &AdminHandler{s: s}
+}
+
+// AddJobRequest POST /jobs 的请求体，时间使用time.ParseDuration的格式，例如"30s"
+type AddJobRequest struct {
+	Name    string          `json:"name"`
+	Type    string          `json:"type"`
+	Spec    string          `json:"spec"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	CatchUp CatchUpPolicy   `json:"catchUp,omitempty"`
+	Overlap OverlapPolicy   `json:"overlap,omitempty"`
+	Timeout string          `json:"timeout,omitempty"`
+	Retry   *RetryRequest   `json:"retry,omitempty"`
+
+	TimeZone string       `json:"timeZone,omitempty"`
+	Calendar string       `json:"calendar,omitempty"`
+	Days     CalendarRule `json:"days,omitempty"`
+}
+
+// RetryRequest 重试策略
+type RetryRequest struct {
+	MaxAttempts int    `json:"maxAttempts"`
+	Backoff     string `json:"backoff,omitempty"`
+	MaxBackoff  string `json:"maxBackoff,omitempty"`
+}
+
+// options 把请求转换为JobOptions
+func (r AddJobRequest) options() (JobOptions, error) {
+	opts := JobOptions{CatchUp: r.CatchUp, Overlap: r.Overlap, TimeZone: r.TimeZone, Calendar: r.Calendar, Days: r.Days}
+	var err error
+	if opts.Timeout, err = parseDuration("timeout", r.Timeout); err != nil {
+		return opts, err
+	}
+	if r.Retry != nil {
+		opts.Retry.MaxAttempts = r.Retry.MaxAttempts
+		if opts.Retry.Backoff, err = parseDuration("retry.backoff", r.Retry.Backoff); err != nil {
+			return opts, err
+		}
+		if opts.Retry.MaxBackoff, err = parseDuration("retry.maxBackoff", r.Retry.MaxBackoff); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func parseDuration(field, v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", field, v, err)
+	}
+	return d, nil
+}
+
+// JobView 任务在管理接口中的表示
+type JobView struct {
+	Name      string          `json:"name"`
+	Type      string          `json:"type,omitempty"`
+	Spec      string          `json:"spec"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	CatchUp   CatchUpPolicy   `json:"catchUp"`
+	Overlap   OverlapPolicy   `json:"overlap"`
+	Timeout   string          `json:"timeout,omitempty"`
+	Retry     RetryRequest    `json:"retry"`
+	TimeZone  string          `json:"timeZone,omitempty"`
+	Calendar  string          `json:"calendar,omitempty"`
+	Days      CalendarRule    `json:"days,omitempty"`
+	Paused    bool            `json:"paused"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Next      *time.Time      `json:"next,omitempty"`
+	Prev      *time.Time      `json:"prev,omitempty"`
+	LastRun   *RunRecord      `json:"lastRun,omitempty"`
+}
+
+func newJobView(info EntryInfo) JobView {
+	def := info.Definition
+	v := JobView{
+		Name:      def.Name,
+		Type:      def.Type,
+		Spec:      def.Spec,
+		Params:    def.Params,
+		CatchUp:   def.Options.CatchUp,
+		Overlap:   def.Options.Overlap,
+		Retry:     RetryRequest{MaxAttempts: def.Options.Retry.MaxAttempts},
+		TimeZone:  def.Options.TimeZone,
+		Calendar:  def.Options.Calendar,
+		Days:      def.Options.Days,
+		Paused:    def.Paused,
+		CreatedAt: def.CreatedAt,
+		LastRun:   info.LastRun,
+	}
+	if def.Options.Timeout > 0 {
+		v.Timeout = def.Options.Timeout.String()
+	}
+	if def.Options.Retry.Backoff > 0 {
+		v.Retry.Backoff = def.Options.Retry.Backoff.String()
+	}
+	if def.Options.Retry.MaxBackoff > 0 {
+		v.Retry.MaxBackoff = def.Options.Retry.MaxBackoff.String()
+	}
+	if !info.Next.IsZero() {
+		v.Next = &info.Next
+	}
+	if !info.Prev.IsZero() {
+		v.Prev = &info.Prev
+	}
+	return v
+}
+
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "types":
+		h.route(w, r, map[string]http.HandlerFunc{"GET": h.listTypes})
+	case len(parts) == 1 && parts[0] == "jobs":
+		h.route(w, r, map[string]http.HandlerFunc{"GET": h.listJobs, "POST": h.addJob})
+	case len(parts) == 2 && parts[0] == "jobs":
+		name := parts[1]
+		h.route(w, r, map[string]http.HandlerFunc{
+			"GET":    func(w http.ResponseWriter, r *http.Request) { h.writeJob(w, name, http.StatusOK) },
+			"DELETE": h.action(name, h.s.Remove, http.StatusNoContent),
+		})
+	case len(parts) == 3 && parts[0] == "jobs":
+		name := parts[1]
+		switch parts[2] {
+		case "pause":
+			h.route(w, r, map[string]http.HandlerFunc{"POST": h.action(name, h.s.Pause, http.StatusOK)})
+		case "resume":
+			h.route(w, r, map[string]http.HandlerFunc{"POST": h.action(name, h.s.Resume, http.StatusOK)})
+		case "trigger":
+			h.route(w, r, map[string]http.HandlerFunc{"POST": h.action(name, h.s.Trigger, http.StatusAccepted)})
+		case "history":
+			h.route(w, r, map[string]http.HandlerFunc{"GET": func(w http.ResponseWriter, r *http.Request) { h.history(w, r, name) }})
+		default:
+			writeError(w, http.StatusNotFound, errors.New("not found"))
+		}
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// route 按请求方法分发，不支持的方法返回405
+func (h *AdminHandler) route(w http.ResponseWriter, r *http.Request, handlers map[string]http.HandlerFunc) {
+	if f, ok := handlers[r.Method]; ok {
+		f(w, r)
+		return
+	}
+	methods := make([]string, 0, len(handlers))
+	for m := range handlers {
+		methods = append(methods, m)
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+}
+
+func (h *AdminHandler) listTypes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.s.registry.Types())
+}
+
+func (h *AdminHandler) listJobs(w http.ResponseWriter, r *http.Request) {
+	infos, err := h.s.Entries()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	views := make([]JobView, len(infos))
+	for i, info := range infos {
+		views[i] = newJobView(info)
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (h *AdminHandler) addJob(w http.ResponseWriter, r *http.Request) {
+	var req AddJobRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if req.Name == "" || req.Type == "" || req.Spec == "" {
+		writeError(w, http.StatusBadRequest, errors.New("name, type and spec are required"))
+		return
+	}
+	opts, err := req.options()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.s.AddType(req.Name, req.Type, req.Spec, req.Params, opts); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	h.writeJob(w, req.Name, http.StatusCreated)
+}
+
+// writeJob 返回任务的最新状态
+func (h *AdminHandler) writeJob(w http.ResponseWriter, name string, status int) {
+	info, err := h.s.Entry(name)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, status, newJobView(info))
+}
+
+// action 执行对单个任务的操作，成功后以status返回任务的最新状态，204时不返回内容
+func (h *AdminHandler) action(name string, op func(string) error, status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := op(name); err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		if status == http.StatusNoContent {
+			w.WriteHeader(status)
+			return
+		}
+		h.writeJob(w, name, status)
+	}
+}
+
+func (h *AdminHandler) history(w http.ResponseWriter, r *http.Request, name string) {
+	if _, err := h.s.Entry(name); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	query := r.URL.Query()
+	q := HistoryQuery{Status: RunStatus(query.Get("status"))}
+	var err error
+	if v := query.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", v))
+			return
+		}
+	}
+	for key, dst := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := query.Get(key); v != "" {
+			if *dst, err = time.Parse(time.RFC3339, v); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s %q: %w", key, v, err))
+				return
+			}
+		}
+	}
+
+	records, err := h.s.History(name, q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if records == nil {
+		records = []RunRecord{}
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+// statusFor 把调度器返回的错误映射为HTTP状态码
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrJobExists):
+		return http.StatusConflict
+	case errors.Is(err, ErrUnknownType), errors.Is(err, ErrInvalidJob):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
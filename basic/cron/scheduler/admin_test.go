@@ -0,0 +1,236 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// counterParams counter类型任务的参数
+type counterParams struct {
+	Step int `json:"step"`
+}
+
+// newTestRegistry 注册counter类型，所有实例共享同一个计数器
+func newTestRegistry(total *atomic.Int64) *Registry {
+	r := NewRegistry()
+	r.Register("counter", func(raw json.RawMessage) (Job, error) {
+		var p counterParams
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, err
+		}
+		if p.Step <= 0 {
+			return nil, errors.New("step must be positive")
+		}
+		return JobFunc(func(ctx context.Context) error {
+			total.Add(int64(p.Step))
+			return nil
+		}), nil
+	})
+	return r
+}
+
+func doRequest(t *testing.T, h http.Handler, method, path, body string) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var decoded map[string]any
+	if strings.HasPrefix(strings.TrimSpace(rec.Body.String()), "{") {
+		if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+			t.Fatalf("%s %s: invalid JSON %q", method, path, rec.Body.String())
+		}
+	}
+	return rec, decoded
+}
+
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("status = %d, want %d, body = %s", rec.Code, want, rec.Body.String())
+	}
+}
+
+func TestAdminAPI(t *testing.T) {
+	var total atomic.Int64
+	store, _ := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	s := New(store, WithRegistry(newTestRegistry(&total)), discard)
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	h := NewAdminHandler(s)
+
+	rec, _ := doRequest(t, h, "GET", "/types", "")
+	expectStatus(t, rec, http.StatusOK)
+	if strings.TrimSpace(rec.Body.String()) != `["counter"]` {
+		t.Fatalf("types = %s", rec.Body.String())
+	}
+
+	rec, job := doRequest(t, h, "POST", "/jobs",
+		`{"name":"count","type":"counter","spec":"0 0 * * * *","params":{"step":2},"timeout":"30s","retry":{"maxAttempts":3,"backoff":"1s"}}`)
+	expectStatus(t, rec, http.StatusCreated)
+	if job["name"] != "count" || job["timeout"] != "30s" || job["next"] == nil || job["paused"] != false {
+		t.Fatalf("created job = %v", job)
+	}
+	if retry := job["retry"].(map[string]any); retry["maxAttempts"] != float64(3) || retry["backoff"] != "1s" {
+		t.Fatalf("retry = %v", retry)
+	}
+
+	invalid := []struct {
+		body string
+		want int
+	}{
+		{`{"name":"count","type":"counter","spec":"* * * * * *","params":{"step":1}}`, http.StatusConflict},
+		{`{"name":"x","type":"missing","spec":"* * * * * *"}`, http.StatusBadRequest},
+		{`{"name":"x","type":"counter","spec":"bad","params":{"step":1}}`, http.StatusBadRequest},
+		{`{"name":"x","type":"counter","spec":"* * * * * *","params":{"step":0}}`, http.StatusBadRequest},
+		{`{"name":"x","type":"counter","spec":"* * * * * *","params":{"step":1},"timeout":"soon"}`, http.StatusBadRequest},
+		{`{"name":"x","type":"counter","spec":"* * * * * *","params":{"step":1},"overlap":"parallel"}`, http.StatusBadRequest},
+		{`{"name":"x","type":"counter","spec":"* * * * * *","extra":true}`, http.StatusBadRequest},
+		{`{"name":"x"}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range invalid {
+		rec, body := doRequest(t, h, "POST", "/jobs", tt.body)
+		if rec.Code != tt.want || body["error"] == nil {
+			t.Errorf("POST %s: status = %d, body = %s, want %d with error", tt.body, rec.Code, rec.Body.String(), tt.want)
+		}
+	}
+
+	rec, _ = doRequest(t, h, "GET", "/jobs", "")
+	expectStatus(t, rec, http.StatusOK)
+	var list []JobView
+	json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list) != 1 || list[0].Name != "count" || list[0].Next == nil {
+		t.Fatalf("jobs = %s", rec.Body.String())
+	}
+
+	rec, job = doRequest(t, h, "POST", "/jobs/count/pause", "")
+	expectStatus(t, rec, http.StatusOK)
+	if job["paused"] != true || job["next"] != nil {
+		t.Fatalf("paused job = %v", job)
+	}
+	rec, job = doRequest(t, h, "POST", "/jobs/count/resume", "")
+	expectStatus(t, rec, http.StatusOK)
+	if job["paused"] != false || job["next"] == nil {
+		t.Fatalf("resumed job = %v", job)
+	}
+
+	rec, _ = doRequest(t, h, "POST", "/jobs/count/trigger", "")
+	expectStatus(t, rec, http.StatusAccepted)
+	waitUntil(t, func() bool { return total.Load() == 2 })
+	waitUntil(t, func() bool {
+		records, _ := s.History("count", HistoryQuery{})
+		return len(records) == 1
+	})
+
+	rec, _ = doRequest(t, h, "GET", "/jobs/count/history?status=success&limit=1", "")
+	expectStatus(t, rec, http.StatusOK)
+	var history []RunRecord
+	json.Unmarshal(rec.Body.Bytes(), &history)
+	if len(history) != 1 || !history[0].Manual || history[0].Status != StatusSuccess {
+		t.Fatalf("history = %s", rec.Body.String())
+	}
+	rec, _ = doRequest(t, h, "GET", "/jobs/count/history?status=failed", "")
+	if strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("failed history = %s", rec.Body.String())
+	}
+	rec, _ = doRequest(t, h, "GET", "/jobs/count/history?since=yesterday", "")
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec, _ = doRequest(t, h, "PUT", "/jobs/count", "")
+	expectStatus(t, rec, http.StatusMethodNotAllowed)
+	if allow := rec.Header().Get("Allow"); !strings.Contains(allow, "DELETE") {
+		t.Fatalf("Allow = %q", allow)
+	}
+
+	rec, _ = doRequest(t, h, "DELETE", "/jobs/count", "")
+	expectStatus(t, rec, http.StatusNoContent)
+	for _, path := range []string{"/jobs/count", "/jobs/count/history"} {
+		rec, _ = doRequest(t, h, "GET", path, "")
+		expectStatus(t, rec, http.StatusNotFound)
+	}
+	rec, _ = doRequest(t, h, "POST", "/jobs/count/trigger", "")
+	expectStatus(t, rec, http.StatusNotFound)
+	rec, _ = doRequest(t, h, "GET", "/unknown", "")
+	expectStatus(t, rec, http.StatusNotFound)
+}
+
+func TestRestoreAndPausePersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := &fakeClock{now: t0}
+	var total atomic.Int64
+	open := func() *Scheduler {
+		store, err := NewFileStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return New(store, WithRegistry(newTestRegistry(&total)), WithClock(clock.Now), discard)
+	}
+
+	s := open()
+	if err := s.AddType("hourly", "counter", everyHour, json.RawMessage(`{"step":1}`), JobOptions{CatchUp: CatchUpAll}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Pause("hourly"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 暂停状态下重启：任务被恢复但不补偿
+	clock.Set(t0.Add(3*time.Hour + 30*time.Minute))
+	s = open()
+	if err := s.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	info, err := s.Entry("hourly")
+	var params bytes.Buffer
+	json.Compact(&params, info.Definition.Params)
+	if err != nil || !info.Definition.Paused || params.String() != `{"step":1}` {
+		t.Fatalf("restored entry = %+v, %v", info, err)
+	}
+	if total.Load() != 0 {
+		t.Fatalf("paused job ran %d times", total.Load())
+	}
+
+	// 恢复后再重启，只补偿恢复之后错过的执行
+	s.Resume("hourly")
+	s.Stop()
+	clock.Set(t0.Add(5*time.Hour + 30*time.Minute))
+	s = open()
+	s.Restore()
+	s.Start()
+	s.Stop()
+	if total.Load() != 2 {
+		t.Fatalf("runs after resume = %d, want 2 (14:00 and 15:00)", total.Load())
+	}
+
+	// 类型未注册时Restore返回错误
+	store, _ := NewFileStore(path)
+	s = New(store, discard)
+	if err := s.Restore(); !errors.Is(err, ErrUnknownType) {
+		t.Fatalf("Restore without registry error = %v, want ErrUnknownType", err)
+	}
+}
+
+// waitUntil 轮询等待条件成立
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
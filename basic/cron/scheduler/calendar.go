@@ -0,0 +1,327 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Calendar 工作日历：周末、节假日和调休上班日
+// 日期按日历日比较，不带时区，由使用它的CalendarSchedule决定在哪个时区判断
+type Calendar struct {
+	Name     string
+	weekend  [7]bool
+	holidays map[civilDate]bool
+	workdays map[civilDate]bool
+}
+
+// civilDate 不带时区的日期
+type civilDate struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateOf(t time.Time) civilDate {
+	y, m, d := t.Date()
+	return civilDate{y, m, d}
+}
+
+// calendarFile 日历文件格式
+// holidays和workdays中的每一项是一个日期"2024-10-01"或者一个闭区间"2024-10-01..2024-10-07"
+type calendarFile struct {
+	Name     string   `json:"name"`
+	Weekend  []string `json:"weekend,omitempty"` // 默认为Saturday、Sunday
+	Holidays []string `json:"holidays,omitempty"`
+	Workdays []string `json:"workdays,omitempty"` // 落在周末的调休上班日
+}
+
+// LoadCalendar 从JSON文件加载日历
+func LoadCalendar(path string) (*Calendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("calendar: %w", err)
+	}
+	cal, err := ParseCalendar(data)
+	if err != nil {
+		return nil, fmt.Errorf("calendar %s: %w", path, err)
+	}
+	return cal, nil
+}
+
+// ParseCalendar 解析JSON格式的日历
+func ParseCalendar(data []byte) (*Calendar, error) {
+	var f calendarFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if f.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if f.Weekend == nil {
+		f.Weekend = []string{"Saturday", "Sunday"}
+	}
+
+	cal := &Calendar{Name: f.Name, holidays: make(map[civilDate]bool), workdays: make(map[civilDate]bool)}
+	for _, name := range f.Weekend {
+		wd, ok := parseWeekday(name)
+		if !ok {
+			return nil, fmt.Errorf("invalid weekend day %q", name)
+		}
+		cal.weekend[wd] = true
+	}
+	if err := addDates(cal.holidays, f.Holidays); err != nil {
+		return nil, fmt.Errorf("holidays: %w", err)
+	}
+	if err := addDates(cal.workdays, f.Workdays); err != nil {
+		return nil, fmt.Errorf("workdays: %w", err)
+	}
+	for d := range cal.workdays {
+		if cal.holidays[d] {
+			return nil, fmt.Errorf("%04d-%02d-%02d is both a holiday and a workday", d.year, d.month, d.day)
+		}
+	}
+	return cal, nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if strings.EqualFold(name, wd.String()) {
+			return wd, true
+		}
+	}
+	return 0, false
+}
+
+// addDates 把日期或日期区间展开加入set
+func addDates(set map[civilDate]bool, entries []string) error {
+	for _, entry := range entries {
+		from, to, isRange := strings.Cut(entry, "..")
+		start, err := time.Parse(time.DateOnly, strings.TrimSpace(from))
+		if err != nil {
+			return fmt.Errorf("invalid date %q", entry)
+		}
+		end := start
+		if isRange {
+			if end, err = time.Parse(time.DateOnly, strings.TrimSpace(to)); err != nil || end.Before(start) {
+				return fmt.Errorf("invalid date range %q", entry)
+			}
+		}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			set[dateOf(d)] = true
+		}
+	}
+	return nil
+}
+
+// IsHoliday t所在的日期是否为节假日
+func (c *Calendar) IsHoliday(t time.Time) bool {
+	return c.holidays[dateOf(t)]
+}
+
+// IsBusinessDay t所在的日期是否为工作日：调休上班日是工作日，其余日期排除周末和节假日
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	d := dateOf(t)
+	if c.workdays[d] {
+		return true
+	}
+	return !c.weekend[t.Weekday()] && !c.holidays[d]
+}
+
+// defaultCalendar 没有指定日历时只把周六周日当作非工作日
+var defaultCalendar = &Calendar{
+	Name:     "default",
+	weekend:  [7]bool{time.Sunday: true, time.Saturday: true},
+	holidays: map[civilDate]bool{},
+	workdays: map[civilDate]bool{},
+}
+
+// CalendarRule 在spec匹配的日期上再按日历过滤
+type CalendarRule string
+
+const (
+	EveryDay         CalendarRule = ""                   // 只按spec
+	SkipHolidays     CalendarRule = "skip-holidays"      // 排除节假日，周末是否执行由spec决定
+	BusinessDays     CalendarRule = "business-days"      // 只在工作日执行
+	FirstBusinessDay CalendarRule = "first-business-day" // 只在每月第一个工作日执行
+	LastBusinessDay  CalendarRule = "last-business-day"  // 只在每月最后一个工作日执行
+)
+
+func (r CalendarRule) valid() bool {
+	switch r {
+	case EveryDay, SkipHolidays, BusinessDays, FirstBusinessDay, LastBusinessDay:
+		return true
+	}
+	return false
+}
+
+// maxSearchDays Next最多向后查找的天数，与robfig/cron一样找不到时返回零值
+const maxSearchDays = 5 * 366
+
+// starBit 与robfig/cron相同，表示字段是"*"或"?"
+const starBit = 1 << 63
+
+// CalendarSchedule 按指定时区的墙上时间和工作日历计算执行时间，实现了cron.Schedule
+//
+// robfig/cron在夏令时切换当天会跳过不存在的时间（02:30的任务那天不执行），
+// 并且重复的时间会执行两次（01:30的任务执行两次）。CalendarSchedule改为：
+//   - 不存在的墙上时间在跳变后立即执行，例如美东02:30的任务在03:00 EDT执行
+//   - 重复的墙上时间只在第一次出现时执行
+//
+// 也就是每个墙上时间最多执行一次，每天执行的任务在切换当天仍然执行且只执行一次。
+type CalendarSchedule struct {
+	spec     *cron.SpecSchedule
+	loc      *time.Location
+	calendar *Calendar
+	rule     CalendarRule
+}
+
+// NewCalendarSchedule 组合spec、时区和日历规则
+// loc为nil时使用spec中CRON_TZ指定的时区，calendar为nil时只把周六周日当作非工作日
+func NewCalendarSchedule(spec *cron.SpecSchedule, loc *time.Location, calendar *Calendar, rule CalendarRule) (*CalendarSchedule, error) {
+	if !rule.valid() {
+		return nil, fmt.Errorf("unknown calendar rule %q", rule)
+	}
+	if loc == nil {
+		loc = spec.Location
+	}
+	if calendar == nil {
+		calendar = defaultCalendar
+	}
+	return &CalendarSchedule{spec: spec, loc: loc, calendar: calendar, rule: rule}, nil
+}
+
+// Next 返回t之后的下一次执行时间，结果使用t的时区
+func (s *CalendarSchedule) Next(t time.Time) time.Time {
+	y, m, d := t.In(s.loc).Date()
+	// 日期用UTC的零点表示，按天递增不受夏令时影响
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < maxSearchDays; i++ {
+		if s.dayMatches(day) {
+			if next := s.nextInDay(day, t); !next.IsZero() {
+				return next.In(t.Location())
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// nextInDay 返回day这一天中晚于after的第一个执行时间
+// wallTime是单调不减的，某个小时或分钟的最后一秒都不晚于after时可以整段跳过
+func (s *CalendarSchedule) nextInDay(day, after time.Time) time.Time {
+	y, m, d := day.Date()
+	for h := 0; h < 24; h++ {
+		if s.spec.Hour&(1<<uint(h)) == 0 || !wallTime(y, m, d, h, 59, 59, s.loc).After(after) {
+			continue
+		}
+		for mi := 0; mi < 60; mi++ {
+			if s.spec.Minute&(1<<uint(mi)) == 0 || !wallTime(y, m, d, h, mi, 59, s.loc).After(after) {
+				continue
+			}
+			for sec := 0; sec < 60; sec++ {
+				if s.spec.Second&(1<<uint(sec)) == 0 {
+					continue
+				}
+				if t := wallTime(y, m, d, h, mi, sec, s.loc); t.After(after) {
+					return t
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches 日期是否同时满足spec的月、日、星期字段和日历规则
+func (s *CalendarSchedule) dayMatches(day time.Time) bool {
+	if s.spec.Month&(1<<uint(day.Month())) == 0 {
+		return false
+	}
+	// 与cron相同：日和星期有一个是*时取交集，否则取并集
+	dom := s.spec.Dom&(1<<uint(day.Day())) > 0
+	dow := s.spec.Dow&(1<<uint(day.Weekday())) > 0
+	if s.spec.Dom&starBit > 0 || s.spec.Dow&starBit > 0 {
+		if !dom || !dow {
+			return false
+		}
+	} else if !dom && !dow {
+		return false
+	}
+
+	switch s.rule {
+	case SkipHolidays:
+		return !s.calendar.IsHoliday(day)
+	case BusinessDays:
+		return s.calendar.IsBusinessDay(day)
+	case FirstBusinessDay:
+		return dateOf(day) == dateOf(s.firstBusinessDay(day.Year(), day.Month()))
+	case LastBusinessDay:
+		return dateOf(day) == dateOf(s.lastBusinessDay(day.Year(), day.Month()))
+	}
+	return true
+}
+
+func (s *CalendarSchedule) firstBusinessDay(year int, month time.Month) time.Time {
+	for d := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC); d.Month() == month; d = d.AddDate(0, 0, 1) {
+		if s.calendar.IsBusinessDay(d) {
+			return d
+		}
+	}
+	return time.Time{}
+}
+
+func (s *CalendarSchedule) lastBusinessDay(year int, month time.Month) time.Time {
+	for d := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC); d.Month() == month; d = d.AddDate(0, 0, -1) {
+		if s.calendar.IsBusinessDay(d) {
+			return d
+		}
+	}
+	return time.Time{}
+}
+
+// wallTime 把loc中的墙上时间转换为时刻
+// 重复的墙上时间（夏令时结束）取第一次出现；不存在的墙上时间（夏令时开始）取跳变的时刻
+func wallTime(year int, month time.Month, day, hour, min, sec int, loc *time.Location) time.Time {
+	naive := time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	// 前后一天的偏移覆盖了当天可能发生的切换
+	_, before := naive.Add(-24 * time.Hour).In(loc).Zone()
+	_, after := naive.Add(24 * time.Hour).In(loc).Zone()
+
+	var first time.Time
+	for _, offset := range []int{before, after} {
+		t := naive.Add(-time.Duration(offset) * time.Second)
+		if sameWallClock(t.In(loc), naive) && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+	if !first.IsZero() {
+		return first
+	}
+
+	// 墙上时间落在跳过的区间内：在[naive-after, naive-before]中二分查找切换到新偏移的时刻
+	lo := naive.Add(-time.Duration(after) * time.Second)
+	hi := naive.Add(-time.Duration(before) * time.Second)
+	if hi.Before(lo) {
+		lo, hi = hi, lo
+	}
+	for hi.Sub(lo) > time.Second {
+		mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+		if _, off := mid.In(loc).Zone(); off == after {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi
+}
+
+func sameWallClock(t, naive time.Time) bool {
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := naive.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2 &&
+		t.Hour() == naive.Hour() && t.Minute() == naive.Minute() && t.Second() == naive.Second()
+}
@@ -0,0 +1,317 @@
+package scheduler
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const testCalendar = `{
+	"name": "cn-2024",
+	"holidays": ["2024-09-15..2024-09-17", "2024-10-01..2024-10-07"],
+	"workdays": ["2024-09-14", "2024-09-29", "2024-10-12"]
+}`
+
+func loadTestCalendar(t *testing.T) *Calendar {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "calendar.json")
+	if err := os.WriteFile(path, []byte(testCalendar), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cal, err := LoadCalendar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cal
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func newTestCalendarSchedule(t *testing.T, spec string, loc *time.Location, cal *Calendar, rule CalendarRule) *CalendarSchedule {
+	t.Helper()
+	parsed, err := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow).Parse(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewCalendarSchedule(parsed.(*cron.SpecSchedule), loc, cal, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// expectNext 从from开始连续调用Next，检查每次的结果
+func expectNext(t *testing.T, s cron.Schedule, from time.Time, want ...time.Time) {
+	t.Helper()
+	next := from
+	for i, w := range want {
+		next = s.Next(next)
+		if !next.Equal(w) {
+			t.Fatalf("Next #%d = %s, want %s", i+1, next, w)
+		}
+	}
+}
+
+func TestParseCalendar(t *testing.T) {
+	cal := loadTestCalendar(t)
+	day := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+
+	cases := map[string]bool{
+		"2024-09-13": true,  // 周五
+		"2024-09-14": true,  // 周六调休上班
+		"2024-09-16": false, // 中秋节区间
+		"2024-09-21": false, // 普通周六
+		"2024-10-07": false, // 国庆区间的最后一天
+		"2024-10-08": true,
+	}
+	for d, want := range cases {
+		if got := cal.IsBusinessDay(day(d)); got != want {
+			t.Errorf("IsBusinessDay(%s) = %v, want %v", d, got, want)
+		}
+	}
+	if !cal.IsHoliday(day("2024-10-01")) || cal.IsHoliday(day("2024-09-21")) {
+		t.Error("IsHoliday should only report listed holidays")
+	}
+
+	custom, err := ParseCalendar([]byte(`{"name": "gulf", "weekend": ["friday", "Saturday"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custom.IsBusinessDay(day("2024-09-13")) || !custom.IsBusinessDay(day("2024-09-15")) {
+		t.Error("custom weekend not applied")
+	}
+
+	invalid := []string{
+		`{"holidays": ["2024-01-01"]}`,
+		`{"name": "x", "weekend": ["Caturday"]}`,
+		`{"name": "x", "holidays": ["2024-13-01"]}`,
+		`{"name": "x", "holidays": ["2024-10-07..2024-10-01"]}`,
+		`{"name": "x", "holidays": ["2024-10-01"], "workdays": ["2024-10-01"]}`,
+		`not json`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseCalendar([]byte(data)); err == nil {
+			t.Errorf("ParseCalendar(%s) expected error", data)
+		}
+	}
+}
+
+func TestCalendarRules(t *testing.T) {
+	cal := loadTestCalendar(t)
+	shanghai := mustLoadLocation(t, "Asia/Shanghai")
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2024, month, day, hour, 0, 0, 0, shanghai)
+	}
+
+	// 8月31日是周六，9月30日是周一，10月31日是周四
+	s := newTestCalendarSchedule(t, "0 0 18 * * *", shanghai, cal, LastBusinessDay)
+	expectNext(t, s, at(time.August, 1, 0), at(time.August, 30, 18), at(time.September, 30, 18), at(time.October, 31, 18))
+
+	// 10月1日到7日放假
+	s = newTestCalendarSchedule(t, "0 0 9 * * *", shanghai, cal, FirstBusinessDay)
+	expectNext(t, s, at(time.September, 15, 0), at(time.October, 8, 9), at(time.November, 1, 9))
+
+	// 周六调休上班，随后三天放假
+	s = newTestCalendarSchedule(t, "0 0 18 * * *", shanghai, cal, BusinessDays)
+	expectNext(t, s, at(time.September, 13, 19), at(time.September, 14, 18), at(time.September, 18, 18))
+
+	// 只排除节假日，spec只在周一到周五执行，调休的周六也不执行
+	s = newTestCalendarSchedule(t, "0 0 9 * * MON-FRI", shanghai, cal, SkipHolidays)
+	expectNext(t, s, at(time.September, 13, 10), at(time.September, 18, 9), at(time.September, 19, 9))
+
+	// 没有指定日历时只排除周末
+	s = newTestCalendarSchedule(t, "0 0 18 * * *", shanghai, nil, LastBusinessDay)
+	expectNext(t, s, at(time.September, 1, 0), at(time.September, 30, 18))
+}
+
+func TestCalendarScheduleDST(t *testing.T) {
+	ny := mustLoadLocation(t, "America/New_York")
+	est := time.FixedZone("EST", -5*3600)
+	edt := time.FixedZone("EDT", -4*3600)
+
+	t.Run("spring forward", func(t *testing.T) {
+		// 2024-03-10 02:00 EST 跳到 03:00 EDT
+		daily := newTestCalendarSchedule(t, "0 30 2 * * *", ny, nil, EveryDay)
+		expectNext(t, daily, time.Date(2024, 3, 9, 12, 0, 0, 0, est),
+			time.Date(2024, 3, 10, 3, 0, 0, 0, edt), // 02:30不存在，在跳变时执行
+			time.Date(2024, 3, 11, 2, 30, 0, 0, edt),
+		)
+
+		hourly := newTestCalendarSchedule(t, "0 0 * * * *", ny, nil, EveryDay)
+		expectNext(t, hourly, time.Date(2024, 3, 10, 0, 30, 0, 0, est),
+			time.Date(2024, 3, 10, 1, 0, 0, 0, est),
+			time.Date(2024, 3, 10, 3, 0, 0, 0, edt), // 02:00和03:00合并为一次
+			time.Date(2024, 3, 10, 4, 0, 0, 0, edt),
+		)
+	})
+
+	t.Run("fall back", func(t *testing.T) {
+		// 2024-11-03 02:00 EDT 回到 01:00 EST，01:00到02:00出现两次
+		daily := newTestCalendarSchedule(t, "0 30 1 * * *", ny, nil, EveryDay)
+		expectNext(t, daily, time.Date(2024, 11, 2, 12, 0, 0, 0, edt),
+			time.Date(2024, 11, 3, 1, 30, 0, 0, edt),
+			time.Date(2024, 11, 4, 1, 30, 0, 0, est),
+		)
+
+		hourly := newTestCalendarSchedule(t, "0 0 * * * *", ny, nil, EveryDay)
+		expectNext(t, hourly, time.Date(2024, 11, 3, 0, 30, 0, 0, edt),
+			time.Date(2024, 11, 3, 1, 0, 0, 0, edt),
+			time.Date(2024, 11, 3, 2, 0, 0, 0, est), // 第二次出现的01:00不执行
+			time.Date(2024, 11, 3, 3, 0, 0, 0, est),
+		)
+	})
+}
+
+// TestRobfigDSTBehavior 记录robfig/cron自身在切换当天的行为，说明为什么需要CalendarSchedule
+func TestRobfigDSTBehavior(t *testing.T) {
+	mustLoadLocation(t, "America/New_York")
+	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+	spring, _ := parser.Parse("CRON_TZ=America/New_York 0 30 2 * * *")
+	if got := spring.Next(time.Date(2024, 3, 10, 0, 0, 0, 0, time.FixedZone("EST", -5*3600))); got.Day() != 11 {
+		t.Fatalf("robfig/cron spring forward next = %s, expected it to skip March 10", got)
+	}
+}
+
+func TestSchedulerCalendarCatchUpAcrossDST(t *testing.T) {
+	mustLoadLocation(t, "America/New_York")
+	cases := []struct {
+		name        string
+		spec        string
+		start, stop time.Time
+		want        []time.Time
+	}{
+		{
+			name:  "spring forward",
+			spec:  "0 30 2 * * *",
+			start: time.Date(2024, 3, 9, 17, 0, 0, 0, time.UTC),
+			stop:  time.Date(2024, 3, 12, 16, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC), // 03:00 EDT
+				time.Date(2024, 3, 11, 6, 30, 0, 0, time.UTC),
+				time.Date(2024, 3, 12, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "fall back",
+			spec:  "0 30 1 * * *",
+			start: time.Date(2024, 11, 2, 16, 0, 0, 0, time.UTC),
+			stop:  time.Date(2024, 11, 5, 17, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), // 01:30 EDT，01:30 EST不再执行
+				time.Date(2024, 11, 4, 6, 30, 0, 0, time.UTC),
+				time.Date(2024, 11, 5, 6, 30, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := &fakeClock{now: tc.start}
+			s := newTestScheduler(t, filepath.Join(t.TempDir(), "state.json"), clock)
+			job := &countingJob{}
+			opts := JobOptions{CatchUp: CatchUpAll, TimeZone: "America/New_York"}
+			if err := s.Add("nightly", tc.spec, job, opts); err != nil {
+				t.Fatal(err)
+			}
+
+			clock.Set(tc.stop)
+			if err := s.Start(); err != nil {
+				t.Fatal(err)
+			}
+			s.Stop()
+
+			history, err := s.History("nightly", HistoryQuery{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(history) != len(tc.want) || job.Count() != len(tc.want) {
+				t.Fatalf("runs = %d (records %d), want %d", job.Count(), len(history), len(tc.want))
+			}
+			// 假时钟下所有记录的开始时间相同，按计划时间排序后比较
+			sort.Slice(history, func(i, j int) bool { return history[i].ScheduledAt.Before(history[j].ScheduledAt) })
+			for i, want := range tc.want {
+				if got := history[i].ScheduledAt; !got.Equal(want) {
+					t.Errorf("run %d scheduled at %s, want %s", i, got.UTC(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerCalendarOptions(t *testing.T) {
+	cal := loadTestCalendar(t)
+	mustLoadLocation(t, "Asia/Shanghai")
+	clock := &fakeClock{now: time.Date(2024, 9, 27, 0, 0, 0, 0, time.UTC)}
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(store, WithClock(clock.Now), WithCalendar(cal), discard)
+
+	opts := JobOptions{TimeZone: "Asia/Shanghai", Calendar: "cn-2024", Days: FirstBusinessDay}
+	if err := s.Add("payroll", "0 0 9 * * *", &countingJob{}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if def, ok, err := store.Job("payroll"); err != nil || !ok ||
+		def.Options.TimeZone != opts.TimeZone || def.Options.Calendar != opts.Calendar || def.Options.Days != opts.Days {
+		t.Fatalf("saved options = %+v, want %+v", def.Options, opts)
+	}
+	want := time.Date(2024, 10, 8, 1, 0, 0, 0, time.UTC) // 10月8日09:00 CST
+	if next := s.jobs["payroll"].schedule.Next(clock.Now()); !next.Equal(want) {
+		t.Fatalf("next = %s, want %s", next.UTC(), want)
+	}
+
+	invalid := []struct {
+		spec string
+		opts JobOptions
+	}{
+		{"0 0 9 * * *", JobOptions{Calendar: "missing"}},
+		{"0 0 9 * * *", JobOptions{TimeZone: "Mars/Olympus"}},
+		{"0 0 9 * * *", JobOptions{Days: "every-other-day"}},
+		{"CRON_TZ=UTC 0 0 9 * * *", JobOptions{TimeZone: "Asia/Shanghai"}},
+		{"@every 1h", JobOptions{Days: BusinessDays}},
+	}
+	for i, c := range invalid {
+		if err := s.Add("invalid", c.spec, &countingJob{}, c.opts); !errors.Is(err, ErrInvalidJob) {
+			t.Errorf("case %d: Add error = %v, want ErrInvalidJob", i, err)
+		}
+	}
+}
+
+func TestAdminCalendarFields(t *testing.T) {
+	var total atomic.Int64
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(store, WithRegistry(newTestRegistry(&total)), WithCalendar(loadTestCalendar(t)), discard)
+	h := NewAdminHandler(s)
+
+	rec, body := doRequest(t, h, "POST", "/jobs", `{
+		"name": "payroll", "type": "counter", "spec": "0 0 18 * * *", "params": {"step": 1},
+		"timeZone": "Asia/Shanghai", "calendar": "cn-2024", "days": "last-business-day"
+	}`)
+	expectStatus(t, rec, 201)
+	if body["timeZone"] != "Asia/Shanghai" || body["calendar"] != "cn-2024" || body["days"] != "last-business-day" {
+		t.Fatalf("job view = %v", body)
+	}
+
+	rec, _ = doRequest(t, h, "POST", "/jobs", `{"name": "bad", "type": "counter", "spec": "0 0 18 * * *", "params": {"step": 1}, "calendar": "missing"}`)
+	expectStatus(t, rec, 400)
+}
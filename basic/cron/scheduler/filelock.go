@@ -0,0 +1,181 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileLocker 基于本地目录的锁，同一台机器上的多个进程可以共享
+// 整个目录用guard文件上的flock互斥，临界区内读写锁文件和令牌计数器；
+// 持有guard的进程崩溃后内核释放flock，不需要判断guard是否失效，只支持unix系统
+type FileLocker struct {
+	dir string
+	now func() time.Time
+}
+
+type fileLockState struct {
+	Owner     string    `json:"owner"`
+	Token     uint64    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+const (
+	guardFile      = ".guard"
+	tokenFile      = ".token"
+	lockFilePrefix = "lock-"
+)
+
+// NewFileLocker 在dir中保存锁，目录不存在时创建
+func NewFileLocker(dir string) (*FileLocker, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("file locker: %w", err)
+	}
+	return &FileLocker{dir: dir, now: time.Now}, nil
+}
+
+func (l *FileLocker) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (Lock, error) {
+	var lock Lock
+	err := l.withGuard(ctx, func() error {
+		now := l.now()
+		path := l.lockPath(key)
+		if st, err := readLockState(path); err != nil {
+			return err
+		} else if st != nil && now.Before(st.ExpiresAt) {
+			return lockedError(key, st.Owner, st.Token)
+		}
+
+		token, err := l.nextToken()
+		if err != nil {
+			return err
+		}
+		st := fileLockState{Owner: owner, Token: token, ExpiresAt: now.Add(ttl)}
+		if err := writeFileAtomic(path, st); err != nil {
+			return err
+		}
+		lock = Lock{Key: key, Owner: owner, Token: token, ExpiresAt: st.ExpiresAt}
+		return l.removeExpired(now)
+	})
+	return lock, err
+}
+
+func (l *FileLocker) Release(ctx context.Context, lock Lock) error {
+	return l.withGuard(ctx, func() error {
+		path := l.lockPath(lock.Key)
+		st, err := readLockState(path)
+		if err != nil {
+			return err
+		}
+		if st == nil || st.Token != lock.Token {
+			return ErrLockNotHeld
+		}
+		return os.Remove(path)
+	})
+}
+
+// withGuard 在guard文件的排他锁保护下执行f，锁被占用时每5ms重试一次直到ctx取消
+// 锁由内核维护，持有者进程崩溃时随文件描述符一起释放，guard文件本身一直保留
+func (l *FileLocker) withGuard(ctx context.Context, f func() error) error {
+	fd, err := os.OpenFile(filepath.Join(l.dir, guardFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("file locker: %w", err)
+	}
+	defer fd.Close()
+	for {
+		ok, err := lockFile(fd)
+		if err != nil {
+			return fmt.Errorf("file locker: %w", err)
+		}
+		if ok {
+			defer unlockFile(fd)
+			return f()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+// nextToken 递增并返回令牌计数器，调用方必须持有guard
+func (l *FileLocker) nextToken() (uint64, error) {
+	path := filepath.Join(l.dir, tokenFile)
+	var token uint64
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if token, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err != nil {
+			return 0, fmt.Errorf("file locker: corrupted token file: %w", err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return 0, fmt.Errorf("file locker: %w", err)
+	}
+	token++
+	if err := writeFileAtomic(path, token); err != nil {
+		return 0, err
+	}
+	return token, nil
+}
+
+// removeExpired 删除已经过期的锁文件，避免每次执行留下的锁文件不断累积，调用方必须持有guard
+func (l *FileLocker) removeExpired(now time.Time) error {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return fmt.Errorf("file locker: %w", err)
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), lockFilePrefix) {
+			continue
+		}
+		path := filepath.Join(l.dir, e.Name())
+		if st, err := readLockState(path); err == nil && st != nil && !now.Before(st.ExpiresAt) {
+			os.Remove(path)
+		}
+	}
+	return nil
+}
+
+// lockPath key可能包含'/'、':'等字符，编码后作为文件名
+func (l *FileLocker) lockPath(key string) string {
+	return filepath.Join(l.dir, lockFilePrefix+base64.RawURLEncoding.EncodeToString([]byte(key)))
+}
+
+// readLockState 读取锁文件，文件不存在时返回nil
+func readLockState(path string) (*fileLockState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("file locker: %w", err)
+	}
+	var st fileLockState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("file locker: corrupted lock file %s: %w", path, err)
+	}
+	return &st, nil
+}
+
+// writeFileAtomic 先写临时文件再rename
+func writeFileAtomic(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("file locker: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("file locker: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package scheduler
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile 非unix系统没有flock，FileLocker不可用
+func lockFile(f *os.File) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+func unlockFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package scheduler
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile 对f加非阻塞的排他flock，其他文件描述符持有锁时返回false
+func lockFile(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
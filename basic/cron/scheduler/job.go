@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Job 可以报告错误、响应取消的任务
+// Run 应该在ctx取消后尽快返回，超时和调度器停止都通过ctx通知
+type Job interface {
+	Run(ctx context.Context) error
+}
+
+// JobFunc 把普通函数适配成Job
+type JobFunc func(ctx context.Context) error
+
+func (f JobFunc) Run(ctx context.Context) error { return f(ctx) }
+
+// CronJob 把只有Run()的cron.Job适配成Job，它不会响应取消，也不会报告错误
+func CronJob(j cron.Job) Job {
+	return JobFunc(func(ctx context.Context) error {
+		j.Run()
+		return nil
+	})
+}
+
+// OverlapPolicy 上一次执行还没有结束时，新的执行如何处理
+type OverlapPolicy string
+
+const (
+	OverlapSkip  OverlapPolicy = "skip"  // 跳过本次执行（默认）
+	OverlapQueue OverlapPolicy = "queue" // 排队，等上一次结束后再执行
+	OverlapAllow OverlapPolicy = "allow" // 允许并发执行
+)
+
+// RetryPolicy 失败重试策略
+// 第n次重试前等待 Backoff*2^(n-1)，不超过MaxBackoff；MaxAttempts包含第一次执行
+type RetryPolicy struct {
+	MaxAttempts int           `json:"maxAttempts,omitempty"`
+	Backoff     time.Duration `json:"backoff,omitempty"`
+	MaxBackoff  time.Duration `json:"maxBackoff,omitempty"`
+}
+
+// delay 返回第attempt次执行失败后、下一次执行前的等待时间
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// JobOptions 任务选项，会和任务定义一起保存
+type JobOptions struct {
+	CatchUp CatchUpPolicy `json:"catchUp"`
+	Overlap OverlapPolicy `json:"overlap"`
+	Timeout time.Duration `json:"timeout,omitempty"` // 每次尝试的超时时间，0表示不限制
+	Retry   RetryPolicy   `json:"retry"`
+
+	// TimeZone、Calendar、Days任意一个不为空时使用CalendarSchedule：
+	// 按TimeZone的墙上时间执行，夏令时切换当天也只执行一次，并按日历规则过滤日期
+	TimeZone string       `json:"timeZone,omitempty"` // IANA时区名，例如"Asia/Shanghai"
+	Calendar string       `json:"calendar,omitempty"` // 通过WithCalendar注册的日历名称
+	Days     CalendarRule `json:"days,omitempty"`
+}
+
+// normalize 填充默认值并检查选项是否合法
+func (o JobOptions) normalize() (JobOptions, error) {
+	switch o.CatchUp {
+	case CatchUpSkip, CatchUpOnce, CatchUpAll:
+	case "":
+		o.CatchUp = CatchUpSkip
+	default:
+		return o, fmt.Errorf("unknown catch-up policy %q", o.CatchUp)
+	}
+	switch o.Overlap {
+	case OverlapSkip, OverlapQueue, OverlapAllow:
+	case "":
+		o.Overlap = OverlapSkip
+	default:
+		return o, fmt.Errorf("unknown overlap policy %q", o.Overlap)
+	}
+	if o.Timeout < 0 || o.Retry.Backoff < 0 || o.Retry.MaxBackoff < 0 {
+		return o, fmt.Errorf("durations must not be negative")
+	}
+	if o.Retry.MaxAttempts < 1 {
+		o.Retry.MaxAttempts = 1
+	}
+	if !o.Days.valid() {
+		return o, fmt.Errorf("unknown calendar rule %q", o.Days)
+	}
+	return o, nil
+}
+
+// errTimeout 单次尝试超过了任务的超时时间
+var errTimeout = errors.New("timeout")
+
+// attempt 执行一次任务，finished在任务的goroutine真正结束时关闭
+// 任务不响应ctx时，超时后直接返回errTimeout，任务的goroutine继续在后台运行直到结束
+func attempt(ctx context.Context, job Job, timeout time.Duration) (finished <-chan struct{}, err error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- job.Run(ctx)
+	}()
+
+	select {
+	case err = <-done:
+		<-exited
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return exited, fmt.Errorf("%w after %s", errTimeout, timeout)
+	}
+	return exited, err
+}
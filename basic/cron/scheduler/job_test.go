@@ -0,0 +1,302 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, w := range want {
+		if got := p.delay(i + 1); got != w*time.Millisecond {
+			t.Errorf("delay(%d) = %v, want %v", i+1, got, w*time.Millisecond)
+		}
+	}
+	if got := (RetryPolicy{Backoff: time.Second}).delay(4); got != 8*time.Second {
+		t.Errorf("uncapped delay(4) = %v, want 8s", got)
+	}
+}
+
+// addJob 注册任务并返回内部的scheduledJob，测试中直接调用execute
+func addJob(t *testing.T, s *Scheduler, name string, job Job, opts JobOptions) *scheduledJob {
+	t.Helper()
+	if err := s.Add(name, everyHour, job, opts); err != nil {
+		t.Fatal(err)
+	}
+	return s.jobs[name]
+}
+
+func newJobTestScheduler(t *testing.T) *Scheduler {
+	t.Helper()
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(store, discard)
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func lastRun(t *testing.T, s *Scheduler, job string) RunRecord {
+	t.Helper()
+	rec, ok, err := s.store.LastRun(job)
+	if err != nil || !ok {
+		t.Fatalf("no run record for %s: %v", job, err)
+	}
+	return rec
+}
+
+func TestRetryUntilSuccess(t *testing.T) {
+	s := newJobTestScheduler(t)
+	var calls atomic.Int32
+	sj := addJob(t, s, "flaky", JobFunc(func(ctx context.Context) error {
+		if calls.Add(1) < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}), JobOptions{Retry: RetryPolicy{MaxAttempts: 5, Backoff: time.Millisecond}})
+
+	s.execute(sj, t0, runScheduled)
+	rec := lastRun(t, s, "flaky")
+	if rec.Status != StatusSuccess || rec.Attempts != 3 || rec.Error != "" {
+		t.Fatalf("record = %+v", rec)
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	s := newJobTestScheduler(t)
+	var calls atomic.Int32
+	sj := addJob(t, s, "broken", JobFunc(func(ctx context.Context) error {
+		calls.Add(1)
+		return errors.New("connection refused")
+	}), JobOptions{Retry: RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}})
+
+	s.execute(sj, t0, runScheduled)
+	rec := lastRun(t, s, "broken")
+	if rec.Status != StatusFailed || rec.Attempts != 3 || rec.Error != "connection refused" || calls.Load() != 3 {
+		t.Fatalf("record = %+v, calls = %d", rec, calls.Load())
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	s := newJobTestScheduler(t)
+
+	// 响应ctx的任务
+	sj := addJob(t, s, "polite", JobFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), JobOptions{Timeout: 10 * time.Millisecond, Retry: RetryPolicy{MaxAttempts: 2}})
+	s.execute(sj, t0, runScheduled)
+	rec := lastRun(t, s, "polite")
+	if rec.Status != StatusTimeout || rec.Attempts != 2 || !strings.Contains(rec.Error, "timeout after 10ms") {
+		t.Fatalf("record = %+v", rec)
+	}
+
+	// 不响应ctx的任务，超时后不再等待它
+	release := make(chan struct{})
+	defer close(release)
+	sj = addJob(t, s, "stubborn", JobFunc(func(ctx context.Context) error {
+		<-release
+		return nil
+	}), JobOptions{Timeout: 10 * time.Millisecond})
+	start := time.Now()
+	s.execute(sj, t0, runScheduled)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("execute blocked for %v", elapsed)
+	}
+	if rec := lastRun(t, s, "stubborn"); rec.Status != StatusTimeout {
+		t.Fatalf("record = %+v", rec)
+	}
+}
+
+func TestTimeoutKeepsOverlapUntilJobExits(t *testing.T) {
+	for _, policy := range []OverlapPolicy{OverlapSkip, OverlapQueue} {
+		t.Run(string(policy), func(t *testing.T) {
+			s := newJobTestScheduler(t)
+			// blockingJob不响应ctx，和CronJob适配的任务一样超时后仍在运行
+			job := newBlockingJob()
+			sj := addJob(t, s, "stubborn", job, JobOptions{Overlap: policy, Timeout: 10 * time.Millisecond})
+
+			s.execute(sj, t0, runScheduled)
+			if rec := lastRun(t, s, "stubborn"); rec.Status != StatusTimeout {
+				t.Fatalf("first run = %+v, want timeout", rec)
+			}
+
+			// execute已经返回，但任务还在运行，下一次执行不能和它重叠
+			second := make(chan struct{})
+			go func() { defer close(second); s.execute(sj, t0.Add(time.Hour), runScheduled) }()
+			if policy == OverlapSkip {
+				<-second
+				if rec := lastRun(t, s, "stubborn"); rec.Status != StatusSkipped {
+					t.Fatalf("overlapping run = %+v, want skipped", rec)
+				}
+			} else {
+				time.Sleep(50 * time.Millisecond)
+				if runs, _ := job.stats(); runs != 1 {
+					t.Fatalf("runs while first is still running = %d, want 1", runs)
+				}
+			}
+			close(job.release)
+			<-second
+
+			if _, peak := job.stats(); peak != 1 {
+				t.Fatalf("peak = %d, want 1", peak)
+			}
+		})
+	}
+}
+
+func TestRetryWaitsForTimedOutAttempt(t *testing.T) {
+	s := newJobTestScheduler(t)
+	job := newBlockingJob()
+	sj := addJob(t, s, "stubborn", job, JobOptions{
+		Timeout: 10 * time.Millisecond,
+		Retry:   RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+	})
+
+	done := make(chan struct{})
+	go func() { defer close(done); s.execute(sj, t0, runScheduled) }()
+	<-job.started
+	time.Sleep(50 * time.Millisecond)
+	if runs, _ := job.stats(); runs != 1 {
+		t.Fatalf("retry started while the first attempt is running, runs = %d", runs)
+	}
+	close(job.release)
+	<-done
+
+	if runs, peak := job.stats(); runs != 2 || peak != 1 {
+		t.Fatalf("runs = %d, peak = %d, want 2, 1", runs, peak)
+	}
+}
+
+// blockingJob 在release关闭前一直阻塞，统计同时执行的最大数量
+type blockingJob struct {
+	release chan struct{}
+	started chan struct{}
+
+	mu            sync.Mutex
+	running, peak int
+	runs          int
+}
+
+func newBlockingJob() *blockingJob {
+	return &blockingJob{release: make(chan struct{}), started: make(chan struct{}, 10)}
+}
+
+func (j *blockingJob) Run(ctx context.Context) error {
+	j.mu.Lock()
+	j.running++
+	j.runs++
+	if j.running > j.peak {
+		j.peak = j.running
+	}
+	j.mu.Unlock()
+	j.started <- struct{}{}
+
+	<-j.release
+
+	j.mu.Lock()
+	j.running--
+	j.mu.Unlock()
+	return nil
+}
+
+func (j *blockingJob) stats() (runs, peak int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.runs, j.peak
+}
+
+func TestOverlapPolicies(t *testing.T) {
+	tests := []struct {
+		policy      OverlapPolicy
+		wantRuns    int
+		wantPeak    int
+		wantSkipped int
+	}{
+		{OverlapSkip, 1, 1, 2},
+		{OverlapQueue, 3, 1, 0},
+		{OverlapAllow, 3, 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			s := newJobTestScheduler(t)
+			job := newBlockingJob()
+			sj := addJob(t, s, "slow", job, JobOptions{Overlap: tt.policy})
+
+			// 第一次执行开始后再触发两次
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() { defer wg.Done(); s.execute(sj, t0, runScheduled) }()
+			<-job.started
+			for i := 1; i <= 2; i++ {
+				wg.Add(1)
+				go func(i int) { defer wg.Done(); s.execute(sj, t0.Add(time.Duration(i)*time.Hour), runScheduled) }(i)
+			}
+			// 等待后两次触发到达：skip立即记录，allow开始执行，queue在排队
+			time.Sleep(50 * time.Millisecond)
+			close(job.release)
+			wg.Wait()
+
+			runs, peak := job.stats()
+			if runs != tt.wantRuns || peak != tt.wantPeak {
+				t.Fatalf("runs = %d, peak = %d, want %d, %d", runs, peak, tt.wantRuns, tt.wantPeak)
+			}
+			skipped, _ := s.History("slow", HistoryQuery{Status: StatusSkipped})
+			if len(skipped) != tt.wantSkipped {
+				t.Fatalf("skipped records = %d, want %d", len(skipped), tt.wantSkipped)
+			}
+			all, _ := s.History("slow", HistoryQuery{})
+			if len(all) != 3 {
+				t.Fatalf("history = %d records, want 3", len(all))
+			}
+		})
+	}
+}
+
+func TestStopCancelsRunningJobs(t *testing.T) {
+	store, _ := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	s := New(store, discard)
+	started := make(chan struct{})
+	sj := addJob(t, s, "long", JobFunc(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}), JobOptions{Retry: RetryPolicy{MaxAttempts: 5, Backoff: time.Hour}})
+
+	done := make(chan struct{})
+	go func() { s.execute(sj, t0, runScheduled); close(done) }()
+	<-started
+	s.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("execute did not return after Stop")
+	}
+	// 调度器停止后不再重试
+	if rec := lastRun(t, s, "long"); rec.Status != StatusFailed || rec.Attempts != 1 || rec.Error != "context canceled" {
+		t.Fatalf("record = %+v", rec)
+	}
+}
+
+func TestJobOptionsValidation(t *testing.T) {
+	invalid := []JobOptions{
+		{Overlap: "parallel"},
+		{Timeout: -time.Second},
+		{Retry: RetryPolicy{MaxAttempts: 3, Backoff: -time.Second}},
+	}
+	s := newJobTestScheduler(t)
+	for i, opts := range invalid {
+		if err := s.Add("job", everyHour, &countingJob{}, opts); err == nil {
+			t.Errorf("options %d (%+v) expected error", i, opts)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLocked 锁被其他持有者持有且没有过期
+var ErrLocked = errors.New("lock is held")
+
+// ErrLockNotHeld 释放的锁已经过期并被其他持有者获取，或者已经释放
+var ErrLockNotHeld = errors.New("lock not held")
+
+// Lock 一把已经获取的锁
+// Token 为栅栏令牌：同一个后端每次成功获取锁都会分配一个更大的值，
+// 下游系统记住见过的最大令牌并拒绝更小的令牌，就能拒绝锁过期后才醒来的旧持有者的写入
+type Lock struct {
+	Key       string
+	Owner     string
+	Token     uint64
+	ExpiresAt time.Time
+}
+
+// Locker 分布式锁后端
+// 多个调度器实例通过同一个后端争抢每一次计划执行，只有获取到锁的实例会执行
+type Locker interface {
+	// Acquire 获取key的锁，锁被其他持有者持有且未过期时返回包装了ErrLocked的错误
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (Lock, error)
+	// Release 提前释放锁，锁已经不属于调用方时返回ErrLockNotHeld
+	Release(ctx context.Context, lock Lock) error
+}
+
+// lockedError 锁被占用时返回的错误，带上当前持有者方便排查
+func lockedError(key, owner string, token uint64) error {
+	return fmt.Errorf("%w: %s by %s (token %d)", ErrLocked, key, owner, token)
+}
+
+type fencingTokenKey struct{}
+
+// withFencingToken 把栅栏令牌放入任务的ctx
+func withFencingToken(ctx context.Context, token uint64) context.Context {
+	return context.WithValue(ctx, fencingTokenKey{}, token)
+}
+
+// FencingToken 返回本次执行持有的栅栏令牌，没有配置Locker或手动触发时返回false
+// 任务写入外部系统时应该带上这个令牌
+func FencingToken(ctx context.Context) (uint64, bool) {
+	token, ok := ctx.Value(fencingTokenKey{}).(uint64)
+	return token, ok
+}
+
+// tickLockKey 每一次计划执行对应一把锁
+func tickLockKey(job string, scheduledAt time.Time) string {
+	return job + "@" + scheduledAt.UTC().Format(time.RFC3339)
+}
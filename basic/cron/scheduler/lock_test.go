@@ -0,0 +1,339 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// lockBackend 同一个后端上创建多个Locker实例，模拟多个进程
+type lockBackend func(t *testing.T, clock *fakeClock) func() Locker
+
+func fileBackend(t *testing.T, clock *fakeClock) func() Locker {
+	dir := t.TempDir()
+	return func() Locker {
+		l, err := NewFileLocker(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if clock != nil {
+			l.now = clock.Now
+		}
+		return l
+	}
+}
+
+func sqlBackend(t *testing.T, clock *fakeClock) func() Locker {
+	dsn := "file:" + filepath.Join(t.TempDir(), "locks.db") + "?_busy_timeout=5000&_txlock=immediate"
+	return func() Locker {
+		db, err := sql.Open("sqlite3", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		l, err := NewSQLLocker(context.Background(), db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if clock != nil {
+			l.now = clock.Now
+		}
+		return l
+	}
+}
+
+var lockBackends = map[string]lockBackend{
+	"file": fileBackend,
+	"sql":  sqlBackend,
+}
+
+func TestLockers(t *testing.T) {
+	ctx := context.Background()
+	for name, backend := range lockBackends {
+		t.Run(name, func(t *testing.T) {
+			clock := &fakeClock{now: t0}
+			newLocker := backend(t, clock)
+			a, b := newLocker(), newLocker()
+
+			first, err := a.Acquire(ctx, "report@t0", "a", time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first.Owner != "a" || !first.ExpiresAt.Equal(t0.Add(time.Minute)) {
+				t.Fatalf("lock = %+v", first)
+			}
+
+			// 未过期时其他持有者获取失败，错误中包含当前持有者
+			if _, err := b.Acquire(ctx, "report@t0", "b", time.Minute); !errors.Is(err, ErrLocked) {
+				t.Fatalf("Acquire held lock error = %v, want ErrLocked", err)
+			}
+			// 不同key互不影响
+			if _, err := b.Acquire(ctx, "report@t1", "b", time.Minute); err != nil {
+				t.Fatal(err)
+			}
+
+			// 过期后可以被接管，令牌变大，旧令牌无法释放
+			clock.Set(t0.Add(time.Minute))
+			second, err := b.Acquire(ctx, "report@t0", "b", time.Minute)
+			if err != nil {
+				t.Fatalf("Acquire expired lock: %v", err)
+			}
+			if second.Token <= first.Token {
+				t.Fatalf("token after takeover = %d, want > %d", second.Token, first.Token)
+			}
+			if err := a.Release(ctx, first); !errors.Is(err, ErrLockNotHeld) {
+				t.Fatalf("Release stale lock error = %v, want ErrLockNotHeld", err)
+			}
+
+			if err := b.Release(ctx, second); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.Release(ctx, second); !errors.Is(err, ErrLockNotHeld) {
+				t.Fatalf("second Release error = %v, want ErrLockNotHeld", err)
+			}
+			if _, err := a.Acquire(ctx, "report@t0", "a", time.Minute); err != nil {
+				t.Fatalf("Acquire released lock: %v", err)
+			}
+		})
+	}
+}
+
+func TestLockersConcurrentAcquire(t *testing.T) {
+	ctx := context.Background()
+	for name, backend := range lockBackends {
+		t.Run(name, func(t *testing.T) {
+			newLocker := backend(t, nil)
+			lockers := []Locker{newLocker(), newLocker(), newLocker()}
+
+			var winners atomic.Int64
+			var wg sync.WaitGroup
+			for i := 0; i < 12; i++ {
+				wg.Add(1)
+				go func(l Locker) {
+					defer wg.Done()
+					_, err := l.Acquire(ctx, "report@t0", "worker", time.Minute)
+					switch {
+					case err == nil:
+						winners.Add(1)
+					case !errors.Is(err, ErrLocked):
+						t.Error(err)
+					}
+				}(lockers[i%len(lockers)])
+			}
+			wg.Wait()
+
+			if n := winners.Load(); n != 1 {
+				t.Fatalf("winners = %d, want 1", n)
+			}
+		})
+	}
+}
+
+func TestFileLockerGuard(t *testing.T) {
+	dir := t.TempDir()
+	guard := filepath.Join(dir, guardFile)
+	// 崩溃的进程留下的guard文件上没有锁，不会挡住其他进程
+	if err := os.WriteFile(guard, []byte("crashed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 多个实例同时争抢guard，任何时刻只有一个在临界区内
+	var inside, overlaps atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		l, err := NewFileLocker(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				err := l.withGuard(context.Background(), func() error {
+					if inside.Add(1) > 1 {
+						overlaps.Add(1)
+					}
+					time.Sleep(100 * time.Microsecond)
+					inside.Add(-1)
+					return nil
+				})
+				if err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := overlaps.Load(); n != 0 {
+		t.Fatalf("%d overlapping critical sections", n)
+	}
+
+	// 其他进程持有guard时一直等待，它退出（关闭文件描述符）后立即可以获取
+	holder, err := os.OpenFile(guard, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := lockFile(holder); !ok || err != nil {
+		t.Fatalf("lockFile = %v, %v", ok, err)
+	}
+	l, _ := NewFileLocker(dir)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, "report@t0", "a", time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire with held guard error = %v, want DeadlineExceeded", err)
+	}
+	holder.Close()
+	if _, err := l.Acquire(context.Background(), "report@t0", "a", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tokenJob 记录每次执行拿到的栅栏令牌
+type tokenJob struct {
+	mu     sync.Mutex
+	tokens []uint64
+}
+
+func (j *tokenJob) Run(ctx context.Context) error {
+	token, _ := FencingToken(ctx)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.tokens = append(j.tokens, token)
+	return nil
+}
+
+func (j *tokenJob) Tokens() []uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]uint64(nil), j.tokens...)
+}
+
+// TestDistributedSchedulers 三个实例各自使用独立的Store，共享同一个锁后端，
+// 每秒执行的任务在每一秒只能运行一次
+func TestDistributedSchedulers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs for several seconds")
+	}
+	for name, backend := range lockBackends {
+		t.Run(name, func(t *testing.T) {
+			newLocker := backend(t, nil)
+			job := &tokenJob{}
+			var instances []*Scheduler
+			for _, id := range []string{"node-1", "node-2", "node-3"} {
+				store, err := NewFileStore(filepath.Join(t.TempDir(), id+".json"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				s := New(store, discard, WithLocker(newLocker(), time.Minute), WithInstanceID(id))
+				if err := s.Add("sync", "* * * * * *", job, JobOptions{}); err != nil {
+					t.Fatal(err)
+				}
+				instances = append(instances, s)
+			}
+
+			for _, s := range instances {
+				if err := s.Start(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			time.Sleep(2500 * time.Millisecond)
+			for _, s := range instances {
+				s.Stop()
+			}
+
+			// 每一次计划执行在每个实例上都有记录，其中只有一条是成功的
+			runs := make(map[time.Time]int)
+			total := 0
+			for _, s := range instances {
+				history, err := s.History("sync", HistoryQuery{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, rec := range history {
+					switch rec.Status {
+					case StatusSuccess:
+						runs[rec.ScheduledAt]++
+						total++
+						if rec.Token == 0 {
+							t.Errorf("run at %s has no fencing token", rec.ScheduledAt)
+						}
+					case StatusSkipped:
+						if _, ok := runs[rec.ScheduledAt]; !ok {
+							runs[rec.ScheduledAt] = 0
+						}
+					default:
+						t.Errorf("unexpected record %+v", rec)
+					}
+				}
+			}
+
+			if len(runs) < 2 {
+				t.Fatalf("ticks = %d, want at least 2", len(runs))
+			}
+			for at, n := range runs {
+				if n != 1 {
+					t.Errorf("tick %s ran %d times, want 1", at.Format(time.RFC3339), n)
+				}
+			}
+			tokens := job.Tokens()
+			if len(tokens) != total {
+				t.Fatalf("job ran %d times, %d successful records", len(tokens), total)
+			}
+			seen := make(map[uint64]bool)
+			for _, token := range tokens {
+				if token == 0 || seen[token] {
+					t.Fatalf("tokens = %v, want unique non-zero tokens", tokens)
+				}
+				seen[token] = true
+			}
+		})
+	}
+}
+
+// TestDistributedCatchUp 两个实例同时重启，错过的执行只补一次
+func TestDistributedCatchUp(t *testing.T) {
+	newLocker := fileBackend(t, nil)
+	clock := &fakeClock{now: t0}
+	job := &tokenJob{}
+
+	var instances []*Scheduler
+	for _, id := range []string{"node-1", "node-2"} {
+		store, err := NewFileStore(filepath.Join(t.TempDir(), id+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := New(store, discard, WithClock(clock.Now), WithLocker(newLocker(), time.Minute), WithInstanceID(id))
+		if err := s.Add("report", everyHour, job, JobOptions{CatchUp: CatchUpOnce}); err != nil {
+			t.Fatal(err)
+		}
+		instances = append(instances, s)
+	}
+
+	clock.Set(t0.Add(3*time.Hour + 30*time.Minute))
+	for _, s := range instances {
+		if err := s.Start(); err != nil {
+			t.Fatal(err)
+		}
+		s.Stop()
+	}
+
+	if tokens := job.Tokens(); len(tokens) != 1 {
+		t.Fatalf("catch-up ran %d times, want 1", len(tokens))
+	}
+	winner := lastRun(t, instances[0], "report")
+	if winner.Status != StatusSuccess || !winner.CatchUp || winner.Token != job.Tokens()[0] {
+		t.Fatalf("first instance record = %+v", winner)
+	}
+	loser := lastRun(t, instances[1], "report")
+	if loser.Status != StatusSkipped || !loser.ScheduledAt.Equal(t0.Add(3*time.Hour)) {
+		t.Fatalf("second instance record = %+v", loser)
+	}
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// JobFactory 根据JSON参数创建任务，参数不合法时返回错误
+type JobFactory func(params json.RawMessage) (Job, error)
+
+// Registry 任务类型注册表
+// 通过管理接口添加的任务只保存类型名和参数，创建和重启恢复时都通过注册表实例化
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]JobFactory
+}
+
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]JobFactory)}
+}
+
+// Register 注册任务类型，同名类型只能注册一次
+func (r *Registry) Register(typ string, f JobFactory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.factories[typ]; ok {
+		return fmt.Errorf("job type %q already registered", typ)
+	}
+	r.factories[typ] = f
+	return nil
+}
+
+// Create 创建typ类型的任务
+func (r *Registry) Create(typ string, params json.RawMessage) (Job, error) {
+	r.mu.RLock()
+	f, ok := r.factories[typ]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownType, typ)
+	}
+	job, err := f(params)
+	if err != nil {
+		return nil, fmt.Errorf("%w: job type %s: params: %w", ErrInvalidJob, typ, err)
+	}
+	return job, nil
+}
+
+// Types 返回所有已注册的类型名
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]string, 0, len(r.factories))
+	for typ := range r.factories {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,721 @@
+// Package scheduler 在robfig/cron外面包了一层持久化：
+// 任务定义和执行历史保存在Store中，进程重启后根据上次执行时间找出错过的执行，
+// 并按任务的补偿策略处理；每次执行支持超时、失败重试和重叠策略。
+// 配置Locker后，多个实例可以运行同一组任务，每次计划执行只会在一个实例上运行。
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobExists   = errors.New("job already exists")
+	ErrUnknownType = errors.New("unknown job type")
+	ErrInvalidJob  = errors.New("invalid job")
+)
+
+// defaultMaxCatchUp CatchUpAll策略下最多补执行的次数
+// 每秒执行的任务停机一天会错过8万多次，全部补执行没有意义
+const defaultMaxCatchUp = 100
+
+// defaultLockTTL 未指定时每次计划执行的锁的有效期
+const defaultLockTTL = 10 * time.Minute
+
+// Scheduler 带持久化和错过补偿的调度器
+type Scheduler struct {
+	cron       *cron.Cron
+	parser     cron.Parser
+	store      Store
+	registry   *Registry
+	now        func() time.Time
+	logger     *log.Logger
+	maxCatchUp int
+	calendars  map[string]*Calendar
+
+	// locker 不为nil时，计划执行和补执行前先获取锁，instance为锁的持有者标识
+	locker   Locker
+	lockTTL  time.Duration
+	instance string
+
+	// ctx 在Stop时取消，通知正在执行的任务和等待重试的任务退出
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	jobs     map[string]*scheduledJob
+	started  bool
+	starting bool           // Start正在补偿错过的执行
+	triggers sync.WaitGroup // Trigger启动的执行
+}
+
+type scheduledJob struct {
+	def      JobDefinition
+	schedule cron.Schedule
+	job      Job
+	id       cron.EntryID
+
+	running atomic.Bool // OverlapSkip：是否有执行正在进行
+	queue   sync.Mutex  // OverlapQueue：串行执行
+}
+
+// Option 调度器选项
+type Option func(*Scheduler)
+
+// WithClock 替换当前时间的来源，只影响错过检测和执行记录，测试时使用
+func WithClock(now func() time.Time) Option {
+	return func(s *Scheduler) { s.now = now }
+}
+
+// WithLogger 设置日志输出
+func WithLogger(l *log.Logger) Option {
+	return func(s *Scheduler) { s.logger = l }
+}
+
+// WithRegistry 设置任务类型注册表，AddType和Restore需要它
+func WithRegistry(r *Registry) Option {
+	return func(s *Scheduler) { s.registry = r }
+}
+
+// WithMaxCatchUp 设置CatchUpAll策略下最多补执行的次数
+func WithMaxCatchUp(n int) Option {
+	return func(s *Scheduler) { s.maxCatchUp = n }
+}
+
+// WithCalendar 注册工作日历，任务通过JobOptions.Calendar按名称引用
+func WithCalendar(cal *Calendar) Option {
+	return func(s *Scheduler) { s.calendars[cal.Name] = cal }
+}
+
+// WithLocker 设置分布式锁，多个实例共享同一个后端时每次计划执行只在一个实例上运行
+// 锁在执行结束后不释放，而是等ttl过期，这样启动较晚或时钟稍慢的实例不会再执行同一次；
+// ttl应大于实例之间的时钟偏差和任务的执行时间，ttl<=0时使用10分钟
+func WithLocker(l Locker, ttl time.Duration) Option {
+	return func(s *Scheduler) {
+		if ttl <= 0 {
+			ttl = defaultLockTTL
+		}
+		s.locker, s.lockTTL = l, ttl
+	}
+}
+
+// WithInstanceID 设置实例标识，作为锁的持有者记录下来，默认为 主机名-进程号
+func WithInstanceID(id string) Option {
+	return func(s *Scheduler) { s.instance = id }
+}
+
+// New 创建调度器，spec格式与cron.WithSeconds()相同，第一个字段为秒
+func New(store Store, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		parser:     cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor),
+		store:      store,
+		now:        time.Now,
+		logger:     log.New(os.Stdout, "[scheduler] ", log.LstdFlags),
+		registry:   NewRegistry(),
+		maxCatchUp: defaultMaxCatchUp,
+		jobs:       make(map[string]*scheduledJob),
+		calendars:  make(map[string]*Calendar),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.instance == "" {
+		host, _ := os.Hostname()
+		s.instance = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	s.cron = cron.New(cron.WithParser(s.parser))
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s
+}
+
+// Add 注册任务并保存任务定义
+// 同名任务已经保存过时沿用原来的创建时间和暂停状态，这样从未执行过的任务重启后也能检测到错过的执行
+func (s *Scheduler) Add(name, spec string, job Job, opts JobOptions) error {
+	return s.add(JobDefinition{Name: name, Spec: spec, Options: opts}, job)
+}
+
+// AddType 通过注册表创建typ类型的任务并注册，类型和参数会保存下来，重启后由Restore恢复
+func (s *Scheduler) AddType(name, typ, spec string, params json.RawMessage, opts JobOptions) error {
+	job, err := s.registry.Create(typ, params)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	return s.add(JobDefinition{Name: name, Spec: spec, Type: typ, Params: params, Options: opts}, job)
+}
+
+// Restore 恢复之前通过AddType添加的任务，应该在Start之前调用
+// 已经注册过的同名任务会被跳过，类型未注册或参数不合法的任务会返回错误
+func (s *Scheduler) Restore() error {
+	defs, err := s.store.Jobs()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, def := range defs {
+		if def.Type == "" || s.has(def.Name) {
+			continue
+		}
+		if err := s.AddType(def.Name, def.Type, def.Spec, def.Params, def.Options); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Scheduler) has(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.jobs[name]
+	return ok
+}
+
+func (s *Scheduler) add(def JobDefinition, job Job) error {
+	opts, err := def.Options.normalize()
+	if err != nil {
+		return fmt.Errorf("job %s: %w: %w", def.Name, ErrInvalidJob, err)
+	}
+	def.Options = opts
+	schedule, err := s.parseSchedule(def.Spec, opts)
+	if err != nil {
+		return fmt.Errorf("job %s: %w: %w", def.Name, ErrInvalidJob, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[def.Name]; ok {
+		return fmt.Errorf("job %s: %w", def.Name, ErrJobExists)
+	}
+
+	def.CreatedAt = s.now()
+	if saved, ok, err := s.store.Job(def.Name); err != nil {
+		return err
+	} else if ok {
+		def.CreatedAt = saved.CreatedAt
+		def.Paused = saved.Paused
+		def.ResumedAt = saved.ResumedAt
+	}
+	if err := s.store.SaveJob(def); err != nil {
+		return err
+	}
+
+	sj := &scheduledJob{def: def, schedule: schedule, job: job}
+	s.jobs[def.Name] = sj
+	if s.started && !def.Paused {
+		s.schedule(sj)
+	}
+	return nil
+}
+
+// Remove 删除任务和它的执行记录，正在进行的执行不受影响
+func (s *Scheduler) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sj, err := s.lookup(name)
+	if err != nil {
+		return err
+	}
+	s.unschedule(sj)
+	delete(s.jobs, name)
+	return s.store.DeleteJob(name)
+}
+
+// Pause 暂停任务，暂停期间错过的执行不会补偿
+func (s *Scheduler) Pause(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sj, err := s.lookup(name)
+	if err != nil || sj.def.Paused {
+		return err
+	}
+	s.unschedule(sj)
+	sj.def.Paused = true
+	return s.store.SaveJob(sj.def)
+}
+
+// Resume 恢复暂停的任务
+func (s *Scheduler) Resume(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sj, err := s.lookup(name)
+	if err != nil || !sj.def.Paused {
+		return err
+	}
+	sj.def.Paused = false
+	sj.def.ResumedAt = s.now()
+	if s.started {
+		s.schedule(sj)
+	}
+	return s.store.SaveJob(sj.def)
+}
+
+// Trigger 立即在后台执行一次任务，不影响原有的调度，暂停的任务也可以触发
+// 重叠策略、超时和重试同样适用
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sj, err := s.lookup(name)
+	if err != nil {
+		return err
+	}
+	s.triggers.Add(1)
+	go func() {
+		defer s.triggers.Done()
+		s.execute(sj, s.now(), runManual)
+	}()
+	return nil
+}
+
+// EntryInfo 任务的当前状态
+// Next 为下一次计划执行时间，暂停或调度器未启动时按当前时间计算；Prev 为上一次计划执行的时间
+type EntryInfo struct {
+	Definition JobDefinition
+	Next       time.Time
+	Prev       time.Time
+	LastRun    *RunRecord
+}
+
+// Entries 按名称排序返回所有任务的状态
+func (s *Scheduler) Entries() ([]EntryInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]EntryInfo, 0, len(s.jobs))
+	for _, sj := range s.jobs {
+		info, err := s.entryInfo(sj)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Definition.Name < infos[j].Definition.Name })
+	return infos, nil
+}
+
+// Entry 返回单个任务的状态
+func (s *Scheduler) Entry(name string) (EntryInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sj, err := s.lookup(name)
+	if err != nil {
+		return EntryInfo{}, err
+	}
+	return s.entryInfo(sj)
+}
+
+// entryInfo 调用方必须持有s.mu
+func (s *Scheduler) entryInfo(sj *scheduledJob) (EntryInfo, error) {
+	info := EntryInfo{Definition: sj.def}
+	if last, ok, err := s.store.LastRun(sj.def.Name); err != nil {
+		return info, err
+	} else if ok {
+		info.LastRun = &last
+		info.Prev = last.ScheduledAt
+	}
+	if sj.id != 0 {
+		e := s.cron.Entry(sj.id)
+		info.Next = e.Next
+		if !e.Prev.IsZero() {
+			info.Prev = e.Prev
+		}
+	}
+	if info.Next.IsZero() && !sj.def.Paused {
+		info.Next = sj.schedule.Next(s.now())
+	}
+	return info, nil
+}
+
+// lookup 查找任务，调用方必须持有s.mu
+func (s *Scheduler) lookup(name string) (*scheduledJob, error) {
+	sj, ok := s.jobs[name]
+	if !ok {
+		return nil, fmt.Errorf("job %s: %w", name, ErrJobNotFound)
+	}
+	return sj, nil
+}
+
+// Start 先处理所有任务错过的执行，再启动cron
+// 补偿执行是同步的，Start返回时已经全部完成；补偿期间不持有s.mu，管理接口可以正常使用，
+// 补偿期间添加、恢复的任务在补偿结束后开始调度，删除、暂停的任务不再调度
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	if s.started || s.starting {
+		s.mu.Unlock()
+		return nil
+	}
+	names := make([]string, 0, len(s.jobs))
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var plans []catchUpPlan
+	for _, name := range names {
+		if sj := s.jobs[name]; !sj.def.Paused {
+			plan, err := s.planCatchUp(sj)
+			if err != nil {
+				s.mu.Unlock()
+				return err
+			}
+			if plan.total > 0 {
+				plans = append(plans, plan)
+			}
+		}
+	}
+	s.starting = true
+	s.mu.Unlock()
+
+	var err error
+	for _, plan := range plans {
+		if err = s.catchUp(plan); err != nil {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.starting = false
+	if err != nil {
+		return err
+	}
+	for _, sj := range s.jobs {
+		if !sj.def.Paused {
+			s.schedule(sj)
+		}
+	}
+	s.cron.Start()
+	s.started = true
+	return nil
+}
+
+// Stop 停止调度，取消正在执行的任务的ctx，并等待它们返回
+func (s *Scheduler) Stop() {
+	s.cancel()
+	<-s.cron.Stop().Done()
+	s.triggers.Wait()
+}
+
+// History 查询任务的执行历史
+func (s *Scheduler) History(job string, q HistoryQuery) ([]RunRecord, error) {
+	return s.store.History(job, q)
+}
+
+// parseSchedule 解析spec，设置了时区或日历时包装成CalendarSchedule
+func (s *Scheduler) parseSchedule(spec string, opts JobOptions) (cron.Schedule, error) {
+	schedule, err := s.parser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("spec %q: %w", spec, err)
+	}
+	if opts.TimeZone == "" && opts.Calendar == "" && opts.Days == EveryDay {
+		return schedule, nil
+	}
+
+	specSchedule, ok := schedule.(*cron.SpecSchedule)
+	if !ok {
+		return nil, fmt.Errorf("spec %q: time zone and calendar rules require a cron expression", spec)
+	}
+	var loc *time.Location
+	if opts.TimeZone != "" {
+		if specSchedule.Location != time.Local {
+			return nil, fmt.Errorf("spec %q: time zone set in both spec and options", spec)
+		}
+		if loc, err = time.LoadLocation(opts.TimeZone); err != nil {
+			return nil, fmt.Errorf("time zone: %w", err)
+		}
+	}
+	var cal *Calendar
+	if opts.Calendar != "" {
+		if cal, ok = s.calendars[opts.Calendar]; !ok {
+			return nil, fmt.Errorf("unknown calendar %q", opts.Calendar)
+		}
+	}
+	return NewCalendarSchedule(specSchedule, loc, cal, opts.Days)
+}
+
+// unschedule 从cron中移除任务，调用方必须持有s.mu
+func (s *Scheduler) unschedule(sj *scheduledJob) {
+	if sj.id != 0 {
+		s.cron.Remove(sj.id)
+		sj.id = 0
+	}
+}
+
+// schedule 把任务交给cron，调用方必须持有s.mu
+// 锁和fencing令牌的key使用cron算出的计划时间，而不是任务开始执行的时间：
+// 各个实例的cron被唤醒的时间有先后，跨过整秒时截断当前时间会得到不同的key，同一次执行就会在两个实例上都运行
+func (s *Scheduler) schedule(sj *scheduledJob) {
+	ticks := &tickRecorder{Schedule: sj.schedule}
+	sj.id = s.cron.Schedule(ticks, cron.FuncJob(func() {
+		scheduledAt, ok := ticks.take(time.Now())
+		if !ok {
+			scheduledAt = s.now().Truncate(time.Second)
+		}
+		s.execute(sj, scheduledAt, runScheduled)
+	}))
+}
+
+// tickRecorder 记录cron通过Next算出的每一个计划时间
+// cron把到期的任务交给协程之后才计算下一次的计划时间，所以任务执行时，
+// 不晚于当前时间的最后一个计划时间就是本次执行的计划时间
+type tickRecorder struct {
+	cron.Schedule
+
+	mu    sync.Mutex
+	ticks []time.Time
+}
+
+func (r *tickRecorder) Next(t time.Time) time.Time {
+	next := r.Schedule.Next(t)
+	if !next.IsZero() {
+		r.mu.Lock()
+		r.ticks = append(r.ticks, next)
+		r.mu.Unlock()
+	}
+	return next
+}
+
+// take 取出不晚于now的最后一个计划时间，更早的计划时间（例如cron重新启动前算出的）一并丢弃
+func (r *tickRecorder) take(now time.Time) (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := 0
+	for i < len(r.ticks) && !r.ticks[i].After(now) {
+		i++
+	}
+	if i == 0 {
+		return time.Time{}, false
+	}
+	tick := r.ticks[i-1]
+	r.ticks = r.ticks[i:]
+	return tick, true
+}
+
+// catchUpPlan 一个任务错过的执行，missed是按策略需要处理的最近几次，total是错过的总次数
+type catchUpPlan struct {
+	sj     *scheduledJob
+	policy CatchUpPolicy
+	since  time.Time
+	missed []time.Time
+	total  int
+}
+
+// planCatchUp 找出上次执行之后到现在之间错过的执行，调用方必须持有s.mu
+func (s *Scheduler) planCatchUp(sj *scheduledJob) (catchUpPlan, error) {
+	plan := catchUpPlan{sj: sj, policy: sj.def.Options.CatchUp, since: sj.def.CreatedAt}
+	if sj.def.ResumedAt.After(plan.since) {
+		plan.since = sj.def.ResumedAt
+	}
+	if last, ok, err := s.store.LastRun(sj.def.Name); err != nil {
+		return plan, err
+	} else if ok && last.ScheduledAt.After(plan.since) {
+		plan.since = last.ScheduledAt
+	}
+
+	keep := 1
+	if plan.policy == CatchUpAll {
+		keep = s.maxCatchUp
+	}
+	plan.missed, plan.total = missedRuns(sj.schedule, plan.since, s.now(), keep)
+	return plan, nil
+}
+
+// catchUp 按策略补偿错过的执行，不需要持有s.mu
+func (s *Scheduler) catchUp(plan catchUpPlan) error {
+	sj, missed, total := plan.sj, plan.missed, plan.total
+	s.logger.Printf("job %s missed %d run(s) since %s, policy %s",
+		sj.def.Name, total, plan.since.Format(time.RFC3339), plan.policy)
+
+	switch plan.policy {
+	case CatchUpOnce:
+		s.execute(sj, missed[len(missed)-1], runCatchUp)
+	case CatchUpAll:
+		if total > len(missed) {
+			s.logger.Printf("job %s: only the latest %d missed run(s) will be executed", sj.def.Name, len(missed))
+		}
+		for _, t := range missed {
+			s.execute(sj, t, runCatchUp)
+		}
+	default:
+		// 记录一条跳过的执行，下次重启时不会再次检测到这些错过的执行
+		now := s.now()
+		return s.store.RecordRun(RunRecord{
+			Job:         sj.def.Name,
+			ScheduledAt: missed[len(missed)-1],
+			StartedAt:   now,
+			FinishedAt:  now,
+			Status:      StatusSkipped,
+			Error:       fmt.Sprintf("skipped %d missed run(s)", total),
+			CatchUp:     true,
+		})
+	}
+	return nil
+}
+
+// runKind 执行的触发方式
+type runKind int
+
+const (
+	runScheduled runKind = iota // cron按计划触发
+	runCatchUp                  // 启动时补偿错过的执行
+	runManual                   // 通过Trigger手动触发
+)
+
+// execute 按重叠策略、超时和重试策略执行任务，并保存执行记录
+func (s *Scheduler) execute(sj *scheduledJob, scheduledAt time.Time, kind runKind) {
+	opts := sj.def.Options
+	rec := RunRecord{
+		Job:         sj.def.Name,
+		ScheduledAt: scheduledAt,
+		CatchUp:     kind == runCatchUp,
+		Manual:      kind == runManual,
+	}
+
+	release := func() {}
+	switch opts.Overlap {
+	case OverlapSkip:
+		if !sj.running.CompareAndSwap(false, true) {
+			rec.StartedAt, rec.FinishedAt = s.now(), s.now()
+			rec.Status = StatusSkipped
+			rec.Error = "previous run still in progress"
+			s.record(rec)
+			return
+		}
+		release = func() { sj.running.Store(false) }
+	case OverlapQueue:
+		sj.queue.Lock()
+		release = sj.queue.Unlock
+	}
+	// 超时后不响应ctx的任务还在后台运行，等它真正结束才允许下一次执行，
+	// 否则CronJob这类任务会绕过重叠策略和下一次执行同时运行
+	var finished <-chan struct{}
+	defer func() {
+		if finished == nil {
+			release()
+			return
+		}
+		select {
+		case <-finished:
+			release()
+		default:
+			go func() {
+				<-finished
+				release()
+			}()
+		}
+	}()
+
+	// 先检查重叠再获取锁，本实例忙时不占用这一次执行，由其他实例执行
+	// 手动触发是明确要求执行一次，不参与实例之间的互斥
+	ctx := s.ctx
+	if s.locker != nil && kind != runManual {
+		lock, err := s.locker.Acquire(ctx, tickLockKey(sj.def.Name, scheduledAt), s.instance, s.lockTTL)
+		if err != nil && s.ctx.Err() != nil {
+			return // 调度器停止，不记录，重启后按补偿策略处理
+		}
+		if err != nil {
+			// 其他实例已经执行过这一次时记为跳过，LastRun照常前进，重启后不会再补执行
+			rec.StartedAt, rec.FinishedAt = s.now(), s.now()
+			rec.Status = StatusSkipped
+			if !errors.Is(err, ErrLocked) {
+				rec.Status = StatusFailed
+				s.logger.Printf("job %s: acquire lock: %v", sj.def.Name, err)
+			}
+			rec.Error = err.Error()
+			s.record(rec)
+			return
+		}
+		rec.Token = lock.Token
+		ctx = withFencingToken(ctx, lock.Token)
+	}
+
+	rec.StartedAt = s.now()
+	var err error
+	for rec.Attempts < opts.Retry.MaxAttempts {
+		if rec.Attempts > 0 && !s.sleep(opts.Retry.delay(rec.Attempts)) {
+			break
+		}
+		if finished != nil && opts.Overlap != OverlapAllow && !s.wait(finished) {
+			break
+		}
+		rec.Attempts++
+		if finished, err = attempt(ctx, sj.job, opts.Timeout); err == nil || s.ctx.Err() != nil {
+			break
+		}
+		s.logger.Printf("job %s attempt %d/%d failed: %v", sj.def.Name, rec.Attempts, opts.Retry.MaxAttempts, err)
+	}
+	rec.FinishedAt = s.now()
+
+	switch {
+	case err == nil:
+		rec.Status = StatusSuccess
+	case errors.Is(err, errTimeout):
+		rec.Status = StatusTimeout
+		rec.Error = err.Error()
+	default:
+		rec.Status = StatusFailed
+		rec.Error = err.Error()
+	}
+	s.record(rec)
+}
+
+func (s *Scheduler) record(rec RunRecord) {
+	if err := s.store.RecordRun(rec); err != nil {
+		s.logger.Printf("job %s: save run record: %v", rec.Job, err)
+	}
+}
+
+// sleep 等待d，调度器停止时提前返回false
+func (s *Scheduler) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+// wait 等待ch关闭，调度器停止时提前返回false
+func (s *Scheduler) wait(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+// missedRuns 统计(since, now)之间的计划执行次数，并按时间顺序返回其中最近的keep个
+func missedRuns(schedule cron.Schedule, since, now time.Time, keep int) ([]time.Time, int) {
+	if keep < 1 {
+		keep = 1
+	}
+	var latest []time.Time
+	total := 0
+	for t := schedule.Next(since); !t.IsZero() && t.Before(now); t = schedule.Next(t) {
+		total++
+		if len(latest) == keep {
+			copy(latest, latest[1:])
+			latest = latest[:keep-1]
+		}
+		latest = append(latest, t)
+	}
+	return latest, total
+}
@@ -0,0 +1,331 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"log"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// fakeClock 可手动设置的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// countingJob 记录执行次数
+type countingJob struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (j *countingJob) Run(ctx context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.count++
+	return nil
+}
+
+func (j *countingJob) Count() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.count
+}
+
+var (
+	t0        = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	discard   = WithLogger(log.New(io.Discard, "", 0))
+	everyHour = "0 0 * * * *"
+)
+
+func newTestScheduler(t *testing.T, path string, clock *fakeClock) *Scheduler {
+	t.Helper()
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(store, WithClock(clock.Now), discard)
+}
+
+// restart 模拟进程在start时间启动、在stop时间重启
+func restart(t *testing.T, policy CatchUpPolicy, stop time.Time) (*countingJob, *FileStore) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := &fakeClock{now: t0}
+
+	first := newTestScheduler(t, path, clock)
+	if err := first.Add("report", everyHour, &countingJob{}, JobOptions{CatchUp: policy}); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Set(stop)
+	second := newTestScheduler(t, path, clock)
+	job := &countingJob{}
+	if err := second.Add("report", everyHour, job, JobOptions{CatchUp: policy}); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Start(); err != nil {
+		t.Fatal(err)
+	}
+	second.Stop()
+	return job, second.store.(*FileStore)
+}
+
+func TestCatchUpPolicies(t *testing.T) {
+	// 10:00创建，13:30重启，错过了11:00、12:00、13:00三次
+	stop := t0.Add(3*time.Hour + 30*time.Minute)
+	tests := []struct {
+		policy     CatchUpPolicy
+		wantRuns   int
+		wantStatus RunStatus
+	}{
+		{CatchUpSkip, 0, StatusSkipped},
+		{CatchUpOnce, 1, StatusSuccess},
+		{CatchUpAll, 3, StatusSuccess},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			job, store := restart(t, tt.policy, stop)
+			if job.Count() != tt.wantRuns {
+				t.Fatalf("runs = %d, want %d", job.Count(), tt.wantRuns)
+			}
+			last, ok, _ := store.LastRun("report")
+			if !ok || last.Status != tt.wantStatus || !last.CatchUp {
+				t.Fatalf("last run = %+v", last)
+			}
+			if want := t0.Add(3 * time.Hour); !last.ScheduledAt.Equal(want) {
+				t.Fatalf("last scheduled = %v, want %v", last.ScheduledAt, want)
+			}
+		})
+	}
+}
+
+func TestCatchUpUsesLastRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := &fakeClock{now: t0}
+
+	s := newTestScheduler(t, path, clock)
+	s.Add("report", everyHour, &countingJob{}, JobOptions{CatchUp: CatchUpAll})
+	s.store.RecordRun(RunRecord{Job: "report", ScheduledAt: t0.Add(2 * time.Hour), Status: StatusSuccess})
+
+	// 12:00已经执行过，13:30重启只错过13:00
+	clock.Set(t0.Add(3*time.Hour + 30*time.Minute))
+	job := &countingJob{}
+	s2 := newTestScheduler(t, path, clock)
+	s2.Add("report", everyHour, job, JobOptions{CatchUp: CatchUpAll})
+	s2.Start()
+	s2.Stop()
+	if job.Count() != 1 {
+		t.Fatalf("runs = %d, want 1", job.Count())
+	}
+
+	// 再次重启时不会重复补偿
+	job = &countingJob{}
+	s3 := newTestScheduler(t, path, clock)
+	s3.Add("report", everyHour, job, JobOptions{CatchUp: CatchUpAll})
+	s3.Start()
+	s3.Stop()
+	if job.Count() != 0 {
+		t.Fatalf("runs after second restart = %d, want 0", job.Count())
+	}
+}
+
+func TestCatchUpAllLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := &fakeClock{now: t0}
+	store, _ := NewFileStore(path)
+
+	s := New(store, WithClock(clock.Now), WithMaxCatchUp(5), discard)
+	s.Add("tick", "0 * * * * *", &countingJob{}, JobOptions{CatchUp: CatchUpAll})
+
+	clock.Set(t0.Add(time.Hour))
+	store, _ = NewFileStore(path)
+	job := &countingJob{}
+	s = New(store, WithClock(clock.Now), WithMaxCatchUp(5), discard)
+	s.Add("tick", "0 * * * * *", job, JobOptions{CatchUp: CatchUpAll})
+	s.Start()
+	s.Stop()
+
+	if job.Count() != 5 {
+		t.Fatalf("runs = %d, want 5", job.Count())
+	}
+	last, _, _ := store.LastRun("tick")
+	if want := t0.Add(59 * time.Minute); !last.ScheduledAt.Equal(want) {
+		t.Fatalf("last scheduled = %v, want %v", last.ScheduledAt, want)
+	}
+}
+
+// TestCatchUpDoesNotBlockAdmin 补偿执行期间管理接口不会被阻塞
+func TestCatchUpDoesNotBlockAdmin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := &fakeClock{now: t0}
+	s := newTestScheduler(t, path, clock)
+	s.Add("report", everyHour, &countingJob{}, JobOptions{CatchUp: CatchUpAll})
+
+	clock.Set(t0.Add(3*time.Hour + 30*time.Minute))
+	job := newBlockingJob()
+	s = newTestScheduler(t, path, clock)
+	s.Add("report", everyHour, job, JobOptions{CatchUp: CatchUpAll})
+	s.Add("cleanup", everyHour, &countingJob{}, JobOptions{})
+
+	started := make(chan error, 1)
+	go func() { started <- s.Start() }()
+	<-job.started
+
+	admin := make(chan error, 1)
+	go func() {
+		if _, err := s.Entries(); err != nil {
+			admin <- err
+			return
+		}
+		if err := s.Pause("cleanup"); err != nil {
+			admin <- err
+			return
+		}
+		admin <- s.Trigger("cleanup")
+	}()
+	select {
+	case err := <-admin:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("admin calls blocked by catch-up")
+	}
+
+	close(job.release)
+	if err := <-started; err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	if runs, _ := job.stats(); runs != 3 {
+		t.Fatalf("catch-up runs = %d, want 3", runs)
+	}
+
+	// 补偿期间暂停的任务在Start之后也不会被调度
+	info, err := s.Entry("cleanup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Definition.Paused {
+		t.Fatal("cleanup should stay paused")
+	}
+	s.mu.Lock()
+	id := s.jobs["cleanup"].id
+	s.mu.Unlock()
+	if id != 0 {
+		t.Fatal("paused job scheduled after catch-up")
+	}
+}
+
+func TestNewJobHasNoMissedRuns(t *testing.T) {
+	job, store := restart(t, CatchUpAll, t0) // 创建后立即重启
+	if job.Count() != 0 {
+		t.Fatalf("runs = %d, want 0", job.Count())
+	}
+	if _, ok, _ := store.LastRun("report"); ok {
+		t.Fatal("unexpected run record")
+	}
+}
+
+func TestPanicRecordedAsFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := &fakeClock{now: t0}
+	s := newTestScheduler(t, path, clock)
+	s.Add("boom", everyHour, JobFunc(func(ctx context.Context) error { return nil }), JobOptions{CatchUp: CatchUpOnce})
+
+	clock.Set(t0.Add(2 * time.Hour))
+	s = newTestScheduler(t, path, clock)
+	s.Add("boom", everyHour, JobFunc(func(ctx context.Context) error { panic("disk full") }), JobOptions{CatchUp: CatchUpOnce})
+	s.Start()
+	s.Stop()
+
+	last, _, _ := s.store.LastRun("boom")
+	if last.Status != StatusFailed || last.Error != "panic: disk full" {
+		t.Fatalf("last run = %+v", last)
+	}
+}
+
+func TestAddValidation(t *testing.T) {
+	s := newTestScheduler(t, filepath.Join(t.TempDir(), "state.json"), &fakeClock{now: t0})
+	if err := s.Add("a", "not a spec", &countingJob{}, JobOptions{CatchUp: CatchUpSkip}); err == nil {
+		t.Error("expected error for invalid spec")
+	}
+	if err := s.Add("a", everyHour, &countingJob{}, JobOptions{CatchUp: "sometimes"}); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+	if err := s.Add("a", everyHour, &countingJob{}, JobOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("a", everyHour, &countingJob{}, JobOptions{CatchUp: CatchUpSkip}); err == nil {
+		t.Error("expected error for duplicate job")
+	}
+	def, _, _ := s.store.Job("a")
+	if def.Options.CatchUp != CatchUpSkip || def.Options.Overlap != OverlapSkip || def.Options.Retry.MaxAttempts != 1 {
+		t.Errorf("default options = %+v", def.Options)
+	}
+}
+
+func TestScheduledRunRecorded(t *testing.T) {
+	store, _ := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	s := New(store, discard)
+	job := &countingJob{}
+	s.Add("fast", "* * * * * *", job, JobOptions{CatchUp: CatchUpSkip})
+	s.Start()
+	defer s.Stop()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for job.Count() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("job did not run")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.Stop()
+	last, ok, _ := store.LastRun("fast")
+	if !ok || last.Status != StatusSuccess || last.CatchUp || last.ScheduledAt.Nanosecond() != 0 {
+		t.Fatalf("last run = %+v", last)
+	}
+}
+
+func TestTickRecorder(t *testing.T) {
+	sched, err := cron.ParseStandard("*/5 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &tickRecorder{Schedule: sched}
+	if _, ok := r.take(t0); ok {
+		t.Fatal("take before any Next should fail")
+	}
+
+	// cron重新启动前算出的计划时间被丢弃，取不晚于当前时间的最后一个
+	r.Next(t0.Add(-10 * time.Minute))
+	first := r.Next(t0.Add(time.Minute))
+	next := r.Next(first.Add(time.Second))
+	if got, ok := r.take(first.Add(1500 * time.Millisecond)); !ok || !got.Equal(first) {
+		t.Fatalf("take = %v, %v; want %v", got, ok, first)
+	}
+	if _, ok := r.take(first.Add(2 * time.Second)); ok {
+		t.Fatal("tick taken twice")
+	}
+	if got, ok := r.take(next.Add(3 * time.Second)); !ok || !got.Equal(next) {
+		t.Fatalf("take = %v, %v; want %v", got, ok, next)
+	}
+}
@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SQLLocker 基于数据库表的锁，多台机器上的实例共享同一个数据库即可互斥
+// 每次Acquire在一个事务中先递增令牌计数器行，行锁把所有获取者串行化，
+// 然后检查并写入锁行；SQL使用?占位符，适用于SQLite和MySQL
+type SQLLocker struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+var sqlLockSchema = []string{
+	`CREATE TABLE IF NOT EXISTS cron_locks (
+		lock_key   VARCHAR(255) PRIMARY KEY,
+		owner      VARCHAR(255) NOT NULL,
+		token      BIGINT NOT NULL,
+		expires_at BIGINT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS cron_lock_tokens (
+		id    INTEGER PRIMARY KEY,
+		value BIGINT NOT NULL
+	)`,
+}
+
+// NewSQLLocker 创建锁表和令牌计数器
+func NewSQLLocker(ctx context.Context, db *sql.DB) (*SQLLocker, error) {
+	for _, stmt := range sqlLockSchema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("sql locker: %w", err)
+		}
+	}
+	// 多个实例同时初始化时只有一个能插入成功
+	var n int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM cron_lock_tokens WHERE id = 1`).Scan(&n); err != nil {
+		return nil, fmt.Errorf("sql locker: %w", err)
+	}
+	if n == 0 {
+		if _, err := db.ExecContext(ctx, `INSERT INTO cron_lock_tokens (id, value) VALUES (1, 0)`); err != nil {
+			if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM cron_lock_tokens WHERE id = 1`).Scan(&n); err != nil || n == 0 {
+				return nil, fmt.Errorf("sql locker: seed token counter: %w", err)
+			}
+		}
+	}
+	return &SQLLocker{db: db, now: time.Now}, nil
+}
+
+func (l *SQLLocker) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (Lock, error) {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Lock{}, fmt.Errorf("sql locker: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE cron_lock_tokens SET value = value + 1 WHERE id = 1`); err != nil {
+		return Lock{}, fmt.Errorf("sql locker: %w", err)
+	}
+	var token uint64
+	if err := tx.QueryRowContext(ctx, `SELECT value FROM cron_lock_tokens WHERE id = 1`).Scan(&token); err != nil {
+		return Lock{}, fmt.Errorf("sql locker: %w", err)
+	}
+
+	now := l.now()
+	var holder string
+	var held uint64
+	var expiresAt int64
+	err = tx.QueryRowContext(ctx, `SELECT owner, token, expires_at FROM cron_locks WHERE lock_key = ?`, key).
+		Scan(&holder, &held, &expiresAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = tx.ExecContext(ctx, `INSERT INTO cron_locks (lock_key, owner, token, expires_at) VALUES (?, ?, ?, ?)`,
+			key, owner, token, now.Add(ttl).UnixNano())
+	case err != nil:
+	case now.UnixNano() < expiresAt:
+		// 未获取到锁时也提交事务，消耗掉的令牌不会被复用
+		if err := tx.Commit(); err != nil {
+			return Lock{}, fmt.Errorf("sql locker: %w", err)
+		}
+		return Lock{}, lockedError(key, holder, held)
+	default:
+		_, err = tx.ExecContext(ctx, `UPDATE cron_locks SET owner = ?, token = ?, expires_at = ? WHERE lock_key = ?`,
+			owner, token, now.Add(ttl).UnixNano(), key)
+	}
+	if err != nil {
+		return Lock{}, fmt.Errorf("sql locker: %w", err)
+	}
+
+	// 清理过期的锁行，避免每次执行留下的行不断累积
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cron_locks WHERE expires_at <= ? AND lock_key <> ?`, now.UnixNano(), key); err != nil {
+		return Lock{}, fmt.Errorf("sql locker: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Lock{}, fmt.Errorf("sql locker: %w", err)
+	}
+	return Lock{Key: key, Owner: owner, Token: token, ExpiresAt: now.Add(ttl)}, nil
+}
+
+func (l *SQLLocker) Release(ctx context.Context, lock Lock) error {
+	res, err := l.db.ExecContext(ctx, `DELETE FROM cron_locks WHERE lock_key = ? AND token = ?`, lock.Key, lock.Token)
+	if err != nil {
+		return fmt.Errorf("sql locker: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sql locker: %w", err)
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CatchUpPolicy 错过执行后的补偿策略
+type CatchUpPolicy string
+
+const (
+	CatchUpSkip CatchUpPolicy = "skip" // 跳过所有错过的执行
+	CatchUpOnce CatchUpPolicy = "once" // 只补执行一次
+	CatchUpAll  CatchUpPolicy = "all"  // 按顺序补执行每一次
+)
+
+// RunStatus 执行结果
+type RunStatus string
+
+const (
+	StatusSuccess RunStatus = "success"
+	StatusFailed  RunStatus = "failed"
+	StatusTimeout RunStatus = "timeout"
+	StatusSkipped RunStatus = "skipped" // 错过的执行按补偿策略被跳过，或上一次执行还没有结束
+)
+
+// JobDefinition 持久化的任务定义
+// Type 和 Params 只有通过注册表创建的任务才有，重启时用它们恢复任务；
+// 暂停期间错过的执行不会补偿，ResumedAt 记录最近一次恢复的时间
+type JobDefinition struct {
+	Name      string          `json:"name"`
+	Spec      string          `json:"spec"`
+	Type      string          `json:"type,omitempty"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	Options   JobOptions      `json:"options"`
+	Paused    bool            `json:"paused,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	ResumedAt time.Time       `json:"resumedAt"`
+}
+
+// RunRecord 一次执行记录，重试的多次尝试合并为一条记录
+// ScheduledAt 为计划执行的时间点，补偿执行时早于StartedAt；Error 为最后一次尝试的错误
+type RunRecord struct {
+	Job         string    `json:"job"`
+	ScheduledAt time.Time `json:"scheduledAt"`
+	StartedAt   time.Time `json:"startedAt"`
+	FinishedAt  time.Time `json:"finishedAt"`
+	Status      RunStatus `json:"status"`
+	Attempts    int       `json:"attempts,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CatchUp     bool      `json:"catchUp,omitempty"`
+	Manual      bool      `json:"manual,omitempty"`
+	Token       uint64    `json:"token,omitempty"` // 配置了Locker时本次执行持有的栅栏令牌
+}
+
+// Duration 执行耗时
+func (r RunRecord) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// HistoryQuery 执行历史查询条件，零值表示不限制
+// Since/Until 按StartedAt过滤，Until不包含
+type HistoryQuery struct {
+	Status RunStatus
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+func (q HistoryQuery) match(r RunRecord) bool {
+	if q.Status != "" && r.Status != q.Status {
+		return false
+	}
+	if !q.Since.IsZero() && r.StartedAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !r.StartedAt.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Store 保存任务定义和执行记录
+type Store interface {
+	SaveJob(def JobDefinition) error
+	DeleteJob(name string) error
+	Job(name string) (JobDefinition, bool, error)
+	Jobs() ([]JobDefinition, error)
+	RecordRun(rec RunRecord) error
+	LastRun(job string) (RunRecord, bool, error)
+	// History 按开始时间从新到旧返回满足条件的执行记录
+	History(job string, q HistoryQuery) ([]RunRecord, error)
+}
+
+// historyLimit FileStore为每个任务保留的执行记录条数
+const historyLimit = 100
+
+// ============= 文件存储 =============
+
+type jobState struct {
+	Definition JobDefinition `json:"definition"`
+	LastRun    *RunRecord    `json:"lastRun,omitempty"`
+	History    []RunRecord   `json:"history,omitempty"` // 按完成顺序追加
+}
+
+// FileStore 把所有状态保存在一个JSON文件中
+// 每次修改都先写临时文件再rename，进程在写入过程中退出不会留下损坏的文件
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+	jobs map[string]*jobState
+}
+
+// NewFileStore 打开path对应的存储，文件不存在时创建空存储
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, jobs: make(map[string]*jobState)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.jobs); err != nil {
+		return nil, fmt.Errorf("decode store %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// SaveJob 保存任务定义，已有的执行记录保持不变
+func (s *FileStore) SaveJob(def JobDefinition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if st, ok := s.jobs[def.Name]; ok {
+		st.Definition = def
+	} else {
+		s.jobs[def.Name] = &jobState{Definition: def}
+	}
+	return s.flush()
+}
+
+// DeleteJob 删除任务定义和它的执行记录
+func (s *FileStore) DeleteJob(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, name)
+	return s.flush()
+}
+
+func (s *FileStore) Job(name string) (JobDefinition, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.jobs[name]
+	if !ok {
+		return JobDefinition{}, false, nil
+	}
+	return st.Definition, true, nil
+}
+
+// Jobs 按名称排序返回所有任务定义
+func (s *FileStore) Jobs() ([]JobDefinition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	defs := make([]JobDefinition, 0, len(s.jobs))
+	for _, st := range s.jobs {
+		defs = append(defs, st.Definition)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs, nil
+}
+
+// RecordRun 保存执行记录，只有计划时间更晚的记录才会替换LastRun
+// 每个任务只保留最近historyLimit条历史
+func (s *FileStore) RecordRun(rec RunRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.jobs[rec.Job]
+	if !ok {
+		return fmt.Errorf("record run: unknown job %q", rec.Job)
+	}
+	if st.LastRun == nil || !rec.ScheduledAt.Before(st.LastRun.ScheduledAt) {
+		st.LastRun = &rec
+	}
+	st.History = append(st.History, rec)
+	if n := len(st.History) - historyLimit; n > 0 {
+		st.History = append(st.History[:0:0], st.History[n:]...)
+	}
+	return s.flush()
+}
+
+func (s *FileStore) LastRun(job string) (RunRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.jobs[job]
+	if !ok || st.LastRun == nil {
+		return RunRecord{}, false, nil
+	}
+	return *st.LastRun, true, nil
+}
+
+func (s *FileStore) History(job string, q HistoryQuery) ([]RunRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.jobs[job]
+	if !ok {
+		return nil, fmt.Errorf("history: unknown job %q", job)
+	}
+	var out []RunRecord
+	for _, r := range st.History {
+		if q.match(r) {
+			out = append(out, r)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartedAt.After(out[j].StartedAt) })
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out, nil
+}
+
+// flush 把当前状态写入文件，调用方必须持有s.mu
+func (s *FileStore) flush() error {
+	data, err := json.MarshalIndent(s.jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("write store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write store: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveJob(JobDefinition{Name: "b", Spec: "0 * * * * *", Options: JobOptions{CatchUp: CatchUpAll}, CreatedAt: created}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveJob(JobDefinition{Name: "a", Spec: "@every 1m", Options: JobOptions{CatchUp: CatchUpSkip}, CreatedAt: created}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordRun(RunRecord{Job: "b", ScheduledAt: created.Add(2 * time.Minute), Status: StatusSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	// 计划时间更早的记录（例如补偿执行晚于正常执行完成）不会覆盖LastRun
+	if err := s.RecordRun(RunRecord{Job: "b", ScheduledAt: created.Add(time.Minute), Status: StatusFailed}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordRun(RunRecord{Job: "unknown"}); err == nil {
+		t.Fatal("expected error for unknown job")
+	}
+
+	// 重新打开后数据仍然存在
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs, _ := reopened.Jobs()
+	if len(defs) != 2 || defs[0].Name != "a" || defs[1].Spec != "0 * * * * *" || !defs[1].CreatedAt.Equal(created) {
+		t.Fatalf("jobs after reopen = %+v", defs)
+	}
+	last, ok, _ := reopened.LastRun("b")
+	if !ok || !last.ScheduledAt.Equal(created.Add(2*time.Minute)) || last.Status != StatusSuccess {
+		t.Fatalf("last run after reopen = %+v, %v", last, ok)
+	}
+
+	// 重新保存定义不会丢失执行记录
+	reopened.SaveJob(JobDefinition{Name: "b", Spec: "30 * * * * *"})
+	if _, ok, _ := reopened.LastRun("b"); !ok {
+		t.Fatal("last run lost after SaveJob")
+	}
+
+	reopened.DeleteJob("b")
+	if _, ok, _ := reopened.Job("b"); ok {
+		t.Fatal("job b still exists after DeleteJob")
+	}
+	if _, ok, _ := reopened.LastRun("b"); ok {
+		t.Fatal("last run of b still exists after DeleteJob")
+	}
+
+	// 没有留下临时文件
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("files in store dir = %d, want 1", len(entries))
+	}
+}
+
+func TestFileStoreCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	os.WriteFile(path, []byte("{not json"), 0o644)
+	if _, err := NewFileStore(path); err == nil {
+		t.Fatal("expected error for corrupted file")
+	}
+}
+
+func TestFileStoreHistory(t *testing.T) {
+	s, err := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SaveJob(JobDefinition{Name: "a"})
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < historyLimit+20; i++ {
+		status := StatusSuccess
+		if i%3 == 0 {
+			status = StatusFailed
+		}
+		at := base.Add(time.Duration(i) * time.Minute)
+		s.RecordRun(RunRecord{Job: "a", ScheduledAt: at, StartedAt: at, FinishedAt: at.Add(time.Second), Status: status})
+	}
+
+	all, _ := s.History("a", HistoryQuery{})
+	if len(all) != historyLimit {
+		t.Fatalf("history = %d records, want %d", len(all), historyLimit)
+	}
+	if newest := base.Add(time.Duration(historyLimit+19) * time.Minute); !all[0].StartedAt.Equal(newest) {
+		t.Fatalf("first record started at %v, want newest %v", all[0].StartedAt, newest)
+	}
+	if all[0].Duration() != time.Second {
+		t.Fatalf("duration = %v, want 1s", all[0].Duration())
+	}
+
+	failed, _ := s.History("a", HistoryQuery{Status: StatusFailed, Limit: 5})
+	if len(failed) != 5 {
+		t.Fatalf("failed = %d records, want 5", len(failed))
+	}
+	for _, r := range failed {
+		if r.Status != StatusFailed {
+			t.Fatalf("unexpected status %s", r.Status)
+		}
+	}
+
+	since, until := base.Add(100*time.Minute), base.Add(110*time.Minute)
+	window, _ := s.History("a", HistoryQuery{Since: since, Until: until})
+	if len(window) != 10 || !window[len(window)-1].StartedAt.Equal(since) {
+		t.Fatalf("window = %d records", len(window))
+	}
+
+	if _, err := s.History("missing", HistoryQuery{}); err == nil {
+		t.Fatal("expected error for unknown job")
+	}
+}
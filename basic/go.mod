@@ -6,6 +6,7 @@ require github.com/robfig/cron/v3 v3.0.1
 
 require (
 	github.com/Knetic/govaluate v3.0.0+incompatible
+	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/panjf2000/ants/v2 v2.11.3
 	github.com/zeromicro/go-zero v1.9.0
 )
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+)
+
+// ============= ModernOrderService的生产实现 =============
+
+// ErrCustomerNotFound 客户不存在，HTTP和内存实现都返回包装它的错误
+var ErrCustomerNotFound = errors.New("customer not found")
+
+// defaultHTTPClient 没有传入client时使用，带超时，避免外部服务挂起时请求一直阻塞
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// maxErrorBody 错误信息中最多带上的响应内容
+const maxErrorBody = 512
+
+// HTTPCustomerValidator 调用客户服务 GET {baseURL}/validate/{customerID}
+type HTTPCustomerValidator struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPCustomerValidator client为nil时使用带10秒超时的默认client
+func NewHTTPCustomerValidator(baseURL string, client *http.Client) *HTTPCustomerValidator {
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	return &HTTPCustomerValidator{baseURL: strings.TrimRight(baseURL, "/"), client: client}
+}
+
+// ValidateCustomer 200表示客户有效，404返回ErrCustomerNotFound，其他状态码都是错误
+func (v *HTTPCustomerValidator) ValidateCustomer(customerID string) error {
+	resp, err := v.client.Get(v.baseURL + "/validate/" + url.PathEscape(customerID))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		io.Copy(io.Discard, resp.Body) // 读完响应，连接可以复用
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrCustomerNotFound, customerID)
+	}
+	return statusError("customer service", resp)
+}
+
+// HTTPPaymentClient 调用支付服务 POST {baseURL}/charge
+type HTTPPaymentClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPPaymentClient client为nil时使用带10秒超时的默认client
+func NewHTTPPaymentClient(baseURL string, client *http.Client) *HTTPPaymentClient {
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	return &HTTPPaymentClient{baseURL: strings.TrimRight(baseURL, "/"), client: client}
+}
+
+type chargeRequest struct {
+	CustomerID string  `json:"customer_id"`
+	Amount     float64 `json:"amount"`
+}
+
+type chargeResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// ProcessPayment 2xx和402的响应体是{"success": bool, "message": string}；
+// 402表示支付被拒绝（例如余额不足），返回Success为false的结果而不是错误，由调用方决定如何处理
+func (p *HTTPPaymentClient) ProcessPayment(customerID string, amount float64) (*PaymentResponse, error) {
+	body, err := json.Marshal(chargeRequest{CustomerID: customerID, Amount: amount})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Post(p.baseURL+"/charge", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusPaymentRequired {
+		return nil, statusError("payment service", resp)
+	}
+	var result chargeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("payment service: decode response: %w", err)
+	}
+	if resp.StatusCode == http.StatusPaymentRequired {
+		result.Success = false
+	}
+	return &PaymentResponse{Success: result.Success, Message: result.Message}, nil
+}
+
+// statusError 意外的状态码，带上一部分响应内容方便排查
+func statusError(service string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return fmt.Errorf("%s: unexpected status %s: %s", service, resp.Status, msg)
+	}
+	return fmt.Errorf("%s: unexpected status %s", service, resp.Status)
+}
+
+// SQLOrderRepository 把订单写入orders表，占位符使用MySQL的?
+type SQLOrderRepository struct {
+	db *sql.DB
+}
+
+// NewSQLOrderRepository db由调用方创建和关闭，整个程序共用一个连接池，
+// 而不是像saveOrderToDB那样每次保存都重新连接
+func NewSQLOrderRepository(db *sql.DB) *SQLOrderRepository {
+	return &SQLOrderRepository{db: db}
+}
+
+func (r *SQLOrderRepository) SaveOrder(order *Order) error {
+	_, err := r.db.Exec("INSERT INTO orders (id, customer_id, amount, created_at, status) VALUES (?, ?, ?, ?, ?)",
+		order.ID, order.CustomerID, order.Amount, order.CreatedAt, order.Status)
+	if err != nil {
+		return fmt.Errorf("insert order %s: %w", order.ID, err)
+	}
+	return nil
+}
+
+// SystemClock 系统时间
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ULIDGenerator 生成ULID：按时间排序，同一毫秒内单调递增，适合作为数据库主键
+type ULIDGenerator struct {
+	clock TimeProvider
+
+	mu      sync.Mutex // ulid.Monotonic不是并发安全的
+	entropy *ulid.MonotonicEntropy
+}
+
+// NewULIDGenerator ID中的时间来自clock，和订单的CreatedAt使用同一个时间来源；clock为nil时使用系统时间
+func NewULIDGenerator(clock TimeProvider) *ULIDGenerator {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &ULIDGenerator{clock: clock, entropy: ulid.Monotonic(rand.Reader, 0)}
+}
+
+func (g *ULIDGenerator) GenerateOrderID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return ulid.MustNew(ulid.Timestamp(g.clock.Now()), g.entropy).String()
+}
+
+// UUIDGenerator 生成UUIDv7，同样按时间排序，适合已经使用UUID列的表
+type UUIDGenerator struct{}
+
+func (UUIDGenerator) GenerateOrderID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============= 生产实现的测试：用httptest和sqlmock代替外部服务 =============
+
+func TestHTTPCustomerValidator(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/validate/CUSTOMER-123":
+			w.WriteHeader(http.StatusOK)
+		case "/api/validate/a%2Fb":
+			w.WriteHeader(http.StatusOK)
+		case "/api/validate/BROKEN":
+			http.Error(w, "database is down", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	validator := NewHTTPCustomerValidator(srv.URL+"/api/", srv.Client())
+
+	assert.NoError(t, validator.ValidateCustomer("CUSTOMER-123"))
+	// 客户ID中的/被转义，不会访问到其他路径
+	assert.NoError(t, validator.ValidateCustomer("a/b"))
+
+	err := validator.ValidateCustomer("UNKNOWN")
+	assert.ErrorIs(t, err, ErrCustomerNotFound)
+	assert.Contains(t, err.Error(), "UNKNOWN")
+
+	err = validator.ValidateCustomer("BROKEN")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCustomerNotFound)
+	assert.Contains(t, err.Error(), "500")
+	assert.Contains(t, err.Error(), "database is down")
+
+	srv.Close()
+	assert.Error(t, validator.ValidateCustomer("CUSTOMER-123"))
+}
+
+func TestHTTPPaymentClient(t *testing.T) {
+	var got chargeRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/charge" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch got.CustomerID {
+		case "POOR":
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(`{"success": true, "message": "Insufficient funds"}`))
+		case "GARBAGE":
+			w.Write([]byte(`<html>`))
+		case "DOWN":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"success": true, "message": "Payment successful"}`))
+		}
+	}))
+	defer srv.Close()
+
+	client := NewHTTPPaymentClient(srv.URL, nil)
+
+	resp, err := client.ProcessPayment("CUSTOMER-123", 100.50)
+	require.NoError(t, err)
+	assert.Equal(t, &PaymentResponse{Success: true, Message: "Payment successful"}, resp)
+	assert.Equal(t, chargeRequest{CustomerID: "CUSTOMER-123", Amount: 100.50}, got)
+
+	// 402是业务上的拒绝，不是错误；即使响应体写了success也按失败处理
+	resp, err = client.ProcessPayment("POOR", 1)
+	require.NoError(t, err)
+	assert.Equal(t, &PaymentResponse{Success: false, Message: "Insufficient funds"}, resp)
+
+	_, err = client.ProcessPayment("GARBAGE", 1)
+	assert.ErrorContains(t, err, "decode response")
+
+	_, err = client.ProcessPayment("DOWN", 1)
+	assert.ErrorContains(t, err, "503")
+}
+
+func TestSQLOrderRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	order := &Order{
+		ID:         "ORDER-12345",
+		CustomerID: "CUSTOMER-123",
+		Amount:     100.50,
+		CreatedAt:  time.Date(2023, 12, 25, 10, 0, 0, 0, time.UTC),
+		Status:     "PAID",
+	}
+	insert := `INSERT INTO orders \(id, customer_id, amount, created_at, status\) VALUES \(\?, \?, \?, \?, \?\)`
+	mock.ExpectExec(insert).
+		WithArgs(order.ID, order.CustomerID, order.Amount, order.CreatedAt, order.Status).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insert).WillReturnError(errors.New("duplicate entry"))
+
+	repo := NewSQLOrderRepository(db)
+	assert.NoError(t, repo.SaveOrder(order))
+	err = repo.SaveOrder(order)
+	assert.ErrorContains(t, err, "insert order ORDER-12345: duplicate entry")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestULIDGenerator(t *testing.T) {
+	now := time.Date(2023, 12, 25, 10, 0, 0, 0, time.UTC)
+	gen := NewULIDGenerator(NewFakeClock(now))
+
+	// 同一毫秒内生成的ID也是递增的
+	ids := make([]string, 100)
+	for i := range ids {
+		ids[i] = gen.GenerateOrderID()
+	}
+	assert.True(t, sort.StringsAreSorted(ids))
+	for _, id := range ids {
+		parsed, err := ulid.ParseStrict(id)
+		require.NoError(t, err)
+		assert.Equal(t, now, ulid.Time(parsed.Time()).UTC())
+	}
+
+	assert.Len(t, NewULIDGenerator(nil).GenerateOrderID(), ulid.EncodedSize)
+}
+
+func TestUUIDGenerator(t *testing.T) {
+	var gen UUIDGenerator
+	a, b := gen.GenerateOrderID(), gen.GenerateOrderID()
+	assert.NotEqual(t, a, b)
+	parsed, err := uuid.Parse(a)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(7), parsed.Version())
+}
+
+func TestSystemClock(t *testing.T) {
+	before := time.Now()
+	now := SystemClock{}.Now()
+	assert.False(t, now.Before(before))
+}
+
+// 生产实现组装成ModernOrderService，外部服务换成本地的httptest和sqlmock
+func TestModernOrderService_WithProductionAdapters(t *testing.T) {
+	customers := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer customers.Close()
+	payments := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success": true, "message": "ok"}`))
+	}))
+	defer payments.Close()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectExec("INSERT INTO orders").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	clock := SystemClock{}
+	service := NewModernOrderService(
+		NewHTTPCustomerValidator(customers.URL, customers.Client()),
+		NewHTTPPaymentClient(payments.URL, payments.Client()),
+		NewSQLOrderRepository(db),
+		clock,
+		NewULIDGenerator(clock),
+	)
+	order, err := service.CreateOrder("CUSTOMER-123", 100.50)
+	require.NoError(t, err)
+	assert.Equal(t, "PAID", order.Status)
+	assert.Len(t, order.ID, ulid.EncodedSize)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
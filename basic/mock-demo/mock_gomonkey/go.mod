@@ -3,7 +3,10 @@ module mock-gomonkey-comparison
 go 1.21
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/agiledragon/gomonkey/v2 v2.11.0
+	github.com/google/uuid v1.6.0
+	github.com/oklog/ulid/v2 v2.1.1
 	github.com/stretchr/testify v1.8.4
 )
 
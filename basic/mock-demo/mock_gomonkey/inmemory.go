@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============= ModernOrderService的内存实现 =============
+//
+// 和Mock不同，内存实现有真实的行为：保存的订单可以查出来，拒绝过的客户一直被拒绝。
+// 集成测试用它们组装完整的服务，不需要网络和数据库，也不需要逐个设置期望调用。
+// 所有类型都是并发安全的。
+
+// ErrDuplicateOrder 订单ID已经存在
+var ErrDuplicateOrder = errors.New("duplicate order id")
+
+// InMemoryCustomers 客户名单，不在名单中的客户返回ErrCustomerNotFound
+type InMemoryCustomers struct {
+	mu  sync.RWMutex
+	ids map[string]bool
+}
+
+func NewInMemoryCustomers(customerIDs ...string) *InMemoryCustomers {
+	c := &InMemoryCustomers{ids: make(map[string]bool)}
+	for _, id := range customerIDs {
+		c.ids[id] = true
+	}
+	return c
+}
+
+func (c *InMemoryCustomers) Add(customerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ids[customerID] = true
+}
+
+func (c *InMemoryCustomers) Remove(customerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.ids, customerID)
+}
+
+func (c *InMemoryCustomers) ValidateCustomer(customerID string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.ids[customerID] {
+		return fmt.Errorf("%w: %s", ErrCustomerNotFound, customerID)
+	}
+	return nil
+}
+
+// Charge 一笔成功的扣款
+type Charge struct {
+	CustomerID string
+	Amount     float64
+}
+
+// InMemoryPayments 默认所有扣款都成功，可以让某个客户的扣款被拒绝，或者让支付服务不可用
+type InMemoryPayments struct {
+	mu       sync.Mutex
+	declined map[string]string
+	err      error
+	charges  []Charge
+}
+
+func NewInMemoryPayments() *InMemoryPayments {
+	return &InMemoryPayments{declined: make(map[string]string)}
+}
+
+// Decline 之后这个客户的扣款都被拒绝，message是拒绝原因
+func (p *InMemoryPayments) Decline(customerID, message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.declined[customerID] = message
+}
+
+// Fail 之后的扣款都返回err，模拟支付服务不可用；err为nil时恢复
+func (p *InMemoryPayments) Fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func (p *InMemoryPayments) ProcessPayment(customerID string, amount float64) (*PaymentResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	if message, ok := p.declined[customerID]; ok {
+		return &PaymentResponse{Success: false, Message: message}, nil
+	}
+	p.charges = append(p.charges, Charge{CustomerID: customerID, Amount: amount})
+	return &PaymentResponse{Success: true, Message: "Payment successful"}, nil
+}
+
+// Charges 按顺序返回成功的扣款
+func (p *InMemoryPayments) Charges() []Charge {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Charge(nil), p.charges...)
+}
+
+// InMemoryOrderRepository 按保存顺序存放订单的副本，ID重复时返回ErrDuplicateOrder
+type InMemoryOrderRepository struct {
+	mu     sync.RWMutex
+	err    error
+	orders []Order
+	index  map[string]int
+}
+
+func NewInMemoryOrderRepository() *InMemoryOrderRepository {
+	return &InMemoryOrderRepository{index: make(map[string]int)}
+}
+
+// Fail 之后的保存都返回err，模拟数据库不可用；err为nil时恢复
+func (r *InMemoryOrderRepository) Fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+func (r *InMemoryOrderRepository) SaveOrder(order *Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	if _, ok := r.index[order.ID]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateOrder, order.ID)
+	}
+	r.index[order.ID] = len(r.orders)
+	r.orders = append(r.orders, *order)
+	return nil
+}
+
+// Order 按ID查找订单，返回副本
+func (r *InMemoryOrderRepository) Order(id string) (*Order, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i, ok := r.index[id]
+	if !ok {
+		return nil, false
+	}
+	order := r.orders[i]
+	return &order, true
+}
+
+// Orders 按保存顺序返回所有订单的副本
+func (r *InMemoryOrderRepository) Orders() []Order {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Order(nil), r.orders...)
+}
+
+// FakeClock 手动控制的时间，只有调用Set或Advance时才会改变
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// SequenceIDGenerator 生成可以预测的ID：prefix-1、prefix-2……
+type SequenceIDGenerator struct {
+	prefix string
+	n      atomic.Int64
+}
+
+func NewSequenceIDGenerator(prefix string) *SequenceIDGenerator {
+	return &SequenceIDGenerator{prefix: prefix}
+}
+
+func (g *SequenceIDGenerator) GenerateOrderID() string {
+	return fmt.Sprintf("%s-%d", g.prefix, g.n.Add(1))
+}
+
+// InMemoryKit 用内存实现组装的ModernOrderService，测试通过各个字段设置场景和检查结果
+type InMemoryKit struct {
+	Customers *InMemoryCustomers
+	Payments  *InMemoryPayments
+	Orders    *InMemoryOrderRepository
+	Clock     *FakeClock
+	IDs       *SequenceIDGenerator
+	Service   *ModernOrderService
+}
+
+// NewInMemoryKit customerIDs是有效的客户，时间从now开始，订单ID是ORDER-1、ORDER-2……
+func NewInMemoryKit(now time.Time, customerIDs ...string) *InMemoryKit {
+	k := &InMemoryKit{
+		Customers: NewInMemoryCustomers(customerIDs...),
+		Payments:  NewInMemoryPayments(),
+		Orders:    NewInMemoryOrderRepository(),
+		Clock:     NewFakeClock(now),
+		IDs:       NewSequenceIDGenerator("ORDER"),
+	}
+	k.Service = NewModernOrderService(k.Customers, k.Payments, k.Orders, k.Clock, k.IDs)
+	return k
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============= 使用内存实现测试ModernOrderService =============
+//
+// 不需要设置期望调用，直接检查结果：订单是否保存、是否扣款
+
+func TestModernOrderService_CreateOrder_Success_WithInMemoryKit(t *testing.T) {
+	fixedTime := time.Date(2023, 12, 25, 10, 0, 0, 0, time.UTC)
+	kit := NewInMemoryKit(fixedTime, "CUSTOMER-123")
+
+	order, err := kit.Service.CreateOrder("CUSTOMER-123", 100.50)
+	require.NoError(t, err)
+	assert.Equal(t, &Order{
+		ID:         "ORDER-1",
+		CustomerID: "CUSTOMER-123",
+		Amount:     100.50,
+		CreatedAt:  fixedTime,
+		Status:     "PAID",
+	}, order)
+
+	saved, ok := kit.Orders.Order("ORDER-1")
+	require.True(t, ok)
+	assert.Equal(t, order, saved)
+	assert.Equal(t, []Charge{{CustomerID: "CUSTOMER-123", Amount: 100.50}}, kit.Payments.Charges())
+
+	kit.Clock.Advance(time.Hour)
+	order, err = kit.Service.CreateOrder("CUSTOMER-123", 20)
+	require.NoError(t, err)
+	assert.Equal(t, "ORDER-2", order.ID)
+	assert.Equal(t, fixedTime.Add(time.Hour), order.CreatedAt)
+	assert.Len(t, kit.Orders.Orders(), 2)
+}
+
+func TestModernOrderService_CreateOrder_Failures_WithInMemoryKit(t *testing.T) {
+	kit := NewInMemoryKit(time.Now(), "CUSTOMER-123", "POOR")
+	kit.Payments.Decline("POOR", "Insufficient funds")
+
+	_, err := kit.Service.CreateOrder("INVALID-CUSTOMER", 100.50)
+	assert.ErrorIs(t, err, ErrCustomerNotFound)
+	assert.Contains(t, err.Error(), "customer validation failed")
+
+	_, err = kit.Service.CreateOrder("POOR", 100.50)
+	assert.ErrorContains(t, err, "payment rejected: Insufficient funds")
+
+	kit.Payments.Fail(errors.New("connection refused"))
+	_, err = kit.Service.CreateOrder("CUSTOMER-123", 100.50)
+	assert.ErrorContains(t, err, "payment failed: connection refused")
+	kit.Payments.Fail(nil)
+
+	kit.Orders.Fail(errors.New("database connection failed"))
+	_, err = kit.Service.CreateOrder("CUSTOMER-123", 100.50)
+	assert.ErrorContains(t, err, "failed to save order")
+
+	// 失败的订单都没有保存；最后一次已经扣款，但订单保存失败
+	assert.Empty(t, kit.Orders.Orders())
+	assert.Len(t, kit.Payments.Charges(), 1)
+
+	// 客户被删除后不能再下单
+	kit.Orders.Fail(nil)
+	kit.Customers.Remove("CUSTOMER-123")
+	_, err = kit.Service.CreateOrder("CUSTOMER-123", 1)
+	assert.ErrorIs(t, err, ErrCustomerNotFound)
+	kit.Customers.Add("CUSTOMER-123")
+	_, err = kit.Service.CreateOrder("CUSTOMER-123", 1)
+	assert.NoError(t, err)
+}
+
+func TestInMemoryOrderRepository(t *testing.T) {
+	repo := NewInMemoryOrderRepository()
+	order := &Order{ID: "ORDER-1", Status: "PAID"}
+	require.NoError(t, repo.SaveOrder(order))
+	assert.ErrorIs(t, repo.SaveOrder(order), ErrDuplicateOrder)
+
+	// 保存的是副本，之后修改原对象不影响仓库中的订单
+	order.Status = "CANCELLED"
+	saved, ok := repo.Order("ORDER-1")
+	require.True(t, ok)
+	assert.Equal(t, "PAID", saved.Status)
+	saved.Status = "REFUNDED"
+	assert.Equal(t, "PAID", repo.Orders()[0].Status)
+
+	_, ok = repo.Order("ORDER-2")
+	assert.False(t, ok)
+}
+
+func TestInMemoryKit_Concurrent(t *testing.T) {
+	kit := NewInMemoryKit(time.Now())
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		customerID := fmt.Sprintf("CUSTOMER-%d", i)
+		kit.Customers.Add(customerID)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := kit.Service.CreateOrder(customerID, 1)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// 并发生成的ID没有重复
+	ids := make(map[string]bool)
+	for _, order := range kit.Orders.Orders() {
+		ids[order.ID] = true
+	}
+	assert.Len(t, ids, 50)
+	assert.Len(t, kit.Payments.Charges(), 50)
+}
@@ -0,0 +1,141 @@
+// modelgen 根据YAML模型定义或者已有的Go结构体生成构造函数、getter/setter、Builder、ORM标签和测试骨架。
+//
+// 通常写在go:generate指令中，在包目录下执行：
+//
+//	//go:generate go run template-demo/cmd/modelgen -in models.yaml
+//	//go:generate go run template-demo/cmd/modelgen -in order.go -types Order
+//
+// 生成xxx_gen.go和xxx_gen_test.go。生成的文件带有校验和，被手工修改过的文件不会被覆盖：
+// xxx_gen.go被修改时报错退出，测试骨架被修改时保留修改并跳过，-force强制覆盖。
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"template-demo/codegen"
+)
+
+func main() {
+	var (
+		in      = flag.String("in", "", "模型定义，.yaml/.yml或者.go文件")
+		out     = flag.String("out", "", "输出文件，默认是输入文件名加_gen.go")
+		typeArg = flag.String("types", "", "从Go文件读取的结构体，逗号分隔，默认全部")
+		pkg     = flag.String("package", "", "生成代码的包名，默认使用YAML中的package或者go generate提供的$GOPACKAGE")
+		gen     = flag.String("gen", "constructor,accessors,builder,tests", "生成哪些代码，逗号分隔")
+		tags    = flag.String("tags", "json,db,gorm", "YAML模型的结构体标签，逗号分隔，为空时不生成")
+		force   = flag.Bool("force", false, "覆盖手工修改过的文件")
+	)
+	flag.Parse()
+	if *in == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*in, *out, *typeArg, *pkg, *gen, *tags, *force); err != nil {
+		fmt.Fprintln(os.Stderr, "modelgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, typeArg, pkg, gen, tags string, force bool) error {
+	file, err := load(in, split(typeArg))
+	if err != nil {
+		return err
+	}
+	switch {
+	case pkg != "":
+		file.Package = pkg
+	case file.Package == "":
+		file.Package = os.Getenv("GOPACKAGE")
+	}
+
+	opts := codegen.Options{Tags: split(tags)}
+	tests := false
+	for _, g := range split(gen) {
+		switch g {
+		case "constructor":
+			opts.Constructor = true
+		case "accessors":
+			opts.Accessors = true
+		case "builder":
+			opts.Builder = true
+		case "tests":
+			tests = true
+		default:
+			return fmt.Errorf("unknown -gen value %q", g)
+		}
+	}
+
+	if out == "" {
+		out = strings.TrimSuffix(in, filepath.Ext(in)) + "_gen.go"
+	}
+	src, err := codegen.Generate(file, opts)
+	if err != nil {
+		return err
+	}
+	if err := write(out, src, force); err != nil {
+		return err
+	}
+
+	if !tests {
+		return nil
+	}
+	src, err = codegen.GenerateTests(file, opts)
+	if err != nil {
+		return err
+	}
+	testOut := strings.TrimSuffix(out, ".go") + "_test.go"
+	if err := write(testOut, src, force); errors.Is(err, codegen.ErrHandEdited) {
+		// 测试骨架本来就是让人修改的
+		fmt.Printf("modelgen: keeping %s: %v\n", testOut, err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+func load(in string, types []string) (*codegen.File, error) {
+	switch filepath.Ext(in) {
+	case ".yaml", ".yml":
+		f, err := os.Open(in)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return codegen.LoadYAML(filepath.Base(in), f)
+	case ".go":
+		file, err := codegen.ParseGo(in, nil, types...)
+		if err != nil {
+			return nil, err
+		}
+		file.Source = filepath.Base(in)
+		return file, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported input, want .yaml, .yml or .go", in)
+}
+
+func write(path string, src []byte, force bool) error {
+	changed, err := codegen.WriteFile(path, src, force)
+	if err != nil {
+		return err
+	}
+	if changed {
+		fmt.Println("modelgen: wrote", path)
+	}
+	return nil
+}
+
+func split(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
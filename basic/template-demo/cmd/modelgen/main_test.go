@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"template-demo/codegen"
+)
+
+const models = `package: shop
+models:
+  - name: Item
+    fields:
+      - {name: Name, type: string}
+`
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "item.yaml")
+	if err := os.WriteFile(in, []byte(models), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gen, test := filepath.Join(dir, "item_gen.go"), filepath.Join(dir, "item_gen_test.go")
+	regenerate := func() error { return run(in, "", "", "", "constructor,accessors,tests", "json", false) }
+
+	if err := regenerate(); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile(gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "func NewItem(name string) *Item") || strings.Contains(string(src), "ItemBuilder") {
+		t.Fatalf("generated:\n%s", src)
+	}
+
+	// 修改过的测试骨架被保留，不算错误
+	edited := "package shop\n\n// 手写的测试\n"
+	os.WriteFile(test, []byte(edited), 0o644)
+	if err := regenerate(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(test); string(data) != edited {
+		t.Fatal("edited test stub was overwritten")
+	}
+
+	// 修改过的生成文件报错
+	os.WriteFile(gen, append(src, "\n// 手工修改\n"...), 0o644)
+	if err := regenerate(); !errors.Is(err, codegen.ErrHandEdited) {
+		t.Fatalf("error = %v, want ErrHandEdited", err)
+	}
+	if err := run(in, "", "", "", "constructor,accessors,tests", "json", true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(test); string(data) == edited {
+		t.Fatal("-force did not overwrite the test stub")
+	}
+}
+
+func TestRunGoSource(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "point.go")
+	os.WriteFile(in, []byte("package geo\n\ntype Point struct {\n\tx, y float64\n}\n"), 0o644)
+
+	out := filepath.Join(dir, "point_methods.go")
+	if err := run(in, out, "Point", "", "accessors", "", false); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "func (m *Point) X() float64") {
+		t.Fatalf("generated:\n%s", src)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "point_methods_test.go")); !os.IsNotExist(err) {
+		t.Fatal("tests generated without -gen tests")
+	}
+
+	// 不能用生成结果覆盖手写的源文件
+	if err := run(in, in, "", "", "accessors", "", false); !errors.Is(err, codegen.ErrHandEdited) {
+		t.Fatalf("overwrite source error = %v, want ErrHandEdited", err)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "item.yaml")
+	os.WriteFile(in, []byte(models), 0o644)
+	if err := run(in, "", "", "", "constructor,docs", "", false); err == nil || !strings.Contains(err.Error(), `"docs"`) {
+		t.Errorf("unknown -gen error = %v", err)
+	}
+	if err := run(filepath.Join(dir, "item.json"), "", "", "", "constructor", "", false); err == nil {
+		t.Error("unsupported input accepted")
+	}
+}
@@ -0,0 +1,101 @@
+package codegen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestExampleUpToDate models包中提交的生成代码必须和模板的当前输出一致，否则需要重新执行go generate
+func TestExampleUpToDate(t *testing.T) {
+	for _, tt := range []struct {
+		input, output string
+		load          func(string) (*File, error)
+	}{
+		{"models.yaml", "models_gen.go", func(path string) (*File, error) {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			return LoadYAML("models.yaml", f)
+		}},
+		{"order.go", "order_gen.go", func(path string) (*File, error) {
+			f, err := ParseGo(path, nil, "Order")
+			if err != nil {
+				return nil, err
+			}
+			f.Source = "order.go"
+			return f, nil
+		}},
+	} {
+		dir := filepath.Join("..", "models")
+		f, err := tt.load(filepath.Join(dir, tt.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for output, generate := range map[string]func(*File, Options) ([]byte, error){
+			tt.output: Generate,
+			strings.TrimSuffix(tt.output, ".go") + "_test.go": GenerateTests,
+		} {
+			src, err := generate(f, AllOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(filepath.Join(dir, output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(src, want) {
+				t.Errorf("models/%s is out of date, run go generate ./models", output)
+			}
+		}
+	}
+}
+
+func TestGenerateOptions(t *testing.T) {
+	f, err := loadString(t, `
+package: shop
+models:
+  - name: Item
+    fields:
+      - {name: Name, type: string, size: 32, notNull: true}
+      - {name: Price, type: float64, default: "0"}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := Generate(f, Options{Tags: []string{"gorm", "json"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(src)
+	for _, want := range []string{
+		"// Code generated by modelgen from test.yaml; DO NOT EDIT.\n// modelgen:checksum ",
+		"Name  string  `gorm:\"column:name;size:32;not null\" json:\"name\"`",
+		"Price float64 `gorm:\"column:price;default:0\" json:\"price\"`",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"func NewItem", "GetName", "ItemBuilder", "TableName", "import"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("output contains %q", unwanted)
+		}
+	}
+
+	if _, err := Generate(f, Options{Tags: []string{"xml"}}); err == nil {
+		t.Error("unsupported tag accepted")
+	}
+	if _, err := GenerateTests(f, Options{}); err == nil {
+		t.Error("GenerateTests with nothing to test succeeded")
+	}
+	f.Package = ""
+	if _, err := Generate(f, AllOptions); err == nil || !strings.Contains(err.Error(), "package name is empty") {
+		t.Errorf("empty package error = %v", err)
+	}
+}
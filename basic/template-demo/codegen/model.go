@@ -0,0 +1,204 @@
+// Package codegen 根据模型定义生成Go代码：结构体、构造函数、getter/setter、Builder、ORM标签和表格驱动的测试骨架。
+//
+// 模型定义有两种来源：
+//
+//	LoadYAML  YAML文件，生成结构体声明和所有方法
+//	ParseGo   已有的Go源文件，用go/parser读取结构体，只生成方法
+//
+// 生成的代码经过gofmt，文件开头带有校验和，WriteFile发现文件被手工修改过时拒绝覆盖。
+// 命令行工具见cmd/modelgen，通常通过go generate调用。
+package codegen
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// File 一个生成文件中的所有模型
+type File struct {
+	Package string
+	Source  string   // 模型定义的文件名，写在生成文件的开头
+	Imports []Import // 字段类型用到的包
+	Models  []Model
+}
+
+// Import 导入的包，Name为空时使用默认包名
+type Import struct {
+	Name string
+	Path string
+}
+
+// Model 一个模型
+type Model struct {
+	Name    string
+	Comment string
+	Table   string // 数据库表名，不为空时生成TableName方法
+	Declare bool   // 是否生成结构体声明，来自Go源文件的模型已经声明过
+	Fields  []Field
+}
+
+// Field 模型的字段
+type Field struct {
+	Name     string
+	Type     string
+	Comment  string
+	ReadOnly bool // 不生成setter
+
+	// ORM选项，只在生成结构体声明时使用
+	Column     string // 列名，默认是字段名的snake_case
+	PrimaryKey bool
+	NotNull    bool
+	Unique     bool
+	Index      bool
+	Size       int
+	Default    string
+}
+
+// Getter 导出的字段是GetName，未导出的字段是Name，和Go的命名习惯一致
+func (f Field) Getter() string {
+	if token.IsExported(f.Name) {
+		return "Get" + f.Name
+	}
+	return exported(f.Name)
+}
+
+// Setter setter的方法名
+func (f Field) Setter() string {
+	return "Set" + exported(f.Name)
+}
+
+// Method Builder中设置这个字段的方法名
+func (f Field) Method() string {
+	return exported(f.Name)
+}
+
+// Param 作为参数时的名字，例如ID是id，CreatedAt是createdAt，Type是type_
+func (f Field) Param() string {
+	p := lowerCamel(f.Name)
+	if token.IsKeyword(p) {
+		p += "_"
+	}
+	return p
+}
+
+// ColumnName 列名
+func (f Field) ColumnName() string {
+	if f.Column != "" {
+		return f.Column
+	}
+	return snakeCase(f.Name)
+}
+
+// validate 检查名字和类型，以及生成的方法名是否互相冲突
+func (m Model) validate() error {
+	if !token.IsIdentifier(m.Name) {
+		return fmt.Errorf("model %q: invalid name", m.Name)
+	}
+	if len(m.Fields) == 0 {
+		return fmt.Errorf("model %s: no fields", m.Name)
+	}
+	// 字段和结构体方法在同一个命名空间，Builder的方法在另一个
+	members := map[string]string{"TableName": "method TableName"}
+	builder := map[string]string{"Build": "method Build"}
+	claim := func(names map[string]string, name, what string) error {
+		if prev, ok := names[name]; ok {
+			return fmt.Errorf("model %s: %s conflicts with %s", m.Name, what, prev)
+		}
+		names[name] = what
+		return nil
+	}
+	for _, f := range m.Fields {
+		if !token.IsIdentifier(f.Name) {
+			return fmt.Errorf("model %s: invalid field name %q", m.Name, f.Name)
+		}
+		if _, err := parser.ParseExpr(f.Type); err != nil {
+			return fmt.Errorf("model %s: field %s: invalid type %q", m.Name, f.Name, f.Type)
+		}
+		if err := claim(members, f.Name, "field "+f.Name); err != nil {
+			return err
+		}
+	}
+	for _, f := range m.Fields {
+		if err := claim(members, f.Getter(), "getter of "+f.Name); err != nil {
+			return err
+		}
+		if !f.ReadOnly {
+			if err := claim(members, f.Setter(), "setter of "+f.Name); err != nil {
+				return err
+			}
+		}
+		if err := claim(builder, f.Method(), "builder method of "+f.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initialisms 整体大写的缩写
+var initialisms = map[string]bool{
+	"ID": true, "URL": true, "URI": true, "API": true, "HTTP": true, "JSON": true,
+	"SQL": true, "UUID": true, "IP": true, "IO": true, "HTML": true, "XML": true,
+}
+
+// exported 首字母大写，整个名字是缩写时全部大写：id是ID，name是Name
+func exported(name string) string {
+	if upper := strings.ToUpper(name); initialisms[upper] {
+		return upper
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// words 按大小写边界拆分名字，连续的大写字母作为一个词：UserID是User ID，HTTPServer是HTTP Server
+func words(name string) []string {
+	r := []rune(name)
+	var out []string
+	start := 0
+	for i := 1; i < len(r); i++ {
+		switch {
+		case r[i] == '_':
+			out = append(out, string(r[start:i]))
+			start = i + 1
+		case unicode.IsUpper(r[i]) && unicode.IsLower(r[i-1]),
+			unicode.IsUpper(r[i]) && unicode.IsDigit(r[i-1]),
+			unicode.IsUpper(r[i]) && i+1 < len(r) && unicode.IsLower(r[i+1]) && unicode.IsUpper(r[i-1]):
+			out = append(out, string(r[start:i]))
+			start = i
+		}
+	}
+	out = append(out, string(r[start:]))
+
+	kept := out[:0]
+	for _, w := range out {
+		if w != "" {
+			kept = append(kept, w)
+		}
+	}
+	return kept
+}
+
+func lowerCamel(name string) string {
+	ws := words(name)
+	for i, w := range ws {
+		if i == 0 {
+			ws[i] = strings.ToLower(w)
+		} else if upper := strings.ToUpper(w); initialisms[upper] {
+			ws[i] = upper
+		} else {
+			ws[i] = exported(w)
+		}
+	}
+	return strings.Join(ws, "")
+}
+
+func snakeCase(name string) string {
+	ws := words(name)
+	for i, w := range ws {
+		ws[i] = strings.ToLower(w)
+	}
+	return strings.Join(ws, "_")
+}
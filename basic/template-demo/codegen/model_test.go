@@ -0,0 +1,52 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNaming(t *testing.T) {
+	for _, tt := range []struct {
+		name                                  string
+		getter, setter, method, param, column string
+	}{
+		{"ID", "GetID", "SetID", "ID", "id", "id"},
+		{"CreatedAt", "GetCreatedAt", "SetCreatedAt", "CreatedAt", "createdAt", "created_at"},
+		{"UserID", "GetUserID", "SetUserID", "UserID", "userID", "user_id"},
+		{"HTTPServer", "GetHTTPServer", "SetHTTPServer", "HTTPServer", "httpServer", "http_server"},
+		{"id", "ID", "SetID", "ID", "id", "id"},
+		{"userID", "UserID", "SetUserID", "UserID", "userID", "user_id"},
+		{"paidAt", "PaidAt", "SetPaidAt", "PaidAt", "paidAt", "paid_at"},
+		{"Type", "GetType", "SetType", "Type", "type_", "type"},
+	} {
+		f := Field{Name: tt.name}
+		got := []string{f.Getter(), f.Setter(), f.Method(), f.Param(), f.ColumnName()}
+		want := []string{tt.getter, tt.setter, tt.method, tt.param, tt.column}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("%s: got %v, want %v", tt.name, got, want)
+		}
+	}
+
+	if c := (Field{Name: "SKU", Column: "stock_keeping_unit"}).ColumnName(); c != "stock_keeping_unit" {
+		t.Errorf("explicit column = %s", c)
+	}
+}
+
+func TestModelValidate(t *testing.T) {
+	for want, m := range map[string]Model{
+		"invalid name":             {Name: "my-model", Fields: []Field{{Name: "ID", Type: "int"}}},
+		"no fields":                {Name: "Empty"},
+		"invalid field name":       {Name: "M", Fields: []Field{{Name: "1st", Type: "int"}}},
+		"invalid type":             {Name: "M", Fields: []Field{{Name: "A", Type: "map[string"}}},
+		"field A conflicts":        {Name: "M", Fields: []Field{{Name: "A", Type: "int"}, {Name: "A", Type: "string"}}},
+		"getter of id conflicts":   {Name: "M", Fields: []Field{{Name: "ID", Type: "int"}, {Name: "id", Type: "int"}}},
+		"method TableName":         {Name: "M", Fields: []Field{{Name: "TableName", Type: "string"}}},
+		"builder method of Build":  {Name: "M", Fields: []Field{{Name: "Build", Type: "int"}}},
+		"getter of name conflicts": {Name: "M", Fields: []Field{{Name: "Name", Type: "string"}, {Name: "name", Type: "string"}}},
+	} {
+		err := m.validate()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("validate(%+v) = %v, want error containing %q", m, err, want)
+		}
+	}
+}
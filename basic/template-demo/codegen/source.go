@@ -0,0 +1,124 @@
+package codegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ParseGo 从Go源文件中读取结构体，src为nil时读取filename
+// names为空时读取文件中所有的结构体，否则只读取这些结构体，按names的顺序
+//
+// 结构体已经声明过，所以只生成方法；字段的标签modelgen:"-"跳过这个字段，modelgen:"readonly"不生成setter，
+// 嵌入字段总是跳过
+func ParseGo(filename string, src any, names ...string) (*File, error) {
+	fset := token.NewFileSet()
+	af, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	structs := make(map[string]Model)
+	var order []string
+	for _, decl := range af.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			if ts.TypeParams != nil {
+				return nil, fmt.Errorf("%s: %s: generic types are not supported", filename, ts.Name.Name)
+			}
+			doc := ts.Doc
+			if doc == nil && len(gen.Specs) == 1 {
+				doc = gen.Doc
+			}
+			m, err := structModel(ts.Name.Name, doc, st)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filename, err)
+			}
+			structs[m.Name] = m
+			order = append(order, m.Name)
+		}
+	}
+
+	if len(names) == 0 {
+		names = order
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s: no struct types", filename)
+	}
+	f := &File{Package: af.Name.Name, Source: filename}
+	for _, name := range names {
+		m, ok := structs[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: struct %s not found", filename, name)
+		}
+		f.Models = append(f.Models, m)
+	}
+
+	// 生成的方法签名中用到的包沿用源文件的导入
+	known := make(map[string]Import)
+	for _, spec := range af.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		imp := Import{Path: p}
+		if spec.Name != nil {
+			imp.Name = spec.Name.Name
+		}
+		known[imp.pkgName()] = imp
+	}
+	if f.Imports, err = resolveImports(f.Models, known); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return f, nil
+}
+
+func structModel(name string, doc *ast.CommentGroup, st *ast.StructType) (Model, error) {
+	m := Model{Name: name, Comment: docText(doc, name)}
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			s, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return m, err
+			}
+			tag = reflect.StructTag(s)
+		}
+		opt := tag.Get("modelgen")
+		if opt == "-" {
+			continue
+		}
+		comment := strings.TrimSpace(field.Comment.Text())
+		if comment == "" {
+			comment = strings.TrimSpace(field.Doc.Text())
+		}
+		for _, id := range field.Names {
+			m.Fields = append(m.Fields, Field{
+				Name:     id.Name,
+				Type:     types.ExprString(field.Type),
+				Comment:  comment,
+				ReadOnly: opt == "readonly",
+			})
+		}
+	}
+	return m, m.validate()
+}
+
+// docText 类型注释的第一行，去掉开头的类型名
+func docText(doc *ast.CommentGroup, name string) string {
+	text, _, _ := strings.Cut(strings.TrimSpace(doc.Text()), "\n")
+	return strings.TrimSpace(strings.TrimPrefix(text, name))
+}
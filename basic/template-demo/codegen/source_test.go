@@ -0,0 +1,86 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const source = `package billing
+
+import (
+	"time"
+
+	dec "github.com/shopspring/decimal"
+)
+
+// Invoice 发票
+// 第二行不会出现在注释中
+type Invoice struct {
+	ID      int64
+	Amount  dec.Decimal // 金额
+	// 开票时间
+	IssuedAt time.Time
+	a, b     string
+	secret   string ` + "`modelgen:\"-\"`" + `
+	Number   string ` + "`json:\"number\" modelgen:\"readonly\"`" + `
+	fmtState
+}
+
+type (
+	fmtState struct{ buf []byte }
+	Pair     [2]int
+)
+
+type Box[T any] struct{ v T }
+`
+
+func TestParseGo(t *testing.T) {
+	f, err := ParseGo("billing.go", strings.ReplaceAll(source, "type Box[T any] struct{ v T }", ""), "Invoice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Package != "billing" || len(f.Models) != 1 {
+		t.Fatalf("file = %+v", f)
+	}
+	m := f.Models[0]
+	if m.Declare || m.Comment != "发票" {
+		t.Fatalf("model = %+v", m)
+	}
+	var fields []string
+	for _, field := range m.Fields {
+		fields = append(fields, fmt.Sprintf("%s:%s:%s:%v", field.Name, field.Type, field.Comment, field.ReadOnly))
+	}
+	want := "ID:int64::false Amount:dec.Decimal:金额:false IssuedAt:time.Time:开票时间:false a:string::false b:string::false Number:string::true"
+	if got := strings.Join(fields, " "); got != want {
+		t.Fatalf("fields = %s\nwant %s", got, want)
+	}
+	if got := fmt.Sprint(f.Imports); got != "[{dec github.com/shopspring/decimal} { time}]" {
+		t.Fatalf("imports = %s", got)
+	}
+}
+
+func TestParseGoAllStructs(t *testing.T) {
+	f, err := ParseGo("billing.go", strings.ReplaceAll(source, "type Box[T any] struct{ v T }", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Models) != 2 || f.Models[0].Name != "Invoice" || f.Models[1].Name != "fmtState" {
+		t.Fatalf("models = %+v", f.Models)
+	}
+}
+
+func TestParseGoErrors(t *testing.T) {
+	if _, err := ParseGo("billing.go", source); err == nil || !strings.Contains(err.Error(), "generic") {
+		t.Errorf("generic error = %v", err)
+	}
+	if _, err := ParseGo("billing.go", "package billing\ntype Invoice struct{ ID int }", "Receipt"); err == nil || !strings.Contains(err.Error(), "Receipt not found") {
+		t.Errorf("missing type error = %v", err)
+	}
+	if _, err := ParseGo("billing.go", "package billing\ntype ID int"); err == nil || !strings.Contains(err.Error(), "no struct types") {
+		t.Errorf("no structs error = %v", err)
+	}
+	if _, err := ParseGo("billing.go", "package billing\ntype Invoice struct{ ID int"); err == nil {
+		t.Error("syntax error not reported")
+	}
+}
@@ -0,0 +1,63 @@
+package codegen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// ErrHandEdited 目标文件不是modelgen生成的，或者生成后被手工修改过
+var ErrHandEdited = errors.New("codegen: file was edited by hand")
+
+const checksumPrefix = "// modelgen:checksum "
+
+// stamp 在代码前面加上说明和校验和，校验和覆盖校验和这一行之后的全部内容
+func stamp(header string, src []byte) []byte {
+	sum := sha256.Sum256(src)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n%s%s\n\n", header, checksumPrefix, hex.EncodeToString(sum[:]))
+	buf.Write(src)
+	return buf.Bytes()
+}
+
+// checkUnmodified 检查文件的校验和
+func checkUnmodified(data []byte) error {
+	_, rest, ok := bytes.Cut(data, []byte("\n"))
+	if !ok || !bytes.HasPrefix(rest, []byte(checksumPrefix)) {
+		return fmt.Errorf("%w: no modelgen checksum", ErrHandEdited)
+	}
+	line, body, _ := bytes.Cut(rest, []byte("\n"))
+	want := string(bytes.TrimPrefix(line, []byte(checksumPrefix)))
+	body = bytes.TrimPrefix(body, []byte("\n"))
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != want {
+		return fmt.Errorf("%w: checksum mismatch", ErrHandEdited)
+	}
+	return nil
+}
+
+// WriteFile 写入生成的代码
+// 文件已经存在时只覆盖modelgen生成且没有被修改过的文件，否则返回ErrHandEdited；force为true时总是覆盖
+// 内容没有变化时不写入，返回的changed为false
+func WriteFile(path string, src []byte, force bool) (changed bool, err error) {
+	old, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return false, err
+	case bytes.Equal(old, src):
+		return false, nil
+	case !force:
+		if err := checkUnmodified(old); err != nil {
+			return false, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := os.WriteFile(path, src, 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
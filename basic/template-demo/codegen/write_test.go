@@ -0,0 +1,47 @@
+package codegen
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user_gen.go")
+	v1 := stamp("// Code generated by modelgen; DO NOT EDIT.", []byte("package models\n\nconst V = 1\n"))
+	v2 := stamp("// Code generated by modelgen; DO NOT EDIT.", []byte("package models\n\nconst V = 2\n"))
+
+	if changed, err := WriteFile(path, v1, false); !changed || err != nil {
+		t.Fatalf("first write = %v, %v", changed, err)
+	}
+	if changed, err := WriteFile(path, v1, false); changed || err != nil {
+		t.Fatalf("same content = %v, %v, want no write", changed, err)
+	}
+	// 没有被修改过的生成文件可以覆盖
+	if changed, err := WriteFile(path, v2, false); !changed || err != nil {
+		t.Fatalf("regenerate = %v, %v", changed, err)
+	}
+
+	// 手工修改后拒绝覆盖
+	edited := append(append([]byte{}, v2...), "\nfunc Helper() {}\n"...)
+	if err := os.WriteFile(path, edited, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteFile(path, v1, false); !errors.Is(err, ErrHandEdited) {
+		t.Fatalf("edited file error = %v, want ErrHandEdited", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(edited) {
+		t.Fatal("edited file was overwritten")
+	}
+	if changed, err := WriteFile(path, v1, true); !changed || err != nil {
+		t.Fatalf("force = %v, %v", changed, err)
+	}
+
+	// 手写的文件没有校验和
+	handWritten := filepath.Join(t.TempDir(), "user.go")
+	os.WriteFile(handWritten, []byte("package models\n"), 0o644)
+	if _, err := WriteFile(handWritten, v1, false); !errors.Is(err, ErrHandEdited) {
+		t.Fatalf("hand-written file error = %v, want ErrHandEdited", err)
+	}
+}
@@ -0,0 +1,174 @@
+package codegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlFile YAML模型文件的格式：
+//
+//	package: models
+//	imports: [github.com/google/uuid]
+//	models:
+//	  - name: User
+//	    table: users
+//	    comment: 系统用户
+//	    fields:
+//	      - {name: ID, type: int64, primaryKey: true}
+//	      - {name: Email, type: string, size: 128, unique: true, notNull: true}
+//	      - {name: CreatedAt, type: time.Time, readonly: true}
+type yamlFile struct {
+	Package string      `yaml:"package"`
+	Imports []string    `yaml:"imports"` // "path"或者"name path"
+	Models  []yamlModel `yaml:"models"`
+}
+
+type yamlModel struct {
+	Name    string      `yaml:"name"`
+	Comment string      `yaml:"comment"`
+	Table   string      `yaml:"table"`
+	Fields  []yamlField `yaml:"fields"`
+}
+
+type yamlField struct {
+	Name       string `yaml:"name"`
+	Type       string `yaml:"type"`
+	Comment    string `yaml:"comment"`
+	ReadOnly   bool   `yaml:"readonly"`
+	Column     string `yaml:"column"`
+	PrimaryKey bool   `yaml:"primaryKey"`
+	NotNull    bool   `yaml:"notNull"`
+	Unique     bool   `yaml:"unique"`
+	Index      bool   `yaml:"index"`
+	Size       int    `yaml:"size"`
+	Default    string `yaml:"default"`
+}
+
+// stdImports 不用在imports中列出的标准库包
+var stdImports = map[string]string{
+	"time":   "time",
+	"sql":    "database/sql",
+	"json":   "encoding/json",
+	"big":    "math/big",
+	"netip":  "net/netip",
+	"url":    "net/url",
+	"driver": "database/sql/driver",
+}
+
+// LoadYAML 读取YAML模型定义，source是写在生成文件开头的文件名
+// 未知的键是错误，避免拼错的选项被悄悄忽略
+func LoadYAML(source string, r io.Reader) (*File, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	var y yamlFile
+	if err := dec.Decode(&y); err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	f := &File{Package: y.Package, Source: source}
+	for _, ym := range y.Models {
+		m := Model{Name: ym.Name, Comment: ym.Comment, Table: ym.Table, Declare: true}
+		for _, yf := range ym.Fields {
+			m.Fields = append(m.Fields, Field{
+				Name: yf.Name, Type: yf.Type, Comment: yf.Comment, ReadOnly: yf.ReadOnly,
+				Column: yf.Column, PrimaryKey: yf.PrimaryKey, NotNull: yf.NotNull, Unique: yf.Unique,
+				Index: yf.Index, Size: yf.Size, Default: yf.Default,
+			})
+		}
+		if err := m.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		f.Models = append(f.Models, m)
+	}
+	if len(f.Models) == 0 {
+		return nil, fmt.Errorf("%s: no models", source)
+	}
+
+	known := make(map[string]Import)
+	for _, spec := range y.Imports {
+		imp := Import{Path: spec}
+		if name, p, ok := strings.Cut(spec, " "); ok {
+			imp = Import{Name: name, Path: strings.TrimSpace(p)}
+		}
+		known[imp.pkgName()] = imp
+	}
+	for name, p := range stdImports {
+		if _, ok := known[name]; !ok {
+			known[name] = Import{Path: p}
+		}
+	}
+	imports, err := resolveImports(f.Models, known)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	f.Imports = imports
+	return f, nil
+}
+
+var (
+	majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+	gopkgVersion = regexp.MustCompile(`\.v[0-9]+$`)
+)
+
+// pkgName 导入后使用的包名；没有写别名时按路径猜测：
+// 最后一段，去掉gopkg.in的.vN后缀，跳过/vN版本目录
+func (imp Import) pkgName() string {
+	if imp.Name != "" {
+		return imp.Name
+	}
+	base := path.Base(imp.Path)
+	if majorVersion.MatchString(base) {
+		base = path.Base(path.Dir(imp.Path))
+	}
+	base = gopkgVersion.ReplaceAllString(base, "")
+	return strings.TrimPrefix(base, "go-")
+}
+
+// resolveImports 找出字段类型中用到的包，按路径排序
+func resolveImports(models []Model, known map[string]Import) ([]Import, error) {
+	used := make(map[string]Import)
+	for _, m := range models {
+		for _, f := range m.Fields {
+			for _, name := range qualifiers(f.Type) {
+				imp, ok := known[name]
+				if !ok {
+					return nil, fmt.Errorf("model %s: field %s: unknown package %q, add it to imports", m.Name, f.Name, name)
+				}
+				used[name] = imp
+			}
+		}
+	}
+	out := make([]Import, 0, len(used))
+	for _, imp := range used {
+		out = append(out, imp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// qualifiers 类型表达式中的包名，例如map[string]time.Time中的time
+func qualifiers(typ string) []string {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				out = append(out, id.Name)
+			}
+			return false
+		}
+		return true
+	})
+	return out
+}
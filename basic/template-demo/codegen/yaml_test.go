@@ -0,0 +1,73 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func loadString(t *testing.T, src string) (*File, error) {
+	t.Helper()
+	return LoadYAML("test.yaml", strings.NewReader(src))
+}
+
+func TestLoadYAML(t *testing.T) {
+	f, err := loadString(t, `
+package: store
+imports:
+  - github.com/google/uuid
+  - pgtype github.com/jackc/pgx/v5/pgtype
+  - gopkg.in/yaml.v3
+models:
+  - name: Account
+    table: accounts
+    fields:
+      - {name: ID, type: uuid.UUID, primaryKey: true}
+      - {name: Balance, type: pgtype.Numeric}
+      - {name: Meta, type: "map[string]yaml.Node"}
+      - {name: History, type: "[]time.Time", readonly: true}
+      - {name: Raw, type: json.RawMessage, column: raw_json}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Package != "store" || f.Source != "test.yaml" || len(f.Models) != 1 {
+		t.Fatalf("file = %+v", f)
+	}
+	m := f.Models[0]
+	if !m.Declare || m.Table != "accounts" || len(m.Fields) != 5 || !m.Fields[0].PrimaryKey || !m.Fields[3].ReadOnly {
+		t.Fatalf("model = %+v", m)
+	}
+	got := fmt.Sprint(f.Imports)
+	want := "[{ encoding/json} { github.com/google/uuid} {pgtype github.com/jackc/pgx/v5/pgtype} { gopkg.in/yaml.v3} { time}]"
+	if got != want {
+		t.Fatalf("imports = %s, want %s", got, want)
+	}
+}
+
+func TestLoadYAMLErrors(t *testing.T) {
+	for want, src := range map[string]string{
+		"field primarykey not found":  "models:\n  - name: A\n    fields:\n      - {name: ID, type: int, primarykey: true}",
+		"no models":                   "package: x",
+		"unknown package \"decimal\"": "models:\n  - name: A\n    fields:\n      - {name: Price, type: decimal.Decimal}",
+		"invalid type":                "models:\n  - name: A\n    fields:\n      - {name: Price, type: \"func(\"}",
+	} {
+		if _, err := loadString(t, src); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestImportName(t *testing.T) {
+	for path, want := range map[string]string{
+		"time":                          "time",
+		"github.com/jackc/pgx/v5":       "pgx",
+		"gopkg.in/yaml.v3":              "yaml",
+		"github.com/pelletier/go-toml":  "toml",
+		"github.com/shopspring/decimal": "decimal",
+	} {
+		if got := (Import{Path: path}).pkgName(); got != want {
+			t.Errorf("pkgName(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package confgen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type serverConfig struct {
+	Host  string
+	Port  int
+	Debug bool
+}
+
+type databaseConfig struct {
+	Driver         string
+	DSN            string `config:"dsn"`
+	MaxConnections uint16
+	Timeout        float64
+	password       string
+}
+
+type appConfig struct {
+	Server   serverConfig
+	Database databaseConfig `config:"db"`
+	Features struct {
+		Enabled []string
+		Weights []float32
+	}
+	Internal struct{ Token string } `config:"-"`
+}
+
+func sampleConfig() appConfig {
+	cfg := appConfig{
+		Server:   serverConfig{Host: "localhost", Port: 8080, Debug: true},
+		Database: databaseConfig{Driver: "mysql", DSN: "root@tcp(localhost:3306)/app", MaxConnections: 100, Timeout: 2.5, password: "secret"},
+	}
+	cfg.Features.Enabled = []string{"signup", "api_v2"}
+	cfg.Features.Weights = []float32{0.5, 1}
+	return cfg
+}
+
+func TestFromStruct(t *testing.T) {
+	doc, err := FromStruct(sampleConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range doc.Sections {
+		for _, e := range s.Entries {
+			got = append(got, fmt.Sprintf("%s.%s=%#v", s.Name, e.Key, e.Value))
+		}
+	}
+	want := []string{
+		`server.host="localhost"`,
+		`server.port=8080`,
+		`server.debug=true`,
+		`db.driver="mysql"`,
+		`db.dsn="root@tcp(localhost:3306)/app"`,
+		`db.max_connections=100`,
+		`db.timeout=2.5`,
+		`features.enabled=[]interface {}{"signup", "api_v2"}`,
+		`features.weights=[]interface {}{0.5, 1}`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("entries:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if _, ok := doc.Lookup("db.password"); ok {
+		t.Fatal("unexported field exported")
+	}
+	if v, ok := doc.Lookup("server.port"); !ok || v != int64(8080) {
+		t.Fatalf("Lookup(server.port) = %#v, %v", v, ok)
+	}
+}
+
+func TestFromStructErrors(t *testing.T) {
+	for name, v := range map[string]any{
+		"not a struct":   42,
+		"scalar section": struct{ Port int }{8080},
+		"map value":      struct{ S struct{ M map[string]int } }{},
+		"nested list":    struct{ S struct{ L [][]int } }{},
+		"uint overflow":  struct{ S struct{ U uint64 } }{struct{ U uint64 }{1 << 63}},
+	} {
+		if _, err := FromStruct(v); err == nil {
+			t.Errorf("%s: FromStruct succeeded", name)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"Host": "host", "MaxConnections": "max_connections", "DB": "db", "HTTPPort": "http_port",
+	} {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+var schema = Schema{
+	"server.host":        {Required: true},
+	"server.port":        {Required: true, Range: Between(1, 65535)},
+	"db.driver":          {Required: true, Enum: []string{"mysql", "postgres", "sqlite"}},
+	"db.max_connections": {Range: Between(1, 1000)},
+	"features.enabled":   {Enum: []string{"signup", "api_v2", "dark_mode"}},
+}
+
+func TestSchemaValidate(t *testing.T) {
+	doc, _ := FromStruct(sampleConfig())
+	if err := schema.Validate(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := sampleConfig()
+	cfg.Server.Host = ""
+	cfg.Server.Port = 70000
+	cfg.Database.Driver = "oracle"
+	cfg.Features.Enabled = append(cfg.Features.Enabled, "beta")
+	doc, _ = FromStruct(cfg)
+	err := Schema{"server.timeout": {}, "server.host": {Range: Between(0, 1)}}.Validate(doc)
+	if err == nil || err.Error() != "server.host: \"\" is not a number\nserver.timeout: missing" {
+		t.Fatalf("schema errors = %v", err)
+	}
+
+	err = schema.Validate(doc)
+	var msgs []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var fe *FieldError
+		if !errors.As(e, &fe) {
+			t.Fatalf("%v is not a *FieldError", e)
+		}
+		msgs = append(msgs, fe.Error())
+	}
+	want := []string{
+		`db.driver: "oracle" is not one of mysql, postgres, sqlite`,
+		`features.enabled: "beta" is not one of signup, api_v2, dark_mode`,
+		`server.host: required`,
+		`server.port: 70000 is out of range [1, 65535]`,
+	}
+	if strings.Join(msgs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("errors:\n%s\nwant:\n%s", strings.Join(msgs, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := Generate(doc, schema, TOML); err == nil {
+		t.Fatal("Generate rendered an invalid config")
+	}
+}
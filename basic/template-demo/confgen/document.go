@@ -0,0 +1,158 @@
+// Package confgen 把同一份配置渲染成TOML、YAML、JSON和.env四种格式。
+//
+// 流程：FromStruct把配置结构体转换成Document，Schema检查必填项、取值范围和枚举值，
+// Render用模板生成各个格式，再用对应的解析器读回来和Document逐项比较，保证生成的文件一定能被正确解析。
+//
+//	doc, err := confgen.FromStruct(cfg)
+//	files, err := confgen.Generate(doc, schema, confgen.Formats...)
+//	os.WriteFile("config"+confgen.TOML.Ext(), files[confgen.TOML], 0o644)
+//
+// 配置是两层结构：第一层是section，第二层是key，.env中写成SECTION_KEY。
+// 值可以是字符串、布尔值、整数、浮点数和它们的切片。
+package confgen
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Document 与格式无关的配置
+type Document struct {
+	Comment  string // 文件开头的注释，可以有多行，JSON不支持注释所以不输出
+	Sections []Section
+}
+
+// Section 一节配置
+type Section struct {
+	Name    string
+	Entries []Entry
+}
+
+// Entry 一个配置项，Value是string、bool、int64、float64或者这些类型组成的[]any
+type Entry struct {
+	Key   string
+	Value any
+}
+
+// Lookup 按"section.key"查找配置项
+func (d *Document) Lookup(path string) (any, bool) {
+	section, key, ok := strings.Cut(path, ".")
+	if !ok {
+		return nil, false
+	}
+	for _, s := range d.Sections {
+		if s.Name != section {
+			continue
+		}
+		for _, e := range s.Entries {
+			if e.Key == key {
+				return e.Value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// FromStruct 把结构体转换成Document，v可以是结构体或者结构体指针
+// 每个结构体类型的字段是一节，其中的字段是配置项；名字取config标签，没有标签时是字段名的snake_case，
+// config:"-"跳过字段
+func FromStruct(v any) (*Document, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("confgen: FromStruct needs a struct, got %T", v)
+	}
+	doc := &Document{}
+	for i := 0; i < rv.NumField(); i++ {
+		sf := rv.Type().Field(i)
+		name, skip := fieldName(sf)
+		if skip {
+			continue
+		}
+		if sf.Type.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("confgen: %s: top-level fields must be structs", sf.Name)
+		}
+		section := Section{Name: name}
+		sv := rv.Field(i)
+		for j := 0; j < sv.NumField(); j++ {
+			kf := sv.Type().Field(j)
+			key, skip := fieldName(kf)
+			if skip {
+				continue
+			}
+			value, err := normalize(sv.Field(j))
+			if err != nil {
+				return nil, fmt.Errorf("confgen: %s.%s: %w", name, key, err)
+			}
+			section.Entries = append(section.Entries, Entry{Key: key, Value: value})
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+	return doc, nil
+}
+
+func fieldName(sf reflect.StructField) (name string, skip bool) {
+	if !sf.IsExported() {
+		return "", true
+	}
+	tag := sf.Tag.Get("config")
+	if tag == "-" {
+		return "", true
+	}
+	if tag != "" {
+		return tag, false
+	}
+	return snakeCase(sf.Name), false
+}
+
+// normalize 把值转换成Entry.Value支持的类型
+func normalize(v reflect.Value) (any, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows int64", v.Uint())
+		}
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("%v is not supported by every format", f)
+		}
+		return f, nil
+	case reflect.Slice, reflect.Array:
+		if k := v.Type().Elem().Kind(); k == reflect.Slice || k == reflect.Array {
+			return nil, fmt.Errorf("nested lists are not supported")
+		}
+		list := make([]any, v.Len())
+		for i := range list {
+			item, err := normalize(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = item
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// snakeCase MaxConnections是max_connections，DB是db
+func snakeCase(name string) string {
+	r := []rune(name)
+	var b strings.Builder
+	for i, c := range r {
+		if unicode.IsUpper(c) && i > 0 && (unicode.IsLower(r[i-1]) || i+1 < len(r) && unicode.IsLower(r[i+1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
@@ -0,0 +1,261 @@
+package confgen
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Format 配置文件格式
+type Format string
+
+const (
+	TOML Format = "toml"
+	YAML Format = "yaml"
+	JSON Format = "json"
+	Env  Format = "env"
+)
+
+// Formats 支持的全部格式
+var Formats = []Format{TOML, YAML, JSON, Env}
+
+// Ext 文件扩展名
+func (f Format) Ext() string {
+	return "." + string(f)
+}
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"lines":   lines,
+	"toml":    tomlValue,
+	"tomlKey": tomlKey,
+	"json":    jsonValue,
+	"yamlKey": yamlKey,
+	"env":     envValue,
+	"envKey":  envKey,
+}).ParseFS(templateFS, "templates/*.tmpl"))
+
+// Generate 检查doc后渲染成各个格式，schema为nil时不检查
+func Generate(doc *Document, schema Schema, formats ...Format) (map[Format][]byte, error) {
+	if err := schema.Validate(doc); err != nil {
+		return nil, err
+	}
+	for _, f := range formats {
+		if f == Env {
+			if _, err := envKeys(doc); err != nil {
+				return nil, err
+			}
+		}
+	}
+	out := make(map[Format][]byte, len(formats))
+	for _, f := range formats {
+		data, err := Render(doc, f)
+		if err != nil {
+			return nil, err
+		}
+		out[f] = data
+	}
+	return out, nil
+}
+
+// Render 渲染成格式f，并用解析器读回来验证，不能被正确读回的值返回包装ErrRoundTrip的错误
+func Render(doc *Document, f Format) ([]byte, error) {
+	tmpl := templates.Lookup(string(f) + ".tmpl")
+	if tmpl == nil {
+		return nil, fmt.Errorf("confgen: unknown format %q", f)
+	}
+	if f == Env {
+		if _, err := envKeys(doc); err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, doc); err != nil {
+		return nil, fmt.Errorf("confgen: render %s: %w", f, err)
+	}
+	if err := Verify(doc, f, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// lines 把注释拆成行
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(s, "\n"), "\n")
+}
+
+// formatFloat 浮点数总是带小数点，YAML和TOML才不会把它读成整数
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+func scalar(v any, str func(string) string) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return str(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return formatFloat(v), nil
+	}
+	return "", fmt.Errorf("unsupported value %T", v)
+}
+
+// list 渲染标量或者[a, b]形式的列表，TOML、YAML和JSON的列表写法相同
+func list(v any, str func(string) string) (string, error) {
+	items, ok := v.([]any)
+	if !ok {
+		return scalar(v, str)
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		s, err := scalar(item, str)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = s
+	}
+	return "[" + strings.Join(parts, ", ") + "]", nil
+}
+
+// jsonString JSON字符串，同时也是合法的YAML双引号字符串
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func jsonValue(v any) (string, error) {
+	return list(v, jsonString)
+}
+
+// tomlString TOML基本字符串，控制字符用\uXXXX转义
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func tomlValue(v any) (string, error) {
+	return list(v, tomlString)
+}
+
+var (
+	bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	bareYAMLKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	// yamlReserved 不加引号时会被读成布尔值或者null的键
+	yamlReserved = map[string]bool{"true": true, "false": true, "null": true, "yes": true, "no": true, "on": true, "off": true, "y": true, "n": true}
+)
+
+func tomlKey(k string) string {
+	if bareTOMLKey.MatchString(k) {
+		return k
+	}
+	return tomlString(k)
+}
+
+func yamlKey(k string) string {
+	if bareYAMLKey.MatchString(k) && !yamlReserved[strings.ToLower(k)] {
+		return k
+	}
+	return jsonString(k)
+}
+
+func envKey(section, key string) string {
+	return strings.ToUpper(section + "_" + key)
+}
+
+// envName .env中的变量名只能由字母、数字和下划线组成，不能以数字开头
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// envKeys 返回每个.env变量名对应的"section.key"
+// 变量名不合法，或者两项的变量名相同（例如db_main.host和db.main_host都是DB_MAIN_HOST）时返回错误
+func envKeys(doc *Document) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, s := range doc.Sections {
+		for _, e := range s.Entries {
+			path, name := s.Name+"."+e.Key, envKey(s.Name, e.Key)
+			if !envName.MatchString(name) {
+				return nil, fmt.Errorf("confgen: %s: %q is not a valid .env variable name", path, name)
+			}
+			if prev, ok := keys[name]; ok {
+				return nil, fmt.Errorf("confgen: %s and %s are both %s in .env", prev, path, name)
+			}
+			keys[name] = path
+		}
+	}
+	return keys, nil
+}
+
+// envString .env中的原始值，列表用逗号连接
+func envString(v any) (string, error) {
+	items, ok := v.([]any)
+	if !ok {
+		return scalar(v, func(s string) string { return s })
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		s, err := scalar(item, func(s string) string { return s })
+		if err != nil {
+			return "", err
+		}
+		if strings.Contains(s, ",") {
+			return "", fmt.Errorf("list item %q contains a comma", s)
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// envValue 优先使用不做任何转义的单引号；含有单引号或者换行时用双引号，
+// 双引号中godotenv会展开$VAR，所以$也要转义
+func envValue(v any) (string, error) {
+	s, err := envString(v)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(s, `\`) {
+		// godotenv把结尾的反斜杠当成转义了引号，单引号和双引号都读不回来
+		return "", fmt.Errorf("value %q ends with a backslash", s)
+	}
+	if !strings.ContainsAny(s, "'\n\r") {
+		return "'" + s + "'", nil
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`)
+	return `"` + r.Replace(s) + `"`, nil
+}
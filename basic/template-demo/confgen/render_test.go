@@ -0,0 +1,207 @@
+package confgen
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGenerateAllFormats(t *testing.T) {
+	doc, err := FromStruct(sampleConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Comment = "应用配置\n由confgen生成"
+	files, err := Generate(doc, schema, Formats...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[Format]string{
+		TOML: `# 应用配置
+# 由confgen生成
+
+[server]
+host = "localhost"
+port = 8080
+debug = true
+
+[db]
+driver = "mysql"
+dsn = "root@tcp(localhost:3306)/app"
+max_connections = 100
+timeout = 2.5
+
+[features]
+enabled = ["signup", "api_v2"]
+weights = [0.5, 1.0]
+`,
+		YAML: `# 应用配置
+# 由confgen生成
+
+server:
+  host: "localhost"
+  port: 8080
+  debug: true
+
+db:
+  driver: "mysql"
+  dsn: "root@tcp(localhost:3306)/app"
+  max_connections: 100
+  timeout: 2.5
+
+features:
+  enabled: ["signup", "api_v2"]
+  weights: [0.5, 1.0]
+`,
+		JSON: `{
+  "server": {
+    "host": "localhost",
+    "port": 8080,
+    "debug": true
+  },
+  "db": {
+    "driver": "mysql",
+    "dsn": "root@tcp(localhost:3306)/app",
+    "max_connections": 100,
+    "timeout": 2.5
+  },
+  "features": {
+    "enabled": ["signup", "api_v2"],
+    "weights": [0.5, 1.0]
+  }
+}
+`,
+		Env: `# 应用配置
+# 由confgen生成
+
+SERVER_HOST='localhost'
+SERVER_PORT='8080'
+SERVER_DEBUG='true'
+
+DB_DRIVER='mysql'
+DB_DSN='root@tcp(localhost:3306)/app'
+DB_MAX_CONNECTIONS='100'
+DB_TIMEOUT='2.5'
+
+FEATURES_ENABLED='signup,api_v2'
+FEATURES_WEIGHTS='0.5,1.0'
+`,
+	}
+	for _, f := range Formats {
+		if got := string(files[f]); got != want[f] {
+			t.Errorf("%s:\n%s\nwant:\n%s", f, got, want[f])
+		}
+	}
+}
+
+// TestRenderTrickyValues 需要转义的值在每种格式中都能被正确读回
+func TestRenderTrickyValues(t *testing.T) {
+	values := map[string]string{
+		"quotes":    `say "hi" and 'bye'`,
+		"backslash": `C:\Program Files\app`,
+		"newline":   "line1\nline2\r\n",
+		"dollar":    "$HOME ${PATH} \\$x",
+		"comment":   "value # not a comment",
+		"yaml":      "yes",
+		"number":    "8080",
+		"unicode":   "中文 😀 \u2028 <b>&</b>",
+		"control":   "tab\there\x01",
+		"empty":     "",
+		"spaces":    "  padded  ",
+	}
+	for name, v := range values {
+		doc := &Document{Sections: []Section{{Name: "s", Entries: []Entry{
+			{Key: "v", Value: v},
+			{Key: "list", Value: []any{"a b", "x\"y"}},
+			{Key: "int", Value: int64(-1)},
+			{Key: "float", Value: 1e21},
+		}}}}
+		for _, f := range Formats {
+			if _, err := Render(doc, f); err != nil {
+				t.Errorf("%s/%s: %v", name, f, err)
+			}
+		}
+	}
+}
+
+func TestRenderQuotedKeys(t *testing.T) {
+	doc := &Document{Sections: []Section{
+		{Name: "on", Entries: []Entry{{Key: "my key", Value: "v"}, {Key: "null", Value: true}}},
+		{Name: "empty"},
+	}}
+	for _, f := range []Format{TOML, YAML, JSON} {
+		if _, err := Render(doc, f); err != nil {
+			t.Errorf("%s: %v", f, err)
+		}
+	}
+	// .env中的变量名没有引号，不合法的名字在渲染之前就报错
+	if _, err := Render(doc, Env); err == nil || !strings.Contains(err.Error(), "not a valid .env variable name") {
+		t.Errorf("env key with space error = %v", err)
+	}
+}
+
+func TestEnvKeys(t *testing.T) {
+	for _, tc := range []struct {
+		doc  *Document
+		want string
+	}{
+		{&Document{Sections: []Section{{Name: "db-main", Entries: []Entry{{Key: "host", Value: "a"}}}}}, "not a valid .env variable name"},
+		{&Document{Sections: []Section{{Name: "db", Entries: []Entry{{Key: "max-conns", Value: int64(1)}}}}}, "not a valid .env variable name"},
+		{&Document{Sections: []Section{
+			{Name: "db_main", Entries: []Entry{{Key: "host", Value: "a"}}},
+			{Name: "db", Entries: []Entry{{Key: "main_host", Value: "b"}}},
+		}}, "db_main.host and db.main_host are both DB_MAIN_HOST"},
+		{&Document{Sections: []Section{
+			{Name: "db", Entries: []Entry{{Key: "host", Value: "a"}}},
+			{Name: "DB", Entries: []Entry{{Key: "HOST", Value: "b"}}},
+		}}, "db.host and DB.HOST are both DB_HOST"},
+	} {
+		if _, err := Generate(tc.doc, nil, Formats...); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Generate(%+v) error = %v, want %q", tc.doc.Sections, err, tc.want)
+		}
+		if err := Verify(tc.doc, Env, nil); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Verify(%+v) error = %v, want %q", tc.doc.Sections, err, tc.want)
+		}
+	}
+	// 其他格式按section和key分开，不受影响
+	doc := &Document{Sections: []Section{
+		{Name: "db_main", Entries: []Entry{{Key: "host", Value: "a"}}},
+		{Name: "db", Entries: []Entry{{Key: "main_host", Value: "b"}}},
+	}}
+	if _, err := Generate(doc, nil, TOML, YAML, JSON); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRenderUnrepresentable(t *testing.T) {
+	doc := &Document{Sections: []Section{{Name: "s", Entries: []Entry{{Key: "list", Value: []any{"a,b"}}}}}}
+	if _, err := Render(doc, Env); err == nil || !strings.Contains(err.Error(), "contains a comma") {
+		t.Errorf("env list with comma error = %v", err)
+	}
+	doc.Sections[0].Entries[0].Value = `C:\temp\`
+	if _, err := Render(doc, Env); err == nil || !strings.Contains(err.Error(), "ends with a backslash") {
+		t.Errorf("env trailing backslash error = %v", err)
+	}
+	if _, err := Render(doc, "ini"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+func TestVerifyDetectsDrift(t *testing.T) {
+	doc, _ := FromStruct(sampleConfig())
+	for f, edited := range map[Format]string{
+		TOML: "[server]\nhost = \"localhost\"\nport = \"8080\"\ndebug = true\n",
+		YAML: "server:\n  host: localhost\n  port: 8080\n  debug: true\n  extra: 1\n",
+		JSON: `{"server": {"host": "localhost", "port": 8080.5, "debug": true}}`,
+		Env:  "SERVER_HOST=localhost\n",
+	} {
+		err := Verify(doc, f, []byte(edited))
+		if !errors.Is(err, ErrRoundTrip) {
+			t.Errorf("%s: Verify = %v, want ErrRoundTrip", f, err)
+		}
+	}
+	if err := Verify(doc, TOML, []byte("[server\n")); !errors.Is(err, ErrRoundTrip) {
+		t.Errorf("syntax error = %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package confgen
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema 按"section.key"描述配置项的约束，没有出现在Schema中的配置项不检查
+type Schema map[string]Rule
+
+// Rule 一个配置项的约束
+type Rule struct {
+	Required bool     // 不能是零值：空字符串、0、false或者空列表
+	Range    *Range   // 数值的范围，列表时检查每个元素
+	Enum     []string // 字符串的取值范围，列表时检查每个元素
+}
+
+// Range 闭区间[Min, Max]
+type Range struct {
+	Min, Max float64
+}
+
+// Between 返回[min, max]
+func Between(min, max float64) *Range {
+	return &Range{Min: min, Max: max}
+}
+
+// FieldError 一个配置项没有通过检查
+type FieldError struct {
+	Key string
+	Msg string
+}
+
+func (e *FieldError) Error() string {
+	return e.Key + ": " + e.Msg
+}
+
+// Validate 检查doc，返回所有问题（errors.Join），可以用errors.As取出*FieldError
+// Schema中的键在doc中不存在也是错误，通常是拼写错误
+func (s Schema) Validate(doc *Document) error {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		rule := s[key]
+		value, ok := doc.Lookup(key)
+		if !ok {
+			errs = append(errs, &FieldError{Key: key, Msg: "missing"})
+			continue
+		}
+		for _, msg := range rule.check(value) {
+			errs = append(errs, &FieldError{Key: key, Msg: msg})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r Rule) check(value any) []string {
+	var problems []string
+	if r.Required && isZero(value) {
+		problems = append(problems, "required")
+	}
+	items := []any{value}
+	if list, ok := value.([]any); ok {
+		items = list
+	}
+	for _, item := range items {
+		if r.Range != nil {
+			if n, ok := number(item); !ok {
+				problems = append(problems, fmt.Sprintf("%#v is not a number", item))
+			} else if n < r.Range.Min || n > r.Range.Max {
+				problems = append(problems, fmt.Sprintf("%v is out of range [%v, %v]", item, r.Range.Min, r.Range.Max))
+			}
+		}
+		if len(r.Enum) > 0 && !contains(r.Enum, item) {
+			problems = append(problems, fmt.Sprintf("%q is not one of %s", fmt.Sprint(item), strings.Join(r.Enum, ", ")))
+		}
+	}
+	return problems
+}
+
+func isZero(v any) bool {
+	switch v := v.(type) {
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case int64:
+		return v == 0
+	case float64:
+		return v == 0
+	case []any:
+		return len(v) == 0
+	}
+	return v == nil
+}
+
+func number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func contains(enum []string, v any) bool {
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+	for _, e := range enum {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
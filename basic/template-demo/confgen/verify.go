@@ -0,0 +1,160 @@
+package confgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrRoundTrip 生成的文件解析失败，或者解析出来的值和Document不一致
+var ErrRoundTrip = errors.New("confgen: round trip failed")
+
+// Verify 用格式f的解析器读取data，检查每一项的类型和值都和doc一致，不多也不少
+// 也可以用来检查手工修改过的配置文件是否和生成它的配置一致
+func Verify(doc *Document, f Format, data []byte) error {
+	got, err := parse(f, data)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrRoundTrip, f, err)
+	}
+	want, err := expected(doc, f)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrRoundTrip, f, err)
+	}
+
+	keys := make(map[string]bool)
+	for k := range got {
+		keys[k] = true
+	}
+	for k := range want {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		g, inGot := got[k]
+		w, inWant := want[k]
+		switch {
+		case !inGot:
+			return fmt.Errorf("%w: %s: %s is missing", ErrRoundTrip, f, k)
+		case !inWant:
+			return fmt.Errorf("%w: %s: unexpected key %s", ErrRoundTrip, f, k)
+		case g != w:
+			return fmt.Errorf("%w: %s: %s = %s, want %s", ErrRoundTrip, f, k, g, w)
+		}
+	}
+	return nil
+}
+
+// expected doc中每一项的规范形式，键和parse的结果一致
+func expected(doc *Document, f Format) (map[string]string, error) {
+	if f == Env {
+		if _, err := envKeys(doc); err != nil {
+			return nil, err
+		}
+	}
+	out := make(map[string]string)
+	for _, s := range doc.Sections {
+		for _, e := range s.Entries {
+			if f == Env {
+				v, err := envString(e.Value)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s: %v", s.Name, e.Key, err)
+				}
+				out[envKey(s.Name, e.Key)] = canonical(v)
+				continue
+			}
+			out[s.Name+"."+e.Key] = canonical(e.Value)
+		}
+	}
+	return out, nil
+}
+
+// parse 解析data，返回每一项的规范形式
+func parse(f Format, data []byte) (map[string]string, error) {
+	if f == Env {
+		m, err := godotenv.Unmarshal(string(data))
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string]string, len(m))
+		for k, v := range m {
+			out[k] = canonical(v)
+		}
+		return out, nil
+	}
+
+	var tree map[string]any
+	switch f {
+	case TOML:
+		if err := toml.Unmarshal(data, &tree); err != nil {
+			return nil, err
+		}
+	case YAML:
+		if err := yaml.Unmarshal(data, &tree); err != nil {
+			return nil, err
+		}
+	case JSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&tree); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q", f)
+	}
+
+	out := make(map[string]string)
+	for section, v := range tree {
+		entries, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s is %T, not a table", section, v)
+		}
+		for key, value := range entries {
+			out[section+"."+key] = canonical(value)
+		}
+	}
+	return out, nil
+}
+
+// canonical 带类型的规范形式，各个解析器返回的不同整数类型都是int，字符串"8080"和整数8080不相等
+func canonical(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return "int " + strconv.Itoa(v)
+	case int64:
+		return "int " + strconv.FormatInt(v, 10)
+	case float64:
+		return "float " + strconv.FormatFloat(v, 'g', -1, 64)
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			f, err := v.Float64()
+			if err != nil {
+				return "number " + v.String()
+			}
+			return canonical(f)
+		}
+		return "int " + v.String()
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = canonical(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprintf("%T %v", v, v)
+}
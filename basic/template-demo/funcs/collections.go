@@ -0,0 +1,178 @@
+package funcs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+func collectionFuncs() map[string]any {
+	return map[string]any{
+		"join":    join,
+		"first":   func(list any) (any, error) { return at("first", list, 0) },
+		"last":    func(list any) (any, error) { return at("last", list, -1) },
+		"take":    take,
+		"drop":    drop,
+		"has":     has,
+		"reverse": reverse,
+	}
+}
+
+func dictFuncs() map[string]any {
+	return map[string]any{
+		"dict":   dict,
+		"keys":   keys,
+		"hasKey": hasKey,
+	}
+}
+
+// toList 把切片或数组转换成切片的reflect.Value，nil视为空切片
+func toList(name string, list any) (reflect.Value, error) {
+	if list == nil {
+		return reflect.ValueOf([]any{}), nil
+	}
+	v := reflect.ValueOf(list)
+	switch v.Kind() {
+	case reflect.Slice:
+		return v, nil
+	case reflect.Array:
+		s := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), v.Len(), v.Len())
+		reflect.Copy(s, v)
+		return s, nil
+	}
+	return reflect.Value{}, fmt.Errorf("%s: expected a slice or array, got %T", name, list)
+}
+
+// join 用sep连接列表中的元素，元素按fmt.Sprint格式化
+func join(sep string, list any) (string, error) {
+	v, err := toList("join", list)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, v.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// at 返回第i个元素，i为负数时从末尾数，列表为空时返回nil
+func at(name string, list any, i int) (any, error) {
+	v, err := toList(name, list)
+	if err != nil || v.Len() == 0 {
+		return nil, err
+	}
+	if i < 0 {
+		i += v.Len()
+	}
+	return v.Index(i).Interface(), nil
+}
+
+// take 返回前n个元素，不足n个时返回全部，类型与输入相同
+func take(n int, list any) (any, error) {
+	v, err := toList("take", list)
+	if err != nil {
+		return nil, err
+	}
+	return v.Slice(0, clamp(n, v.Len())).Interface(), nil
+}
+
+// drop 去掉前n个元素
+func drop(n int, list any) (any, error) {
+	v, err := toList("drop", list)
+	if err != nil {
+		return nil, err
+	}
+	return v.Slice(clamp(n, v.Len()), v.Len()).Interface(), nil
+}
+
+func clamp(n, length int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > length {
+		return length
+	}
+	return n
+}
+
+// has 列表中是否有和item相等的元素
+func has(item, list any) (bool, error) {
+	v, err := toList("has", list)
+	if err != nil {
+		return false, err
+	}
+	for i := 0; i < v.Len(); i++ {
+		if reflect.DeepEqual(v.Index(i).Interface(), item) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// reverse 返回倒序的新切片，不修改输入
+func reverse(list any) (any, error) {
+	v, err := toList("reverse", list)
+	if err != nil {
+		return nil, err
+	}
+	n := v.Len()
+	out := reflect.MakeSlice(v.Type(), n, n)
+	for i := 0; i < n; i++ {
+		out.Index(i).Set(v.Index(n - 1 - i))
+	}
+	return out.Interface(), nil
+}
+
+// dict 用键值对创建map，常用于给子模板传多个参数：{{template "card" dict "User" .User "Compact" true}}
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict: odd number of arguments %d", len(pairs))
+	}
+	m := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict: key %v is %T, not string", pairs[i], pairs[i])
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
+
+// keys 返回排好序的键，m必须是以字符串为键的map
+func keys(m any) ([]string, error) {
+	v, err := stringMap("keys", m)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		out = append(out, k.String())
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func hasKey(key string, m any) (bool, error) {
+	v, err := stringMap("hasKey", m)
+	if err != nil {
+		return false, err
+	}
+	if v.Len() == 0 {
+		return false, nil
+	}
+	return v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())).IsValid(), nil
+}
+
+func stringMap(name string, m any) (reflect.Value, error) {
+	if m == nil {
+		return reflect.ValueOf(map[string]any{}), nil
+	}
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return reflect.Value{}, fmt.Errorf("%s: expected a map with string keys, got %T", name, m)
+	}
+	return v, nil
+}
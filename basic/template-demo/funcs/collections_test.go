@@ -0,0 +1,46 @@
+package funcs
+
+import "testing"
+
+func TestCollectionFuncs(t *testing.T) {
+	tags := []string{"Go", "Docker", "Kubernetes", "微服务", "云原生"}
+	runCases(t, []renderCase{
+		{`{{join ", " .}}`, tags, "Go, Docker, Kubernetes, 微服务, 云原生"},
+		{`{{. | take 2 | join ", "}}`, tags, "Go, Docker"},
+		{`{{. | drop 3 | join "/"}}`, tags, "微服务/云原生"},
+		{`{{join "+" .}}`, []int{1, 2, 3}, "1+2+3"},
+		{`{{join "," .}}`, [3]float64{1.5, 2, 3}, "1.5,2,3"},
+		{`[{{join "," .}}]`, nil, "[]"},
+		{`{{first .}} {{last .}}`, tags, "Go 云原生"},
+		{`{{first .}}`, []int{}, "<no value>"},
+		{`{{take 10 . | len}} {{take -1 . | len}} {{drop 10 . | len}}`, tags, "5 0 0"},
+		{`{{take 2 . | printf "%T"}}`, tags, "[]string"},
+		{`{{has "Go" .}} {{has "Rust" .}}`, tags, "true false"},
+		{`{{has 2 .}}`, []int{1, 2}, "true"},
+		{`{{reverse . | join ""}} {{join "" .}}`, []string{"a", "b", "c"}, "cba abc"},
+		{`{{range reverse .}}{{.}}{{end}}`, [2]int{1, 2}, "21"},
+	})
+}
+
+func TestDictFuncs(t *testing.T) {
+	runCases(t, []renderCase{
+		{`{{$d := dict "Name" "alice" "Age" 30}}{{$d.Name}} {{$d.Age}}`, nil, "alice 30"},
+		{`{{define "card"}}{{.User}}{{if .Compact}}!{{end}}{{end}}{{template "card" dict "User" . "Compact" true}}`, "bob", "bob!"},
+		{`{{keys . | join ","}}`, map[string]int{"b": 1, "a": 2, "c": 3}, "a,b,c"},
+		{`{{hasKey "a" .}} {{hasKey "z" .}}`, map[string]int{"a": 1}, "true false"},
+		{`{{hasKey "a" .}} {{keys . | len}}`, nil, "false 0"},
+		{`{{len (dict)}}`, nil, "0"},
+	})
+}
+
+func TestCollectionErrors(t *testing.T) {
+	runErrors(t, []renderCase{
+		{`{{join "," .}}`, "abc", "expected a slice or array, got string"},
+		{`{{first .}}`, 42, "expected a slice or array"},
+		{`{{take 1 .}}`, map[string]int{}, "expected a slice or array"},
+		{`{{dict "a"}}`, nil, "odd number of arguments"},
+		{`{{dict 1 2}}`, nil, "key 1 is int"},
+		{`{{keys .}}`, map[int]int{1: 1}, "string keys"},
+		{`{{hasKey "a" .}}`, []int{}, "string keys"},
+	})
+}
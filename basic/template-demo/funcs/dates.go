@@ -0,0 +1,76 @@
+package funcs
+
+import (
+	"fmt"
+	"time"
+)
+
+func dateFuncs(c *config) map[string]any {
+	return map[string]any{
+		"now":            c.now,
+		"date":           c.date,
+		"formatDate":     func(t any) (string, error) { return c.date(c.locale.DateLayout, t) },
+		"formatDateTime": func(t any) (string, error) { return c.date(c.locale.DateTimeLayout, t) },
+		"timeAgo":        c.timeAgo,
+	}
+}
+
+// date 按Go的时间格式layout格式化，t可以是time.Time或*time.Time，零值和nil返回空字符串
+func (c *config) date(layout string, t any) (string, error) {
+	tm, ok, err := toTime(t)
+	if err != nil || !ok {
+		return "", err
+	}
+	if c.location != nil {
+		tm = tm.In(c.location)
+	}
+	return tm.Format(layout), nil
+}
+
+// timeAgo 相对于当前时间的描述，例如"3分钟前"、"in 2 days"，零值和nil返回空字符串
+func (c *config) timeAgo(t any) (string, error) {
+	tm, ok, err := toTime(t)
+	if err != nil || !ok {
+		return "", err
+	}
+
+	d := c.now().Sub(tm)
+	wrap := c.locale.Ago
+	if d < 0 {
+		d, wrap = -d, c.locale.Later
+	}
+
+	var n int
+	var one, many string
+	switch {
+	case d < time.Minute:
+		return c.locale.JustNow, nil
+	case d < time.Hour:
+		n, one, many = int(d/time.Minute), c.locale.Minute, c.locale.Minutes
+	case d < 24*time.Hour:
+		n, one, many = int(d/time.Hour), c.locale.Hour, c.locale.Hours
+	default:
+		n, one, many = int(d/(24*time.Hour)), c.locale.Day, c.locale.Days
+	}
+	unit := many
+	if n == 1 {
+		unit = one
+	}
+	return fmt.Sprintf(wrap, fmt.Sprintf(unit, n)), nil
+}
+
+// toTime ok为false表示零值或nil
+func toTime(v any) (t time.Time, ok bool, err error) {
+	switch v := v.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v != nil {
+			t = *v
+		}
+	case nil:
+	default:
+		return t, false, fmt.Errorf("expected time.Time, got %T", v)
+	}
+	return t, !t.IsZero(), nil
+}
@@ -0,0 +1,68 @@
+package funcs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateFuncs(t *testing.T) {
+	ts := time.Date(2024, 1, 5, 14, 3, 9, 0, time.UTC)
+	runCases(t, []renderCase{
+		{`{{formatDate .}}`, ts, "2024年01月05日"},
+		{`{{formatDateTime .}}`, ts, "2024-01-05 14:03:09"},
+		{`{{date "2006/01/02" .}}`, ts, "2024/01/05"},
+		{`{{. | date "15:04"}}`, &ts, "14:03"},
+		{`[{{formatDate .}}]`, time.Time{}, "[]"},
+		{`[{{formatDate .}}]`, (*time.Time)(nil), "[]"},
+		{`{{now | formatDate}}`, nil, "2024年03月15日"},
+	})
+
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip(err)
+	}
+	runCases(t, []renderCase{
+		{`{{formatDateTime .}}`, time.Date(2024, 1, 5, 20, 0, 0, 0, time.UTC), "2024-01-06 04:00:00"},
+	}, WithLocation(shanghai))
+}
+
+func TestDateLocales(t *testing.T) {
+	ts := time.Date(2024, 1, 5, 14, 3, 0, 0, time.UTC)
+	cases := map[Locale]string{
+		EnUS: "Jan 5, 2024 / Jan 5, 2024 2:03 PM",
+		DeDE: "05.01.2024 / 05.01.2024 14:03",
+		JaJP: "2024年1月5日 / 2024/01/05 14:03",
+	}
+	for loc, want := range cases {
+		runCases(t, []renderCase{{`{{formatDate .}} / {{formatDateTime .}}`, ts, want}}, WithLocale(loc))
+	}
+}
+
+func TestTimeAgo(t *testing.T) {
+	ago := func(d time.Duration) time.Time { return fixedNow.Add(-d) }
+	runCases(t, []renderCase{
+		{`{{timeAgo .}}`, ago(30 * time.Second), "刚刚"},
+		{`{{timeAgo .}}`, ago(5 * time.Minute), "5分钟前"},
+		{`{{timeAgo .}}`, ago(2 * time.Hour), "2小时前"},
+		{`{{timeAgo .}}`, ago(365 * 24 * time.Hour), "365天前"},
+		{`{{timeAgo .}}`, ago(-3 * 24 * time.Hour), "3天后"},
+		{`[{{timeAgo .}}]`, (*time.Time)(nil), "[]"},
+	})
+	runCases(t, []renderCase{
+		{`{{timeAgo .}}`, ago(time.Minute), "1 minute ago"},
+		{`{{timeAgo .}}`, ago(90 * time.Minute), "1 hour ago"},
+		{`{{timeAgo .}}`, ago(49 * time.Hour), "2 days ago"},
+		{`{{timeAgo .}}`, ago(-2 * time.Hour), "in 2 hours"},
+		{`{{timeAgo .}}`, ago(10 * time.Second), "just now"},
+	}, WithLocale(EnUS))
+	runCases(t, []renderCase{
+		{`{{timeAgo .}}`, ago(24 * time.Hour), "vor 1 Tag"},
+	}, WithLocale(DeDE))
+}
+
+func TestDateErrors(t *testing.T) {
+	runErrors(t, []renderCase{
+		{`{{formatDate "2024-01-01"}}`, nil, "expected time.Time, got string"},
+		{`{{timeAgo 5}}`, nil, "expected time.Time, got int"},
+	})
+}
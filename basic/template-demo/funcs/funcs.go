@@ -0,0 +1,123 @@
+// Package funcs 模板函数库，text/template和html/template都可以使用。
+//
+// 参数顺序约定：被处理的值总是最后一个参数，这样可以放在管道中：
+//
+//	{{.Bio | truncate 20}}
+//	{{.Tags | take 2 | join ", "}}
+//	{{.Website | default "未设置"}}
+//
+// 数学函数按书写顺序，sub 10 3 是 10-3。字符串函数按rune处理，不会截断多字节字符；
+// 数字、货币和日期按Locale格式化。函数出错时（例如除以0）返回错误，模板执行停止。
+//
+// 不覆盖模板内置的len、index、slice、eq、lt等函数。
+package funcs
+
+import (
+	"time"
+)
+
+// Locale 数字、货币和日期的格式
+type Locale struct {
+	Tag            string // 例如zh-CN
+	Decimal        string // 小数点
+	Group          string // 千位分隔符
+	Currency       string // 默认货币代码，ISO 4217
+	SymbolAfter    bool   // 货币符号放在数字后面，例如 1.234,50 €
+	DateLayout     string
+	DateTimeLayout string
+
+	JustNow string // 一分钟以内
+	Ago     string // 包装过去的时间，例如"%s前"
+	Later   string // 包装将来的时间，例如"%s后"
+	// 单位，%d替换为数量；单数和复数相同的语言两个字段写一样的值
+	Minute, Minutes string
+	Hour, Hours     string
+	Day, Days       string
+}
+
+var (
+	ZhCN = Locale{
+		Tag: "zh-CN", Decimal: ".", Group: ",", Currency: "CNY",
+		DateLayout: "2006年01月02日", DateTimeLayout: "2006-01-02 15:04:05",
+		JustNow: "刚刚", Ago: "%s前", Later: "%s后",
+		Minute: "%d分钟", Minutes: "%d分钟", Hour: "%d小时", Hours: "%d小时", Day: "%d天", Days: "%d天",
+	}
+	EnUS = Locale{
+		Tag: "en-US", Decimal: ".", Group: ",", Currency: "USD",
+		DateLayout: "Jan 2, 2006", DateTimeLayout: "Jan 2, 2006 3:04 PM",
+		JustNow: "just now", Ago: "%s ago", Later: "in %s",
+		Minute: "%d minute", Minutes: "%d minutes", Hour: "%d hour", Hours: "%d hours", Day: "%d day", Days: "%d days",
+	}
+	DeDE = Locale{
+		Tag: "de-DE", Decimal: ",", Group: ".", Currency: "EUR", SymbolAfter: true,
+		DateLayout: "02.01.2006", DateTimeLayout: "02.01.2006 15:04",
+		JustNow: "gerade eben", Ago: "vor %s", Later: "in %s",
+		Minute: "%d Minute", Minutes: "%d Minuten", Hour: "%d Stunde", Hours: "%d Stunden", Day: "%d Tag", Days: "%d Tagen",
+	}
+	JaJP = Locale{
+		Tag: "ja-JP", Decimal: ".", Group: ",", Currency: "JPY",
+		DateLayout: "2006年1月2日", DateTimeLayout: "2006/01/02 15:04",
+		JustNow: "たった今", Ago: "%s前", Later: "%s後",
+		Minute: "%d分", Minutes: "%d分", Hour: "%d時間", Hours: "%d時間", Day: "%d日", Days: "%d日",
+	}
+)
+
+var locales = map[string]Locale{
+	ZhCN.Tag: ZhCN,
+	EnUS.Tag: EnUS,
+	DeDE.Tag: DeDE,
+	JaJP.Tag: JaJP,
+}
+
+// LookupLocale 按标签查找内置的Locale，例如"en-US"
+func LookupLocale(tag string) (Locale, bool) {
+	loc, ok := locales[tag]
+	return loc, ok
+}
+
+type config struct {
+	locale   Locale
+	now      func() time.Time
+	location *time.Location
+}
+
+// Option 函数库选项
+type Option func(*config)
+
+// WithLocale 设置格式，默认ZhCN
+func WithLocale(loc Locale) Option {
+	return func(c *config) { c.locale = loc }
+}
+
+// WithClock 设置now和timeAgo使用的时钟，默认time.Now
+func WithClock(now func() time.Time) Option {
+	return func(c *config) { c.now = now }
+}
+
+// WithLocation 日期函数先把时间转换到loc再格式化，默认保持时间本身的时区
+func WithLocation(loc *time.Location) Option {
+	return func(c *config) { c.location = loc }
+}
+
+// New 返回函数表，可以直接传给text/template和html/template的Funcs
+func New(opts ...Option) map[string]any {
+	c := &config{locale: ZhCN, now: time.Now}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	m := make(map[string]any)
+	for _, group := range []map[string]any{
+		stringFuncs(),
+		numberFuncs(c),
+		mathFuncs(),
+		dateFuncs(c),
+		collectionFuncs(),
+		dictFuncs(),
+	} {
+		for name, fn := range group {
+			m[name] = fn
+		}
+	}
+	return m
+}
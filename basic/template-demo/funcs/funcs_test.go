@@ -0,0 +1,92 @@
+package funcs
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+var fixedNow = time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+
+type renderCase struct {
+	tmpl string
+	data any
+	want string
+}
+
+func execute(tmpl string, data any, opts ...Option) (string, error) {
+	opts = append([]Option{WithClock(func() time.Time { return fixedNow })}, opts...)
+	t, err := template.New("t").Funcs(New(opts...)).Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	err = t.Execute(&sb, data)
+	return sb.String(), err
+}
+
+// runCases 逐个渲染模板并比较输出
+func runCases(t *testing.T, cases []renderCase, opts ...Option) {
+	t.Helper()
+	for _, c := range cases {
+		got, err := execute(c.tmpl, c.data, opts...)
+		if err != nil {
+			t.Errorf("%s: %v", c.tmpl, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s = %q, want %q", c.tmpl, got, c.want)
+		}
+	}
+}
+
+// runErrors 每个模板执行时都应该返回包含want的错误
+func runErrors(t *testing.T, cases []renderCase, opts ...Option) {
+	t.Helper()
+	for _, c := range cases {
+		got, err := execute(c.tmpl, c.data, opts...)
+		if err == nil {
+			t.Errorf("%s = %q, want error containing %q", c.tmpl, got, c.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s error = %v, want it to contain %q", c.tmpl, err, c.want)
+		}
+	}
+}
+
+func TestHTMLTemplate(t *testing.T) {
+	tmpl, err := htmltemplate.New("t").Funcs(New()).Parse(`<p>{{.Bio | truncate 3}}</p><p>{{currency .Price}}</p>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	data := map[string]any{"Bio": "<b>粗体</b>", "Price": 1234.5}
+	if err := tmpl.Execute(&sb, data); err != nil {
+		t.Fatal(err)
+	}
+	if want := "<p>&lt;b&gt;...</p><p>¥1,234.50</p>"; sb.String() != want {
+		t.Fatalf("got %q, want %q", sb.String(), want)
+	}
+}
+
+func TestNoBuiltinOverrides(t *testing.T) {
+	for _, builtin := range []string{"and", "call", "eq", "ge", "gt", "html", "index", "js", "le", "len", "lt", "ne", "not", "or", "print", "printf", "println", "slice", "urlquery"} {
+		if _, ok := New()[builtin]; ok {
+			t.Errorf("%s overrides a template builtin", builtin)
+		}
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	for _, tag := range []string{"zh-CN", "en-US", "de-DE", "ja-JP"} {
+		if loc, ok := LookupLocale(tag); !ok || loc.Tag != tag {
+			t.Errorf("LookupLocale(%s) = %v, %v", tag, loc.Tag, ok)
+		}
+	}
+	if _, ok := LookupLocale("xx-XX"); ok {
+		t.Error("LookupLocale(xx-XX) should fail")
+	}
+}
@@ -0,0 +1,143 @@
+package funcs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	errOverflow   = errors.New("integer overflow")
+	errDivideZero = errors.New("division by zero")
+)
+
+func mathFuncs() map[string]any {
+	return map[string]any{
+		"add": func(a, b any) (any, error) { return arith("add", a, b) },
+		"sub": func(a, b any) (any, error) { return arith("sub", a, b) },
+		"mul": func(a, b any) (any, error) { return arith("mul", a, b) },
+		"div": func(a, b any) (any, error) { return arith("div", a, b) },
+		"mod": func(a, b any) (any, error) { return arith("mod", a, b) },
+		"max": func(first any, rest ...any) (any, error) { return extreme("max", first, rest) },
+		"min": func(first any, rest ...any) (any, error) { return extreme("min", first, rest) },
+	}
+}
+
+// arith 两个整数的运算结果是int，溢出时返回错误；有一个是浮点数时结果是float64
+// 整数除法向零取整，除数为0时返回错误
+func arith(op string, a, b any) (any, error) {
+	x, err := toNumber(a)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	y, err := toNumber(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if x.isInt && y.isInt {
+		r, err := intArith(op, x.i, y.i)
+		if err == nil && (r < math.MinInt || r > math.MaxInt) {
+			err = errOverflow
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %d %d: %w", op, x.i, y.i, err)
+		}
+		return int(r), nil
+	}
+
+	f, g := x.float(), y.float()
+	var r float64
+	switch op {
+	case "add":
+		r = f + g
+	case "sub":
+		r = f - g
+	case "mul":
+		r = f * g
+	case "div", "mod":
+		if g == 0 {
+			return nil, fmt.Errorf("%s %v %v: %w", op, f, g, errDivideZero)
+		}
+		if op == "div" {
+			r = f / g
+		} else {
+			r = math.Mod(f, g)
+		}
+	}
+	if math.IsInf(r, 0) {
+		return nil, fmt.Errorf("%s %v %v: float overflow", op, f, g)
+	}
+	return r, nil
+}
+
+func intArith(op string, a, b int64) (int64, error) {
+	switch op {
+	case "add":
+		r := a + b
+		if (a > 0 && b > 0 && r < 0) || (a < 0 && b < 0 && r >= 0) {
+			return 0, errOverflow
+		}
+		return r, nil
+	case "sub":
+		r := a - b
+		if (a >= 0 && b < 0 && r < 0) || (a < 0 && b > 0 && r >= 0) {
+			return 0, errOverflow
+		}
+		return r, nil
+	case "mul":
+		if a == 0 || b == 0 {
+			return 0, nil
+		}
+		r := a * b
+		if r/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+			return 0, errOverflow
+		}
+		return r, nil
+	case "div", "mod":
+		if b == 0 {
+			return 0, errDivideZero
+		}
+		if a == math.MinInt64 && b == -1 {
+			if op == "mod" {
+				return 0, nil
+			}
+			return 0, errOverflow
+		}
+		if op == "div" {
+			return a / b, nil
+		}
+		return a % b, nil
+	}
+	return 0, fmt.Errorf("unknown operator %s", op)
+}
+
+// extreme 返回最大或最小值，都是整数时结果是int，否则是float64
+func extreme(op string, first any, rest []any) (any, error) {
+	best, err := toNumber(first)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	allInt := best.isInt
+	for _, v := range rest {
+		n, err := toNumber(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		allInt = allInt && n.isInt
+		if (op == "max" && greater(n, best)) || (op == "min" && greater(best, n)) {
+			best = n
+		}
+	}
+	if !allInt && best.isInt {
+		return best.float(), nil
+	}
+	return best.value(), nil
+}
+
+func greater(a, b number) bool {
+	if a.isInt && b.isInt {
+		return a.i > b.i
+	}
+	return a.float() > b.float()
+}
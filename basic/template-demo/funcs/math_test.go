@@ -0,0 +1,44 @@
+package funcs
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMathFuncs(t *testing.T) {
+	runCases(t, []renderCase{
+		{`{{add 1 2}} {{sub 10 3}} {{mul 4 5}} {{div 7 2}} {{mod 7 2}}`, nil, "3 7 20 3 1"},
+		{`{{div -7 2}} {{mod -7 2}}`, nil, "-3 -1"},
+		{`{{add 1 0.5}} {{mul 3 0.5}} {{div 7 2.0}} {{mod 7.5 2}}`, nil, "1.5 1.5 3.5 1.5"},
+		{`{{add .A .B}}`, map[string]any{"A": int8(100), "B": uint16(200)}, "300"},
+		{`{{. | sub 10}}`, 3, "7"},
+
+		// 整数结果是int，可以直接传给接收int的函数
+		{`{{truncate (add 1 1) "abcdef"}}`, nil, "ab..."},
+		{`{{printf "%T %T" (add 1 2) (add 1 2.0)}}`, nil, "int float64"},
+
+		{`{{max 1 5 3}} {{min 4 2 8}} {{max 7}}`, nil, "5 2 7"},
+		{`{{max 1 2.5}} {{min 1 2.5 | printf "%T"}}`, nil, "2.5 float64"},
+		{`{{min .Age 100}} - {{max .Age 18}}`, map[string]int{"Age": 28}, "28 - 28"},
+		{`{{div .MinInt -1.0}}`, map[string]any{"MinInt": math.MinInt64}, "9.223372036854776e+18"},
+		{`{{mod .MinInt -1}}`, map[string]any{"MinInt": math.MinInt64}, "0"},
+	})
+}
+
+func TestMathErrors(t *testing.T) {
+	big := map[string]any{"Max": math.MaxInt64, "Min": math.MinInt64}
+	runErrors(t, []renderCase{
+		{`{{div 1 0}}`, nil, "division by zero"},
+		{`{{mod 1 0}}`, nil, "division by zero"},
+		{`{{div 1.5 0}}`, nil, "division by zero"},
+		{`{{add .Max 1}}`, big, "integer overflow"},
+		{`{{sub .Min 1}}`, big, "integer overflow"},
+		{`{{mul .Max 2}}`, big, "integer overflow"},
+		{`{{mul .Min -1}}`, big, "integer overflow"},
+		{`{{div .Min -1}}`, big, "integer overflow"},
+		{`{{mul 1e308 10.0}}`, nil, "float overflow"},
+		{`{{add 1 "2"}}`, nil, "expected a number, got string"},
+		{`{{max 1 "2"}}`, nil, "expected a number"},
+		{`{{add 1 .}}`, math.NaN(), "not a finite number"},
+	})
+}
@@ -0,0 +1,184 @@
+package funcs
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxDecimals formatNumber、round允许的最大小数位数
+const maxDecimals = 10
+
+// currencyInfo 货币符号和小数位数
+type currencyInfo struct {
+	symbol   string
+	decimals int
+}
+
+var currencies = map[string]currencyInfo{
+	"CNY": {"¥", 2},
+	"USD": {"$", 2},
+	"EUR": {"€", 2},
+	"GBP": {"£", 2},
+	"JPY": {"¥", 0},
+	"HKD": {"HK$", 2},
+}
+
+func numberFuncs(c *config) map[string]any {
+	return map[string]any{
+		"formatNumber": c.formatNumber,
+		"percent":      c.percent,
+		"round":        round,
+		"currency":     func(v any) (string, error) { return c.money(c.locale.Currency, v) },
+		"money":        c.money,
+	}
+}
+
+// formatNumber 保留decimals位小数，按Locale加上千位分隔符
+func (c *config) formatNumber(decimals int, v any) (string, error) {
+	if decimals < 0 || decimals > maxDecimals {
+		return "", fmt.Errorf("formatNumber: decimals %d not in [0, %d]", decimals, maxDecimals)
+	}
+	n, err := toNumber(v)
+	if err != nil {
+		return "", fmt.Errorf("formatNumber: %w", err)
+	}
+	return c.localize(n.format(decimals)), nil
+}
+
+// percent value占total的百分比，保留1位小数，total为0时是0
+func (c *config) percent(value, total any) (string, error) {
+	v, err := toNumber(value)
+	if err != nil {
+		return "", fmt.Errorf("percent: %w", err)
+	}
+	t, err := toNumber(total)
+	if err != nil {
+		return "", fmt.Errorf("percent: %w", err)
+	}
+	p := 0.0
+	if t.float() != 0 {
+		p = v.float() / t.float() * 100
+	}
+	return c.localize(strconv.FormatFloat(p, 'f', 1, 64)) + "%", nil
+}
+
+// money 按货币代码格式化金额，未知的货币使用代码作为符号
+func (c *config) money(code string, v any) (string, error) {
+	n, err := toNumber(v)
+	if err != nil {
+		return "", fmt.Errorf("money: %w", err)
+	}
+	info, ok := currencies[code]
+	if !ok {
+		info = currencyInfo{symbol: code, decimals: 2}
+	}
+
+	s := c.localize(n.format(info.decimals))
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if c.locale.SymbolAfter {
+		return sign + s + " " + info.symbol, nil
+	}
+	// 字母符号和数字之间加空格，例如CHF 12.00
+	if r := []rune(info.symbol); unicode.IsLetter(r[len(r)-1]) {
+		return sign + info.symbol + " " + s, nil
+	}
+	return sign + info.symbol + s, nil
+}
+
+// localize 把strconv格式的数字（-1234.5）转换成Locale的格式（-1,234.5）
+func (c *config) localize(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac, hasFrac := strings.Cut(s, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, d := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(c.locale.Group)
+		}
+		b.WriteRune(d)
+	}
+	if hasFrac {
+		b.WriteString(c.locale.Decimal)
+		b.WriteString(frac)
+	}
+	return b.String()
+}
+
+// round 四舍五入到decimals位小数
+func round(decimals int, v any) (float64, error) {
+	if decimals < 0 || decimals > maxDecimals {
+		return 0, fmt.Errorf("round: decimals %d not in [0, %d]", decimals, maxDecimals)
+	}
+	n, err := toNumber(v)
+	if err != nil {
+		return 0, fmt.Errorf("round: %w", err)
+	}
+	scale := math.Pow10(decimals)
+	return math.Round(n.float()*scale) / scale, nil
+}
+
+// number 模板中的数字，整数保持精确，浮点数用float64
+type number struct {
+	i     int64
+	f     float64
+	isInt bool
+}
+
+func toNumber(v any) (number, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return number{i: rv.Int(), isInt: true}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if u > math.MaxInt64 {
+			return number{}, fmt.Errorf("%d overflows int64", u)
+		}
+		return number{i: int64(u), isInt: true}, nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return number{}, fmt.Errorf("%v is not a finite number", f)
+		}
+		return number{f: f}, nil
+	}
+	return number{}, fmt.Errorf("expected a number, got %T", v)
+}
+
+func (n number) float() float64 {
+	if n.isInt {
+		return float64(n.i)
+	}
+	return n.f
+}
+
+// format 返回strconv格式的字符串，整数不经过float64以免丢失精度
+func (n number) format(decimals int) string {
+	if !n.isInt {
+		return strconv.FormatFloat(n.f, 'f', decimals, 64)
+	}
+	s := strconv.FormatInt(n.i, 10)
+	if decimals > 0 {
+		s += "." + strings.Repeat("0", decimals)
+	}
+	return s
+}
+
+// value 整数返回int，方便传给接收int参数的函数
+func (n number) value() any {
+	if n.isInt {
+		return int(n.i)
+	}
+	return n.f
+}
@@ -0,0 +1,45 @@
+package funcs
+
+import "testing"
+
+func TestNumberFuncs(t *testing.T) {
+	runCases(t, []renderCase{
+		{`{{formatNumber 2 .}}`, 1234567.891, "1,234,567.89"},
+		{`{{formatNumber 0 .}}`, 999, "999"},
+		{`{{formatNumber 0 .}}`, 1000, "1,000"},
+		{`{{formatNumber 1 .}}`, -1234, "-1,234.0"},
+		{`{{formatNumber 0 .}}`, int64(9007199254740993), "9,007,199,254,740,993"},
+		{`{{formatNumber 2 .}}`, uint8(7), "7.00"},
+		{`{{formatNumber 3 .}}`, float32(0.5), "0.500"},
+		{`{{percent 85.5 100}} {{percent 1 3}} {{percent 5 0}}`, nil, "85.5% 33.3% 0.0%"},
+		{`{{round 2 3.14159}} {{round 0 2.5}} {{round 1 -1.25}}`, nil, "3.14 3 -1.3"},
+		{`{{currency 1234.5}}`, nil, "¥1,234.50"},
+		{`{{currency -0.5}}`, nil, "-¥0.50"},
+		{`{{money "USD" 1234.5}} {{money "JPY" 1234.5}} {{money "CHF" 12}}`, nil, "$1,234.50 ¥1,234 CHF 12.00"},
+		{`{{. | currency}}`, 99, "¥99.00"},
+	})
+}
+
+func TestNumberLocales(t *testing.T) {
+	runCases(t, []renderCase{
+		{`{{formatNumber 2 .}} {{currency .}} {{percent 1 8}}`, 1234567.5, "1.234.567,50 1.234.567,50 € 12,5%"},
+		{`{{money "USD" -3}}`, nil, "-3,00 $"},
+	}, WithLocale(DeDE))
+	runCases(t, []renderCase{
+		{`{{currency .}}`, 1234567.5, "$1,234,567.50"},
+	}, WithLocale(EnUS))
+	runCases(t, []renderCase{
+		{`{{currency .}}`, 1234567.5, "¥1,234,568"},
+	}, WithLocale(JaJP))
+}
+
+func TestNumberErrors(t *testing.T) {
+	runErrors(t, []renderCase{
+		{`{{formatNumber 2 "12"}}`, nil, "expected a number, got string"},
+		{`{{formatNumber -1 12}}`, nil, "decimals -1"},
+		{`{{formatNumber 11 12}}`, nil, "decimals 11"},
+		{`{{currency .}}`, nil, "expected a number, got <nil>"},
+		{`{{percent 1 "x"}}`, nil, "expected a number"},
+		{`{{round 2 .}}`, uint64(1 << 63), "overflows int64"},
+	})
+}
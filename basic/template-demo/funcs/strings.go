@@ -0,0 +1,99 @@
+package funcs
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxRepeat repeat、padLeft、padRight生成的字符串的最大长度，防止模板生成超大字符串
+const maxRepeat = 1 << 20
+
+func stringFuncs() map[string]any {
+	return map[string]any{
+		"upper":     strings.ToUpper,
+		"lower":     strings.ToLower,
+		"title":     title,
+		"trim":      strings.TrimSpace,
+		"truncate":  truncate,
+		"replace":   func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":  func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix": func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix": func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":     func(sep, s string) []string { return strings.Split(s, sep) },
+		"repeat":    repeat,
+		"runeCount": utf8.RuneCountInString,
+		"padLeft":   func(n int, s string) (string, error) { return pad(n, s, true) },
+		"padRight":  func(n int, s string) (string, error) { return pad(n, s, false) },
+		"default":   defaultValue,
+	}
+}
+
+// title 每个单词的第一个字母大写，替代已废弃的strings.Title
+func title(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	wordStart := true
+	for _, r := range s {
+		if wordStart {
+			b.WriteRune(unicode.ToTitle(r))
+		} else {
+			b.WriteRune(r)
+		}
+		wordStart = unicode.IsSpace(r) || r == '-' || r == '_'
+	}
+	return b.String()
+}
+
+// truncate 按字符截断，超过n个字符时保留前n个并加上"..."
+func truncate(n int, s string) string {
+	if n <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	i := 0
+	for pos := range s {
+		if i == n {
+			return s[:pos] + "..."
+		}
+		i++
+	}
+	return s
+}
+
+func repeat(n int, s string) (string, error) {
+	if n < 0 {
+		return "", fmt.Errorf("repeat: negative count %d", n)
+	}
+	if n > 0 && len(s) > maxRepeat/n {
+		return "", fmt.Errorf("repeat: result longer than %d bytes", maxRepeat)
+	}
+	return strings.Repeat(s, n), nil
+}
+
+// pad 用空格把s补到n个字符，已经不少于n个字符时原样返回
+func pad(n int, s string, left bool) (string, error) {
+	if n > maxRepeat {
+		return "", fmt.Errorf("pad: width %d too large", n)
+	}
+	missing := n - utf8.RuneCountInString(s)
+	if missing <= 0 {
+		return s, nil
+	}
+	if left {
+		return strings.Repeat(" ", missing) + s, nil
+	}
+	return s + strings.Repeat(" ", missing), nil
+}
+
+// defaultValue value在模板中为假（零值、空字符串、空集合、nil）时返回def
+func defaultValue(def, value any) any {
+	if truth, ok := template.IsTrue(value); !ok || !truth {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,59 @@
+package funcs
+
+import "testing"
+
+func TestStringFuncs(t *testing.T) {
+	runCases(t, []renderCase{
+		{`{{upper "héllo"}}`, nil, "HÉLLO"},
+		{`{{lower "HÉLLO"}}`, nil, "héllo"},
+		{`{{title "hello go-lang world_wide"}}`, nil, "Hello Go-Lang World_Wide"},
+		{`{{title "ünïcode  spaces"}}`, nil, "Ünïcode  Spaces"},
+		{`[{{trim "  x \n"}}]`, nil, "[x]"},
+
+		// 按字符截断，不会把中文切成两半
+		{`{{truncate 5 .}}`, "专注于云原生技术的全栈开发工程师", "专注于云原..."},
+		{`{{. | truncate 5}}`, "专注于云原生", "专注于云原..."},
+		{`{{truncate 6 .}}`, "专注于云原生", "专注于云原生"},
+		{`{{truncate 3 .}}`, "abc", "abc"},
+		{`{{truncate 2 .}}`, "abc", "ab..."},
+		{`[{{truncate 0 .}}]`, "abc", "[]"},
+		{`{{truncate 2 .}}`, "👍🏽ok", "👍🏽..."},
+
+		{`{{replace "-" "_" .}}`, "a-b-c", "a_b_c"},
+		{`{{contains "云" .}} {{contains "雨" .}}`, "云原生", "true false"},
+		{`{{hasPrefix "云" .}} {{hasSuffix "生" .}} {{hasSuffix "云" .}}`, "云原生", "true true false"},
+		{`{{split "," . | len}} {{index (split "," .) 1}}`, "a,b,c", "3 b"},
+		{`{{repeat 3 "ab"}}[{{repeat 0 "ab"}}]`, nil, "ababab[]"},
+		{`{{runeCount .}} {{len .}}`, "云原生", "3 9"},
+		{`[{{padLeft 5 "云"}}][{{padRight 4 "ab"}}][{{padLeft 1 "abc"}}]`, nil, "[    云][ab  ][abc]"},
+	})
+}
+
+func TestDefault(t *testing.T) {
+	type profile struct {
+		Website string
+		Tags    []string
+		Age     int
+		Ptr     *int
+	}
+	one := 1
+	runCases(t, []renderCase{
+		{`{{.Website | default "未设置"}}`, profile{}, "未设置"},
+		{`{{.Website | default "未设置"}}`, profile{Website: "https://go.dev"}, "https://go.dev"},
+		{`{{.Tags | default "无标签"}}`, profile{}, "无标签"},
+		{`{{.Tags | default "无标签"}}`, profile{Tags: []string{"go"}}, "[go]"},
+		{`{{.Age | default 18}}`, profile{}, "18"},
+		{`{{.Ptr | default "nil"}}`, profile{}, "nil"},
+		{`{{.Ptr | default "nil" | printf "%T"}}`, profile{Ptr: &one}, "*int"},
+		{`{{default "x" .}}`, nil, "x"},
+	})
+}
+
+func TestStringErrors(t *testing.T) {
+	runErrors(t, []renderCase{
+		{`{{repeat -1 "a"}}`, nil, "negative count"},
+		{`{{repeat 2000000 "a"}}`, nil, "longer than"},
+		{`{{padLeft 2000000 "a"}}`, nil, "too large"},
+		{`{{truncate "3" "abc"}}`, nil, "expected integer"},
+	})
+}
@@ -1,3 +1,9 @@
 module template-demo
 
 go 1.21
+
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/joho/godotenv v1.5.1
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,358 @@
+package mailer
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// token 注释、<script>、<style>或者开始标签，按这个顺序匹配，注释和脚本中的标签不会被处理
+	token      = regexp.MustCompile(`(?is)<!--.*?-->|<script\b.*?</script\s*>|<style\b([^>]*)>(.*?)</style\s*>|<([a-z][a-z0-9]*)\b([^>]*)>`)
+	attribute  = regexp.MustCompile(`([^\s"'=/<>]+)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'=<>` + "`" + `]+)))?`)
+	selectorRe = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9]*|\*)?((?:[.#][-_a-zA-Z0-9]+)*)$`)
+	simplePart = regexp.MustCompile(`[.#][^.#]+`)
+	comment    = regexp.MustCompile(`(?s)/\*.*?\*/`)
+)
+
+// noStyle 不需要内联样式的元素
+var noStyle = map[string]bool{"html": true, "head": true, "title": true, "meta": true, "link": true, "base": true}
+
+// InlineCSS 把<style>中的规则写进匹配元素的style属性，很多邮件客户端会删掉<style>
+//
+// 只支持简单选择器：标签、.class、#id以及它们的组合（例如td.price），可以用逗号分隔。
+// 按特异性和出现顺序应用，元素原有的style属性优先，!important的规则最优先。
+// 不能内联的规则（@media、:hover、后代选择器等）保留在第一个<style>的位置；
+// 带media属性的<style>原样保留。
+func InlineCSS(doc string) (string, error) {
+	var css strings.Builder
+	placeholder := -1
+	var out strings.Builder
+	last := 0
+	for _, m := range token.FindAllStringSubmatchIndex(doc, -1) {
+		if m[4] < 0 || attrValue(doc[m[2]:m[3]], "media") != nil {
+			continue
+		}
+		out.WriteString(doc[last:m[0]])
+		if placeholder < 0 {
+			placeholder = out.Len()
+		}
+		css.WriteString(doc[m[4]:m[5]])
+		css.WriteString("\n")
+		last = m[1]
+	}
+	if placeholder < 0 {
+		return doc, nil
+	}
+	out.WriteString(doc[last:])
+	doc = out.String()
+
+	rules, rest, err := parseCSS(css.String())
+	if err != nil {
+		return "", err
+	}
+	if rest != "" {
+		doc = doc[:placeholder] + "<style>\n" + rest + "</style>" + doc[placeholder:]
+	}
+
+	out.Reset()
+	last = 0
+	for _, m := range token.FindAllStringSubmatchIndex(doc, -1) {
+		if m[6] < 0 {
+			continue
+		}
+		tag := strings.ToLower(doc[m[6]:m[7]])
+		if noStyle[tag] {
+			continue
+		}
+		attrs := doc[m[8]:m[9]]
+		if style, ok := applyRules(rules, tag, attrs); ok {
+			out.WriteString(doc[last:m[0]])
+			out.WriteString("<" + doc[m[6]:m[7]] + setStyle(attrs, style) + ">")
+			last = m[1]
+		}
+	}
+	out.WriteString(doc[last:])
+	return out.String(), nil
+}
+
+type declaration struct {
+	prop, value string
+	important   bool
+}
+
+type rule struct {
+	tag         string
+	id          string
+	classes     []string
+	specificity int
+	decls       []declaration
+}
+
+func (r rule) matches(tag, id string, classes map[string]bool) bool {
+	if r.tag != "" && r.tag != "*" && r.tag != tag {
+		return false
+	}
+	if r.id != "" && r.id != id {
+		return false
+	}
+	for _, c := range r.classes {
+		if !classes[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCSS 返回可以内联的规则（按出现顺序）和不能内联的部分
+func parseCSS(css string) ([]rule, string, error) {
+	css = comment.ReplaceAllString(css, "")
+	var rules []rule
+	var rest strings.Builder
+	for {
+		css = strings.TrimSpace(css)
+		if css == "" {
+			break
+		}
+		if css[0] == '@' {
+			end := atRuleEnd(css)
+			if end < 0 {
+				return nil, "", fmt.Errorf("mailer: unterminated %s", firstWord(css))
+			}
+			rest.WriteString(css[:end] + "\n")
+			css = css[end:]
+			continue
+		}
+
+		open := indexTop(css, '{')
+		if open < 0 {
+			return nil, "", fmt.Errorf("mailer: css: expected { after %q", css)
+		}
+		close := indexTop(css[open:], '}')
+		if close < 0 {
+			return nil, "", fmt.Errorf("mailer: css: unterminated rule %q", strings.TrimSpace(css[:open]))
+		}
+		body := css[open+1 : open+close]
+		decls := parseDeclarations(body)
+		for _, sel := range splitTop(css[:open], ',') {
+			sel = strings.TrimSpace(sel)
+			r, ok := parseSelector(sel)
+			if !ok {
+				rest.WriteString(sel + " {" + body + "}\n")
+				continue
+			}
+			r.decls = decls
+			rules = append(rules, r)
+		}
+		css = css[open+close+1:]
+	}
+	return rules, rest.String(), nil
+}
+
+func parseSelector(sel string) (rule, bool) {
+	m := selectorRe.FindStringSubmatch(sel)
+	if m == nil || sel == "" {
+		return rule{}, false
+	}
+	r := rule{tag: strings.ToLower(m[1])}
+	if r.tag != "" && r.tag != "*" {
+		r.specificity = 1
+	}
+	for _, part := range simplePart.FindAllString(m[2], -1) {
+		if part[0] == '#' {
+			if r.id != "" {
+				return rule{}, false
+			}
+			r.id = part[1:]
+			r.specificity += 10000
+		} else {
+			r.classes = append(r.classes, part[1:])
+			r.specificity += 100
+		}
+	}
+	return r, true
+}
+
+func parseDeclarations(s string) []declaration {
+	var out []declaration
+	for _, d := range splitTop(s, ';') {
+		prop, value, ok := strings.Cut(d, ":")
+		prop, value = strings.ToLower(strings.TrimSpace(prop)), strings.TrimSpace(value)
+		if !ok || prop == "" || value == "" {
+			continue
+		}
+		decl := declaration{prop: prop, value: value}
+		if v, ok := cutSuffixFold(value, "!important"); ok {
+			decl.value, decl.important = strings.TrimSpace(v), true
+		}
+		out = append(out, decl)
+	}
+	return out
+}
+
+// attrValue 返回属性的值（已经反转义），没有这个属性时返回nil
+func attrValue(attrs, name string) *string {
+	for _, a := range attribute.FindAllStringSubmatch(attrs, -1) {
+		if strings.EqualFold(a[1], name) {
+			v := html.UnescapeString(a[2] + a[3] + a[4])
+			return &v
+		}
+	}
+	return nil
+}
+
+// applyRules 计算元素最终的style属性，没有匹配的规则时返回false，元素保持原样
+func applyRules(rules []rule, tag, attrs string) (string, bool) {
+	var id, inline string
+	if v := attrValue(attrs, "id"); v != nil {
+		id = *v
+	}
+	if v := attrValue(attrs, "style"); v != nil {
+		inline = *v
+	}
+	classes := make(map[string]bool)
+	if v := attrValue(attrs, "class"); v != nil {
+		for _, c := range strings.Fields(*v) {
+			classes[c] = true
+		}
+	}
+
+	var matched []rule
+	for _, r := range rules {
+		if r.matches(tag, id, classes) {
+			matched = append(matched, r)
+		}
+	}
+	if len(matched) == 0 {
+		return "", false
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].specificity < matched[j].specificity })
+
+	var style styleList
+	for _, r := range matched {
+		style.set(r.decls, false)
+	}
+	style.set(parseDeclarations(inline), false)
+	for _, r := range matched {
+		style.set(r.decls, true)
+	}
+	return style.String(), true
+}
+
+// styleList 保持属性第一次出现的顺序，后设置的值覆盖前面的值
+type styleList []declaration
+
+func (s *styleList) set(decls []declaration, important bool) {
+	for _, d := range decls {
+		if important && !d.important {
+			continue
+		}
+		found := false
+		for i := range *s {
+			if (*s)[i].prop == d.prop {
+				(*s)[i].value = d.value
+				found = true
+			}
+		}
+		if !found {
+			*s = append(*s, d)
+		}
+	}
+}
+
+func (s styleList) String() string {
+	parts := make([]string, len(s))
+	for i, d := range s {
+		parts[i] = d.prop + ": " + d.value
+	}
+	return strings.Join(parts, "; ")
+}
+
+// setStyle 替换或者添加style属性，其他属性保持原样
+func setStyle(attrs, style string) string {
+	value := `style="` + html.EscapeString(style) + `"`
+	for _, m := range attribute.FindAllStringSubmatchIndex(attrs, -1) {
+		if strings.EqualFold(attrs[m[2]:m[3]], "style") {
+			return attrs[:m[0]] + value + attrs[m[1]:]
+		}
+	}
+	trimmed := strings.TrimRight(attrs, " \t\r\n/")
+	return trimmed + " " + value + attrs[len(trimmed):]
+}
+
+// atRuleEnd @import等以分号结束，@media等以配对的大括号结束
+func atRuleEnd(css string) int {
+	semi, open := indexTop(css, ';'), indexTop(css, '{')
+	if semi >= 0 && (open < 0 || semi < open) {
+		return semi + 1
+	}
+	if open < 0 {
+		return -1
+	}
+	depth := 0
+	for i := open; i < len(css); i++ {
+		switch css[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// indexTop 返回b第一次出现的位置，跳过引号和括号中的内容，例如url("data:image/png;base64,...")
+func indexTop(s string, b byte) int {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == b && depth == 0:
+			return i
+		}
+	}
+	return -1
+}
+
+func splitTop(s string, sep byte) []string {
+	var out []string
+	for {
+		i := indexTop(s, sep)
+		if i < 0 {
+			return append(out, s)
+		}
+		out = append(out, s[:i])
+		s = s[i+1:]
+	}
+}
+
+func cutSuffixFold(s, suffix string) (string, bool) {
+	if len(s) >= len(suffix) && strings.EqualFold(s[len(s)-len(suffix):], suffix) {
+		return s[:len(s)-len(suffix)], true
+	}
+	return s, false
+}
+
+func firstWord(s string) string {
+	if i := strings.IndexAny(s, " \t\r\n{;"); i > 0 {
+		return s[:i]
+	}
+	return s
+}
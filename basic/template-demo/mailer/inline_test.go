@@ -0,0 +1,84 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInlineCSS(t *testing.T) {
+	doc := `<html><head><style type="text/css">
+/* 注释 */
+p { color: red; margin: 0 }
+.note { color: blue }
+p.note, #main { font-size: 12px }
+#main { color: black !important }
+td { background: url("data:image/png;base64,AAA;B}") }
+a:hover, div p { color: green }
+@media (max-width: 600px) { p { margin: 4px } }
+</style>
+<style media="print">p { display: none }</style></head>
+<body>
+<p>a</p>
+<p class="note extra" style="margin: 8px">b</p>
+<P id="main" style="color: gray" />
+<td>c</td>
+<!-- <p>注释中的标签</p> -->
+<div title="a &gt; b" data-x='1'>d</div>
+</body></html>`
+
+	got, err := InlineCSS(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<p style=\"color: red; margin: 0\">a</p>",
+		// 类选择器覆盖标签选择器，元素原有的style覆盖规则
+		`<p class="note extra" style="color: blue; margin: 8px; font-size: 12px">b</p>`,
+		// !important覆盖原有的style，自闭合标签保留/
+		`<P id="main" style="color: black; margin: 0; font-size: 12px" />`,
+		`<td style="background: url(&#34;data:image/png;base64,AAA;B}&#34;)">c</td>`,
+		"<!-- <p>注释中的标签</p> -->",
+		`<div title="a &gt; b" data-x='1'>d</div>`,
+		// 不能内联的规则保留在第一个<style>的位置，带media属性的<style>原样保留
+		"<head><style>\na:hover { color: green }\ndiv p { color: green }\n@media (max-width: 600px) { p { margin: 4px } }\n</style>\n<style media=\"print\">p { display: none }</style></head>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestInlineCSSNoStyle(t *testing.T) {
+	doc := `<p class="x">没有样式</p>`
+	if got, err := InlineCSS(doc); err != nil || got != doc {
+		t.Fatalf("InlineCSS = %q, %v", got, err)
+	}
+	got, err := InlineCSS(`<style>p { color: red }</style><p>a</p>`)
+	if err != nil || got != `<p style="color: red">a</p>` {
+		t.Fatalf("InlineCSS = %q, %v", got, err)
+	}
+}
+
+func TestInlineCSSErrors(t *testing.T) {
+	for _, css := range []string{"p { color: red", "p color: red }", "@media screen { p { color: red }"} {
+		if _, err := InlineCSS("<style>" + css + "</style>"); err == nil {
+			t.Errorf("InlineCSS(%q) succeeded", css)
+		}
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	for sel, want := range map[string]int{
+		"p": 1, "*": 0, ".a": 100, "td.a.b": 201, "#x": 10000, "div#x.a": 10101,
+	} {
+		r, ok := parseSelector(sel)
+		if !ok || r.specificity != want {
+			t.Errorf("parseSelector(%s) = %d, %v; want %d", sel, r.specificity, ok, want)
+		}
+	}
+	for _, sel := range []string{"", "div p", "a:hover", "p > a", "#a#b", "[href]"} {
+		if _, ok := parseSelector(sel); ok {
+			t.Errorf("parseSelector(%q) accepted", sel)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+// Package mailer 用成对的HTML和纯文本模板生成多语言邮件。
+//
+// 模板目录中每种语言一个子目录，目录名是funcs.LookupLocale认识的标签，每个子目录是一个registry：
+//
+//	zh-CN/layouts/base.html
+//	zh-CN/order_confirmation.html
+//	zh-CN/order_confirmation.txt
+//	en-US/...
+//
+// 每封邮件由同名的.html和.txt两个模板组成，缺少任何一个都是错误。.txt模板第一行是"Subject: 主题"，
+// 然后是一个空行，后面是正文。模板中可以使用funcs函数库，数字、货币和日期按该语言格式化。
+//
+// Compose渲染模板、把HTML中<style>的规则内联到style属性中，返回Message；
+// Message.WriteTo输出multipart MIME格式的邮件（.eml），也可以交给Sender发送。
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/mail"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"template-demo/funcs"
+	"template-demo/registry"
+)
+
+const (
+	htmlExt = ".html"
+	textExt = ".txt"
+)
+
+// Composer 邮件生成器，可以并发使用
+type Composer struct {
+	from     *mail.Address
+	fallback string
+	now      func() time.Time
+	funcs    map[string]any
+
+	locales map[string]*registry.Registry
+	pages   map[string]map[string]bool // 语言 -> 邮件模板名
+	tags    []string
+}
+
+// Option 邮件生成器选项
+type Option func(*Composer) error
+
+// WithFrom 发件人，例如"商城 <noreply@shop.example>"
+func WithFrom(from string) Option {
+	return func(c *Composer) error {
+		addr, err := mail.ParseAddress(from)
+		if err != nil {
+			return fmt.Errorf("mailer: from %q: %w", from, err)
+		}
+		c.from = addr
+		return nil
+	}
+}
+
+// WithFallback 找不到请求的语言或者该语言没有这个模板时使用的语言，默认zh-CN
+func WithFallback(tag string) Option {
+	return func(c *Composer) error {
+		c.fallback = tag
+		return nil
+	}
+}
+
+// WithClock 设置Date头和模板函数now、timeAgo使用的时钟，默认time.Now
+func WithClock(now func() time.Time) Option {
+	return func(c *Composer) error {
+		c.now = now
+		return nil
+	}
+}
+
+// WithFuncs 在funcs函数库之外注册模板函数，同名时覆盖函数库中的函数
+func WithFuncs(fm map[string]any) Option {
+	return func(c *Composer) error {
+		for name, fn := range fm {
+			c.funcs[name] = fn
+		}
+		return nil
+	}
+}
+
+// New 加载fsys中所有语言的模板
+func New(fsys fs.FS, opts ...Option) (*Composer, error) {
+	c := &Composer{
+		fallback: funcs.ZhCN.Tag,
+		now:      time.Now,
+		funcs:    make(map[string]any),
+		locales:  make(map[string]*registry.Registry),
+		pages:    make(map[string]map[string]bool),
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if err := c.load(fsys, e.Name()); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := c.locales[c.fallback]; !ok {
+		return nil, fmt.Errorf("mailer: no templates for fallback locale %s", c.fallback)
+	}
+	return c, nil
+}
+
+// load 加载一种语言，检查每个邮件模板都有.html和.txt
+func (c *Composer) load(fsys fs.FS, tag string) error {
+	loc, ok := funcs.LookupLocale(tag)
+	if !ok {
+		return fmt.Errorf("mailer: unknown locale directory %s", tag)
+	}
+	sub, err := fs.Sub(fsys, tag)
+	if err != nil {
+		return err
+	}
+	fm := funcs.New(funcs.WithLocale(loc), funcs.WithClock(c.now))
+	for name, fn := range c.funcs {
+		fm[name] = fn
+	}
+	reg, err := registry.New(sub, registry.WithFuncs(fm))
+	if err != nil {
+		return fmt.Errorf("mailer: %s: %w", tag, err)
+	}
+
+	exts := make(map[string][]string)
+	for _, name := range reg.Names() {
+		ext := path.Ext(name)
+		if ext != htmlExt && ext != textExt {
+			continue
+		}
+		base := strings.TrimSuffix(name, ext)
+		exts[base] = append(exts[base], ext)
+	}
+	pages := make(map[string]bool, len(exts))
+	for base, found := range exts {
+		if len(found) != 2 {
+			return fmt.Errorf("mailer: %s/%s: need both %s and %s templates", tag, base, htmlExt, textExt)
+		}
+		pages[base] = true
+	}
+
+	c.locales[tag] = reg
+	c.pages[tag] = pages
+	c.tags = append(c.tags, tag)
+	sort.Strings(c.tags)
+	return nil
+}
+
+// Locales 返回所有语言
+func (c *Composer) Locales() []string {
+	return append([]string(nil), c.tags...)
+}
+
+// Email 要生成的邮件
+type Email struct {
+	Template    string   // 模板名，不带扩展名，例如"order_confirmation"
+	Locale      string   // 例如"en-US"；没有这个地区时使用同一语言的其他地区，例如"en"、"en-GB"都匹配"en-US"
+	To, Cc, Bcc []string // 收件人，例如"张三 <zhangsan@example.com>"
+	Data        any
+	Attachments []Attachment
+}
+
+// Compose 渲染邮件，Message.Locale是实际使用的语言
+func (c *Composer) Compose(e Email) (*Message, error) {
+	if c.from == nil {
+		return nil, fmt.Errorf("mailer: no From address, use WithFrom")
+	}
+	tag, ok := c.resolve(e.Locale, e.Template)
+	if !ok {
+		return nil, fmt.Errorf("mailer: %s: %w", e.Template, registry.ErrNotFound)
+	}
+
+	msg := &Message{
+		From:        *c.from,
+		Locale:      tag,
+		Date:        c.now(),
+		MessageID:   newMessageID(c.from.Address),
+		Attachments: e.Attachments,
+	}
+	var err error
+	if msg.To, err = parseAddresses("to", e.To); err != nil {
+		return nil, err
+	}
+	if msg.Cc, err = parseAddresses("cc", e.Cc); err != nil {
+		return nil, err
+	}
+	if msg.Bcc, err = parseAddresses("bcc", e.Bcc); err != nil {
+		return nil, err
+	}
+	if len(msg.Recipients()) == 0 {
+		return nil, fmt.Errorf("mailer: %s: no recipients", e.Template)
+	}
+
+	reg := c.locales[tag]
+	text, err := reg.RenderString(e.Template+textExt, e.Data)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Subject, msg.Text, err = splitSubject(text); err != nil {
+		return nil, fmt.Errorf("mailer: %s/%s%s: %w", tag, e.Template, textExt, err)
+	}
+	html, err := reg.RenderString(e.Template+htmlExt, e.Data)
+	if err != nil {
+		return nil, err
+	}
+	if msg.HTML, err = InlineCSS(html); err != nil {
+		return nil, fmt.Errorf("mailer: %s/%s%s: %w", tag, e.Template, htmlExt, err)
+	}
+	return msg, nil
+}
+
+// resolve 依次尝试完全匹配（不区分大小写）、同一语言的其他地区和fallback，返回第一个有这个模板的语言
+func (c *Composer) resolve(locale, template string) (string, bool) {
+	var candidates []string
+	lang, _, _ := strings.Cut(locale, "-")
+	for _, tag := range c.tags {
+		if strings.EqualFold(tag, locale) {
+			candidates = append(candidates, tag)
+		}
+	}
+	for _, tag := range c.tags {
+		if l, _, _ := strings.Cut(tag, "-"); lang != "" && strings.EqualFold(l, lang) {
+			candidates = append(candidates, tag)
+		}
+	}
+	candidates = append(candidates, c.fallback)
+	for _, tag := range candidates {
+		if c.pages[tag][template] {
+			return tag, true
+		}
+	}
+	return "", false
+}
+
+// splitSubject 拆分.txt模板的输出："Subject: 主题"、空行、正文
+func splitSubject(s string) (subject, body string, err error) {
+	first, rest, _ := strings.Cut(s, "\n")
+	subject, ok := strings.CutPrefix(strings.TrimSuffix(first, "\r"), "Subject:")
+	if !ok {
+		return "", "", fmt.Errorf(`first line must be "Subject: ..."`)
+	}
+	if subject = strings.TrimSpace(subject); subject == "" {
+		return "", "", fmt.Errorf("empty subject")
+	}
+	blank, body, _ := strings.Cut(rest, "\n")
+	if strings.TrimSuffix(blank, "\r") != "" {
+		return "", "", fmt.Errorf("subject must be followed by a blank line")
+	}
+	return subject, body, nil
+}
+
+func parseAddresses(field string, list []string) ([]mail.Address, error) {
+	var out []mail.Address
+	for _, s := range list {
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return nil, fmt.Errorf("mailer: %s %q: %w", field, s, err)
+		}
+		out = append(out, *addr)
+	}
+	return out, nil
+}
+
+// newMessageID <随机数@发件人的域名>
+func newMessageID(from string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	_, domain, _ := strings.Cut(from, "@")
+	if domain == "" {
+		domain = "localhost"
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
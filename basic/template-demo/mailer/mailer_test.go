@@ -0,0 +1,184 @@
+package mailer
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"template-demo/registry"
+)
+
+type item struct {
+	Name  string
+	Price float64
+}
+
+type order struct {
+	ID    string
+	Name  string
+	Items []item
+	Total float64
+	Date  time.Time
+}
+
+var (
+	testDate  = time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC)
+	testOrder = order{
+		ID: "ORD-1", Name: "张三", Total: 1234.5, Date: testDate,
+		Items: []item{{"手机", 1200}, {"手机壳", 34.5}},
+	}
+)
+
+func newComposer(t *testing.T, opts ...Option) *Composer {
+	t.Helper()
+	opts = append([]Option{
+		WithFrom("商城 <noreply@shop.example>"),
+		WithClock(func() time.Time { return testDate }),
+	}, opts...)
+	c, err := New(os.DirFS("testdata"), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestComposeLocales(t *testing.T) {
+	c := newComposer(t)
+	if got := strings.Join(c.Locales(), ","); got != "en-US,zh-CN" {
+		t.Fatalf("Locales = %s", got)
+	}
+
+	for _, tc := range []struct {
+		locale, want, subject string
+		text                  []string
+	}{
+		{"zh-CN", "zh-CN", "订单确认 - ORD-1", []string{"张三，您好：", "- 手机  ¥1,200.00", "合计：¥1,234.50", "下单时间：2024年03月05日"}},
+		{"en-US", "en-US", "Order confirmation - ORD-1", []string{"Hi 张三,", "- 手机  $1,200.00", "Total: $1,234.50", "Ordered on Mar 5, 2024"}},
+		{"en-gb", "en-US", "Order confirmation - ORD-1", nil},
+		{"EN", "en-US", "Order confirmation - ORD-1", nil},
+		{"fr-FR", "zh-CN", "订单确认 - ORD-1", nil},
+		{"", "zh-CN", "订单确认 - ORD-1", nil},
+	} {
+		msg, err := c.Compose(Email{Template: "order", Locale: tc.locale, To: []string{"zhangsan@example.com"}, Data: testOrder})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.locale, err)
+		}
+		if msg.Locale != tc.want || msg.Subject != tc.subject {
+			t.Errorf("%s: locale %s, subject %q", tc.locale, msg.Locale, msg.Subject)
+		}
+		for _, want := range tc.text {
+			if !strings.Contains(msg.Text, want) {
+				t.Errorf("%s: text missing %q:\n%s", tc.locale, want, msg.Text)
+			}
+		}
+	}
+}
+
+func TestComposeFallbackPerTemplate(t *testing.T) {
+	c := newComposer(t)
+	// en-US没有welcome模板，使用zh-CN
+	msg, err := c.Compose(Email{Template: "welcome", Locale: "en-US", To: []string{"a@example.com"}, Data: "Alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Locale != "zh-CN" || msg.Subject != "欢迎加入" || msg.Text != "欢迎，Alice！\n" {
+		t.Fatalf("got %s %q %q", msg.Locale, msg.Subject, msg.Text)
+	}
+
+	c = newComposer(t, WithFallback("en-US"))
+	if _, err := c.Compose(Email{Template: "welcome", Locale: "en-US", To: []string{"a@example.com"}}); !errors.Is(err, registry.ErrNotFound) {
+		t.Fatalf("missing template error = %v", err)
+	}
+}
+
+func TestComposeMessage(t *testing.T) {
+	c := newComposer(t)
+	msg, err := c.Compose(Email{
+		Template: "order",
+		To:       []string{"张三 <zhangsan@example.com>"},
+		Cc:       []string{"sales@shop.example"},
+		Bcc:      []string{"audit@shop.example"},
+		Data:     testOrder,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.From.Name != "商城" || msg.From.Address != "noreply@shop.example" {
+		t.Errorf("From = %v", msg.From)
+	}
+	if !msg.Date.Equal(testDate) {
+		t.Errorf("Date = %v", msg.Date)
+	}
+	if !strings.HasSuffix(msg.MessageID, "@shop.example>") {
+		t.Errorf("MessageID = %s", msg.MessageID)
+	}
+	if got := strings.Join(msg.Recipients(), ","); got != "zhangsan@example.com,sales@shop.example,audit@shop.example" {
+		t.Errorf("Recipients = %s", got)
+	}
+
+	// CSS已经内联，不能内联的:hover保留在<style>中
+	for _, want := range []string{
+		`<body style="font-family: sans-serif; color: #333">`,
+		`<td class="price" style="font-weight: bold">¥1,200.00</td>`,
+		`<p class="total" style="font-weight: bold; color: green">合计：¥1,234.50</p>`,
+		"<style>\na:hover { color: red }\n</style>",
+	} {
+		if !strings.Contains(msg.HTML, want) {
+			t.Errorf("HTML missing %q:\n%s", want, msg.HTML)
+		}
+	}
+}
+
+func TestComposeErrors(t *testing.T) {
+	c := newComposer(t)
+	for name, e := range map[string]Email{
+		"no recipients": {Template: "order", Data: testOrder},
+		"bad address":   {Template: "order", To: []string{"not an address"}, Data: testOrder},
+		"render error":  {Template: "order", To: []string{"a@example.com"}, Data: "not an order"},
+	} {
+		if _, err := c.Compose(e); err == nil {
+			t.Errorf("%s: Compose succeeded", name)
+		}
+	}
+
+	noFrom, err := New(os.DirFS("testdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := noFrom.Compose(Email{Template: "order", To: []string{"a@example.com"}, Data: testOrder}); err == nil {
+		t.Error("Compose without From succeeded")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	page := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+	for name, fsys := range map[string]fstest.MapFS{
+		"unknown locale": {"xx-XX/a.txt": page("Subject: a\n\n"), "xx-XX/a.html": page("a")},
+		"missing html":   {"zh-CN/a.txt": page("Subject: a\n\n")},
+		"missing text":   {"zh-CN/a.html": page("a")},
+		"parse error":    {"zh-CN/a.txt": page("{{"), "zh-CN/a.html": page("a")},
+		"no fallback":    {"en-US/a.txt": page("Subject: a\n\n"), "en-US/a.html": page("a")},
+	} {
+		if _, err := New(fsys, WithFrom("a@example.com")); err == nil {
+			t.Errorf("%s: New succeeded", name)
+		}
+	}
+	if _, err := New(fstest.MapFS{}, WithFrom("not an address")); err == nil {
+		t.Error("bad From accepted")
+	}
+}
+
+func TestSplitSubject(t *testing.T) {
+	subject, body, err := splitSubject("Subject:  你好 \r\n\r\n正文\n")
+	if err != nil || subject != "你好" || body != "正文\n" {
+		t.Fatalf("splitSubject = %q, %q, %v", subject, body, err)
+	}
+	for _, s := range []string{"正文", "Subject: \n\n", "Subject: a\nb\n", "subject: a\n\n"} {
+		if _, _, err := splitSubject(s); err == nil {
+			t.Errorf("splitSubject(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Package mailtest 本地SMTP服务器，用于测试发送邮件的代码，类似net/http/httptest。
+//
+//	srv := mailtest.NewServer()
+//	defer srv.Close()
+//	sender := &mailer.SMTPSender{Addr: srv.Addr}
+//	...
+//	msgs := srv.Messages()
+//
+// 只实现发送一封邮件需要的命令：EHLO/HELO、AUTH PLAIN、MAIL、RCPT、DATA、RSET、NOOP、QUIT，
+// 不支持STARTTLS，任何用户名和密码都能通过认证。
+package mailtest
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Message 服务器收到的一封邮件
+type Message struct {
+	Username string   // AUTH PLAIN的用户名，没有认证时为空
+	From     string   // MAIL FROM的地址
+	To       []string // RCPT TO的地址
+	Data     []byte   // DATA的内容，换行是CRLF，已经去掉了行首用于转义的"."
+}
+
+// Server 本地SMTP服务器
+type Server struct {
+	Addr string // 监听地址，例如127.0.0.1:54321
+
+	ln       net.Listener
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	messages []Message
+	reject   map[string]bool
+	conns    map[net.Conn]bool
+}
+
+// NewServer 在127.0.0.1的随机端口启动服务器，失败时panic
+func NewServer() *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("mailtest: failed to listen: %v", err))
+	}
+	s := &Server{
+		Addr:   ln.Addr().String(),
+		ln:     ln,
+		reject: make(map[string]bool),
+		conns:  make(map[net.Conn]bool),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Close 停止服务器，等待所有连接结束
+func (s *Server) Close() {
+	s.ln.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// Reject RCPT TO这个地址时返回550，用来测试发送失败
+func (s *Server) Reject(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reject[strings.ToLower(addr)] = true
+}
+
+// Messages 返回收到的所有邮件，按收到的顺序
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// session 一个连接的状态，RSET和每封邮件发送完后清空
+type session struct {
+	username string
+	msg      *Message
+}
+
+func (s *Server) handle(conn net.Conn) {
+	tp := textproto.NewConn(conn)
+	reply := func(format string, args ...any) bool {
+		return tp.PrintfLine(format, args...) == nil
+	}
+	if !reply("220 mailtest ESMTP ready") {
+		return
+	}
+
+	var sess session
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			sess = session{}
+			if !reply("250-mailtest\r\n250-8BITMIME\r\n250 AUTH PLAIN") {
+				return
+			}
+		case "HELO":
+			sess = session{}
+			reply("250 mailtest")
+		case "AUTH":
+			username, ok := plainAuth(arg)
+			if !ok {
+				reply("501 only AUTH PLAIN with an initial response is supported")
+				continue
+			}
+			sess.username = username
+			reply("235 2.7.0 authentication successful")
+		case "MAIL":
+			addr, ok := path(arg, "FROM:")
+			if !ok {
+				reply("501 syntax: MAIL FROM:<address>")
+				continue
+			}
+			sess.msg = &Message{Username: sess.username, From: addr}
+			reply("250 OK")
+		case "RCPT":
+			addr, ok := path(arg, "TO:")
+			switch {
+			case !ok:
+				reply("501 syntax: RCPT TO:<address>")
+			case sess.msg == nil:
+				reply("503 need MAIL first")
+			case s.rejected(addr):
+				reply("550 5.1.1 mailbox unavailable: %s", addr)
+			default:
+				sess.msg.To = append(sess.msg.To, addr)
+				reply("250 OK")
+			}
+		case "DATA":
+			if sess.msg == nil || len(sess.msg.To) == 0 {
+				reply("503 need MAIL and RCPT first")
+				continue
+			}
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			data, err := readData(tp.Reader.R)
+			if err != nil {
+				return
+			}
+			sess.msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, *sess.msg)
+			s.mu.Unlock()
+			sess.msg = nil
+			reply("250 OK: queued")
+		case "RSET":
+			sess.msg = nil
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+func (s *Server) rejected(addr string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reject[strings.ToLower(addr)]
+}
+
+// path 解析"FROM:<a@b.c> SIZE=123"，返回a@b.c
+func path(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	rest := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(rest, "<") {
+		return "", false
+	}
+	addr, _, ok := strings.Cut(rest[1:], ">")
+	return addr, ok
+}
+
+// plainAuth 解析"PLAIN base64(authzid\0username\0password)"
+func plainAuth(arg string) (string, bool) {
+	mech, resp, _ := strings.Cut(arg, " ")
+	if !strings.EqualFold(mech, "PLAIN") || resp == "" {
+		return "", false
+	}
+	b, err := base64.StdEncoding.DecodeString(resp)
+	if err != nil {
+		return "", false
+	}
+	parts := strings.Split(string(b), "\x00")
+	if len(parts) != 3 {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// readData 读到单独一行"."为止，保留CRLF，去掉行首转义用的"."
+func readData(r *bufio.Reader) ([]byte, error) {
+	var out []byte
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if line == ".\r\n" || line == ".\n" {
+			return out, nil
+		}
+		line = strings.TrimPrefix(line, ".")
+		out = append(out, line...)
+	}
+}
@@ -0,0 +1,64 @@
+package mailtest
+
+import (
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestServerProtocol(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	c, err := textproto.Dial("tcp", srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, _, err := c.ReadResponse(220); err != nil {
+		t.Fatal(err)
+	}
+	cmd := func(code int, format string, args ...any) {
+		t.Helper()
+		id, err := c.Cmd(format, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.StartResponse(id)
+		defer c.EndResponse(id)
+		if _, msg, err := c.ReadResponse(code); err != nil {
+			t.Fatalf("%s: %v %s", format, err, msg)
+		}
+	}
+
+	cmd(250, "HELO test")
+	cmd(503, "RCPT TO:<a@example.com>")
+	cmd(503, "DATA")
+	cmd(501, "MAIL FROM:a@example.com")
+	cmd(501, "AUTH LOGIN")
+	cmd(502, "VRFY a@example.com")
+	cmd(250, "NOOP")
+
+	// RSET丢弃正在发送的邮件
+	cmd(250, "MAIL FROM:<a@example.com>")
+	cmd(250, "RSET")
+	cmd(503, "RCPT TO:<b@example.com>")
+
+	cmd(250, "MAIL FROM:<a@example.com> SIZE=10")
+	cmd(250, "rcpt to:<B@example.com>")
+	cmd(354, "DATA")
+	cmd(250, "Subject: hi\r\n\r\n..dot\r\nbody\r\n.")
+	cmd(221, "QUIT")
+
+	msgs := srv.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages", len(msgs))
+	}
+	m := msgs[0]
+	if m.From != "a@example.com" || strings.Join(m.To, ",") != "B@example.com" || m.Username != "" {
+		t.Errorf("got %+v", m)
+	}
+	if string(m.Data) != "Subject: hi\r\n\r\n.dot\r\nbody\r\n" {
+		t.Errorf("data = %q", m.Data)
+	}
+}
@@ -0,0 +1,281 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path"
+	"strings"
+	"time"
+)
+
+// Attachment 附件
+type Attachment struct {
+	Filename    string
+	ContentType string // 为空时按扩展名推断，推断不出来时是application/octet-stream
+	Data        []byte
+}
+
+// Message 一封完整的邮件
+type Message struct {
+	From        mail.Address
+	To, Cc, Bcc []mail.Address // Bcc只用于发送，不写入邮件头
+	Subject     string
+	Locale      string // 写入Content-Language
+	Date        time.Time
+	MessageID   string // 例如<id@shop.example>
+	Text, HTML  string
+	Attachments []Attachment
+}
+
+// Recipients 所有收件人的地址，包括Bcc，用于SMTP的RCPT TO
+func (m *Message) Recipients() []string {
+	var out []string
+	for _, list := range [][]mail.Address{m.To, m.Cc, m.Bcc} {
+		for _, a := range list {
+			out = append(out, a.Address)
+		}
+	}
+	return out
+}
+
+// WriteTo 输出RFC 5322格式的邮件，也就是.eml文件的内容，换行是CRLF
+func (m *Message) WriteTo(w io.Writer) (int64, error) {
+	data, err := m.Bytes()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Bytes 返回WriteTo输出的内容
+//
+// 结构：只有纯文本或者HTML时是单个text/*；两者都有时是multipart/alternative，HTML在后面（客户端优先显示）；
+// 有附件时外面再包一层multipart/mixed。文本用quoted-printable编码，附件用base64编码。
+// 分隔符由邮件内容计算，同一封邮件每次输出的内容相同。
+func (m *Message) Bytes() ([]byte, error) {
+	if m.From.Address == "" {
+		return nil, errors.New("mailer: message has no From address")
+	}
+	if err := m.checkHeaders(); err != nil {
+		return nil, err
+	}
+	body, err := m.body()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", m.From.String())
+	if len(m.To) > 0 {
+		writeHeader(&buf, "To", addressList(m.To))
+	}
+	if len(m.Cc) > 0 {
+		writeHeader(&buf, "Cc", addressList(m.Cc))
+	}
+	writeHeader(&buf, "Subject", encodeWords(m.Subject))
+	if !m.Date.IsZero() {
+		writeHeader(&buf, "Date", m.Date.Format(time.RFC1123Z))
+	}
+	if m.MessageID != "" {
+		writeHeader(&buf, "Message-ID", m.MessageID)
+	}
+	if m.Locale != "" {
+		writeHeader(&buf, "Content-Language", m.Locale)
+	}
+	writeHeader(&buf, "MIME-Version", "1.0")
+	for _, kv := range body.fields() {
+		writeHeader(&buf, kv[0], kv[1])
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body.data)
+	return buf.Bytes(), nil
+}
+
+// checkHeaders 写入邮件头的值（包括只用于发送的Bcc和附件的文件名）都不能含有CR、LF，
+// Message可以由调用方直接构造，这些值可能来自用户输入，换行会注入新的邮件头
+func (m *Message) checkHeaders() error {
+	fields := [][2]string{
+		{"From", m.From.Name}, {"From", m.From.Address},
+		{"Subject", m.Subject},
+		{"Message-ID", m.MessageID},
+		{"Content-Language", m.Locale},
+	}
+	for _, list := range []struct {
+		key   string
+		addrs []mail.Address
+	}{{"To", m.To}, {"Cc", m.Cc}, {"Bcc", m.Bcc}} {
+		for _, a := range list.addrs {
+			fields = append(fields, [2]string{list.key, a.Name}, [2]string{list.key, a.Address})
+		}
+	}
+	for _, a := range m.Attachments {
+		fields = append(fields, [2]string{"attachment filename", a.Filename}, [2]string{"attachment content type", a.ContentType})
+	}
+	for _, kv := range fields {
+		if strings.ContainsAny(kv[1], "\r\n") {
+			return fmt.Errorf("mailer: %s contains a line break: %q", kv[0], kv[1])
+		}
+	}
+	return nil
+}
+
+func (m *Message) body() (entity, error) {
+	var alternatives []entity
+	if m.Text != "" {
+		alternatives = append(alternatives, textEntity("plain", m.Text))
+	}
+	if m.HTML != "" {
+		alternatives = append(alternatives, textEntity("html", m.HTML))
+	}
+	if len(alternatives) == 0 {
+		return entity{}, errors.New("mailer: message has no body")
+	}
+
+	seed := m.boundarySeed()
+	body := alternatives[0]
+	if len(alternatives) > 1 {
+		var err error
+		if body, err = multipartEntity("alternative", "=_alt_"+seed, alternatives); err != nil {
+			return entity{}, err
+		}
+	}
+	if len(m.Attachments) == 0 {
+		return body, nil
+	}
+
+	parts := []entity{body}
+	for _, a := range m.Attachments {
+		part, err := attachmentEntity(a)
+		if err != nil {
+			return entity{}, err
+		}
+		parts = append(parts, part)
+	}
+	return multipartEntity("mixed", "=_mixed_"+seed, parts)
+}
+
+// boundarySeed 分隔符以"=_"开头，quoted-printable和base64的输出中都不会出现
+func (m *Message) boundarySeed() string {
+	h := sha256.New()
+	for _, s := range []string{m.MessageID, m.Subject, m.Text, m.HTML} {
+		io.WriteString(h, s)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:24]
+}
+
+// entity 一个MIME实体：Content-*头和编码后的内容
+type entity struct {
+	contentType string
+	encoding    string
+	disposition string
+	data        []byte
+}
+
+func (e entity) fields() [][2]string {
+	out := [][2]string{{"Content-Type", e.contentType}}
+	if e.encoding != "" {
+		out = append(out, [2]string{"Content-Transfer-Encoding", e.encoding})
+	}
+	if e.disposition != "" {
+		out = append(out, [2]string{"Content-Disposition", e.disposition})
+	}
+	return out
+}
+
+func textEntity(subtype, s string) entity {
+	var buf bytes.Buffer
+	qp := quotedprintable.NewWriter(&buf)
+	io.WriteString(qp, s)
+	qp.Close()
+	return entity{
+		contentType: mime.FormatMediaType("text/"+subtype, map[string]string{"charset": "UTF-8"}),
+		encoding:    "quoted-printable",
+		data:        buf.Bytes(),
+	}
+}
+
+func attachmentEntity(a Attachment) (entity, error) {
+	if a.Filename == "" {
+		return entity{}, errors.New("mailer: attachment has no filename")
+	}
+	ct := a.ContentType
+	if ct == "" {
+		if ct = mime.TypeByExtension(path.Ext(a.Filename)); ct == "" {
+			ct = "application/octet-stream"
+		}
+	}
+	mediaType, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return entity{}, fmt.Errorf("mailer: attachment %s: %w", a.Filename, err)
+	}
+
+	var buf bytes.Buffer
+	encoded := base64.StdEncoding.EncodeToString(a.Data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return entity{
+		contentType: mime.FormatMediaType(mediaType, params),
+		encoding:    "base64",
+		disposition: mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}),
+		data:        buf.Bytes(),
+	}, nil
+}
+
+func multipartEntity(subtype, boundary string, parts []entity) (entity, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return entity{}, err
+	}
+	for _, p := range parts {
+		h := make(textproto.MIMEHeader)
+		for _, kv := range p.fields() {
+			h.Set(kv[0], kv[1])
+		}
+		w, err := mw.CreatePart(h)
+		if err != nil {
+			return entity{}, err
+		}
+		w.Write(p.data)
+	}
+	if err := mw.Close(); err != nil {
+		return entity{}, err
+	}
+	return entity{
+		contentType: mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": boundary}),
+		data:        buf.Bytes(),
+	}, nil
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key + ": " + value + "\r\n")
+}
+
+// addressList 每个地址一行，非ASCII的名字由mail.Address编码
+func addressList(list []mail.Address) string {
+	parts := make([]string, len(list))
+	for i, a := range list {
+		parts[i] = a.String()
+	}
+	return strings.Join(parts, ",\r\n ")
+}
+
+// encodeWords 非ASCII或者含有控制字符时编码成RFC 2047的encoded-word，每个encoded-word一行
+func encodeWords(s string) string {
+	return strings.ReplaceAll(mime.BEncoding.Encode("UTF-8", s), "?= =?", "?=\r\n =?")
+}
@@ -0,0 +1,224 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMessage() *Message {
+	return &Message{
+		From:      mail.Address{Name: "商城", Address: "noreply@shop.example"},
+		To:        []mail.Address{{Name: "张三", Address: "zhangsan@example.com"}, {Address: "lisi@example.com"}},
+		Bcc:       []mail.Address{{Address: "audit@shop.example"}},
+		Subject:   "订单确认 - ORD-1",
+		Locale:    "zh-CN",
+		Date:      testDate,
+		MessageID: "<1@shop.example>",
+		Text:      "你好\n" + strings.Repeat("很长的一行", 30) + "\n",
+		HTML:      `<p style="color: red">你好</p>`,
+		Attachments: []Attachment{
+			{Filename: "发票.pdf", Data: bytes.Repeat([]byte{0, 1, 2, 255}, 100)},
+			{Filename: "orders.dat", ContentType: "text/csv; charset=utf-8", Data: []byte("id,total\n1,99\n")},
+		},
+	}
+}
+
+// part 解析出来的叶子实体
+type part struct {
+	contentType string
+	filename    string
+	body        string
+}
+
+// parseMessage 用标准库把WriteTo的输出读回来
+func parseMessage(t *testing.T, data []byte) (*mail.Message, []part) {
+	t.Helper()
+	if bytes.Contains(bytes.ReplaceAll(data, []byte("\r\n"), nil), []byte("\n")) {
+		t.Fatal("message contains a bare LF")
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []part
+	var walk func(contentType, encoding, disposition string, r io.Reader)
+	walk = func(contentType, encoding, disposition string, r io.Reader) {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(mediaType, "multipart/") {
+			mr := multipart.NewReader(r, params["boundary"])
+			for {
+				p, err := mr.NextRawPart()
+				if err == io.EOF {
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				walk(p.Header.Get("Content-Type"), p.Header.Get("Content-Transfer-Encoding"), p.Header.Get("Content-Disposition"), p)
+			}
+		}
+		switch encoding {
+		case "quoted-printable":
+			r = quotedprintable.NewReader(r)
+		case "base64":
+			r = base64.NewDecoder(base64.StdEncoding, r)
+		}
+		body, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := part{contentType: contentType, body: string(body)}
+		if disposition != "" {
+			_, dp, err := mime.ParseMediaType(disposition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.filename = dp["filename"]
+		}
+		parts = append(parts, p)
+	}
+	walk(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), "", msg.Body)
+	return msg, parts
+}
+
+func TestMessageWriteTo(t *testing.T) {
+	m := testMessage()
+	var buf bytes.Buffer
+	n, err := m.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("WriteTo = %d, %v", n, err)
+	}
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > 78 {
+			t.Errorf("line longer than 78: %q", line)
+		}
+	}
+
+	msg, parts := parseMessage(t, buf.Bytes())
+	var dec mime.WordDecoder
+	subject, err := dec.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != m.Subject {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+	to, err := msg.Header.AddressList("To")
+	if err != nil || len(to) != 2 || to[0].Name != "张三" || to[1].Address != "lisi@example.com" {
+		t.Errorf("To = %v, %v", to, err)
+	}
+	if date, err := msg.Header.Date(); err != nil || !date.Equal(testDate) {
+		t.Errorf("Date = %v, %v", date, err)
+	}
+	for key, want := range map[string]string{
+		"Message-ID":       "<1@shop.example>",
+		"Content-Language": "zh-CN",
+		"MIME-Version":     "1.0",
+		"Bcc":              "",
+	} {
+		if got := msg.Header.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if !strings.HasPrefix(msg.Header.Get("Content-Type"), "multipart/mixed;") {
+		t.Errorf("Content-Type = %s", msg.Header.Get("Content-Type"))
+	}
+
+	want := []part{
+		{contentType: "text/plain; charset=UTF-8", body: strings.ReplaceAll(m.Text, "\n", "\r\n")},
+		{contentType: "text/html; charset=UTF-8", body: m.HTML},
+		{contentType: "application/pdf", filename: "发票.pdf", body: string(m.Attachments[0].Data)},
+		{contentType: "text/csv; charset=utf-8", filename: "orders.dat", body: string(m.Attachments[1].Data)},
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("got %d parts, want %d", len(parts), len(want))
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("part %d = %+v\nwant %+v", i, parts[i], want[i])
+		}
+	}
+
+	again, _ := m.Bytes()
+	if !bytes.Equal(again, buf.Bytes()) {
+		t.Error("output is not deterministic")
+	}
+}
+
+func TestMessageStructure(t *testing.T) {
+	m := testMessage()
+	m.Attachments = nil
+	data, err := m.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, parts := parseMessage(t, data)
+	if ct := msg.Header.Get("Content-Type"); !strings.HasPrefix(ct, "multipart/alternative;") || len(parts) != 2 {
+		t.Errorf("without attachments: %s, %d parts", ct, len(parts))
+	}
+
+	m.HTML = ""
+	data, _ = m.Bytes()
+	msg, parts = parseMessage(t, data)
+	if ct := msg.Header.Get("Content-Type"); ct != "text/plain; charset=UTF-8" || len(parts) != 1 {
+		t.Errorf("text only: %s, %d parts", ct, len(parts))
+	}
+}
+
+func TestMessageHeaderInjection(t *testing.T) {
+	const injected = "x\r\nBcc: victim@example.com"
+	for name, edit := range map[string]func(*Message){
+		"subject":         func(m *Message) { m.Subject = injected },
+		"from name":       func(m *Message) { m.From.Name = injected },
+		"from address":    func(m *Message) { m.From.Address = "a@example.com\r\nBcc: victim@example.com" },
+		"to name":         func(m *Message) { m.To[0].Name = injected },
+		"cc address":      func(m *Message) { m.Cc = []mail.Address{{Address: "a@example.com\nBcc: b@example.com"}} },
+		"bcc address":     func(m *Message) { m.Bcc[0].Address = "a@example.com\r\nRCPT TO:<b@example.com>" },
+		"message id":      func(m *Message) { m.MessageID = "<1@shop.example>\r\nBcc: victim@example.com" },
+		"locale":          func(m *Message) { m.Locale = "zh-CN\nBcc: victim@example.com" },
+		"filename":        func(m *Message) { m.Attachments[0].Filename = "a.pdf\r\nX-Evil: 1" },
+		"content type":    func(m *Message) { m.Attachments[1].ContentType = "text/csv\r\nX-Evil: 1" },
+		"bare cr in name": func(m *Message) { m.To[1].Name = "x\ry" },
+	} {
+		m := testMessage()
+		edit(m)
+		data, err := m.Bytes()
+		if err == nil || !strings.Contains(err.Error(), "line break") {
+			t.Errorf("%s: Bytes = %q, %v; want line break error", name, data, err)
+		}
+	}
+}
+
+func TestMessageErrors(t *testing.T) {
+	for name, edit := range map[string]func(*Message){
+		"no from":          func(m *Message) { m.From = mail.Address{} },
+		"no body":          func(m *Message) { m.Text, m.HTML = "", "" },
+		"no filename":      func(m *Message) { m.Attachments[0].Filename = "" },
+		"bad content type": func(m *Message) { m.Attachments[0].ContentType = "text/" },
+	} {
+		m := testMessage()
+		edit(m)
+		if _, err := m.Bytes(); err == nil {
+			t.Errorf("%s: Bytes succeeded", name)
+		}
+	}
+
+	m := testMessage()
+	m.Date = time.Time{}
+	m.MessageID = ""
+	data, err := m.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("Date:")) || bytes.Contains(data, []byte("Message-ID:")) {
+		t.Error("empty Date or Message-ID written")
+	}
+}
@@ -0,0 +1,141 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Sender 发送邮件，实现可以是SMTP、第三方API或者测试中的记录器
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SMTPSender 通过SMTP服务器发送，和smtp.SendMail一样服务器支持时使用STARTTLS
+type SMTPSender struct {
+	Addr      string      // host:port
+	Auth      smtp.Auth   // 为nil时不认证
+	TLSConfig *tls.Config // STARTTLS使用的配置，为nil时ServerName是Addr的主机名
+	LocalName string      // EHLO时使用的名字，默认localhost
+}
+
+// Send 发送邮件，ctx取消时关闭连接
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	rcpts := msg.Recipients()
+	if len(rcpts) == 0 {
+		return errors.New("mailer: no recipients")
+	}
+
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return fmt.Errorf("mailer: smtp address %q: %w", s.Addr, err)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("mailer: smtp: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("mailer: smtp: %w", err)
+	}
+	defer c.Close()
+	if err := s.send(c, host, msg.From.Address, rcpts, data); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("mailer: smtp: %w", err)
+	}
+	return nil
+}
+
+func (s *SMTPSender) send(c *smtp.Client, host, from string, rcpts []string, data []byte) error {
+	if s.LocalName != "" {
+		if err := c.Hello(s.LocalName); err != nil {
+			return err
+		}
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		config := s.TLSConfig
+		if config == nil {
+			config = &tls.Config{ServerName: host}
+		}
+		if err := c.StartTLS(config); err != nil {
+			return err
+		}
+	}
+	if s.Auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		if err := c.Auth(s.Auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range rcpts {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("rcpt %s: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// FileSender 把邮件保存成Dir目录中的.eml文件，文件名来自Message-ID，用于开发环境预览
+type FileSender struct {
+	Dir string
+}
+
+// Send 写入文件，同一封邮件重复发送时覆盖
+func (s FileSender) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	name, err := s.Filename(msg)
+	if err != nil {
+		return err
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
+
+// Filename 返回msg保存的路径
+func (s FileSender) Filename(msg *Message) (string, error) {
+	id := strings.Trim(msg.MessageID, "<>")
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("mailer: can't name a file after Message-ID %q", msg.MessageID)
+	}
+	return filepath.Join(s.Dir, id+".eml"), nil
+}
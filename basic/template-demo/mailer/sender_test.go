@@ -0,0 +1,103 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"template-demo/mailer/mailtest"
+)
+
+func TestSMTPSender(t *testing.T) {
+	srv := mailtest.NewServer()
+	defer srv.Close()
+
+	m := testMessage()
+	m.Text = "第一行\n.以点开头的行\n.\n"
+	host, _, _ := strings.Cut(srv.Addr, ":")
+	sender := &SMTPSender{Addr: srv.Addr, Auth: smtp.PlainAuth("", "shop", "secret", host)}
+	if err := sender.Send(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := srv.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("server got %d messages", len(msgs))
+	}
+	got := msgs[0]
+	if got.Username != "shop" || got.From != "noreply@shop.example" {
+		t.Errorf("username %q, from %q", got.Username, got.From)
+	}
+	if strings.Join(got.To, ",") != "zhangsan@example.com,lisi@example.com,audit@shop.example" {
+		t.Errorf("rcpt = %v", got.To)
+	}
+	// 以"."开头的行经过转义后原样到达
+	want, _ := m.Bytes()
+	if !bytes.Equal(got.Data, want) {
+		t.Errorf("data differs:\n%s\nwant:\n%s", got.Data, want)
+	}
+	_, parts := parseMessage(t, got.Data)
+	if parts[0].body != "第一行\r\n.以点开头的行\r\n.\r\n" {
+		t.Errorf("text = %q", parts[0].body)
+	}
+}
+
+func TestSMTPSenderErrors(t *testing.T) {
+	srv := mailtest.NewServer()
+	defer srv.Close()
+	srv.Reject("lisi@example.com")
+
+	sender := &SMTPSender{Addr: srv.Addr}
+	err := sender.Send(context.Background(), testMessage())
+	if err == nil || !strings.Contains(err.Error(), "lisi@example.com") {
+		t.Fatalf("rejected recipient error = %v", err)
+	}
+	if n := len(srv.Messages()); n != 0 {
+		t.Fatalf("server got %d messages", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sender.Send(ctx, testMessage()); err == nil {
+		t.Error("Send with canceled context succeeded")
+	}
+
+	srv.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := sender.Send(ctx, testMessage()); err == nil {
+		t.Error("Send to closed server succeeded")
+	}
+	if err := (&SMTPSender{Addr: "no-port"}).Send(ctx, testMessage()); err == nil {
+		t.Error("bad address accepted")
+	}
+}
+
+func TestFileSender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	sender := FileSender{Dir: dir}
+	m := testMessage()
+	if err := sender.Send(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "1@shop.example.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := m.Bytes()
+	if !bytes.Equal(data, want) {
+		t.Error("file content differs from Message.Bytes")
+	}
+
+	for _, id := range []string{"", "<../x@y>", "<.hidden>"} {
+		m.MessageID = id
+		if err := sender.Send(context.Background(), m); err == nil {
+			t.Errorf("Message-ID %q accepted", id)
+		}
+	}
+}
@@ -1,15 +1,27 @@
 package main
 
 import (
+	"embed"
 	"fmt"
 	htmlTemplate "html/template"
+	"io/fs"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"text/template"
 	"time"
+
+	"template-demo/funcs"
+	"template-demo/registry"
+	"template-demo/tmpllint"
 )
 
+// 编译进二进制的模板目录，生产环境不依赖工作目录中的文件
+//
+//go:embed templates
+var embeddedTemplates embed.FS
+
 // 用户数据结构
 type User struct {
 	Name     string
@@ -71,6 +83,14 @@ func main() {
 	// 示例8: 实际应用场景
 	fmt.Println("\n8. 实际应用场景:")
 	runPracticalExamples()
+
+	// 示例9: 模板注册表
+	fmt.Println("\n9. 模板注册表:")
+	registryExample()
+
+	// 示例10: 模板静态检查
+	fmt.Println("\n10. 模板静态检查:")
+	lintExample()
 }
 
 // 基本模板使用
@@ -108,53 +128,20 @@ func basicTemplateExample() {
 
 // 使用 FuncMap 添加自定义函数
 func funcMapExample() {
-	// 定义自定义函数映射
-	funcMap := template.FuncMap{
-		// 字符串处理函数
-		"upper": strings.ToUpper,
-		"lower": strings.ToLower,
-		"title": strings.Title,
-
-		// 数学函数
-		"add": func(a, b int) int {
-			return a + b
-		},
-		"multiply": func(a, b float64) float64 {
-			return a * b
-		},
-
-		// 格式化函数
-		"formatPrice": func(price float64) string {
-			return fmt.Sprintf("¥%.2f", price)
-		},
-
-		// 时间格式化函数
-		"formatDate": func(t time.Time) string {
-			return t.Format("2006-01-02 15:04:05")
-		},
-
-		// 条件函数
-		"isEven": func(n int) bool {
-			return n%2 == 0
-		},
-
-		// 字符串切片连接
-		"join": func(sep string, items []string) string {
-			return strings.Join(items, sep)
-		},
-	}
+	// 自定义函数来自funcs包中共享的函数库
+	funcMap := funcs.New(funcs.WithLocale(funcs.ZhCN))
 
 	// 定义模板字符串
 	tmplStr := `
 产品信息:
 名称: {{.Name | upper}}
-价格: {{formatPrice .Price}}
+价格: {{.Price | currency}}
 描述: {{.Description | title}}
 库存: {{if .InStock}}有货{{else}}缺货{{end}}
-分类: {{join ", " .Categories}}
-ID是否为偶数: {{isEven .ID}}
+分类: {{.Categories | join ", "}}
+ID是否为偶数: {{eq (mod .ID 2) 0}}
 计算: 2 + 3 = {{add 2 3}}
-价格乘以2: {{multiply .Price 2.0}}
+价格乘以2: {{mul .Price 2 | currency}}
 `
 
 	// 创建带有自定义函数的模板
@@ -183,14 +170,7 @@ ID是否为偶数: {{isEven .ID}}
 
 // 条件和循环示例
 func conditionalAndLoopExample() {
-	funcMap := template.FuncMap{
-		"len": func(items []string) int {
-			return len(items)
-		},
-		"index": func(i int) int {
-			return i + 1
-		},
-	}
+	funcMap := funcs.New()
 
 	tmplStr := `
 用户详情:
@@ -204,7 +184,7 @@ func conditionalAndLoopExample() {
 {{if .Tags -}}
 标签 (共{{len .Tags}}个):
 {{- range $i, $tag := .Tags}}
-  {{index $i}}. {{$tag}}
+  {{add $i 1}}. {{$tag}}
 {{- end}}
 {{- else}}
 暂无标签
@@ -316,13 +296,10 @@ func nestedTemplateExample() {
 // HTML 模板示例
 func htmlTemplateExample() {
 	// 使用 html/template 包，自动转义HTML
-	funcMap := htmlTemplate.FuncMap{
-		"safeHTML": func(s string) htmlTemplate.HTML {
-			return htmlTemplate.HTML(s)
-		},
-		"formatPrice": func(price float64) string {
-			return fmt.Sprintf("¥%.2f", price)
-		},
+	// 共享的函数库之外只加上html/template特有的safeHTML
+	funcMap := htmlTemplate.FuncMap(funcs.New())
+	funcMap["safeHTML"] = func(s string) htmlTemplate.HTML {
+		return htmlTemplate.HTML(s)
 	}
 
 	tmplStr := `<!DOCTYPE html>
@@ -381,100 +358,23 @@ func htmlTemplateExample() {
 	}
 }
 
-// 复杂的 FuncMap 示例
+// 复杂的 FuncMap 示例：使用funcs包中共享的函数库
+// 被处理的值是最后一个参数，可以放在管道中；truncate按字符截断，不会把中文切成两半
 func advancedFuncMapExample() {
-	funcMap := template.FuncMap{
-		// 字符串处理
-		"truncate": func(s string, length int) string {
-			if len(s) <= length {
-				return s
-			}
-			return s[:length] + "..."
-		},
-
-		// 数组/切片操作
-		"first": func(items []string) string {
-			if len(items) > 0 {
-				return items[0]
-			}
-			return ""
-		},
-		"last": func(items []string) string {
-			if len(items) > 0 {
-				return items[len(items)-1]
-			}
-			return ""
-		},
-		"slice": func(items []string, start, end int) []string {
-			if start < 0 || start >= len(items) {
-				return []string{}
-			}
-			if end > len(items) {
-				end = len(items)
-			}
-			return items[start:end]
-		},
-
-		// 条件函数
-		"default": func(defaultValue, value interface{}) interface{} {
-			if value == nil || value == "" {
-				return defaultValue
-			}
-			return value
-		},
-
-		// 数学函数
-		"max": func(a, b int) int {
-			if a > b {
-				return a
-			}
-			return b
-		},
-		"min": func(a, b int) int {
-			if a < b {
-				return a
-			}
-			return b
-		},
-
-		// 时间函数
-		"timeAgo": func(t time.Time) string {
-			duration := time.Since(t)
-			if duration < time.Minute {
-				return "刚刚"
-			} else if duration < time.Hour {
-				return fmt.Sprintf("%d分钟前", int(duration.Minutes()))
-			} else if duration < 24*time.Hour {
-				return fmt.Sprintf("%d小时前", int(duration.Hours()))
-			} else {
-				return fmt.Sprintf("%d天前", int(duration.Hours()/24))
-			}
-		},
-
-		// 格式化函数
-		"percentage": func(value, total float64) string {
-			if total == 0 {
-				return "0%"
-			}
-			return fmt.Sprintf("%.1f%%", (value/total)*100)
-		},
-
-		// 字符串连接函数
-		"join": strings.Join,
-	}
+	funcMap := funcs.New(funcs.WithLocale(funcs.ZhCN))
 
 	tmplStr := `
 高级函数示例:
 
 用户: {{.Name}}
-简介: {{truncate .Profile.Bio 20}}
-网站: {{default "未设置" .Profile.Website}}
+简介: {{.Profile.Bio | truncate 20}}
+网站: {{.Profile.Website | default "未设置"}}
 
 标签信息:
 {{if .Tags -}}
 第一个标签: {{first .Tags}}
 最后一个标签: {{last .Tags}}
-前两个标签: {{(slice .Tags 0 2) | join ", "}}
+前两个标签: {{.Tags | take 2 | join ", "}}
 {{- end}}
 
 时间信息:
@@ -485,7 +385,7 @@ func advancedFuncMapExample() {
 
 统计信息:
 年龄范围: {{min .Age 100}} - {{max .Age 18}}
-活跃度: {{if .IsActive}}{{percentage 85.5 100}}{{else}}{{percentage 12.3 100}}{{end}}
+活跃度: {{if .IsActive}}{{percent 85.5 100}}{{else}}{{percent 12.3 100}}{{end}}
 `
 
 	tmpl, err := template.New("advanced").Funcs(funcMap).Parse(tmplStr)
@@ -583,3 +483,113 @@ func createTemplateFiles() {
 		log.Printf("创建布局模板文件失败: %v", err)
 	}
 }
+
+// 模板注册表示例：生产环境使用嵌入的模板，开发环境从磁盘加载并在修改后自动重新加载
+func registryExample() {
+	templatesFS, err := fs.Sub(embeddedTemplates, "templates")
+	if err != nil {
+		log.Printf("读取嵌入模板失败: %v", err)
+		return
+	}
+	reg, err := registry.New(templatesFS)
+	if err != nil {
+		log.Printf("加载模板失败: %v", err)
+		return
+	}
+	fmt.Printf("已加载页面: %v\n", reg.Names())
+
+	user := User{
+		Name:     "孙七",
+		Email:    "sunqi@example.com",
+		IsActive: true,
+		Tags:     []string{"<前端>", "设计"},
+	}
+	if err := reg.Render(os.Stdout, "users/show.html", user); err != nil {
+		log.Printf("渲染页面失败: %v", err)
+	}
+	if err := reg.Render(os.Stdout, "emails/welcome.txt", user); err != nil {
+		log.Printf("渲染邮件失败: %v", err)
+	}
+
+	// 开发模式：把模板复制到临时目录，修改后下一次Render自动生效
+	dir, err := os.MkdirTemp("", "templates-")
+	if err != nil {
+		log.Printf("创建临时目录失败: %v", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	if err := copyFS(dir, templatesFS); err != nil {
+		log.Printf("复制模板失败: %v", err)
+		return
+	}
+	dev, err := registry.New(os.DirFS(dir), registry.WithDevMode())
+	if err != nil {
+		log.Printf("加载模板失败: %v", err)
+		return
+	}
+	signature := filepath.Join(dir, "partials", "signature.txt")
+	updated := "{{define \"signature\"}}\n--\n用户管理系统团队（签名已在开发模式下修改）\n{{- end}}\n"
+	if err := os.WriteFile(signature, []byte(updated), 0644); err != nil {
+		log.Printf("修改模板失败: %v", err)
+		return
+	}
+	fmt.Println("修改签名后重新渲染:")
+	if err := dev.Render(os.Stdout, "emails/welcome.txt", user); err != nil {
+		log.Printf("渲染邮件失败: %v", err)
+	}
+}
+
+// copyFS 把fsys中的文件复制到dir
+func copyFS(dir string, fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(p))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+}
+
+// 模板静态检查示例：不执行模板，按数据类型检查字段链、函数和未使用的define
+func lintExample() {
+	templatesFS, err := fs.Sub(embeddedTemplates, "templates")
+	if err != nil {
+		log.Printf("读取嵌入模板失败: %v", err)
+		return
+	}
+	linter := tmpllint.New(
+		tmpllint.WithData("users/show.html", User{}),
+		tmpllint.WithData("emails/welcome.txt", User{}),
+	)
+	issues, err := linter.LintFS(templatesFS)
+	if err != nil {
+		log.Printf("检查模板失败: %v", err)
+		return
+	}
+	fmt.Printf("templates目录: %d 个问题\n", len(issues))
+
+	// 字段拼写错误、没有注册的函数、没有使用的define，执行前就能发现
+	page := `{{/* lint:type Page */}}
+<h1>{{.User.Nmae}}</h1>
+<p>{{.User.Profile.Bio | truncate 20}}</p>
+{{range .User.Tags}}<span>{{upper .}}</span>{{end}}
+{{define "sidebar"}}<aside>{{.Title}}</aside>{{end}}`
+	type pageData struct {
+		Title string
+		User  User
+	}
+	linter = tmpllint.New(
+		tmpllint.WithFuncs(template.FuncMap{"upper": strings.ToUpper}),
+		tmpllint.WithType("Page", pageData{}),
+	)
+	for _, issue := range linter.Lint("profile.html", page) {
+		fmt.Println(issue)
+	}
+}
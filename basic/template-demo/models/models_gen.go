@@ -0,0 +1,227 @@
+// Code generated by modelgen from models.yaml; DO NOT EDIT.
+// modelgen:checksum 243a309189503e3fead243e4c8545fd6747556b110db864f50f255cf2fbb18d5
+
+package models
+
+import (
+	"time"
+)
+
+// User 系统用户
+type User struct {
+	ID        int64     `json:"id" db:"id" gorm:"column:id;primaryKey"`                        // 用户ID
+	Name      string    `json:"name" db:"name" gorm:"column:name;size:64;not null"`            // 用户姓名
+	Email     string    `json:"email" db:"email" gorm:"column:email;size:128;not null;unique"` // 邮箱地址
+	Status    string    `json:"status" db:"status" gorm:"column:status;size:16;index;default:active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at" gorm:"column:created_at"` // 创建时间
+}
+
+// TableName User对应的数据库表
+func (User) TableName() string {
+	return "users"
+}
+
+// NewUser 创建User
+func NewUser(id int64, name string, email string, status string, createdAt time.Time) *User {
+	return &User{
+		ID:        id,
+		Name:      name,
+		Email:     email,
+		Status:    status,
+		CreatedAt: createdAt,
+	}
+}
+
+// GetID 返回ID
+func (m *User) GetID() int64 {
+	return m.ID
+}
+
+// SetID 设置ID
+func (m *User) SetID(v int64) {
+	m.ID = v
+}
+
+// GetName 返回Name
+func (m *User) GetName() string {
+	return m.Name
+}
+
+// SetName 设置Name
+func (m *User) SetName(v string) {
+	m.Name = v
+}
+
+// GetEmail 返回Email
+func (m *User) GetEmail() string {
+	return m.Email
+}
+
+// SetEmail 设置Email
+func (m *User) SetEmail(v string) {
+	m.Email = v
+}
+
+// GetStatus 返回Status
+func (m *User) GetStatus() string {
+	return m.Status
+}
+
+// SetStatus 设置Status
+func (m *User) SetStatus(v string) {
+	m.Status = v
+}
+
+// GetCreatedAt 返回CreatedAt
+func (m *User) GetCreatedAt() time.Time {
+	return m.CreatedAt
+}
+
+// UserBuilder 逐个设置字段，最后用Build创建User
+type UserBuilder struct {
+	v User
+}
+
+// NewUserBuilder 创建UserBuilder
+func NewUserBuilder() *UserBuilder {
+	return &UserBuilder{}
+}
+
+// ID 设置ID
+func (b *UserBuilder) ID(v int64) *UserBuilder {
+	b.v.ID = v
+	return b
+}
+
+// Name 设置Name
+func (b *UserBuilder) Name(v string) *UserBuilder {
+	b.v.Name = v
+	return b
+}
+
+// Email 设置Email
+func (b *UserBuilder) Email(v string) *UserBuilder {
+	b.v.Email = v
+	return b
+}
+
+// Status 设置Status
+func (b *UserBuilder) Status(v string) *UserBuilder {
+	b.v.Status = v
+	return b
+}
+
+// CreatedAt 设置CreatedAt
+func (b *UserBuilder) CreatedAt(v time.Time) *UserBuilder {
+	b.v.CreatedAt = v
+	return b
+}
+
+// Build 返回创建好的User，之后继续使用Builder不影响它
+func (b *UserBuilder) Build() *User {
+	v := b.v
+	return &v
+}
+
+// Product 商品
+type Product struct {
+	ID    int64    `json:"id" db:"id" gorm:"column:id;primaryKey"`
+	SKU   string   `json:"sku" db:"sku" gorm:"column:sku;size:32;unique"`
+	Price float64  `json:"price" db:"price" gorm:"column:price;not null"` // 单价，单位元
+	Tags  []string `json:"tags" db:"tags" gorm:"column:tags"`             // 标签
+}
+
+// TableName Product对应的数据库表
+func (Product) TableName() string {
+	return "products"
+}
+
+// NewProduct 创建Product
+func NewProduct(id int64, sku string, price float64, tags []string) *Product {
+	return &Product{
+		ID:    id,
+		SKU:   sku,
+		Price: price,
+		Tags:  tags,
+	}
+}
+
+// GetID 返回ID
+func (m *Product) GetID() int64 {
+	return m.ID
+}
+
+// SetID 设置ID
+func (m *Product) SetID(v int64) {
+	m.ID = v
+}
+
+// GetSKU 返回SKU
+func (m *Product) GetSKU() string {
+	return m.SKU
+}
+
+// SetSKU 设置SKU
+func (m *Product) SetSKU(v string) {
+	m.SKU = v
+}
+
+// GetPrice 返回Price
+func (m *Product) GetPrice() float64 {
+	return m.Price
+}
+
+// SetPrice 设置Price
+func (m *Product) SetPrice(v float64) {
+	m.Price = v
+}
+
+// GetTags 返回Tags
+func (m *Product) GetTags() []string {
+	return m.Tags
+}
+
+// SetTags 设置Tags
+func (m *Product) SetTags(v []string) {
+	m.Tags = v
+}
+
+// ProductBuilder 逐个设置字段，最后用Build创建Product
+type ProductBuilder struct {
+	v Product
+}
+
+// NewProductBuilder 创建ProductBuilder
+func NewProductBuilder() *ProductBuilder {
+	return &ProductBuilder{}
+}
+
+// ID 设置ID
+func (b *ProductBuilder) ID(v int64) *ProductBuilder {
+	b.v.ID = v
+	return b
+}
+
+// SKU 设置SKU
+func (b *ProductBuilder) SKU(v string) *ProductBuilder {
+	b.v.SKU = v
+	return b
+}
+
+// Price 设置Price
+func (b *ProductBuilder) Price(v float64) *ProductBuilder {
+	b.v.Price = v
+	return b
+}
+
+// Tags 设置Tags
+func (b *ProductBuilder) Tags(v []string) *ProductBuilder {
+	b.v.Tags = v
+	return b
+}
+
+// Build 返回创建好的Product，之后继续使用Builder不影响它
+func (b *ProductBuilder) Build() *Product {
+	v := b.v
+	return &v
+}
@@ -0,0 +1,123 @@
+// Test stubs generated by modelgen from models.yaml. 可以直接修改和添加用例，修改后modelgen不会再覆盖这个文件。
+// modelgen:checksum f3141ed92d3c74a13376197234c62ab3b48af706e55a2aa0c70096208754b6bd
+
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUser(t *testing.T) {
+	tests := []struct {
+		name string
+		want User
+	}{
+		{name: "zero"},
+		{name: "sample", want: User{
+			ID:     1,
+			Name:   "name",
+			Email:  "email",
+			Status: "status",
+		}},
+		// TODO: 添加边界值和业务相关的用例
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewUser(tt.want.ID, tt.want.Name, tt.want.Email, tt.want.Status, tt.want.CreatedAt)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("NewUser() = %+v, want %+v", *got, tt.want)
+			}
+
+			var m User
+			m.SetID(tt.want.ID)
+			if v := m.GetID(); !reflect.DeepEqual(v, tt.want.ID) {
+				t.Errorf("GetID() = %v, want %v", v, tt.want.ID)
+			}
+			m.SetName(tt.want.Name)
+			if v := m.GetName(); !reflect.DeepEqual(v, tt.want.Name) {
+				t.Errorf("GetName() = %v, want %v", v, tt.want.Name)
+			}
+			m.SetEmail(tt.want.Email)
+			if v := m.GetEmail(); !reflect.DeepEqual(v, tt.want.Email) {
+				t.Errorf("GetEmail() = %v, want %v", v, tt.want.Email)
+			}
+			m.SetStatus(tt.want.Status)
+			if v := m.GetStatus(); !reflect.DeepEqual(v, tt.want.Status) {
+				t.Errorf("GetStatus() = %v, want %v", v, tt.want.Status)
+			}
+			m.CreatedAt = tt.want.CreatedAt
+			if v := m.GetCreatedAt(); !reflect.DeepEqual(v, tt.want.CreatedAt) {
+				t.Errorf("GetCreatedAt() = %v, want %v", v, tt.want.CreatedAt)
+			}
+			if !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("after setters = %+v, want %+v", m, tt.want)
+			}
+
+			built := NewUserBuilder().
+				ID(tt.want.ID).
+				Name(tt.want.Name).
+				Email(tt.want.Email).
+				Status(tt.want.Status).
+				CreatedAt(tt.want.CreatedAt).
+				Build()
+			if !reflect.DeepEqual(*built, tt.want) {
+				t.Errorf("Build() = %+v, want %+v", *built, tt.want)
+			}
+		})
+	}
+}
+
+func TestProduct(t *testing.T) {
+	tests := []struct {
+		name string
+		want Product
+	}{
+		{name: "zero"},
+		{name: "sample", want: Product{
+			ID:    1,
+			SKU:   "sku",
+			Price: 3.5,
+		}},
+		// TODO: 添加边界值和业务相关的用例
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewProduct(tt.want.ID, tt.want.SKU, tt.want.Price, tt.want.Tags)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("NewProduct() = %+v, want %+v", *got, tt.want)
+			}
+
+			var m Product
+			m.SetID(tt.want.ID)
+			if v := m.GetID(); !reflect.DeepEqual(v, tt.want.ID) {
+				t.Errorf("GetID() = %v, want %v", v, tt.want.ID)
+			}
+			m.SetSKU(tt.want.SKU)
+			if v := m.GetSKU(); !reflect.DeepEqual(v, tt.want.SKU) {
+				t.Errorf("GetSKU() = %v, want %v", v, tt.want.SKU)
+			}
+			m.SetPrice(tt.want.Price)
+			if v := m.GetPrice(); !reflect.DeepEqual(v, tt.want.Price) {
+				t.Errorf("GetPrice() = %v, want %v", v, tt.want.Price)
+			}
+			m.SetTags(tt.want.Tags)
+			if v := m.GetTags(); !reflect.DeepEqual(v, tt.want.Tags) {
+				t.Errorf("GetTags() = %v, want %v", v, tt.want.Tags)
+			}
+			if !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("after setters = %+v, want %+v", m, tt.want)
+			}
+
+			built := NewProductBuilder().
+				ID(tt.want.ID).
+				SKU(tt.want.SKU).
+				Price(tt.want.Price).
+				Tags(tt.want.Tags).
+				Build()
+			if !reflect.DeepEqual(*built, tt.want) {
+				t.Errorf("Build() = %+v, want %+v", *built, tt.want)
+			}
+		})
+	}
+}
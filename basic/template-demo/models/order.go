@@ -0,0 +1,18 @@
+// Package models 演示modelgen：User和Product定义在models.yaml中，Order是手写的结构体，
+// 两者的构造函数、getter/setter、Builder和测试骨架都由go generate生成
+package models
+
+import "time"
+
+//go:generate go run template-demo/cmd/modelgen -in models.yaml
+//go:generate go run template-demo/cmd/modelgen -in order.go -types Order
+
+// Order 订单，字段不导出，只能通过生成的方法访问
+type Order struct {
+	id       int64
+	userID   int64     // 下单用户
+	amount   float64   // 订单金额
+	paidAt   time.Time // 支付时间，未支付时为零值
+	note     string    `modelgen:"readonly"`
+	internal []byte    `modelgen:"-"` // 内部状态，不生成方法
+}
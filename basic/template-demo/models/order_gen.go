@@ -0,0 +1,110 @@
+// Code generated by modelgen from order.go; DO NOT EDIT.
+// modelgen:checksum 16f383472e7360eee126b2dc47805307c194cf0c71f8bf308130e8df0596df4a
+
+package models
+
+import (
+	"time"
+)
+
+// NewOrder 创建Order
+func NewOrder(id int64, userID int64, amount float64, paidAt time.Time, note string) *Order {
+	return &Order{
+		id:     id,
+		userID: userID,
+		amount: amount,
+		paidAt: paidAt,
+		note:   note,
+	}
+}
+
+// ID 返回id
+func (m *Order) ID() int64 {
+	return m.id
+}
+
+// SetID 设置id
+func (m *Order) SetID(v int64) {
+	m.id = v
+}
+
+// UserID 返回userID
+func (m *Order) UserID() int64 {
+	return m.userID
+}
+
+// SetUserID 设置userID
+func (m *Order) SetUserID(v int64) {
+	m.userID = v
+}
+
+// Amount 返回amount
+func (m *Order) Amount() float64 {
+	return m.amount
+}
+
+// SetAmount 设置amount
+func (m *Order) SetAmount(v float64) {
+	m.amount = v
+}
+
+// PaidAt 返回paidAt
+func (m *Order) PaidAt() time.Time {
+	return m.paidAt
+}
+
+// SetPaidAt 设置paidAt
+func (m *Order) SetPaidAt(v time.Time) {
+	m.paidAt = v
+}
+
+// Note 返回note
+func (m *Order) Note() string {
+	return m.note
+}
+
+// OrderBuilder 逐个设置字段，最后用Build创建Order
+type OrderBuilder struct {
+	v Order
+}
+
+// NewOrderBuilder 创建OrderBuilder
+func NewOrderBuilder() *OrderBuilder {
+	return &OrderBuilder{}
+}
+
+// ID 设置id
+func (b *OrderBuilder) ID(v int64) *OrderBuilder {
+	b.v.id = v
+	return b
+}
+
+// UserID 设置userID
+func (b *OrderBuilder) UserID(v int64) *OrderBuilder {
+	b.v.userID = v
+	return b
+}
+
+// Amount 设置amount
+func (b *OrderBuilder) Amount(v float64) *OrderBuilder {
+	b.v.amount = v
+	return b
+}
+
+// PaidAt 设置paidAt
+func (b *OrderBuilder) PaidAt(v time.Time) *OrderBuilder {
+	b.v.paidAt = v
+	return b
+}
+
+// Note 设置note
+func (b *OrderBuilder) Note(v string) *OrderBuilder {
+	b.v.note = v
+	return b
+}
+
+// Build 返回创建好的Order，之后继续使用Builder不影响它
+func (b *OrderBuilder) Build() *Order {
+	v := b.v
+	return &v
+}
@@ -0,0 +1,69 @@
+// Test stubs generated by modelgen from order.go. 可以直接修改和添加用例，修改后modelgen不会再覆盖这个文件。
+// modelgen:checksum 802226e69588d5f39fda8c2157d18dbf05c382d35f98b6f2f11b7efbd13595d0
+
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		want Order
+	}{
+		{name: "zero"},
+		{name: "sample", want: Order{
+			id:     1,
+			userID: 2,
+			amount: 3.5,
+			note:   "note",
+		}},
+		// TODO: 添加边界值和业务相关的用例
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewOrder(tt.want.id, tt.want.userID, tt.want.amount, tt.want.paidAt, tt.want.note)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("NewOrder() = %+v, want %+v", *got, tt.want)
+			}
+
+			var m Order
+			m.SetID(tt.want.id)
+			if v := m.ID(); !reflect.DeepEqual(v, tt.want.id) {
+				t.Errorf("ID() = %v, want %v", v, tt.want.id)
+			}
+			m.SetUserID(tt.want.userID)
+			if v := m.UserID(); !reflect.DeepEqual(v, tt.want.userID) {
+				t.Errorf("UserID() = %v, want %v", v, tt.want.userID)
+			}
+			m.SetAmount(tt.want.amount)
+			if v := m.Amount(); !reflect.DeepEqual(v, tt.want.amount) {
+				t.Errorf("Amount() = %v, want %v", v, tt.want.amount)
+			}
+			m.SetPaidAt(tt.want.paidAt)
+			if v := m.PaidAt(); !reflect.DeepEqual(v, tt.want.paidAt) {
+				t.Errorf("PaidAt() = %v, want %v", v, tt.want.paidAt)
+			}
+			m.note = tt.want.note
+			if v := m.Note(); !reflect.DeepEqual(v, tt.want.note) {
+				t.Errorf("Note() = %v, want %v", v, tt.want.note)
+			}
+			if !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("after setters = %+v, want %+v", m, tt.want)
+			}
+
+			built := NewOrderBuilder().
+				ID(tt.want.id).
+				UserID(tt.want.userID).
+				Amount(tt.want.amount).
+				PaidAt(tt.want.paidAt).
+				Note(tt.want.note).
+				Build()
+			if !reflect.DeepEqual(*built, tt.want) {
+				t.Errorf("Build() = %+v, want %+v", *built, tt.want)
+			}
+		})
+	}
+}
@@ -1,78 +1,44 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"embed"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
-	"text/template"
 	"time"
+
+	"template-demo/codegen"
+	"template-demo/confgen"
+	"template-demo/mailer"
+	"template-demo/mailer/mailtest"
+	"template-demo/sqltmpl"
 )
 
 // 实际应用场景示例
 
-// 邮件模板示例
+//go:embed mails
+var mailTemplates embed.FS
+
+// 邮件模板示例：mailer用每种语言成对的HTML和纯文本模板生成邮件，内联CSS，带附件，
+// 通过SMTP发送（这里用mailtest启动的本地SMTP服务器代替真实的邮件服务），也可以保存成.eml文件
 func emailTemplateExample() {
 	fmt.Println("=== 邮件模板示例 ===")
 
-	funcMap := template.FuncMap{
-		"formatDate": func(t time.Time) string {
-			return t.Format("2006年01月02日")
-		},
-		"currency": func(amount float64) string {
-			return fmt.Sprintf("¥%.2f", amount)
-		},
-	}
-
-	emailTemplate := `
-主题: 订单确认 - {{.OrderID}}
-
-亲爱的 {{.CustomerName}}，
-
-感谢您的订单！以下是您的订单详情：
-
-订单编号: {{.OrderID}}
-下单时间: {{formatDate .OrderDate}}
-订单状态: {{.Status}}
-
-商品清单:
-{{range .Items}}
-- {{.Name}} x {{.Quantity}} = {{currency .Total}}
-{{end}}
-
-订单总额: {{currency .TotalAmount}}
-{{if .DiscountAmount}}折扣金额: -{{currency .DiscountAmount}}{{end}}
-实付金额: {{currency .FinalAmount}}
-
-配送地址:
-{{.ShippingAddress}}
-
-预计送达时间: {{formatDate .EstimatedDelivery}}
-
-如有任何问题，请联系我们的客服。
-
-祝您购物愉快！
-电商平台客服团队
-`
-
-	tmpl, err := template.New("email").Funcs(funcMap).Parse(emailTemplate)
-	if err != nil {
-		log.Printf("解析邮件模板失败: %v", err)
-		return
+	type orderItem struct {
+		Name     string
+		Quantity int
+		Total    float64
 	}
-
-	// 订单数据
 	orderData := struct {
-		OrderID      string
-		CustomerName string
-		OrderDate    time.Time
-		Status       string
-		Items        []struct {
-			Name     string
-			Quantity int
-			Total    float64
-		}
+		OrderID           string
+		CustomerName      string
+		OrderDate         time.Time
+		Currency          string
+		Items             []orderItem
 		TotalAmount       float64
 		DiscountAmount    float64
 		FinalAmount       float64
@@ -82,12 +48,8 @@ func emailTemplateExample() {
 		OrderID:      "ORD-2024-001",
 		CustomerName: "张三",
 		OrderDate:    time.Now(),
-		Status:       "已确认",
-		Items: []struct {
-			Name     string
-			Quantity int
-			Total    float64
-		}{
+		Currency:     "CNY",
+		Items: []orderItem{
 			{"智能手机", 1, 2999.00},
 			{"手机壳", 2, 58.00},
 			{"钢化膜", 1, 29.00},
@@ -98,70 +60,63 @@ func emailTemplateExample() {
 		ShippingAddress:   "北京市朝阳区某某街道123号",
 		EstimatedDelivery: time.Now().Add(48 * time.Hour),
 	}
+	invoice := mailer.Attachment{
+		Filename:    "invoice-ORD-2024-001.csv",
+		ContentType: "text/csv; charset=utf-8",
+		Data:        []byte("item,quantity,total\n智能手机,1,2999.00\n手机壳,2,58.00\n钢化膜,1,29.00\n"),
+	}
 
-	err = tmpl.Execute(&bytes.Buffer{}, orderData)
+	mailsFS, err := fs.Sub(mailTemplates, "mails")
 	if err != nil {
-		log.Printf("执行邮件模板失败: %v", err)
+		log.Printf("读取邮件模板失败: %v", err)
+		return
+	}
+	composer, err := mailer.New(mailsFS, mailer.WithFrom("电商平台 <noreply@shop.example>"))
+	if err != nil {
+		log.Printf("加载邮件模板失败: %v", err)
 		return
 	}
 
-	// 输出到控制台
-	tmpl.Execute(os.Stdout, orderData)
-}
-
-// 配置文件生成示例
-func configTemplateExample() {
-	fmt.Println("\n=== 配置文件生成示例 ===")
+	srv := mailtest.NewServer()
+	defer srv.Close()
+	outbox := mailer.FileSender{Dir: filepath.Join(os.TempDir(), "template-demo-mails")}
+	senders := []mailer.Sender{&mailer.SMTPSender{Addr: srv.Addr}, outbox}
+
+	for _, locale := range []string{"zh-CN", "en-US"} {
+		msg, err := composer.Compose(mailer.Email{
+			Template:    "order_confirmation",
+			Locale:      locale,
+			To:          []string{"张三 <zhangsan@example.com>"},
+			Data:        orderData,
+			Attachments: []mailer.Attachment{invoice},
+		})
+		if err != nil {
+			log.Printf("生成邮件失败: %v", err)
+			return
+		}
+		fmt.Printf("--- [%s] %s ---\n%s", msg.Locale, msg.Subject, msg.Text)
 
-	funcMap := template.FuncMap{
-		"join": strings.Join,
-		"quote": func(s string) string {
-			return fmt.Sprintf(`"%s"`, s)
-		},
+		for _, sender := range senders {
+			if err := sender.Send(context.Background(), msg); err != nil {
+				log.Printf("发送邮件失败: %v", err)
+				return
+			}
+		}
+		name, _ := outbox.Filename(msg)
+		fmt.Printf("已保存: %s\n", name)
 	}
 
-	configTemplate := `# 应用配置文件
-# 生成时间: {{.GeneratedAt.Format "2006-01-02 15:04:05"}}
-
-[server]
-host = {{quote .Server.Host}}
-port = {{.Server.Port}}
-debug = {{.Server.Debug}}
-
-[database]
-driver = {{quote .Database.Driver}}
-host = {{quote .Database.Host}}
-port = {{.Database.Port}}
-name = {{quote .Database.Name}}
-user = {{quote .Database.User}}
-password = {{quote .Database.Password}}
-max_connections = {{.Database.MaxConnections}}
-
-[redis]
-host = {{quote .Redis.Host}}
-port = {{.Redis.Port}}
-password = {{quote .Redis.Password}}
-db = {{.Redis.DB}}
-
-[logging]
-level = {{quote .Logging.Level}}
-file = {{quote .Logging.File}}
-max_size = {{.Logging.MaxSize}}
-
-[features]
-enabled = [{{range $i, $feature := .Features.Enabled}}{{if $i}}, {{end}}{{quote $feature}}{{end}}]
-disabled = [{{range $i, $feature := .Features.Disabled}}{{if $i}}, {{end}}{{quote $feature}}{{end}}]
-`
+	received := srv.Messages()
+	fmt.Printf("SMTP服务器收到 %d 封邮件，第一封 %d 字节，收件人 %v\n", len(received), len(received[0].Data), received[0].To)
+}
 
-	tmpl, err := template.New("config").Funcs(funcMap).Parse(configTemplate)
-	if err != nil {
-		log.Printf("解析配置模板失败: %v", err)
-		return
-	}
+// 配置文件生成示例：confgen把同一份配置生成TOML、YAML、JSON和.env，
+// 生成前按Schema检查，生成后用各自的解析器读回来验证
+func configTemplateExample() {
+	fmt.Println("\n=== 配置文件生成示例 ===")
 
-	configData := struct {
-		GeneratedAt time.Time
-		Server      struct {
+	type appConfig struct {
+		Server struct {
 			Host  string
 			Port  int
 			Debug bool
@@ -179,7 +134,7 @@ disabled = [{{range $i, $feature := .Features.Disabled}}{{if $i}}, {{end}}{{quot
 			Host     string
 			Port     int
 			Password string
-			DB       int
+			DB       int `config:"db"`
 		}
 		Logging struct {
 			Level   string
@@ -190,151 +145,128 @@ disabled = [{{range $i, $feature := .Features.Disabled}}{{if $i}}, {{end}}{{quot
 			Enabled  []string
 			Disabled []string
 		}
-	}{
-		GeneratedAt: time.Now(),
-		Server: struct {
-			Host  string
-			Port  int
-			Debug bool
-		}{
-			Host:  "localhost",
-			Port:  8080,
-			Debug: true,
-		},
-		Database: struct {
-			Driver         string
-			Host           string
-			Port           int
-			Name           string
-			User           string
-			Password       string
-			MaxConnections int
-		}{
-			Driver:         "mysql",
-			Host:           "localhost",
-			Port:           3306,
-			Name:           "myapp",
-			User:           "root",
-			Password:       "password123",
-			MaxConnections: 100,
-		},
-		Redis: struct {
-			Host     string
-			Port     int
-			Password string
-			DB       int
-		}{
-			Host:     "localhost",
-			Port:     6379,
-			Password: "",
-			DB:       0,
-		},
-		Logging: struct {
-			Level   string
-			File    string
-			MaxSize int
-		}{
-			Level:   "info",
-			File:    "/var/log/myapp.log",
-			MaxSize: 100,
-		},
-		Features: struct {
-			Enabled  []string
-			Disabled []string
-		}{
-			Enabled:  []string{"user_registration", "email_notifications", "api_v2"},
-			Disabled: []string{"legacy_api", "debug_mode"},
-		},
 	}
 
-	err = tmpl.Execute(os.Stdout, configData)
+	var cfg appConfig
+	cfg.Server.Host, cfg.Server.Port, cfg.Server.Debug = "localhost", 8080, true
+	cfg.Database.Driver, cfg.Database.Host, cfg.Database.Port = "mysql", "localhost", 3306
+	cfg.Database.Name, cfg.Database.User, cfg.Database.Password = "myapp", "root", `pa$$w0rd"#1`
+	cfg.Database.MaxConnections = 100
+	cfg.Redis.Host, cfg.Redis.Port = "localhost", 6379
+	cfg.Logging.Level, cfg.Logging.File, cfg.Logging.MaxSize = "info", "/var/log/myapp.log", 100
+	cfg.Features.Enabled = []string{"user_registration", "email_notifications", "api_v2"}
+	cfg.Features.Disabled = []string{"legacy_api", "debug_mode"}
+
+	schema := confgen.Schema{
+		"server.host":              {Required: true},
+		"server.port":              {Required: true, Range: confgen.Between(1, 65535)},
+		"database.driver":          {Required: true, Enum: []string{"mysql", "postgres", "sqlite"}},
+		"database.max_connections": {Range: confgen.Between(1, 1000)},
+		"redis.db":                 {Range: confgen.Between(0, 15)},
+		"logging.level":            {Enum: []string{"debug", "info", "warn", "error"}},
+	}
+
+	doc, err := confgen.FromStruct(cfg)
 	if err != nil {
-		log.Printf("执行配置模板失败: %v", err)
+		log.Printf("读取配置失败: %v", err)
+		return
+	}
+	doc.Comment = "应用配置文件\n生成时间: " + time.Now().Format("2006-01-02 15:04:05")
+
+	files, err := confgen.Generate(doc, schema, confgen.Formats...)
+	if err != nil {
+		log.Printf("生成配置失败: %v", err)
+		return
+	}
+	for _, f := range confgen.Formats {
+		fmt.Printf("--- config%s ---\n%s", f.Ext(), files[f])
+	}
+
+	// 不符合Schema的配置不会生成任何文件，所有问题一次报告
+	cfg.Server.Port = 70000
+	cfg.Logging.Level = "verbose"
+	doc, _ = confgen.FromStruct(cfg)
+	if _, err := confgen.Generate(doc, schema, confgen.Formats...); err != nil {
+		fmt.Printf("--- 配置检查失败 ---\n%v\n", err)
 	}
 }
 
-// SQL 查询生成示例
+// SQL 查询生成示例：值变成占位符和参数，标识符、运算符和排序方向经过校验
 func sqlTemplateExample() {
 	fmt.Println("\n=== SQL 查询生成示例 ===")
 
-	funcMap := template.FuncMap{
-		"join": strings.Join,
-		"quote": func(s string) string {
-			return fmt.Sprintf("`%s`", s)
-		},
-		"sqlValue": func(v interface{}) string {
-			switch val := v.(type) {
-			case string:
-				return fmt.Sprintf("'%s'", strings.ReplaceAll(val, "'", "''"))
-			case int, int64, float64:
-				return fmt.Sprintf("%v", val)
-			case bool:
-				if val {
-					return "1"
-				}
-				return "0"
-			default:
-				return "NULL"
-			}
-		},
+	type condition struct {
+		Column   string
+		Operator string
+		Value    interface{}
 	}
 
-	// SELECT 查询模板
-	selectTemplate := `SELECT {{range $i, $col := .Columns}}{{if $i}}, {{end}}{{quote $col}}{{end}}
-FROM {{quote .Table}}
-{{if .Conditions}}WHERE {{range $i, $cond := .Conditions}}{{if $i}} AND {{end}}{{quote $cond.Column}} {{$cond.Operator}} {{sqlValue $cond.Value}}{{end}}{{end}}
-{{if .OrderBy}}ORDER BY {{range $i, $order := .OrderBy}}{{if $i}}, {{end}}{{quote $order.Column}} {{$order.Direction}}{{end}}{{end}}
-{{if .Limit}}LIMIT {{.Limit}}{{end}};`
+	// SELECT 查询模板，{{$cond.Value}}和{{.Limit}}会自动变成占位符
+	selectTemplate := `SELECT {{idents .Columns}}
+FROM {{ident .Table}}
+{{- if .Conditions}}
+WHERE {{range $i, $cond := .Conditions}}{{if $i}} AND {{end}}{{ident $cond.Column}} {{op $cond.Operator}} {{$cond.Value}}{{end}}
+{{- end}}
+{{- if .OrderBy}}
+ORDER BY {{range $i, $order := .OrderBy}}{{if $i}}, {{end}}{{ident $order.Column}} {{dir $order.Direction}}{{end}}
+{{- end}}
+{{- if .Limit}}
+LIMIT {{.Limit}}
+{{- end}}`
 
 	// INSERT 查询模板
-	insertTemplate := `INSERT INTO {{quote .Table}} ({{range $i, $col := .Columns}}{{if $i}}, {{end}}{{quote $col}}{{end}})
-VALUES {{range $i, $row := .Values}}{{if $i}}, {{end}}({{range $j, $val := $row}}{{if $j}}, {{end}}{{sqlValue $val}}{{end}}){{end}};`
+	insertTemplate := `INSERT INTO {{ident .Table}} ({{idents .Columns}})
+VALUES {{range $i, $row := .Values}}{{if $i}}, {{end}}({{binds $row}}){{end}}`
 
 	// UPDATE 查询模板
-	updateTemplate := `UPDATE {{quote .Table}}
-SET {{range $i, $set := .Sets}}{{if $i}}, {{end}}{{quote $set.Column}} = {{sqlValue $set.Value}}{{end}}
-{{if .Conditions}}WHERE {{range $i, $cond := .Conditions}}{{if $i}} AND {{end}}{{quote $cond.Column}} {{$cond.Operator}} {{sqlValue $cond.Value}}{{end}}{{end}};`
-
-	selectTmpl, _ := template.New("select").Funcs(funcMap).Parse(selectTemplate)
-	insertTmpl, _ := template.New("insert").Funcs(funcMap).Parse(insertTemplate)
-	updateTmpl, _ := template.New("update").Funcs(funcMap).Parse(updateTemplate)
+	updateTemplate := `UPDATE {{ident .Table}}
+SET {{range $i, $set := .Sets}}{{if $i}}, {{end}}{{ident $set.Column}} = {{$set.Value}}{{end}}
+WHERE {{range $i, $cond := .Conditions}}{{if $i}} AND {{end}}{{ident $cond.Column}} {{op $cond.Operator}} {{$cond.Value}}{{end}}`
+
+	// 列名只能来自白名单，排序字段等来自请求参数的名字也无法注入
+	columns := sqltmpl.WithIdentifiers("users", "id", "name", "email", "age", "status", "active",
+		"created_at", "last_login", "login_count")
+	selectTmpl := sqltmpl.Must(sqltmpl.New("select", columns).Parse(selectTemplate))
+	insertTmpl := sqltmpl.Must(sqltmpl.New("insert", columns).Parse(insertTemplate))
+	updateTmpl := sqltmpl.Must(sqltmpl.New("update", columns, sqltmpl.WithDialect(sqltmpl.Postgres)).Parse(updateTemplate))
+
+	show := func(tmpl *sqltmpl.Template, data interface{}) {
+		query, args, err := tmpl.Execute(data)
+		if err != nil {
+			fmt.Printf("拒绝生成: %v\n", err)
+			return
+		}
+		// 实际使用时: db.QueryContext(ctx, query, args...)
+		fmt.Println(query)
+		fmt.Printf("args: %#v\n", args)
+	}
 
 	// SELECT 示例
 	fmt.Println("SELECT 查询:")
+	type order struct {
+		Column    string
+		Direction string
+	}
 	selectData := struct {
 		Table      string
 		Columns    []string
-		Conditions []struct {
-			Column   string
-			Operator string
-			Value    interface{}
-		}
-		OrderBy []struct {
-			Column    string
-			Direction string
-		}
-		Limit int
+		Conditions []condition
+		OrderBy    []order
+		Limit      int
 	}{
 		Table:   "users",
 		Columns: []string{"id", "name", "email", "created_at"},
-		Conditions: []struct {
-			Column   string
-			Operator string
-			Value    interface{}
-		}{
+		Conditions: []condition{
 			{"age", ">=", 18},
 			{"status", "=", "active"},
 		},
-		OrderBy: []struct {
-			Column    string
-			Direction string
-		}{
+		OrderBy: []order{
 			{"created_at", "DESC"},
 			{"name", "ASC"},
 		},
 		Limit: 10,
 	}
-	selectTmpl.Execute(os.Stdout, selectData)
+	show(selectTmpl, selectData)
 
 	// INSERT 示例
 	fmt.Println("\nINSERT 查询:")
@@ -350,111 +282,74 @@ SET {{range $i, $set := .Sets}}{{if $i}}, {{end}}{{quote $set.Column}} = {{sqlVa
 			{"李四", "lisi@example.com", 30, false},
 		},
 	}
-	insertTmpl.Execute(os.Stdout, insertData)
+	show(insertTmpl, insertData)
 
-	// UPDATE 示例
+	// UPDATE 示例（PostgreSQL占位符）
 	fmt.Println("\nUPDATE 查询:")
+	type set struct {
+		Column string
+		Value  interface{}
+	}
 	updateData := struct {
-		Table string
-		Sets  []struct {
-			Column string
-			Value  interface{}
-		}
-		Conditions []struct {
-			Column   string
-			Operator string
-			Value    interface{}
-		}
+		Table      string
+		Sets       []set
+		Conditions []condition
 	}{
 		Table: "users",
-		Sets: []struct {
-			Column string
-			Value  interface{}
-		}{
-			{"last_login", time.Now().Format("2006-01-02 15:04:05")},
+		Sets: []set{
+			{"last_login", time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)},
 			{"login_count", 10},
 		},
-		Conditions: []struct {
-			Column   string
-			Operator string
-			Value    interface{}
-		}{
+		Conditions: []condition{
 			{"id", "=", 1},
 		},
 	}
-	updateTmpl.Execute(os.Stdout, updateData)
+	show(updateTmpl, updateData)
+
+	// 注入尝试：恶意的值只是一个参数，恶意的标识符和排序方向被拒绝
+	fmt.Println("\n注入尝试:")
+	selectData.Conditions = []condition{{"name", "=", "' OR '1'='1"}}
+	selectData.OrderBy = nil
+	show(selectTmpl, selectData)
+	selectData.OrderBy = []order{{"name", "ASC; DROP TABLE users"}}
+	show(selectTmpl, selectData)
+	selectData.OrderBy = []order{{"(SELECT password FROM admins)", "ASC"}}
+	show(selectTmpl, selectData)
 }
 
-// 代码生成示例
+// 代码生成示例：codegen包根据模型定义生成结构体、构造函数和getter/setter
+// 实际项目中通过go generate调用cmd/modelgen，见models目录
 func codeGenerationExample() {
 	fmt.Println("\n=== 代码生成示例 ===")
 
-	funcMap := template.FuncMap{
-		"title": strings.Title,
-		"lower": strings.ToLower,
-		"join":  strings.Join,
-	}
-
-	// Go 结构体生成模板
-	structTemplate := `// {{.Comment}}
-type {{.Name}} struct {
-{{range .Fields}}	{{.Name}} {{.Type}} {{if .Tag}}{{.Tag}}{{end}} {{if .Comment}}// {{.Comment}}{{end}}
-{{end}}}
-
-// New{{.Name}} 创建新的{{.Name}}实例
-func New{{.Name}}({{range $i, $field := .Fields}}{{if $i}}, {{end}}{{lower $field.Name}} {{$field.Type}}{{end}}) *{{.Name}} {
-	return &{{.Name}}{
-{{range .Fields}}		{{.Name}}: {{lower .Name}},
-{{end}}	}
-}
-
-{{range .Fields}}{{if eq .Type "string"}}
-// Get{{.Name}} 获取{{.Name}}
-func ({{lower $.Name}} *{{$.Name}}) Get{{.Name}}() {{.Type}} {
-	return {{lower $.Name}}.{{.Name}}
-}
-
-// Set{{.Name}} 设置{{.Name}}
-func ({{lower $.Name}} *{{$.Name}}) Set{{.Name}}({{lower .Name}} {{.Type}}) {
-	{{lower $.Name}}.{{.Name}} = {{lower .Name}}
-}
-{{end}}{{end}}`
-
-	tmpl, err := template.New("struct").Funcs(funcMap).Parse(structTemplate)
+	modelYAML := `
+package: models
+models:
+  - name: User
+    table: users
+    comment: 表示系统用户
+    fields:
+      - {name: ID, type: int64, primaryKey: true, comment: 用户ID}
+      - {name: Name, type: string, size: 64, notNull: true, comment: 用户姓名}
+      - {name: Email, type: string, unique: true, comment: 邮箱地址}
+      - {name: CreatedAt, type: time.Time, readonly: true, comment: 创建时间}
+`
+	file, err := codegen.LoadYAML("user.yaml", strings.NewReader(modelYAML))
 	if err != nil {
-		log.Printf("解析结构体模板失败: %v", err)
+		log.Printf("读取模型定义失败: %v", err)
 		return
 	}
 
-	structData := struct {
-		Name    string
-		Comment string
-		Fields  []struct {
-			Name    string
-			Type    string
-			Tag     string
-			Comment string
-		}
-	}{
-		Name:    "User",
-		Comment: "User 表示系统用户",
-		Fields: []struct {
-			Name    string
-			Type    string
-			Tag     string
-			Comment string
-		}{
-			{"ID", "int64", "`json:\"id\" db:\"id\"`", "用户ID"},
-			{"Name", "string", "`json:\"name\" db:\"name\"`", "用户姓名"},
-			{"Email", "string", "`json:\"email\" db:\"email\"`", "邮箱地址"},
-			{"CreatedAt", "time.Time", "`json:\"created_at\" db:\"created_at\"`", "创建时间"},
-		},
-	}
-
-	err = tmpl.Execute(os.Stdout, structData)
+	src, err := codegen.Generate(file, codegen.Options{
+		Constructor: true,
+		Accessors:   true,
+		Tags:        []string{"json", "db"},
+	})
 	if err != nil {
-		log.Printf("执行结构体模板失败: %v", err)
+		log.Printf("生成代码失败: %v", err)
+		return
 	}
+	fmt.Print(string(src))
 }
 
 // 运行所有实际应用示例
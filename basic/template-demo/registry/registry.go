@@ -0,0 +1,264 @@
+// Package registry 从一个目录树加载模板，支持布局、局部模板以及text/template和html/template两种引擎。
+//
+// 目录约定：
+//
+//	layouts/   布局，例如layouts/base.html
+//	partials/  局部模板，例如partials/user-card.html
+//	其他文件    页面，按相对路径命名，例如users/show.html
+//
+// 文件名中含有".html"的模板使用html/template（自动转义），其他使用text/template。
+// 每个页面和同一种引擎的所有布局、局部模板一起解析，页面可以用define覆盖布局中的block：
+//
+//	{{template "layouts/base.html" .}}
+//	{{define "title"}}用户详情{{end}}
+//	{{define "content"}}{{template "partials/user-card.html" .}}{{end}}
+//
+// 生产环境传入embed.FS，启动时一次性解析全部模板；开发环境传入os.DirFS并开启WithDevMode，
+// 文件修改后下一次Render自动重新加载。
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// ErrNotFound 没有这个页面
+var ErrNotFound = errors.New("registry: template not found")
+
+const (
+	layoutsDir  = "layouts"
+	partialsDir = "partials"
+)
+
+// executor *texttemplate.Template和*htmltemplate.Template共有的方法
+type executor interface {
+	ExecuteTemplate(w io.Writer, name string, data any) error
+}
+
+// Registry 模板注册表，可以并发使用
+type Registry struct {
+	fsys  fs.FS
+	funcs map[string]any
+	dev   bool
+
+	mu          sync.RWMutex
+	pages       map[string]executor
+	fingerprint [sha256.Size]byte
+}
+
+// Option 注册表选项
+type Option func(*Registry)
+
+// WithFuncs 注册模板函数，对两种引擎都生效
+func WithFuncs(funcs map[string]any) Option {
+	return func(r *Registry) {
+		for name, fn := range funcs {
+			r.funcs[name] = fn
+		}
+	}
+}
+
+// WithDevMode 开发模式：每次Render前检查文件是否有变化，有变化时重新加载
+func WithDevMode() Option {
+	return func(r *Registry) { r.dev = true }
+}
+
+// New 加载fsys中的全部模板，任何一个模板解析失败都返回错误
+func New(fsys fs.FS, opts ...Option) (*Registry, error) {
+	r := &Registry{fsys: fsys, funcs: make(map[string]any)}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Must New出错时panic，用于在包级变量中加载嵌入的模板
+func Must(r *Registry, err error) *Registry {
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Reload 重新加载全部模板，失败时保留之前加载的模板
+func (r *Registry) Reload() error {
+	files, fingerprint, err := r.scan()
+	if err != nil {
+		return err
+	}
+	pages, err := r.parse(files)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pages = pages
+	r.fingerprint = fingerprint
+	return nil
+}
+
+// Names 返回所有页面的名字
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.pages))
+	for name := range r.pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render 渲染页面，出错时不向w写入任何内容
+// 开发模式下如果文件有变化先重新加载，重新加载失败时返回解析错误
+func (r *Registry) Render(w io.Writer, name string, data any) error {
+	if r.dev {
+		if err := r.reloadIfChanged(); err != nil {
+			return err
+		}
+	}
+
+	r.mu.RLock()
+	page, ok := r.pages[name]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	var buf bytes.Buffer
+	if err := page.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// RenderString 渲染页面并返回字符串
+func (r *Registry) RenderString(name string, data any) (string, error) {
+	var sb strings.Builder
+	if err := r.Render(&sb, name, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func (r *Registry) reloadIfChanged() error {
+	_, fingerprint, err := r.scan()
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	changed := fingerprint != r.fingerprint
+	r.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	return r.Reload()
+}
+
+type templateFile struct {
+	name    string
+	content string
+}
+
+// scan 读取所有模板文件，跳过以"."开头的文件和目录，指纹由文件名和内容计算
+func (r *Registry) scan() ([]templateFile, [sha256.Size]byte, error) {
+	var files []templateFile
+	h := sha256.New()
+	err := fs.WalkDir(r.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := fs.ReadFile(r.fsys, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, templateFile{name: p, content: string(data)})
+		fmt.Fprintf(h, "%s\x00%d\x00", p, len(data))
+		h.Write(data)
+		return nil
+	})
+
+	var fingerprint [sha256.Size]byte
+	copy(fingerprint[:], h.Sum(nil))
+	return files, fingerprint, err
+}
+
+// parse 先把布局和局部模板解析到两种引擎的基础模板中，每个页面在基础模板的副本上解析
+func (r *Registry) parse(files []templateFile) (map[string]executor, error) {
+	textBase := texttemplate.New("").Funcs(texttemplate.FuncMap(r.funcs))
+	htmlBase := htmltemplate.New("").Funcs(htmltemplate.FuncMap(r.funcs))
+
+	var pages []templateFile
+	for _, f := range files {
+		if !isShared(f.name) {
+			pages = append(pages, f)
+			continue
+		}
+		var err error
+		if isHTML(f.name) {
+			_, err = htmlBase.New(f.name).Parse(f.content)
+		} else {
+			_, err = textBase.New(f.name).Parse(f.content)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := make(map[string]executor, len(pages))
+	for _, f := range pages {
+		if isHTML(f.name) {
+			t, err := htmlBase.Clone()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := t.New(f.name).Parse(f.content); err != nil {
+				return nil, err
+			}
+			out[f.name] = t
+		} else {
+			t, err := textBase.Clone()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := t.New(f.name).Parse(f.content); err != nil {
+				return nil, err
+			}
+			out[f.name] = t
+		}
+	}
+	return out, nil
+}
+
+func isShared(name string) bool {
+	dir, _, _ := strings.Cut(name, "/")
+	return strings.Contains(name, "/") && (dir == layoutsDir || dir == partialsDir)
+}
+
+func isHTML(name string) bool {
+	return strings.Contains(path.Base(name), ".html")
+}
@@ -0,0 +1,171 @@
+package registry
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+//go:embed testdata/site
+var siteFS embed.FS
+
+type user struct {
+	Name  string
+	Email string
+}
+
+func newSite(t *testing.T, opts ...Option) *Registry {
+	t.Helper()
+	site, err := fs.Sub(siteFS, "testdata/site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = append([]Option{WithFuncs(map[string]any{"upper": strings.ToUpper})}, opts...)
+	r, err := New(site, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRegistryNames(t *testing.T) {
+	r := newSite(t)
+	got := strings.Join(r.Names(), ",")
+	if got != "emails/welcome.txt,users/list.html,users/show.html" {
+		t.Fatalf("Names = %s", got)
+	}
+}
+
+func TestRegistryLayoutAndPartials(t *testing.T) {
+	r := newSite(t)
+	out, err := r.RenderString("users/show.html", user{Name: "alice", Email: "<script>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>用户 ALICE</title>",
+		`<div class="card">alice &lt;&lt;script&gt;&gt;</div>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	// 另一个页面没有覆盖title，使用布局中的默认值；两个页面的content互不影响
+	out, err = r.RenderString("users/list.html", []user{{Name: "a"}, {Name: "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "<title>默认标题</title>") || strings.Count(out, `class="card"`) != 2 {
+		t.Fatalf("list output:\n%s", out)
+	}
+}
+
+func TestRegistryTextTemplates(t *testing.T) {
+	r := newSite(t)
+	out, err := r.RenderString("emails/welcome.txt", user{Name: "<alice>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "你好 <alice>，欢迎加入！<b>不会被转义</b>\n-- \n客服团队\n"
+	if out != want {
+		t.Fatalf("output = %q, want %q", out, want)
+	}
+}
+
+func TestRegistryErrors(t *testing.T) {
+	r := newSite(t)
+	if err := r.Render(&strings.Builder{}, "users/missing.html", nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Render missing error = %v, want ErrNotFound", err)
+	}
+	if _, err := r.RenderString("layouts/base.html", nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("layouts are not pages, error = %v", err)
+	}
+
+	// 执行出错时不输出半截内容
+	var sb strings.Builder
+	if err := r.Render(&sb, "users/show.html", 42); err == nil || sb.Len() != 0 {
+		t.Fatalf("Render with bad data = (%q, %v), want error and no output", sb.String(), err)
+	}
+
+	bad := fstest.MapFS{"page.txt": {Data: []byte("{{.Name")}}
+	if _, err := New(bad); err == nil {
+		t.Fatal("New with a broken template should fail")
+	}
+	missingFunc := fstest.MapFS{"page.txt": {Data: []byte("{{upper .}}")}}
+	if _, err := New(missingFunc); err == nil {
+		t.Fatal("New with an undefined function should fail")
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistryDevModeReload(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "layouts/base.txt", `[{{block "body" .}}{{end}}]`)
+	writeFile(t, dir, "hello.txt", `{{template "layouts/base.txt" .}}{{define "body"}}hello {{.}}{{end}}`)
+	writeFile(t, dir, ".hello.txt.swp", `{{`) // 编辑器的临时文件被忽略
+
+	prod, err := New(os.DirFS(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev, err := New(os.DirFS(dir), WithDevMode())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 布局修改后开发模式立即生效，生产模式仍使用启动时加载的模板
+	writeFile(t, dir, "layouts/base.txt", `<{{block "body" .}}{{end}}>`)
+	if out, _ := dev.RenderString("hello.txt", "go"); out != "<hello go>" {
+		t.Fatalf("dev output = %q, want <hello go>", out)
+	}
+	if out, _ := prod.RenderString("hello.txt", "go"); out != "[hello go]" {
+		t.Fatalf("prod output = %q, want [hello go]", out)
+	}
+
+	// 新增页面
+	writeFile(t, dir, "bye.txt", `bye {{.}}`)
+	if out, err := dev.RenderString("bye.txt", "go"); err != nil || out != "bye go" {
+		t.Fatalf("new page = (%q, %v)", out, err)
+	}
+
+	// 改坏之后返回解析错误，修好之后恢复
+	writeFile(t, dir, "hello.txt", `{{define "body"}}`)
+	if _, err := dev.RenderString("hello.txt", "go"); err == nil {
+		t.Fatal("broken template should return the parse error in dev mode")
+	}
+	writeFile(t, dir, "hello.txt", `{{template "layouts/base.txt" .}}{{define "body"}}hi {{.}}{{end}}`)
+	if out, err := dev.RenderString("hello.txt", "go"); err != nil || out != "<hi go>" {
+		t.Fatalf("fixed page = (%q, %v)", out, err)
+	}
+}
+
+func TestRegistryReloadKeepsOldOnError(t *testing.T) {
+	fsys := fstest.MapFS{"a.txt": {Data: []byte("a={{.}}")}}
+	r, err := New(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys["a.txt"] = &fstest.MapFile{Data: []byte("{{")}
+	if err := r.Reload(); err == nil {
+		t.Fatal("Reload should fail")
+	}
+	if out, err := r.RenderString("a.txt", 1); err != nil || out != "a=1" {
+		t.Fatalf("after failed reload = (%q, %v), want the old template", out, err)
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strings"
 	"text/template"
 	"time"
+
+	"template-demo/funcs"
 )
 
 // 简单的 text/template 和 FuncMap 使用示例
@@ -66,32 +67,15 @@ func basicExample() {
 
 // FuncMap 基本使用示例
 func funcMapBasicExample() {
-	// 定义自定义函数映射
-	funcMap := template.FuncMap{
-		// 字符串处理
-		"upper": strings.ToUpper,
-		"lower": strings.ToLower,
-
-		// 数学运算
-		"add": func(a, b int) int {
-			return a + b
-		},
-		"multiply": func(a, b float64) float64 {
-			return a * b
-		},
-
-		// 格式化
-		"formatPrice": func(price float64) string {
-			return fmt.Sprintf("¥%.2f", price)
-		},
-	}
+	// 自定义函数来自funcs包中共享的函数库
+	funcMap := funcs.New(funcs.WithLocale(funcs.ZhCN))
 
 	// 定义模板字符串
 	tmplStr := `
 产品信息:
 名称: {{.Name | upper}}
-价格: {{formatPrice .Price}}
-折扣价: {{formatPrice (multiply .Price 0.8)}}
+价格: {{.Price | currency}}
+折扣价: {{mul .Price 0.8 | currency}}
 总数: {{add .Count 10}}
 `
 
@@ -122,55 +106,19 @@ func funcMapBasicExample() {
 
 // 实用的 FuncMap 函数示例
 func practicalFuncMapExample() {
-	// 定义实用的自定义函数
-	funcMap := template.FuncMap{
-		// 默认值函数
-		"default": func(defaultValue, value interface{}) interface{} {
-			if value == nil || value == "" {
-				return defaultValue
-			}
-			return value
-		},
-
-		// 字符串截断
-		"truncate": func(s string, length int) string {
-			if len(s) <= length {
-				return s
-			}
-			return s[:length] + "..."
-		},
-
-		// 时间格式化
-		"formatTime": func(t time.Time, layout string) string {
-			return t.Format(layout)
-		},
-
-		// 数组长度
-		"len": func(items []string) int {
-			return len(items)
-		},
-
-		// 字符串连接
-		"join": strings.Join,
-
-		// 条件判断
-		"eq": func(a, b interface{}) bool {
-			return a == b
-		},
-		"gt": func(a, b int) bool {
-			return a > b
-		},
-	}
+	// default、truncate、date、join来自funcs包，被处理的值是最后一个参数；
+	// truncate按字符而不是字节截断，避免把中文切成两半；len、eq、gt是模板内置的函数
+	funcMap := funcs.New()
 
 	// 定义模板字符串
 	tmplStr := `
 用户资料:
 姓名: {{.Name}}
-简介: {{truncate .Bio 30}}
-网站: {{default "未设置" .Website}}
-注册时间: {{formatTime .RegisterTime "2006-01-02"}}
+简介: {{.Bio | truncate 30}}
+网站: {{.Website | default "未设置"}}
+注册时间: {{.RegisterTime | date "2006-01-02"}}
 标签数量: {{len .Tags}}
-{{if gt (len .Tags) 0}}标签: {{join .Tags ", "}}{{end}}
+{{if gt (len .Tags) 0}}标签: {{.Tags | join ", "}}{{end}}
 {{if eq .Role "admin"}}权限: 管理员{{else}}权限: 普通用户{{end}}
 `
 
@@ -0,0 +1,317 @@
+// Package sqltmpl 用text/template生成参数化SQL，结果是可以直接交给database/sql的(query, args)。
+//
+// 模板中的值永远不会拼进SQL：解析时每个输出动作的末尾都被追加一个bind，
+// {{.Name}} 输出占位符并把值放进args，和html/template自动转义的做法一样，忘记调用函数也是安全的。
+// 只有下面这些函数的结果作为SQL片段原样输出，它们都会校验输入：
+//
+//	ident name     标识符（表名、列名），必须符合严格的语法，设置了白名单时还必须在白名单中，按方言加引号
+//	idents list    逗号分隔的标识符列表
+//	in list        (?, ?, ?)，列表为空时出错
+//	binds list     ?, ?, ?，用于INSERT的一行值
+//	op s           比较运算符：= <> != < <= > >= LIKE NOT LIKE IS IS NOT
+//	dir s          排序方向：ASC DESC
+//
+// SQL关键字写在动作外面的模板文本中，{{"ORDER BY"}}这样的字符串常量也会变成占位符。
+//
+//	t := sqltmpl.Must(sqltmpl.New("user", sqltmpl.WithDialect(sqltmpl.Postgres)).Parse(
+//		`SELECT {{idents .Columns}} FROM users WHERE id = {{.ID}}`))
+//	query, args, err := t.Execute(data)
+//	rows, err := db.QueryContext(ctx, query, args...)
+package sqltmpl
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// ErrUnsafe 标识符、运算符或排序方向没有通过校验
+var ErrUnsafe = errors.New("sqltmpl: unsafe input")
+
+// Dialect 数据库方言：占位符和标识符引号
+type Dialect struct {
+	Name        string
+	Placeholder func(n int) string       // 第n个参数的占位符，n从1开始
+	QuoteIdent  func(name string) string // 给已经校验过的单个标识符加引号
+}
+
+var (
+	MySQL     = Dialect{Name: "mysql", Placeholder: question, QuoteIdent: wrap("`", "`")}
+	SQLite    = Dialect{Name: "sqlite", Placeholder: question, QuoteIdent: wrap(`"`, `"`)}
+	Postgres  = Dialect{Name: "postgres", Placeholder: numbered("$"), QuoteIdent: wrap(`"`, `"`)}
+	SQLServer = Dialect{Name: "sqlserver", Placeholder: numbered("@p"), QuoteIdent: wrap("[", "]")}
+)
+
+func question(int) string { return "?" }
+
+func numbered(prefix string) func(int) string {
+	return func(n int) string { return prefix + strconv.Itoa(n) }
+}
+
+func wrap(left, right string) func(string) string {
+	return func(name string) string { return left + name + right }
+}
+
+// identPart 标识符的一段：字母或下划线开头，只含ASCII字母、数字和下划线，最长64个字符
+var identPart = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+
+var operators = map[string]bool{
+	"=": true, "<>": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"LIKE": true, "NOT LIKE": true, "IS": true, "IS NOT": true,
+}
+
+// Fragment 已经校验过、可以原样输出的SQL片段，bind不会把它变成占位符
+// 和html/template的template.HTML一样，不要把用户输入转换成Fragment
+type Fragment string
+
+// Template SQL模板，解析完成后可以并发执行
+type Template struct {
+	tmpl    *template.Template
+	dialect Dialect
+	allow   map[string]bool
+}
+
+// Option 模板选项
+type Option func(*Template)
+
+// WithDialect 设置方言，默认MySQL
+func WithDialect(d Dialect) Option {
+	return func(t *Template) { t.dialect = d }
+}
+
+// WithIdentifiers 标识符白名单，设置后ident只接受其中的名字，带schema的名字按完整写法匹配，例如"app.users"
+func WithIdentifiers(names ...string) Option {
+	return func(t *Template) {
+		if t.allow == nil {
+			t.allow = make(map[string]bool)
+		}
+		for _, name := range names {
+			t.allow[name] = true
+		}
+	}
+}
+
+// WithFuncs 添加其他模板函数，例如funcs包的函数库；它们的结果仍然会被bind成参数
+// 和本包函数同名的函数被忽略
+func WithFuncs(funcs map[string]any) Option {
+	return func(t *Template) { t.tmpl.Funcs(funcs) }
+}
+
+// New 创建SQL模板
+func New(name string, opts ...Option) *Template {
+	t := &Template{tmpl: template.New(name), dialect: MySQL}
+	for _, opt := range opts {
+		opt(t)
+	}
+	// 解析时只需要函数名存在，执行时每次换成绑定到新参数列表的实现
+	t.tmpl.Funcs((&binder{t: t}).funcs())
+	return t
+}
+
+// Must 解析出错时panic，用于包级变量
+func Must(t *Template, err error) *Template {
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Parse 解析模板文本，可以多次调用来添加define定义的子模板，必须在执行之前完成
+func (t *Template) Parse(text string) (*Template, error) {
+	if _, err := t.tmpl.Parse(text); err != nil {
+		return nil, err
+	}
+	for _, tt := range t.tmpl.Templates() {
+		if tt.Tree != nil {
+			escapeList(tt.Tree.Root)
+		}
+	}
+	return t, nil
+}
+
+// Execute 执行模板，返回SQL和按占位符顺序排列的参数
+func (t *Template) Execute(data any) (string, []any, error) {
+	return t.ExecuteTemplate(t.tmpl.Name(), data)
+}
+
+// ExecuteTemplate 执行名为name的子模板
+func (t *Template) ExecuteTemplate(name string, data any) (string, []any, error) {
+	tmpl, err := t.tmpl.Clone()
+	if err != nil {
+		return "", nil, err
+	}
+	b := &binder{t: t}
+	tmpl.Funcs(b.funcs())
+
+	var sb strings.Builder
+	if err := tmpl.ExecuteTemplate(&sb, name, data); err != nil {
+		return "", nil, err
+	}
+	return sb.String(), b.args, nil
+}
+
+// escapeList 给每个输出动作追加bind
+func escapeList(l *parse.ListNode) {
+	if l == nil {
+		return
+	}
+	for _, n := range l.Nodes {
+		switch n := n.(type) {
+		case *parse.ActionNode:
+			escapeAction(n)
+		case *parse.IfNode:
+			escapeList(n.List)
+			escapeList(n.ElseList)
+		case *parse.RangeNode:
+			escapeList(n.List)
+			escapeList(n.ElseList)
+		case *parse.WithNode:
+			escapeList(n.List)
+			escapeList(n.ElseList)
+		}
+	}
+}
+
+func escapeAction(n *parse.ActionNode) {
+	// {{$x := ...}}和{{$x = ...}}不输出
+	if len(n.Pipe.Decl) > 0 {
+		return
+	}
+	cmds := n.Pipe.Cmds
+	if last := cmds[len(cmds)-1]; len(last.Args) > 0 {
+		if id, ok := last.Args[0].(*parse.IdentifierNode); ok && id.Ident == "bind" {
+			return
+		}
+	}
+	n.Pipe.Cmds = append(cmds, &parse.CommandNode{
+		NodeType: parse.NodeCommand,
+		Pos:      n.Pos,
+		Args:     []parse.Node{parse.NewIdentifier("bind").SetTree(nil).SetPos(n.Pos)},
+	})
+}
+
+// binder 一次执行的参数列表
+type binder struct {
+	t    *Template
+	args []any
+}
+
+func (b *binder) funcs() template.FuncMap {
+	return template.FuncMap{
+		"bind":   b.bind,
+		"ident":  b.ident,
+		"idents": b.idents,
+		"in":     b.in,
+		"binds":  b.binds,
+		"op":     op,
+		"dir":    dir,
+	}
+}
+
+// bind 把值加入参数列表并返回占位符，Fragment原样返回
+func (b *binder) bind(v any) Fragment {
+	if f, ok := v.(Fragment); ok {
+		return f
+	}
+	b.args = append(b.args, v)
+	return Fragment(b.t.dialect.Placeholder(len(b.args)))
+}
+
+func (b *binder) ident(name string) (Fragment, error) {
+	if b.t.allow != nil && !b.t.allow[name] {
+		return "", fmt.Errorf("%w: identifier %q is not in the allowlist", ErrUnsafe, name)
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) > 3 {
+		return "", fmt.Errorf("%w: identifier %q has too many parts", ErrUnsafe, name)
+	}
+	for i, part := range parts {
+		if !identPart.MatchString(part) {
+			return "", fmt.Errorf("%w: invalid identifier %q", ErrUnsafe, name)
+		}
+		parts[i] = b.t.dialect.QuoteIdent(part)
+	}
+	return Fragment(strings.Join(parts, ".")), nil
+}
+
+func (b *binder) idents(list any) (Fragment, error) {
+	items, err := listItems("idents", list)
+	if err != nil {
+		return "", err
+	}
+	out := make([]string, len(items))
+	for i, item := range items {
+		name, ok := item.(string)
+		if !ok {
+			return "", fmt.Errorf("idents: element %d is %T, not a string", i, item)
+		}
+		f, err := b.ident(name)
+		if err != nil {
+			return "", err
+		}
+		out[i] = string(f)
+	}
+	return Fragment(strings.Join(out, ", ")), nil
+}
+
+func (b *binder) binds(list any) (Fragment, error) {
+	return b.bindList("binds", list)
+}
+
+// in 空的IN ()不是合法的SQL，而且多半是调用方的错误，所以返回错误而不是生成恒假条件
+func (b *binder) in(list any) (Fragment, error) {
+	f, err := b.bindList("in", list)
+	if err != nil {
+		return "", err
+	}
+	return "(" + f + ")", nil
+}
+
+func (b *binder) bindList(fn string, list any) (Fragment, error) {
+	items, err := listItems(fn, list)
+	if err != nil {
+		return "", err
+	}
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = string(b.bind(item))
+	}
+	return Fragment(strings.Join(out, ", ")), nil
+}
+
+// listItems 把任意切片或数组展开，空列表返回错误
+func listItems(fn string, list any) ([]any, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%s: expected a list, got %T", fn, list)
+	}
+	if v.Len() == 0 {
+		return nil, fmt.Errorf("%s: empty list", fn)
+	}
+	items := make([]any, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, nil
+}
+
+func op(s string) (Fragment, error) {
+	norm := strings.ToUpper(strings.Join(strings.Fields(s), " "))
+	if !operators[norm] {
+		return "", fmt.Errorf("%w: operator %q", ErrUnsafe, s)
+	}
+	return Fragment(norm), nil
+}
+
+func dir(s string) (Fragment, error) {
+	switch norm := strings.ToUpper(strings.TrimSpace(s)); norm {
+	case "ASC", "DESC":
+		return Fragment(norm), nil
+	}
+	return "", fmt.Errorf("%w: sort direction %q", ErrUnsafe, s)
+}
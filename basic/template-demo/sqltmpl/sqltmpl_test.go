@@ -0,0 +1,245 @@
+package sqltmpl
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type condition struct {
+	Column   string
+	Operator string
+	Value    any
+}
+
+type query struct {
+	Table      string
+	Columns    []string
+	Conditions []condition
+	OrderBy    string
+	Direction  string
+	Limit      int
+}
+
+const selectText = `SELECT {{idents .Columns}} FROM {{ident .Table}}
+{{- with .Conditions}} WHERE {{range $i, $c := .}}{{if $i}} AND {{end}}{{ident $c.Column}} {{op $c.Operator}} {{$c.Value}}{{end}}{{end}}
+{{- if .OrderBy}} ORDER BY {{ident .OrderBy}} {{dir .Direction}}{{end}}
+{{- if .Limit}} LIMIT {{.Limit}}{{end}}`
+
+func execute(t *testing.T, tmpl *Template, data any) (string, []any) {
+	t.Helper()
+	q, args, err := tmpl.Execute(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q, args
+}
+
+func TestValuesBecomePlaceholders(t *testing.T) {
+	tmpl := Must(New("select").Parse(selectText))
+	q, args := execute(t, tmpl, query{
+		Table:   "users",
+		Columns: []string{"id", "name"},
+		Conditions: []condition{
+			{"name", "=", "' OR 1=1 --"},
+			{"age", ">=", 18},
+		},
+		OrderBy:   "created_at",
+		Direction: "desc",
+		Limit:     10,
+	})
+
+	want := "SELECT `id`, `name` FROM `users` WHERE `name` = ? AND `age` >= ? ORDER BY `created_at` DESC LIMIT ?"
+	if q != want {
+		t.Fatalf("query =\n%s\nwant\n%s", q, want)
+	}
+	if fmt.Sprint(args) != "[' OR 1=1 -- 18 10]" {
+		t.Fatalf("args = %#v", args)
+	}
+}
+
+func TestDialects(t *testing.T) {
+	text := `SELECT {{idents .Columns}} FROM {{ident "app.users"}} WHERE id {{in .IDs}} AND name = {{.Name}}`
+	data := map[string]any{"Columns": []string{"id", "name"}, "IDs": []int{1, 2, 3}, "Name": "张三"}
+
+	for _, tt := range []struct {
+		dialect Dialect
+		want    string
+	}{
+		{MySQL, "SELECT `id`, `name` FROM `app`.`users` WHERE id (?, ?, ?) AND name = ?"},
+		{SQLite, `SELECT "id", "name" FROM "app"."users" WHERE id (?, ?, ?) AND name = ?`},
+		{Postgres, `SELECT "id", "name" FROM "app"."users" WHERE id ($1, $2, $3) AND name = $4`},
+		{SQLServer, `SELECT [id], [name] FROM [app].[users] WHERE id (@p1, @p2, @p3) AND name = @p4`},
+	} {
+		tmpl := Must(New("q", WithDialect(tt.dialect)).Parse(text))
+		q, args := execute(t, tmpl, data)
+		if q != tt.want {
+			t.Errorf("%s: query = %s, want %s", tt.dialect.Name, q, tt.want)
+		}
+		if fmt.Sprint(args) != "[1 2 3 张三]" {
+			t.Errorf("%s: args = %v", tt.dialect.Name, args)
+		}
+	}
+}
+
+func TestUnsafeInputRejected(t *testing.T) {
+	tmpl := Must(New("select").Parse(selectText))
+	base := query{Table: "users", Columns: []string{"id"}, OrderBy: "id", Direction: "ASC"}
+
+	for name, mutate := range map[string]func(*query){
+		"table":       func(q *query) { q.Table = "users; DROP TABLE users" },
+		"quoted":      func(q *query) { q.Table = "users`" },
+		"column":      func(q *query) { q.Columns = []string{"id", "(SELECT password FROM admins)"} },
+		"leading dot": func(q *query) { q.OrderBy = ".id" },
+		"too deep":    func(q *query) { q.OrderBy = "a.b.c.d" },
+		"unicode":     func(q *query) { q.OrderBy = "名字" },
+		"direction":   func(q *query) { q.Direction = "ASC, (SELECT 1)" },
+		"operator":    func(q *query) { q.Conditions = []condition{{"id", "= 1 OR 1 =", 1}} },
+	} {
+		q := base
+		mutate(&q)
+		if _, _, err := tmpl.Execute(q); !errors.Is(err, ErrUnsafe) {
+			t.Errorf("%s: error = %v, want ErrUnsafe", name, err)
+		}
+	}
+
+	q, _ := execute(t, tmpl, query{Table: "users", Columns: []string{"id"}, Conditions: []condition{{"deleted_at", "is  not", nil}}})
+	if !strings.HasSuffix(q, "`deleted_at` IS NOT ?") {
+		t.Fatalf("query = %s, want the operator normalized", q)
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	tmpl := Must(New("select", WithIdentifiers("users", "id", "name", "app.orders")).Parse(
+		`SELECT {{idents .Columns}} FROM {{ident .Table}}`))
+
+	q, _ := execute(t, tmpl, query{Table: "app.orders", Columns: []string{"id", "name"}})
+	if q != "SELECT `id`, `name` FROM `app`.`orders`" {
+		t.Fatalf("query = %s", q)
+	}
+	// 语法合法但不在白名单中
+	if _, _, err := tmpl.Execute(query{Table: "users", Columns: []string{"password_hash"}}); !errors.Is(err, ErrUnsafe) {
+		t.Fatalf("error = %v, want ErrUnsafe", err)
+	}
+	if _, _, err := tmpl.Execute(query{Table: "orders", Columns: []string{"id"}}); !errors.Is(err, ErrUnsafe) {
+		t.Fatalf("unqualified name error = %v, want ErrUnsafe", err)
+	}
+}
+
+func TestNestedTemplates(t *testing.T) {
+	tmpl := Must(New("insert", WithDialect(Postgres)).Parse(`
+{{- define "row"}}({{binds .}}){{end -}}
+{{- $table := ident .Table -}}
+INSERT INTO {{$table}} ({{idents .Columns}}) VALUES {{range $i, $row := .Rows}}{{if $i}}, {{end}}{{template "row" $row}}{{end}}
+{{- with .Returning}} RETURNING {{ident .}}{{else}};{{end}}`))
+
+	q, args := execute(t, tmpl, map[string]any{
+		"Table":     "users",
+		"Columns":   []string{"name", "age"},
+		"Rows":      [][]any{{"张三", 25}, {"李四", 30}},
+		"Returning": "id",
+	})
+	if q != `INSERT INTO "users" ("name", "age") VALUES ($1, $2), ($3, $4) RETURNING "id"` {
+		t.Fatalf("query = %s", q)
+	}
+	if fmt.Sprint(args) != "[张三 25 李四 30]" {
+		t.Fatalf("args = %v", args)
+	}
+}
+
+func TestExplicitBindAndFuncs(t *testing.T) {
+	tmpl := Must(New("q", WithFuncs(map[string]any{
+		"upper": strings.ToUpper,
+		"ident": func(s string) string { return s }, // 不能覆盖本包的函数
+	})).Parse(`{{bind .A}} {{.B | upper}} {{ident .C}} {{.C | printf "%s"}}`))
+
+	q, args := execute(t, tmpl, map[string]string{"A": "a", "B": "b", "C": "c"})
+	if q != "? ? `c` ?" || fmt.Sprint(args) != "[a B c]" {
+		t.Fatalf("got %q %v", q, args)
+	}
+}
+
+func TestListErrors(t *testing.T) {
+	tmpl := Must(New("q").Parse(`id IN {{in .}}`))
+	if _, _, err := tmpl.Execute([]int{}); err == nil || !strings.Contains(err.Error(), "in: empty list") {
+		t.Fatalf("empty list error = %v", err)
+	}
+	if _, _, err := tmpl.Execute("1, 2"); err == nil || !strings.Contains(err.Error(), "expected a list") {
+		t.Fatalf("string error = %v", err)
+	}
+	if _, _, err := Must(New("q").Parse(`{{idents .}}`)).Execute([]any{"id", 1}); err == nil {
+		t.Fatal("idents accepted a non-string")
+	}
+}
+
+func TestConcurrentExecute(t *testing.T) {
+	tmpl := Must(New("q", WithDialect(Postgres)).Parse(`SELECT * FROM t WHERE a = {{.}} OR b = {{.}}`))
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			q, args, err := tmpl.Execute(i)
+			if err != nil || q != "SELECT * FROM t WHERE a = $1 OR b = $2" || len(args) != 2 || args[0] != i {
+				t.Errorf("Execute(%d) = %q, %v, %v", i, q, args, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// recordingDriver 检查占位符数量并记录收到的参数，证明结果可以直接交给database/sql
+type recordingDriver struct {
+	mu   sync.Mutex
+	args []driver.Value
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return &recordingConn{d: d}, nil }
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c *recordingConn) Prepare(q string) (driver.Stmt, error) {
+	return &recordingStmt{d: c.d, n: strings.Count(q, "?")}, nil
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type recordingStmt struct {
+	d *recordingDriver
+	n int
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return s.n }
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.args = args
+	return driver.RowsAffected(1), nil
+}
+func (s *recordingStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestDatabaseSQL(t *testing.T) {
+	d := &recordingDriver{}
+	sql.Register("sqltmpl-recording", d)
+	db, err := sql.Open("sqltmpl-recording", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tmpl := Must(New("update").Parse(`UPDATE {{ident .Table}} SET name = {{.Name}}, active = {{.Active}} WHERE id {{in .IDs}}`))
+	q, args := execute(t, tmpl, map[string]any{"Table": "users", "Name": "Robert'); DROP TABLE users;--", "Active": true, "IDs": []int64{7, 8}})
+	if _, err := db.Exec(q, args...); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(d.args) != "[Robert'); DROP TABLE users;-- true 7 8]" {
+		t.Fatalf("driver args = %v", d.args)
+	}
+}
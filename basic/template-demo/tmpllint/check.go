@@ -0,0 +1,324 @@
+package tmpllint
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// checker 检查一组一起解析的模板，类型为nil表示未知
+type checker struct {
+	l       *Linter
+	r       *report
+	trees   map[string]*parse.Tree
+	used    map[string]bool
+	checked map[checkKey]bool
+}
+
+// checkKey 同一个模板用同一个类型只检查一次，也避免递归的模板无限循环
+type checkKey struct {
+	name string
+	typ  reflect.Type
+}
+
+// scope 当前的dot和变量，进入if、range、with时复制变量
+type scope struct {
+	dot  reflect.Type
+	vars map[string]reflect.Type
+}
+
+func (s scope) with(dot reflect.Type) scope {
+	vars := make(map[string]reflect.Type, len(s.vars))
+	for k, v := range s.vars {
+		vars[k] = v
+	}
+	return scope{dot: dot, vars: vars}
+}
+
+func (l *Linter) check(trees map[string]*parse.Tree, r *report) *checker {
+	c := &checker{l: l, r: r, trees: trees, used: make(map[string]bool), checked: make(map[checkKey]bool)}
+	names := make([]string, 0, len(trees))
+	for name := range trees {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := trees[name]
+		c.tree(t, c.declared(t))
+	}
+	return c
+}
+
+// declared 注释或者WithData声明的类型
+func (c *checker) declared(t *parse.Tree) reflect.Type {
+	for _, n := range t.Root.Nodes {
+		cn, ok := n.(*parse.CommentNode)
+		if !ok {
+			continue
+		}
+		text := strings.TrimSuffix(strings.TrimPrefix(cn.Text, "/*"), "*/")
+		expr, ok := strings.CutPrefix(strings.TrimSpace(text), "lint:type ")
+		if !ok {
+			continue
+		}
+		typ, err := c.l.parseType(strings.TrimSpace(expr))
+		if err != nil {
+			c.r.add(t, cn, "%v", err)
+			return nil
+		}
+		return typ
+	}
+	return c.l.data[t.Name]
+}
+
+func (c *checker) tree(t *parse.Tree, dot reflect.Type) {
+	key := checkKey{t.Name, dot}
+	if c.checked[key] {
+		return
+	}
+	c.checked[key] = true
+	c.list(t, t.Root, scope{dot: dot, vars: map[string]reflect.Type{"$": dot}})
+}
+
+func (c *checker) list(t *parse.Tree, list *parse.ListNode, s scope) {
+	if list == nil {
+		return
+	}
+	for _, n := range list.Nodes {
+		c.node(t, n, s)
+	}
+}
+
+func (c *checker) node(t *parse.Tree, n parse.Node, s scope) {
+	switch n := n.(type) {
+	case *parse.ActionNode:
+		c.declare(n.Pipe, s, c.pipe(t, n.Pipe, s))
+	case *parse.IfNode:
+		inner := s.with(s.dot)
+		c.declare(n.Pipe, inner, c.pipe(t, n.Pipe, inner))
+		c.list(t, n.List, inner)
+		c.list(t, n.ElseList, inner)
+	case *parse.WithNode:
+		inner := s.with(s.dot)
+		typ := c.pipe(t, n.Pipe, inner)
+		c.declare(n.Pipe, inner, typ)
+		c.list(t, n.ElseList, inner)
+		inner.dot = typ
+		c.list(t, n.List, inner)
+	case *parse.RangeNode:
+		inner := s.with(s.dot)
+		typ := c.pipe(t, n.Pipe, inner)
+		key, elem, ok := rangeTypes(typ)
+		if !ok {
+			c.r.add(t, n, "range can't iterate over %s", typ)
+		}
+		switch len(n.Pipe.Decl) {
+		case 1:
+			inner.vars[n.Pipe.Decl[0].Ident[0]] = elem
+		case 2:
+			inner.vars[n.Pipe.Decl[0].Ident[0]] = key
+			inner.vars[n.Pipe.Decl[1].Ident[0]] = elem
+		}
+		c.list(t, n.ElseList, inner)
+		inner.dot = elem
+		c.list(t, n.List, inner)
+	case *parse.TemplateNode:
+		var arg reflect.Type
+		if n.Pipe != nil {
+			arg = c.pipe(t, n.Pipe, s)
+		}
+		c.used[n.Name] = true
+		callee, ok := c.trees[n.Name]
+		if !ok {
+			c.r.add(t, n, "no such template %q", n.Name)
+			return
+		}
+		typ := arg
+		if declared := c.declared(callee); declared != nil {
+			if arg != nil && !arg.AssignableTo(declared) {
+				c.r.add(t, n, "template %q expects %s, got %s", n.Name, declared, arg)
+			}
+			typ = declared
+		}
+		c.tree(callee, typ)
+	}
+}
+
+// declare 给变量赋值；用=重新赋值为不同类型时变量的类型变成未知
+func (c *checker) declare(p *parse.PipeNode, s scope, typ reflect.Type) {
+	for _, v := range p.Decl {
+		name := v.Ident[0]
+		if old, ok := s.vars[name]; p.IsAssign && ok && old != typ {
+			typ = nil
+		}
+		s.vars[name] = typ
+	}
+}
+
+// pipe 返回管道结果的类型，管道中前一个命令的结果是后一个命令的最后一个参数
+func (c *checker) pipe(t *parse.Tree, p *parse.PipeNode, s scope) reflect.Type {
+	var typ reflect.Type
+	for i, cmd := range p.Cmds {
+		typ = c.command(t, cmd, s, i > 0, typ)
+	}
+	return typ
+}
+
+func (c *checker) command(t *parse.Tree, cmd *parse.CommandNode, s scope, piped bool, prev reflect.Type) reflect.Type {
+	args := make([]reflect.Type, 0, len(cmd.Args))
+	for _, a := range cmd.Args[1:] {
+		args = append(args, c.arg(t, a, s))
+	}
+	if piped {
+		args = append(args, prev)
+	}
+
+	switch n := cmd.Args[0].(type) {
+	case *parse.FieldNode:
+		return c.chain(t, n, s.dot, n.Ident, len(args))
+	case *parse.ChainNode:
+		return c.chain(t, n, c.arg(t, n.Node, s), n.Field, len(args))
+	case *parse.VariableNode:
+		return c.chain(t, n, s.vars[n.Ident[0]], n.Ident[1:], len(args))
+	case *parse.IdentifierNode:
+		return c.call(t, n, args)
+	}
+	if len(args) > 0 {
+		c.r.add(t, cmd, "can't give argument to non-function %s", cmd.Args[0])
+	}
+	return c.arg(t, cmd.Args[0], s)
+}
+
+// arg 命令中不是第一个的参数
+func (c *checker) arg(t *parse.Tree, n parse.Node, s scope) reflect.Type {
+	switch n := n.(type) {
+	case *parse.DotNode:
+		return s.dot
+	case *parse.FieldNode:
+		return c.chain(t, n, s.dot, n.Ident, 0)
+	case *parse.ChainNode:
+		return c.chain(t, n, c.arg(t, n.Node, s), n.Field, 0)
+	case *parse.VariableNode:
+		return c.chain(t, n, s.vars[n.Ident[0]], n.Ident[1:], 0)
+	case *parse.IdentifierNode:
+		return c.call(t, n, nil)
+	case *parse.PipeNode:
+		return c.pipe(t, n, s)
+	case *parse.StringNode:
+		return stringType
+	case *parse.BoolNode:
+		return boolType
+	case *parse.NumberNode:
+		switch {
+		case n.IsInt:
+			return intType
+		case n.IsFloat:
+			return float64Type
+		}
+	}
+	return nil
+}
+
+// chain 逐级求值.A.B.C，最后一级得到命令的参数
+func (c *checker) chain(t *parse.Tree, n parse.Node, typ reflect.Type, idents []string, nargs int) reflect.Type {
+	if len(idents) == 0 && nargs > 0 {
+		c.r.add(t, n, "can't give argument to non-function %s", n)
+	}
+	for i, name := range idents {
+		if typ == nil {
+			return nil
+		}
+		if i < len(idents)-1 {
+			typ = c.field(t, n, typ, name, 0)
+		} else {
+			typ = c.field(t, n, typ, name, nargs)
+		}
+	}
+	return typ
+}
+
+// field 和text/template的evalField一样先找方法，再找结构体字段和map的键
+func (c *checker) field(t *parse.Tree, n parse.Node, typ reflect.Type, name string, nargs int) reflect.Type {
+	if typ.Kind() == reflect.Interface {
+		if m, ok := typ.MethodByName(name); ok {
+			return c.callable(t, n, name, m.Type, false, nargs)
+		}
+		return nil
+	}
+	ptr := typ
+	if ptr.Kind() != reflect.Pointer {
+		ptr = reflect.PointerTo(typ)
+	}
+	if m, ok := ptr.MethodByName(name); ok {
+		return c.callable(t, n, name, m.Type, true, nargs)
+	}
+
+	base := typ
+	for base.Kind() == reflect.Pointer {
+		base = base.Elem()
+	}
+	switch base.Kind() {
+	case reflect.Struct:
+		f, ok := base.FieldByName(name)
+		if !ok {
+			c.r.add(t, n, "can't evaluate field %s in type %s", name, typ)
+			return nil
+		}
+		if !f.IsExported() {
+			c.r.add(t, n, "%s is an unexported field of struct type %s", name, typ)
+			return nil
+		}
+		if nargs > 0 {
+			c.r.add(t, n, "%s has arguments but cannot be invoked as function", name)
+		}
+		return f.Type
+	case reflect.Map:
+		if !stringType.AssignableTo(base.Key()) {
+			c.r.add(t, n, "can't evaluate field %s in type %s", name, typ)
+			return nil
+		}
+		if nargs > 0 {
+			c.r.add(t, n, "%s is not a method but has arguments", name)
+		}
+		return base.Elem()
+	case reflect.Interface:
+		return nil
+	}
+	c.r.add(t, n, "can't evaluate field %s in type %s", name, typ)
+	return nil
+}
+
+// call 调用函数，内置函数按参数类型推断结果类型
+func (c *checker) call(t *parse.Tree, n *parse.IdentifierNode, args []reflect.Type) reflect.Type {
+	if fn, ok := builtins[n.Ident]; ok {
+		return fn(args)
+	}
+	ft, ok := c.l.funcs[n.Ident]
+	if !ok {
+		c.r.add(t, n, "function %q not defined", n.Ident)
+		return nil
+	}
+	if ft == nil || ft.Kind() != reflect.Func {
+		return nil
+	}
+	return c.callable(t, n, n.Ident, ft, false, len(args))
+}
+
+// callable 检查参数和返回值的个数，返回第一个返回值的类型；method为true时ft的第一个参数是接收者
+func (c *checker) callable(t *parse.Tree, n parse.Node, name string, ft reflect.Type, method bool, nargs int) reflect.Type {
+	in := ft.NumIn()
+	if method {
+		in--
+	}
+	if ft.IsVariadic() {
+		if nargs < in-1 {
+			c.r.add(t, n, "wrong number of args for %s: want at least %d got %d", name, in-1, nargs)
+		}
+	} else if nargs != in {
+		c.r.add(t, n, "wrong number of args for %s: want %d got %d", name, in, nargs)
+	}
+	return result(ft, func(out int) {
+		c.r.add(t, n, "can't call method/function %q with %d results", name, out)
+	})
+}
@@ -0,0 +1,116 @@
+package tmpllint
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type order struct {
+	ID     int
+	Buyer  *user
+	Items  []item
+	Meta   map[string]string
+	Counts map[int]int
+	Extra  any
+	Stream chan item
+	note   string
+}
+
+type item struct {
+	SKU   string
+	Price float64
+}
+
+func (o order) Total() float64                           { return 0 }
+func (o order) Item(i int) (item, error)                 { return item{}, nil }
+func (o order) Pair() (int, int)                         { return 0, 0 }
+func (o order) All() func(yield func(string, item) bool) { return nil }
+func (o order) Discount(code string, n ...int) int       { return 0 }
+
+func TestCheck(t *testing.T) {
+	l := New(
+		WithFuncs(map[string]any{
+			"upper":  strings.ToUpper,
+			"join":   strings.Join,
+			"printf": fmt.Sprintf,
+			"pair":   func() (int, int) { return 0, 0 },
+			"now":    "not a function",
+		}),
+		WithType("Item", item{}),
+		WithData("page.txt", order{}),
+	)
+	for _, tc := range []struct {
+		text string
+		want []string
+	}{
+		// 字段、方法和map
+		{`{{.ID}}{{.Buyer.Name}}{{.Buyer.Greeting "hi"}}{{.Total}}{{.Meta.anything}}{{.Extra.Whatever.X}}`, nil},
+		{`{{.Buyer.Nmae}}`, []string{"can't evaluate field Nmae in type *tmpllint.user"}},
+		{`{{.note}}`, []string{"note is an unexported field of struct type tmpllint.order"}},
+		{`{{.Counts.a}}`, []string{"can't evaluate field a in type map[int]int"}},
+		{`{{.ID.Value}}`, []string{"can't evaluate field Value in type int"}},
+		{`{{.ID 1}}`, []string{"ID has arguments but cannot be invoked as function"}},
+		{`{{.Meta.k 1}}`, []string{"k is not a method but has arguments"}},
+		{`{{.Buyer.Greeting}}`, []string{"wrong number of args for Greeting: want 1 got 0"}},
+		{`{{.Item 1}}{{(.Item 0).SKU}}{{(.Item 0).Sku}}`, []string{"can't evaluate field Sku in type tmpllint.item"}},
+		{`{{.Discount "a"}}{{.Discount "a" 1 2}}{{.Discount}}`, []string{"wrong number of args for Discount: want at least 1 got 0"}},
+		{`{{.Pair}}`, []string{`can't call method/function "Pair" with 2 results`}},
+		// 函数和管道
+		{`{{upper .Buyer.Name}}{{.Buyer.Name | upper}}{{join .Buyer.Tags ","}}{{printf "%d" .ID}}{{now}}`, nil},
+		{`{{lower .Buyer.Name}}`, []string{`function "lower" not defined`}},
+		{`{{upper}}{{"a" | upper | upper 1}}`, []string{
+			"wrong number of args for upper: want 1 got 0",
+			"wrong number of args for upper: want 1 got 2",
+		}},
+		{`{{pair}}`, []string{`can't call method/function "pair" with 2 results`}},
+		{`{{"a" 1}}{{. 1}}{{$ 1}}`, []string{
+			`can't give argument to non-function "a"`,
+			"can't give argument to non-function .",
+			"can't give argument to non-function $",
+		}},
+		// 内置函数的结果类型
+		{`{{(index .Items 0).SKU}}{{(index .Meta "k").X}}{{(len .Items).X}}{{(slice .Items 1).X}}`, []string{
+			"can't evaluate field X in type string",
+			"can't evaluate field X in type int",
+			"can't evaluate field X in type []tmpllint.item",
+		}},
+		{`{{(and .Buyer .Buyer).Nmae}}{{(or .ID .Buyer).Nmae}}{{(eq .ID 1).X}}`, []string{
+			"can't evaluate field Nmae in type *tmpllint.user",
+			"can't evaluate field X in type bool",
+		}},
+		// range、with、if和变量
+		{`{{range .Items}}{{.SKU}}{{.Sku}}{{else}}{{.ID}}{{end}}`, []string{"can't evaluate field Sku in type tmpllint.item"}},
+		{`{{range $i, $it := .Items}}{{$i.X}}{{$it.Price}}{{$.ID}}{{end}}`, []string{"can't evaluate field X in type int"}},
+		{`{{range $k, $v := .Meta}}{{$k.X}}{{end}}{{range .Stream}}{{.SKU}}{{end}}{{range $k, $v := .All}}{{$v.Sku}}{{end}}`, []string{
+			"can't evaluate field X in type string",
+			"can't evaluate field Sku in type tmpllint.item",
+		}},
+		{`{{range 3}}{{.X}}{{end}}{{range .Extra}}{{.X}}{{end}}`, []string{"can't evaluate field X in type int"}},
+		{`{{range .Total}}{{end}}`, []string{"range can't iterate over float64"}},
+		{`{{with .Buyer}}{{.Name}}{{.ID}}{{else}}{{.ID}}{{end}}`, []string{"can't evaluate field ID in type *tmpllint.user"}},
+		{`{{with $b := .Buyer}}{{$b.Nmae}}{{end}}`, []string{"can't evaluate field Nmae in type *tmpllint.user"}},
+		{`{{if $n := .ID}}{{$n.X}}{{else}}{{$n.X}}{{end}}`, []string{
+			"can't evaluate field X in type int",
+			"can't evaluate field X in type int",
+		}},
+		{`{{$x := .Buyer}}{{$x.Nmae}}{{$x = .ID}}{{$x.Anything}}`, []string{"can't evaluate field Nmae in type *tmpllint.user"}},
+		// 模板调用
+		{`{{define "buyer"}}{{.Name}}{{.Nmae}}{{end}}{{template "buyer" .Buyer}}`, []string{"can't evaluate field Nmae in type *tmpllint.user"}},
+		{`{{define "any"}}{{.Anything}}{{end}}{{template "any"}}{{template "any" .}}`, []string{"can't evaluate field Anything in type tmpllint.order"}},
+		{`{{template "missing" .}}`, []string{`no such template "missing"`}},
+		{`{{define "items"}}{{/* lint:type []Item */}}{{range .}}{{.SKU}}{{end}}{{end}}{{template "items" .Items}}{{template "items" .Buyer}}`, []string{
+			`template "items" expects []tmpllint.item, got *tmpllint.user`,
+		}},
+		// 递归的模板只检查一次
+		{`{{define "tree"}}{{.X}}{{template "tree" .}}{{end}}{{template "tree" .Buyer}}`, []string{"can't evaluate field X in type *tmpllint.user"}},
+	} {
+		var got []string
+		for _, issue := range l.Lint("page.txt", tc.text) {
+			got = append(got, issue.Msg)
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("%s:\ngot  %q\nwant %q", tc.text, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Package tmpllint 静态检查text/template和html/template模板，在执行之前发现{{.User.Nmae}}这样的错误。
+//
+// 检查内容：
+//   - 字段和方法链：按数据类型逐级检查字段、方法和map，以及方法的参数个数和返回值个数
+//   - range、with、变量和{{template "name" .X}}带来的数据类型变化
+//   - 没有定义的函数和模板
+//   - 没有被使用的define
+//
+// 模板的数据类型有两个来源，注释优先：
+//
+//	{{/* lint:type User */}}                      模板或者define中的注释，类型名用WithType注册，可以写*User、[]User、map[string]User
+//	tmpllint.WithData("users/show.html", User{})  注册模板的数据类型
+//
+// 没有声明类型的define在每个{{template}}调用处按传入的类型检查。没有类型的模板只检查函数、模板和define。
+// 数据是interface类型（例如any）时运行时的类型无法确定，不再往下检查。
+//
+// 模板执行时指针接收者的方法只有在值可以取地址时才能调用，这里总是认为可以调用。
+package tmpllint
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// Issue 一个问题，位置格式和模板执行错误中的相同，解析错误没有列
+type Issue struct {
+	Template  string // 文件名
+	Line, Col int
+	Msg       string
+}
+
+func (i Issue) String() string {
+	switch {
+	case i.Line == 0:
+		return fmt.Sprintf("%s: %s", i.Template, i.Msg)
+	case i.Col == 0:
+		return fmt.Sprintf("%s:%d: %s", i.Template, i.Line, i.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", i.Template, i.Line, i.Col, i.Msg)
+}
+
+// Linter 模板检查器
+type Linter struct {
+	funcs map[string]reflect.Type
+	types map[string]reflect.Type
+	data  map[string]reflect.Type
+}
+
+// Option 检查器选项
+type Option func(*Linter)
+
+// WithFuncs 模板中可以使用的函数，和传给Funcs的相同，用于检查函数名、参数个数和返回值类型
+func WithFuncs(funcs map[string]any) Option {
+	return func(l *Linter) {
+		for name, fn := range funcs {
+			l.funcs[name] = reflect.TypeOf(fn)
+		}
+	}
+}
+
+// WithType 注册注释{{/* lint:type name */}}中可以使用的类型名，v是该类型的值
+func WithType(name string, v any) Option {
+	return func(l *Linter) { l.types[name] = reflect.TypeOf(v) }
+}
+
+// WithData 注册模板执行时的数据类型，v是该类型的值，name是文件名或者define的名字
+func WithData(name string, v any) Option {
+	return func(l *Linter) { l.data[name] = reflect.TypeOf(v) }
+}
+
+// New 创建检查器
+func New(opts ...Option) *Linter {
+	l := &Linter{
+		funcs: make(map[string]reflect.Type),
+		types: make(map[string]reflect.Type),
+		data:  make(map[string]reflect.Type),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Lint 检查一个模板文件和其中的define
+func (l *Linter) Lint(name, text string) []Issue {
+	var r report
+	trees := make(map[string]*parse.Tree)
+	if !r.parse(trees, name, text) {
+		return r.sorted()
+	}
+	c := l.check(trees, &r)
+	for _, t := range trees {
+		if isDefine(t) && !c.used[t.Name] {
+			r.add(t, t.Root, "template %q is defined but never used", t.Name)
+		}
+	}
+	return r.sorted()
+}
+
+// LintFS 按registry的目录约定检查fsys中的全部模板：每个页面和同一种引擎的layouts、partials一起检查。
+// layouts和partials中的define只要被任何一个页面使用就不算未使用
+func (l *Linter) LintFS(fsys fs.FS) ([]Issue, error) {
+	var shared, pages []string
+	files := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		files[p] = string(data)
+		if isShared(p) {
+			shared = append(shared, p)
+		} else {
+			pages = append(pages, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var r report
+	sharedTrees := make(map[string]*parse.Tree)
+	used := make(map[string]bool)
+	for _, page := range pages {
+		trees := make(map[string]*parse.Tree)
+		ok := true
+		for _, name := range shared {
+			if isHTML(name) == isHTML(page) {
+				ok = r.parse(trees, name, files[name]) && ok
+			}
+		}
+		if !r.parse(trees, page, files[page]) || !ok {
+			continue
+		}
+		c := l.check(trees, &r)
+		for _, t := range trees {
+			if !isDefine(t) {
+				continue
+			}
+			if t.ParseName == page {
+				if !c.used[t.Name] {
+					r.add(t, t.Root, "template %q is defined but never used", t.Name)
+				}
+			} else {
+				sharedTrees[t.ParseName+"\x00"+t.Name] = t
+				used[t.ParseName+"\x00"+t.Name] = used[t.ParseName+"\x00"+t.Name] || c.used[t.Name]
+			}
+		}
+	}
+	for key, t := range sharedTrees {
+		if !used[key] {
+			r.add(t, t.Root, "template %q is defined but never used", t.Name)
+		}
+	}
+	return r.sorted(), nil
+}
+
+// isDefine define或者block定义的模板，而不是文件本身
+func isDefine(t *parse.Tree) bool {
+	return t.Name != t.ParseName
+}
+
+// isShared和isHTML与registry的目录约定相同
+func isShared(name string) bool {
+	dir, _, _ := strings.Cut(name, "/")
+	return strings.Contains(name, "/") && (dir == "layouts" || dir == "partials")
+}
+
+func isHTML(name string) bool {
+	return strings.Contains(path.Base(name), ".html")
+}
+
+// report 去重后的问题
+type report struct {
+	seen   map[Issue]bool
+	issues []Issue
+}
+
+func (r *report) add(t *parse.Tree, n parse.Node, format string, args ...any) {
+	location, _ := t.ErrorContext(n)
+	issue := Issue{Template: t.ParseName, Msg: fmt.Sprintf(format, args...)}
+	// location是"文件名:行:列"，文件名中可能有冒号，从右边取
+	rest, col, _ := cutLast(location, ":")
+	_, line, _ := cutLast(rest, ":")
+	fmt.Sscan(line, &issue.Line)
+	fmt.Sscan(col, &issue.Col)
+	r.addIssue(issue)
+}
+
+func (r *report) addIssue(issue Issue) {
+	if r.seen == nil {
+		r.seen = make(map[Issue]bool)
+	}
+	if !r.seen[issue] {
+		r.seen[issue] = true
+		r.issues = append(r.issues, issue)
+	}
+}
+
+// parse 解析一个文件，把其中的模板加入trees，后解析的define覆盖先解析的（和template.AddParseTree相同）
+func (r *report) parse(trees map[string]*parse.Tree, name, text string) bool {
+	t := parse.New(name)
+	t.Mode = parse.ParseComments | parse.SkipFuncCheck
+	set := make(map[string]*parse.Tree)
+	if _, err := t.Parse(text, "", "", set); err != nil {
+		r.addIssue(parseIssue(name, err))
+		return false
+	}
+	for n, tree := range set {
+		if old := trees[n]; old != nil && parse.IsEmptyTree(tree.Root) && !parse.IsEmptyTree(old.Root) {
+			continue
+		}
+		trees[n] = tree
+	}
+	return true
+}
+
+// parseIssue 解析错误的格式是"template: 文件名:行: 消息"，没有列
+func parseIssue(name string, err error) Issue {
+	issue := Issue{Template: name, Msg: err.Error()}
+	rest, ok := strings.CutPrefix(err.Error(), "template: "+name+":")
+	if !ok {
+		return issue
+	}
+	line, msg, ok := strings.Cut(rest, ": ")
+	if _, err := fmt.Sscan(line, &issue.Line); ok && err == nil {
+		issue.Msg = msg
+	}
+	return issue
+}
+
+func (r *report) sorted() []Issue {
+	sort.Slice(r.issues, func(i, j int) bool {
+		a, b := r.issues[i], r.issues[j]
+		if a.Template != b.Template {
+			return a.Template < b.Template
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Col != b.Col {
+			return a.Col < b.Col
+		}
+		return a.Msg < b.Msg
+	})
+	return r.issues
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
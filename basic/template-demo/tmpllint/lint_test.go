@@ -0,0 +1,125 @@
+package tmpllint
+
+import (
+	"embed"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+//go:embed testdata/site
+var siteFS embed.FS
+
+type user struct {
+	Name  string
+	Email string
+	Tags  []string
+}
+
+func (u *user) Greeting(prefix string) string { return prefix + u.Name }
+
+func issueStrings(issues []Issue) []string {
+	out := make([]string, len(issues))
+	for i, issue := range issues {
+		out[i] = issue.String()
+	}
+	return out
+}
+
+func TestLintFS(t *testing.T) {
+	site, err := fs.Sub(siteFS, "testdata/site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(
+		WithFuncs(map[string]any{"upper": strings.ToUpper}),
+		WithType("User", user{}),
+		WithData("users/show.html", user{}),
+	)
+	issues, err := l.LintFS(site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"emails/broken.txt:3: unexpected EOF",
+		`partials/card.html:1:59: can't evaluate field Emial in type *tmpllint.user`,
+		`partials/card.html:2:21: template "old-card" is defined but never used`,
+		"partials/footer.txt:2:2: can't evaluate field Team in type tmpllint.user",
+		`users/show.html:3:31: template "card" expects *tmpllint.user, got tmpllint.user`,
+		"users/show.html:3:58: can't evaluate field Label in type string",
+		`users/show.html:4:19: template "unused" is defined but never used`,
+	}
+	got := issueStrings(issues)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintFSSharedDefineUsedByAnyPage(t *testing.T) {
+	fsys := fstest.MapFS{
+		"partials/a.txt":  {Data: []byte(`{{define "a"}}a{{end}}{{define "b"}}b{{end}}`)},
+		"partials/c.html": {Data: []byte(`{{define "c"}}c{{end}}`)},
+		"one.txt":         {Data: []byte(`{{template "a"}}`)},
+		"two.txt":         {Data: []byte(`{{template "b"}}`)},
+		// html页面看不到txt的partials
+		"three.html":        {Data: []byte(`{{template "c"}}{{template "a"}}`)},
+		".hidden/x.txt":     {Data: []byte(`{{define "x"}}{{end}}`)},
+		"partials/.swp.txt": {Data: []byte(`{{`)},
+	}
+	issues, err := New().LintFS(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `three.html:1:27: no such template "a"`
+	if got := strings.Join(issueStrings(issues), "\n"); got != want {
+		t.Errorf("issues:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLintParseError(t *testing.T) {
+	issues := New().Lint("page.txt", "ok\n{{.Name")
+	if len(issues) != 1 {
+		t.Fatalf("issues = %v", issues)
+	}
+	if got := issues[0]; got.Template != "page.txt" || got.Line != 2 || got.Msg != "unclosed action" {
+		t.Errorf("issue = %+v", got)
+	}
+}
+
+func TestLintUnusedDefine(t *testing.T) {
+	text := `{{define "used"}}{{end}}{{define "unused"}}{{end}}{{template "used"}}{{block "b" .}}{{end}}`
+	want := `page.txt:1:43: template "unused" is defined but never used`
+	if got := strings.Join(issueStrings(New().Lint("page.txt", text)), "\n"); got != want {
+		t.Errorf("issues:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLintAnnotation(t *testing.T) {
+	l := New(WithType("User", user{}))
+	for _, tc := range []struct {
+		text string
+		want string
+	}{
+		{`{{/* lint:type User */}}{{.Nmae}}`, "page.txt:1:26: can't evaluate field Nmae in type tmpllint.user"},
+		{`{{/* lint:type []*User */}}{{range .}}{{.Nmae}}{{end}}`, "page.txt:1:40: can't evaluate field Nmae in type *tmpllint.user"},
+		{`{{/* lint:type map[string]User */}}{{.alice.Nmae}}`, "page.txt:1:43: can't evaluate field Nmae in type tmpllint.user"},
+		{`{{- /* lint:type string */ -}}{{.Name}}`, "page.txt:1:32: can't evaluate field Name in type string"},
+		{`{{/* lint:type Admin */}}{{.Name}}`, `page.txt:1:2: lint:type: unknown type "Admin", register it with WithType`},
+		{`{{/* 普通注释 */}}{{.Nmae}}`, ""},
+	} {
+		if got := strings.Join(issueStrings(l.Lint("page.txt", tc.text)), "\n"); got != tc.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestLintDataOverriddenByAnnotation(t *testing.T) {
+	l := New(WithType("User", user{}), WithData("page.txt", 0))
+	if issues := l.Lint("page.txt", `{{/* lint:type User */}}{{.Name}}`); len(issues) != 0 {
+		t.Errorf("issues = %v", issues)
+	}
+	if issues := l.Lint("page.txt", `{{.Name}}`); len(issues) != 1 {
+		t.Errorf("issues = %v", issues)
+	}
+}
@@ -0,0 +1,183 @@
+package tmpllint
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	stringType  = reflect.TypeOf("")
+	boolType    = reflect.TypeOf(false)
+	intType     = reflect.TypeOf(0)
+	float64Type = reflect.TypeOf(0.0)
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	anyType     = reflect.TypeOf((*any)(nil)).Elem()
+)
+
+// basicTypes 注释中不用注册就可以使用的类型
+var basicTypes = map[string]reflect.Type{
+	"string":  stringType,
+	"bool":    boolType,
+	"int":     intType,
+	"int64":   reflect.TypeOf(int64(0)),
+	"float64": float64Type,
+	"any":     anyType,
+}
+
+// builtins 模板内置函数，返回值类型由参数类型决定，nil表示未知
+var builtins = map[string]func(args []reflect.Type) reflect.Type{
+	"and":      sameType,
+	"or":       sameType,
+	"not":      constType(boolType),
+	"eq":       constType(boolType),
+	"ne":       constType(boolType),
+	"lt":       constType(boolType),
+	"le":       constType(boolType),
+	"gt":       constType(boolType),
+	"ge":       constType(boolType),
+	"len":      constType(intType),
+	"print":    constType(stringType),
+	"printf":   constType(stringType),
+	"println":  constType(stringType),
+	"html":     constType(stringType),
+	"js":       constType(stringType),
+	"urlquery": constType(stringType),
+	"index":    indexType,
+	"slice":    firstType,
+	"call":     callType,
+}
+
+func constType(t reflect.Type) func([]reflect.Type) reflect.Type {
+	return func([]reflect.Type) reflect.Type { return t }
+}
+
+// sameType and和or返回其中一个参数，所有参数类型相同时结果也是这个类型
+func sameType(args []reflect.Type) reflect.Type {
+	if len(args) == 0 {
+		return nil
+	}
+	for _, a := range args[1:] {
+		if a != args[0] {
+			return nil
+		}
+	}
+	return args[0]
+}
+
+func firstType(args []reflect.Type) reflect.Type {
+	if len(args) == 0 {
+		return nil
+	}
+	return args[0]
+}
+
+// indexType index x 1 2是x[1][2]
+func indexType(args []reflect.Type) reflect.Type {
+	typ := firstType(args)
+	for range args[1:] {
+		for typ != nil && typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		if typ == nil {
+			return nil
+		}
+		switch typ.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			typ = typ.Elem()
+		case reflect.String:
+			typ = reflect.TypeOf(byte(0))
+		default:
+			return nil
+		}
+	}
+	return typ
+}
+
+func callType(args []reflect.Type) reflect.Type {
+	fn := firstType(args)
+	if fn == nil || fn.Kind() != reflect.Func {
+		return nil
+	}
+	return result(fn, func(int) {})
+}
+
+// result 函数和方法只能有一个返回值，或者第二个返回值是error
+func result(ft reflect.Type, bad func(out int)) reflect.Type {
+	switch {
+	case ft.NumOut() == 1:
+		return ft.Out(0)
+	case ft.NumOut() == 2 && ft.Out(1) == errorType:
+		return ft.Out(0)
+	}
+	bad(ft.NumOut())
+	return nil
+}
+
+// rangeTypes range的键（或者下标）和元素的类型，ok为false时不能range
+func rangeTypes(typ reflect.Type) (key, elem reflect.Type, ok bool) {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil {
+		return nil, nil, true
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		return intType, typ.Elem(), true
+	case reflect.Map:
+		return typ.Key(), typ.Elem(), true
+	case reflect.Chan:
+		return nil, typ.Elem(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return nil, typ, true
+	case reflect.Interface:
+		return nil, nil, true
+	case reflect.Func:
+		// iter.Seq和iter.Seq2
+		if typ.NumIn() == 1 && typ.NumOut() == 0 {
+			yield := typ.In(0)
+			if yield.Kind() == reflect.Func && yield.NumOut() == 1 && yield.Out(0) == boolType {
+				switch yield.NumIn() {
+				case 1:
+					return nil, yield.In(0), true
+				case 2:
+					return yield.In(0), yield.In(1), true
+				}
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// parseType 解析注释中的类型：注册的类型名、基本类型，以及*T、[]T、map[string]T
+func (l *Linter) parseType(expr string) (reflect.Type, error) {
+	switch {
+	case strings.HasPrefix(expr, "*"):
+		t, err := l.parseType(expr[1:])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.PointerTo(t), nil
+	case strings.HasPrefix(expr, "[]"):
+		t, err := l.parseType(expr[2:])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(t), nil
+	case strings.HasPrefix(expr, "map[string]"):
+		t, err := l.parseType(expr[len("map[string]"):])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(stringType, t), nil
+	}
+	if t, ok := l.types[expr]; ok {
+		return t, nil
+	}
+	if t, ok := basicTypes[expr]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("lint:type: unknown type %q, register it with WithType", expr)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidationChain(t *testing.T) {
+	chain := newValidationChain()
+	tests := []struct {
+		order   Order
+		wantErr string
+	}{
+		{Order{CustomerID: "CUSTOMER-123", Amount: 100}, ""},
+		{Order{CustomerID: "CUSTOMER-123", Amount: 0}, "out of range"},
+		{Order{CustomerID: "CUSTOMER-123", Amount: 60000}, "out of range"},
+		{Order{Amount: 100}, "required"},
+		{Order{CustomerID: "CUSTOMER-000", Amount: 100}, "blocked"},
+		{Order{CustomerID: "CUSTOMER-456", Amount: 20000}, "verified"},
+		{Order{CustomerID: "CUSTOMER-123", Amount: 20000}, ""},
+	}
+	for _, tt := range tests {
+		o := tt.order
+		err := chain.handle(&o)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("handle(%+v) error: %v", tt.order, err)
+			} else if o.Status != "VALIDATED" {
+				t.Errorf("handle(%+v) status = %q, want VALIDATED", tt.order, o.Status)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("handle(%+v) error = %v, want containing %q", tt.order, err, tt.wantErr)
+		}
+		if o.Status != "" {
+			t.Errorf("rejected order status = %q, want unchanged", o.Status)
+		}
+	}
+}
+
+// countingHandler 记录被调用的次数
+type countingHandler struct {
+	baseHandler
+	calls int
+}
+
+func (h *countingHandler) handle(o *Order) error {
+	h.calls++
+	return h.handleNext(o)
+}
+
+func TestChainStopsAtFirstFailure(t *testing.T) {
+	first := &countingHandler{}
+	last := &countingHandler{}
+	first.setNext(&amountHandler{min: 1, max: 10}).setNext(last)
+
+	if err := first.handle(&Order{Amount: 100}); err == nil {
+		t.Fatal("expected error")
+	}
+	if first.calls != 1 || last.calls != 0 {
+		t.Fatalf("calls = (%d, %d), want (1, 0)", first.calls, last.calls)
+	}
+
+	if err := first.handle(&Order{Amount: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if last.calls != 1 {
+		t.Fatalf("last.calls = %d, want 1", last.calls)
+	}
+}
@@ -0,0 +1,3 @@
+module chain-of-responsibility-pattern
+
+go 1.21
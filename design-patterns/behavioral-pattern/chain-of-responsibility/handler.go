@@ -0,0 +1,38 @@
+package main
+
+import "time"
+
+// Order 订单，字段与mock-demo中的Order相同
+type Order struct {
+	ID         string
+	CustomerID string
+	Amount     float64
+	CreatedAt  time.Time
+	Status     string
+}
+
+// orderHandler 处理者接口
+// 每个处理者只负责一项检查，检查通过后交给下一个处理者
+type orderHandler interface {
+	setNext(next orderHandler) orderHandler
+	handle(o *Order) error
+}
+
+// baseHandler 保存下一个处理者，具体处理者嵌入它复用链接逻辑
+type baseHandler struct {
+	next orderHandler
+}
+
+// setNext 返回next，可以写成 a.setNext(b).setNext(c)
+func (b *baseHandler) setNext(next orderHandler) orderHandler {
+	b.next = next
+	return next
+}
+
+// handleNext 交给下一个处理者，已经是链尾时返回nil
+func (b *baseHandler) handleNext(o *Order) error {
+	if b.next == nil {
+		return nil
+	}
+	return b.next.handle(o)
+}
@@ -0,0 +1,56 @@
+package main
+
+import "fmt"
+
+// amountHandler 检查订单金额范围
+type amountHandler struct {
+	baseHandler
+	min, max float64
+}
+
+func (h *amountHandler) handle(o *Order) error {
+	if o.Amount < h.min || o.Amount > h.max {
+		return fmt.Errorf("amount %.2f out of range [%.2f, %.2f]", o.Amount, h.min, h.max)
+	}
+	return h.handleNext(o)
+}
+
+// customerHandler 校验客户，对应ModernOrderService中的CustomerValidator
+type customerHandler struct {
+	baseHandler
+	blocked map[string]bool
+}
+
+func (h *customerHandler) handle(o *Order) error {
+	if o.CustomerID == "" {
+		return fmt.Errorf("customer id is required")
+	}
+	if h.blocked[o.CustomerID] {
+		return fmt.Errorf("customer %s is blocked", o.CustomerID)
+	}
+	return h.handleNext(o)
+}
+
+// riskHandler 风控检查，超过limit的订单只允许已实名的客户提交
+type riskHandler struct {
+	baseHandler
+	limit    float64
+	verified map[string]bool
+}
+
+func (h *riskHandler) handle(o *Order) error {
+	if o.Amount > h.limit && !h.verified[o.CustomerID] {
+		return fmt.Errorf("amount %.2f requires verified customer", o.Amount)
+	}
+	return h.handleNext(o)
+}
+
+// statusHandler 链尾，所有检查通过后把订单标记为已校验
+type statusHandler struct {
+	baseHandler
+}
+
+func (h *statusHandler) handle(o *Order) error {
+	o.Status = "VALIDATED"
+	return h.handleNext(o)
+}
@@ -0,0 +1,33 @@
+package main
+
+import "fmt"
+
+// newValidationChain 组装校验链：金额 -> 客户 -> 风控 -> 标记状态
+func newValidationChain() orderHandler {
+	chain := &amountHandler{min: 0.01, max: 50000}
+	chain.setNext(&customerHandler{blocked: map[string]bool{"CUSTOMER-000": true}}).
+		setNext(&riskHandler{limit: 10000, verified: map[string]bool{"CUSTOMER-123": true}}).
+		setNext(&statusHandler{})
+	return chain
+}
+
+func main() {
+	fmt.Println("责任链模式示例")
+	fmt.Println("==============")
+
+	chain := newValidationChain()
+	orders := []*Order{
+		{ID: "ORDER-1", CustomerID: "CUSTOMER-123", Amount: 100},
+		{ID: "ORDER-2", CustomerID: "CUSTOMER-123", Amount: 0},
+		{ID: "ORDER-3", CustomerID: "CUSTOMER-000", Amount: 100},
+		{ID: "ORDER-4", CustomerID: "CUSTOMER-456", Amount: 20000},
+		{ID: "ORDER-5", CustomerID: "CUSTOMER-123", Amount: 20000},
+	}
+	for _, o := range orders {
+		if err := chain.handle(o); err != nil {
+			fmt.Printf("%s 校验失败: %v\n", o.ID, err)
+			continue
+		}
+		fmt.Printf("%s 校验通过, 状态: %s\n", o.ID, o.Status)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// cart 接收者，命令最终调用它的方法完成实际工作
+type cart struct {
+	items    map[string]int // SKU -> 数量
+	prices   map[string]float64
+	discount float64
+}
+
+func newCart(prices map[string]float64) *cart {
+	return &cart{items: make(map[string]int), prices: prices}
+}
+
+func (c *cart) add(sku string, qty int) error {
+	if _, ok := c.prices[sku]; !ok {
+		return fmt.Errorf("unknown sku %q", sku)
+	}
+	if qty <= 0 {
+		return fmt.Errorf("invalid quantity %d", qty)
+	}
+	c.items[sku] += qty
+	return nil
+}
+
+// remove 删除qty件商品，数量不足时返回错误
+func (c *cart) remove(sku string, qty int) error {
+	if c.items[sku] < qty {
+		return fmt.Errorf("only %d of %s in cart", c.items[sku], sku)
+	}
+	c.items[sku] -= qty
+	if c.items[sku] == 0 {
+		delete(c.items, sku)
+	}
+	return nil
+}
+
+func (c *cart) total() float64 {
+	sum := 0.0
+	for sku, qty := range c.items {
+		sum += c.prices[sku] * float64(qty)
+	}
+	if sum < c.discount {
+		return 0
+	}
+	return sum - c.discount
+}
+
+func (c *cart) String() string {
+	skus := make([]string, 0, len(c.items))
+	for sku := range c.items {
+		skus = append(skus, sku)
+	}
+	sort.Strings(skus)
+
+	lines := make([]string, len(skus))
+	for i, sku := range skus {
+		lines[i] = fmt.Sprintf("%s x%d", sku, c.items[sku])
+	}
+	return fmt.Sprintf("[%s] 优惠: %.2f 合计: %.2f", strings.Join(lines, " "), c.discount, c.total())
+}
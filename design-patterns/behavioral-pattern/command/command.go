@@ -0,0 +1,8 @@
+package main
+
+// command 命令接口
+// 每个命令封装一次对购物车的修改，并且知道如何撤销它
+type command interface {
+	execute() error
+	undo()
+}
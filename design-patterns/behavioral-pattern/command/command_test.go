@@ -0,0 +1,94 @@
+package main
+
+import "testing"
+
+func newTestCart() *cart {
+	return newCart(map[string]float64{"BOOK": 45, "PEN": 5})
+}
+
+func TestUndoRedo(t *testing.T) {
+	c := newTestCart()
+	e := &cartEditor{}
+
+	cmds := []command{
+		&addItemCommand{cart: c, sku: "BOOK", qty: 2},
+		&addItemCommand{cart: c, sku: "PEN", qty: 3},
+		&applyCouponCommand{cart: c, amount: 20},
+		&removeItemCommand{cart: c, sku: "PEN", qty: 1},
+	}
+	totals := []float64{90, 105, 85, 80}
+	for i, cmd := range cmds {
+		if err := e.run(cmd); err != nil {
+			t.Fatalf("command %d: %v", i, err)
+		}
+		if got := c.total(); got != totals[i] {
+			t.Fatalf("total after command %d = %v, want %v", i, got, totals[i])
+		}
+	}
+
+	// 依次撤销，合计回到每一步之前的值
+	for i := len(cmds) - 1; i > 0; i-- {
+		if err := e.undo(); err != nil {
+			t.Fatal(err)
+		}
+		if got := c.total(); got != totals[i-1] {
+			t.Fatalf("total after undoing command %d = %v, want %v", i, got, totals[i-1])
+		}
+	}
+	e.undo()
+	if len(c.items) != 0 || c.total() != 0 {
+		t.Fatalf("cart not empty after undoing everything: %v", c)
+	}
+	if err := e.undo(); err == nil {
+		t.Fatal("undo with empty history should fail")
+	}
+
+	if err := e.redo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.total(); got != 90 {
+		t.Fatalf("total after redo = %v, want 90", got)
+	}
+
+	// 执行新命令后不能再重做
+	e.run(&addItemCommand{cart: c, sku: "PEN", qty: 1})
+	if err := e.redo(); err == nil {
+		t.Fatal("redo after a new command should fail")
+	}
+}
+
+func TestFailedCommandNotRecorded(t *testing.T) {
+	c := newTestCart()
+	e := &cartEditor{}
+
+	failing := []command{
+		&addItemCommand{cart: c, sku: "UNKNOWN", qty: 1},
+		&addItemCommand{cart: c, sku: "BOOK", qty: 0},
+		&removeItemCommand{cart: c, sku: "BOOK", qty: 1},
+		&applyCouponCommand{cart: c, amount: -5},
+	}
+	for i, cmd := range failing {
+		if err := e.run(cmd); err == nil {
+			t.Errorf("command %d expected error", i)
+		}
+	}
+	if len(e.done) != 0 {
+		t.Fatalf("failed commands recorded in history: %d", len(e.done))
+	}
+}
+
+func TestApplyCouponUndoRestoresPrevious(t *testing.T) {
+	c := newTestCart()
+	e := &cartEditor{}
+	c.add("BOOK", 1)
+
+	e.run(&applyCouponCommand{cart: c, amount: 10})
+	e.run(&applyCouponCommand{cart: c, amount: 100})
+	if got := c.total(); got != 0 {
+		t.Fatalf("total with coupon larger than amount = %v, want 0", got)
+	}
+	e.undo()
+	if c.discount != 10 {
+		t.Fatalf("discount after undo = %v, want 10", c.discount)
+	}
+}
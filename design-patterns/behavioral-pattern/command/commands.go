@@ -0,0 +1,41 @@
+package main
+
+import "fmt"
+
+// addItemCommand 添加商品
+type addItemCommand struct {
+	cart *cart
+	sku  string
+	qty  int
+}
+
+func (c *addItemCommand) execute() error { return c.cart.add(c.sku, c.qty) }
+func (c *addItemCommand) undo()          { c.cart.remove(c.sku, c.qty) }
+
+// removeItemCommand 移除商品
+type removeItemCommand struct {
+	cart *cart
+	sku  string
+	qty  int
+}
+
+func (c *removeItemCommand) execute() error { return c.cart.remove(c.sku, c.qty) }
+func (c *removeItemCommand) undo()          { c.cart.add(c.sku, c.qty) }
+
+// applyCouponCommand 使用优惠券，撤销时恢复之前的优惠金额
+type applyCouponCommand struct {
+	cart     *cart
+	amount   float64
+	previous float64
+}
+
+func (c *applyCouponCommand) execute() error {
+	if c.amount <= 0 {
+		return fmt.Errorf("invalid coupon amount %.2f", c.amount)
+	}
+	c.previous = c.cart.discount
+	c.cart.discount = c.amount
+	return nil
+}
+
+func (c *applyCouponCommand) undo() { c.cart.discount = c.previous }
@@ -0,0 +1,3 @@
+module command-pattern
+
+go 1.21
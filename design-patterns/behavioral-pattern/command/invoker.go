@@ -0,0 +1,46 @@
+package main
+
+import "fmt"
+
+// cartEditor 调用者
+// 执行命令并记录历史，支持撤销和重做；执行新命令后重做历史被清空
+type cartEditor struct {
+	done   []command
+	undone []command
+}
+
+// run 执行命令，失败的命令不会进入历史
+func (e *cartEditor) run(c command) error {
+	if err := c.execute(); err != nil {
+		return err
+	}
+	e.done = append(e.done, c)
+	e.undone = nil
+	return nil
+}
+
+// undo 撤销最近一次执行的命令
+func (e *cartEditor) undo() error {
+	if len(e.done) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	c := e.done[len(e.done)-1]
+	e.done = e.done[:len(e.done)-1]
+	c.undo()
+	e.undone = append(e.undone, c)
+	return nil
+}
+
+// redo 重新执行最近一次撤销的命令
+func (e *cartEditor) redo() error {
+	if len(e.undone) == 0 {
+		return fmt.Errorf("nothing to redo")
+	}
+	c := e.undone[len(e.undone)-1]
+	if err := c.execute(); err != nil {
+		return err
+	}
+	e.undone = e.undone[:len(e.undone)-1]
+	e.done = append(e.done, c)
+	return nil
+}
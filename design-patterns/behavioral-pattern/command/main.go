@@ -0,0 +1,36 @@
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("命令模式示例")
+	fmt.Println("============")
+
+	c := newCart(map[string]float64{"BOOK": 45, "PEN": 5})
+	editor := &cartEditor{}
+
+	steps := []struct {
+		desc string
+		cmd  command
+	}{
+		{"添加2本书", &addItemCommand{cart: c, sku: "BOOK", qty: 2}},
+		{"添加3支笔", &addItemCommand{cart: c, sku: "PEN", qty: 3}},
+		{"使用20元优惠券", &applyCouponCommand{cart: c, amount: 20}},
+		{"移除1支笔", &removeItemCommand{cart: c, sku: "PEN", qty: 1}},
+		{"移除5本书", &removeItemCommand{cart: c, sku: "BOOK", qty: 5}},
+	}
+	for _, s := range steps {
+		if err := editor.run(s.cmd); err != nil {
+			fmt.Printf("%s 失败: %v\n", s.desc, err)
+			continue
+		}
+		fmt.Printf("%s: %v\n", s.desc, c)
+	}
+
+	editor.undo()
+	fmt.Println("撤销:", c)
+	editor.undo()
+	fmt.Println("撤销:", c)
+	editor.redo()
+	fmt.Println("重做:", c)
+}
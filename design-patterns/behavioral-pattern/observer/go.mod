@@ -0,0 +1,3 @@
+module observer-pattern
+
+go 1.21
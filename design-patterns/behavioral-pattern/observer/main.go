@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func main() {
+	fmt.Println("观察者模式示例")
+	fmt.Println("==============")
+
+	events := &orderEvents{}
+	inventory := newInventoryObserver()
+	notifier := &notificationObserver{}
+	audit := &auditObserver{}
+	events.subscribe(inventory)
+	events.subscribe(notifier)
+	events.subscribe(audit)
+
+	order := Order{
+		ID:         "ORDER-1",
+		CustomerID: "CUSTOMER-123",
+		Amount:     100,
+		CreatedAt:  time.Now(),
+		Status:     "PENDING",
+	}
+
+	fmt.Println("\n创建订单:")
+	events.publish(orderEvent{Type: "created", Order: order})
+
+	fmt.Println("\n支付订单:")
+	order.Status = "PAID"
+	events.publish(orderEvent{Type: "paid", Order: order})
+
+	// 注销通知服务后不再收到事件
+	events.unsubscribe(notifier)
+
+	fmt.Println("\n取消订单:")
+	order.Status = "CANCELLED"
+	events.publish(orderEvent{Type: "cancelled", Order: order})
+}
@@ -0,0 +1,23 @@
+package main
+
+import "time"
+
+// Order 订单，字段与mock-demo中的Order相同
+type Order struct {
+	ID         string
+	CustomerID string
+	Amount     float64
+	CreatedAt  time.Time
+	Status     string
+}
+
+// orderEvent 订单事件
+type orderEvent struct {
+	Type  string // created、paid、cancelled
+	Order Order
+}
+
+// orderObserver 观察者接口
+type orderObserver interface {
+	onOrderEvent(e orderEvent)
+}
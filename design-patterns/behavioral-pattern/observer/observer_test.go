@@ -0,0 +1,122 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// recordingObserver 记录收到的事件类型
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recordingObserver) onOrderEvent(e orderEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e.Type)
+}
+
+func TestPublishNotifiesAllObservers(t *testing.T) {
+	events := &orderEvents{}
+	a, b := &recordingObserver{}, &recordingObserver{}
+	events.subscribe(a)
+	events.subscribe(b)
+
+	events.publish(orderEvent{Type: "created"})
+	events.unsubscribe(a)
+	events.publish(orderEvent{Type: "paid"})
+
+	if !reflect.DeepEqual(a.events, []string{"created"}) {
+		t.Errorf("a.events = %v, want [created]", a.events)
+	}
+	if !reflect.DeepEqual(b.events, []string{"created", "paid"}) {
+		t.Errorf("b.events = %v, want [created paid]", b.events)
+	}
+
+	// 注销不存在的观察者不影响其他观察者
+	events.unsubscribe(&recordingObserver{})
+	events.publish(orderEvent{Type: "cancelled"})
+	if len(b.events) != 3 {
+		t.Errorf("b.events = %v, want 3 events", b.events)
+	}
+}
+
+// selfRemovingObserver 在回调中注销自己
+type selfRemovingObserver struct {
+	events *orderEvents
+	calls  int
+}
+
+func (s *selfRemovingObserver) onOrderEvent(e orderEvent) {
+	s.calls++
+	s.events.unsubscribe(s)
+}
+
+func TestUnsubscribeDuringPublish(t *testing.T) {
+	events := &orderEvents{}
+	self := &selfRemovingObserver{events: events}
+	after := &recordingObserver{}
+	events.subscribe(self)
+	events.subscribe(after)
+
+	events.publish(orderEvent{Type: "created"})
+	events.publish(orderEvent{Type: "paid"})
+
+	if self.calls != 1 {
+		t.Errorf("self.calls = %d, want 1", self.calls)
+	}
+	// 同一轮通知中排在后面的观察者仍然收到事件
+	if len(after.events) != 2 {
+		t.Errorf("after.events = %v, want 2 events", after.events)
+	}
+}
+
+func TestConcreteObservers(t *testing.T) {
+	events := &orderEvents{}
+	inventory := newInventoryObserver()
+	notifier := &notificationObserver{}
+	audit := &auditObserver{}
+	events.subscribe(inventory)
+	events.subscribe(notifier)
+	events.subscribe(audit)
+
+	order := Order{ID: "ORDER-1", CustomerID: "C1", Amount: 100}
+	events.publish(orderEvent{Type: "created", Order: order})
+	if !inventory.reserved["ORDER-1"] {
+		t.Error("inventory not reserved after created")
+	}
+	events.publish(orderEvent{Type: "paid", Order: order})
+	events.publish(orderEvent{Type: "cancelled", Order: order})
+
+	if inventory.reserved["ORDER-1"] {
+		t.Error("inventory still reserved after cancelled")
+	}
+	if len(notifier.sent) != 1 {
+		t.Errorf("notifications = %v, want 1", notifier.sent)
+	}
+	want := []string{"created ORDER-1", "paid ORDER-1", "cancelled ORDER-1"}
+	if !reflect.DeepEqual(audit.log, want) {
+		t.Errorf("audit log = %v, want %v", audit.log, want)
+	}
+}
+
+func TestConcurrentSubscribeAndPublish(t *testing.T) {
+	events := &orderEvents{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			o := &recordingObserver{}
+			events.subscribe(o)
+			events.unsubscribe(o)
+		}()
+		go func() {
+			defer wg.Done()
+			events.publish(orderEvent{Type: "created"})
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,47 @@
+package main
+
+import "fmt"
+
+// inventoryObserver 库存服务，下单时锁定库存，取消时释放
+type inventoryObserver struct {
+	reserved map[string]bool
+}
+
+func newInventoryObserver() *inventoryObserver {
+	return &inventoryObserver{reserved: make(map[string]bool)}
+}
+
+func (i *inventoryObserver) onOrderEvent(e orderEvent) {
+	switch e.Type {
+	case "created":
+		i.reserved[e.Order.ID] = true
+		fmt.Printf("[库存] 锁定订单 %s 的库存\n", e.Order.ID)
+	case "cancelled":
+		delete(i.reserved, e.Order.ID)
+		fmt.Printf("[库存] 释放订单 %s 的库存\n", e.Order.ID)
+	}
+}
+
+// notificationObserver 通知服务，支付成功后通知客户
+type notificationObserver struct {
+	sent []string
+}
+
+func (n *notificationObserver) onOrderEvent(e orderEvent) {
+	if e.Type != "paid" {
+		return
+	}
+	msg := fmt.Sprintf("客户 %s 的订单 %s 已支付 %.2f", e.Order.CustomerID, e.Order.ID, e.Order.Amount)
+	n.sent = append(n.sent, msg)
+	fmt.Println("[通知]", msg)
+}
+
+// auditObserver 审计日志，记录所有事件
+type auditObserver struct {
+	log []string
+}
+
+func (a *auditObserver) onOrderEvent(e orderEvent) {
+	a.log = append(a.log, e.Type+" "+e.Order.ID)
+	fmt.Printf("[审计] %s %s\n", e.Type, e.Order.ID)
+}
@@ -0,0 +1,41 @@
+package main
+
+import "sync"
+
+// orderEvents 主题
+// 订单服务只负责发布事件，不知道有哪些观察者在监听
+type orderEvents struct {
+	mu        sync.RWMutex
+	observers []orderObserver
+}
+
+// subscribe 注册观察者
+func (s *orderEvents) subscribe(o orderObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, o)
+}
+
+// unsubscribe 注销观察者
+func (s *orderEvents) unsubscribe(o orderObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.observers {
+		if existing == o {
+			s.observers = append(s.observers[:i:i], s.observers[i+1:]...)
+			return
+		}
+	}
+}
+
+// publish 按注册顺序通知所有观察者
+// 通知前复制观察者列表，观察者在回调中注销自己不会导致死锁
+func (s *orderEvents) publish(e orderEvent) {
+	s.mu.RLock()
+	observers := append([]orderObserver(nil), s.observers...)
+	s.mu.RUnlock()
+
+	for _, o := range observers {
+		o.onOrderEvent(e)
+	}
+}
@@ -0,0 +1,3 @@
+module state-pattern
+
+go 1.21
@@ -0,0 +1,34 @@
+package main
+
+import "fmt"
+
+// run 依次执行操作并打印每一步之后的状态
+func run(o *Order, actions ...string) {
+	ops := map[string]func() error{
+		"pay":     o.pay,
+		"ship":    o.ship,
+		"deliver": o.deliver,
+		"cancel":  o.cancel,
+	}
+	for _, a := range actions {
+		if err := ops[a](); err != nil {
+			fmt.Printf("  %-7s -> 失败: %v\n", a, err)
+			continue
+		}
+		fmt.Printf("  %-7s -> %s\n", a, o.Status)
+	}
+}
+
+func main() {
+	fmt.Println("状态模式示例")
+	fmt.Println("============")
+
+	o1 := newOrder("ORDER-1", "CUSTOMER-123", 100)
+	fmt.Printf("\n%s 初始状态: %s\n", o1.ID, o1.Status)
+	run(o1, "ship", "pay", "ship", "cancel", "deliver")
+
+	o2 := newOrder("ORDER-2", "CUSTOMER-456", 200)
+	fmt.Printf("\n%s 初始状态: %s\n", o2.ID, o2.Status)
+	run(o2, "pay", "cancel", "pay")
+	fmt.Printf("  已退款: %v\n", o2.refunded)
+}
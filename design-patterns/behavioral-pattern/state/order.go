@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Order 上下文，字段与mock-demo中的Order相同，Status始终与当前状态保持一致
+// 订单把操作委托给当前状态，自己不包含任何状态判断
+type Order struct {
+	ID         string
+	CustomerID string
+	Amount     float64
+	CreatedAt  time.Time
+	Status     string
+
+	state    orderState
+	refunded bool
+}
+
+func newOrder(id, customerID string, amount float64) *Order {
+	o := &Order{ID: id, CustomerID: customerID, Amount: amount, CreatedAt: time.Now()}
+	o.setState(pendingState{})
+	return o
+}
+
+func (o *Order) setState(s orderState) {
+	o.state = s
+	o.Status = s.name()
+}
+
+func (o *Order) pay() error     { return o.state.pay(o) }
+func (o *Order) ship() error    { return o.state.ship(o) }
+func (o *Order) deliver() error { return o.state.deliver(o) }
+func (o *Order) cancel() error  { return o.state.cancel(o) }
+
+// invalidTransition 当前状态不允许执行的操作
+func invalidTransition(s orderState, action string) error {
+	return fmt.Errorf("cannot %s order in %s state", action, s.name())
+}
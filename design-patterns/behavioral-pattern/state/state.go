@@ -0,0 +1,11 @@
+package main
+
+// orderState 状态接口
+// 每个状态决定在该状态下哪些操作是合法的，以及操作之后进入哪个状态
+type orderState interface {
+	name() string
+	pay(o *Order) error
+	ship(o *Order) error
+	deliver(o *Order) error
+	cancel(o *Order) error
+}
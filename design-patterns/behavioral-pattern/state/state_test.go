@@ -0,0 +1,71 @@
+package main
+
+import "testing"
+
+func TestOrderTransitions(t *testing.T) {
+	// 每个状态下执行各个操作的结果，操作失败时状态保持不变
+	tests := []struct {
+		setup   []string
+		action  string
+		want    string
+		wantErr bool
+	}{
+		{nil, "pay", "PAID", false},
+		{nil, "ship", "PENDING", true},
+		{nil, "deliver", "PENDING", true},
+		{nil, "cancel", "CANCELLED", false},
+		{[]string{"pay"}, "pay", "PAID", true},
+		{[]string{"pay"}, "ship", "SHIPPED", false},
+		{[]string{"pay"}, "deliver", "PAID", true},
+		{[]string{"pay"}, "cancel", "CANCELLED", false},
+		{[]string{"pay", "ship"}, "pay", "SHIPPED", true},
+		{[]string{"pay", "ship"}, "ship", "SHIPPED", true},
+		{[]string{"pay", "ship"}, "deliver", "DELIVERED", false},
+		{[]string{"pay", "ship"}, "cancel", "SHIPPED", true},
+		{[]string{"pay", "ship", "deliver"}, "cancel", "DELIVERED", true},
+		{[]string{"cancel"}, "pay", "CANCELLED", true},
+	}
+	for _, tt := range tests {
+		o := newOrder("ORDER-1", "C1", 100)
+		for _, a := range tt.setup {
+			if err := do(o, a); err != nil {
+				t.Fatalf("setup %v: %v", tt.setup, err)
+			}
+		}
+		err := do(o, tt.action)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v then %s: err = %v, wantErr %v", tt.setup, tt.action, err, tt.wantErr)
+		}
+		if o.Status != tt.want {
+			t.Errorf("%v then %s: status = %s, want %s", tt.setup, tt.action, o.Status, tt.want)
+		}
+	}
+}
+
+func TestCancelRefundsOnlyPaidOrders(t *testing.T) {
+	pending := newOrder("ORDER-1", "C1", 100)
+	pending.cancel()
+	if pending.refunded {
+		t.Error("pending order refunded on cancel")
+	}
+
+	paid := newOrder("ORDER-2", "C1", 100)
+	paid.pay()
+	paid.cancel()
+	if !paid.refunded {
+		t.Error("paid order not refunded on cancel")
+	}
+}
+
+func do(o *Order, action string) error {
+	switch action {
+	case "pay":
+		return o.pay()
+	case "ship":
+		return o.ship()
+	case "deliver":
+		return o.deliver()
+	default:
+		return o.cancel()
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+// pendingState 待支付：可以支付或取消
+type pendingState struct{}
+
+func (pendingState) name() string { return "PENDING" }
+
+func (pendingState) pay(o *Order) error {
+	o.setState(paidState{})
+	return nil
+}
+
+func (s pendingState) ship(o *Order) error    { return invalidTransition(s, "ship") }
+func (s pendingState) deliver(o *Order) error { return invalidTransition(s, "deliver") }
+
+func (pendingState) cancel(o *Order) error {
+	o.setState(cancelledState{})
+	return nil
+}
+
+// paidState 已支付：可以发货，取消时需要退款
+type paidState struct{}
+
+func (paidState) name() string { return "PAID" }
+
+func (s paidState) pay(o *Order) error { return invalidTransition(s, "pay") }
+
+func (paidState) ship(o *Order) error {
+	o.setState(shippedState{})
+	return nil
+}
+
+func (s paidState) deliver(o *Order) error { return invalidTransition(s, "deliver") }
+
+func (paidState) cancel(o *Order) error {
+	o.refunded = true
+	o.setState(cancelledState{})
+	return nil
+}
+
+// shippedState 已发货：只能确认收货，不能再取消
+type shippedState struct{}
+
+func (shippedState) name() string { return "SHIPPED" }
+
+func (s shippedState) pay(o *Order) error  { return invalidTransition(s, "pay") }
+func (s shippedState) ship(o *Order) error { return invalidTransition(s, "ship") }
+
+func (shippedState) deliver(o *Order) error {
+	o.setState(deliveredState{})
+	return nil
+}
+
+func (s shippedState) cancel(o *Order) error { return invalidTransition(s, "cancel") }
+
+// deliveredState 已收货：终止状态
+type deliveredState struct{}
+
+func (deliveredState) name() string { return "DELIVERED" }
+
+func (s deliveredState) pay(o *Order) error     { return invalidTransition(s, "pay") }
+func (s deliveredState) ship(o *Order) error    { return invalidTransition(s, "ship") }
+func (s deliveredState) deliver(o *Order) error { return invalidTransition(s, "deliver") }
+func (s deliveredState) cancel(o *Order) error  { return invalidTransition(s, "cancel") }
+
+// cancelledState 已取消：终止状态
+type cancelledState struct{}
+
+func (cancelledState) name() string { return "CANCELLED" }
+
+func (s cancelledState) pay(o *Order) error     { return invalidTransition(s, "pay") }
+func (s cancelledState) ship(o *Order) error    { return invalidTransition(s, "ship") }
+func (s cancelledState) deliver(o *Order) error { return invalidTransition(s, "deliver") }
+func (s cancelledState) cancel(o *Order) error  { return invalidTransition(s, "cancel") }
@@ -0,0 +1,27 @@
+package main
+
+// checkout 上下文
+// 结算时不关心具体的优惠规则，运行时可以随时替换策略
+type checkout struct {
+	strategy discountStrategy
+}
+
+func newCheckout(s discountStrategy) *checkout {
+	if s == nil {
+		s = noDiscount{}
+	}
+	return &checkout{strategy: s}
+}
+
+func (c *checkout) setStrategy(s discountStrategy) {
+	c.strategy = s
+}
+
+// payable 返回应付金额，优惠金额不会超过订单金额
+func (c *checkout) payable(amount float64) float64 {
+	d := c.strategy.discount(amount)
+	if d > amount {
+		d = amount
+	}
+	return round2(amount - d)
+}
@@ -0,0 +1,3 @@
+module strategy-pattern
+
+go 1.21
@@ -0,0 +1,24 @@
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("策略模式示例")
+	fmt.Println("============")
+
+	strategies := []discountStrategy{
+		noDiscount{},
+		percentageDiscount{rate: 0.8},
+		fullReductionDiscount{threshold: 100, off: 20},
+	}
+	strategies = append(strategies, bestOfDiscount{strategies: strategies})
+
+	c := newCheckout(nil)
+	for _, amount := range []float64{99, 250} {
+		fmt.Printf("\n订单金额: %.2f\n", amount)
+		for _, s := range strategies {
+			c.setStrategy(s)
+			fmt.Printf("  %s: 应付 %.2f\n", s.name(), c.payable(amount))
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// noDiscount 不打折
+type noDiscount struct{}
+
+func (noDiscount) name() string                    { return "原价" }
+func (noDiscount) discount(amount float64) float64 { return 0 }
+
+// percentageDiscount 按比例打折，rate为0.8表示八折
+type percentageDiscount struct {
+	rate float64
+}
+
+func (p percentageDiscount) name() string {
+	return fmt.Sprintf("%g折", p.rate*10)
+}
+
+func (p percentageDiscount) discount(amount float64) float64 {
+	return round2(amount * (1 - p.rate))
+}
+
+// fullReductionDiscount 满减，每满threshold减off，例如每满100减20
+type fullReductionDiscount struct {
+	threshold float64
+	off       float64
+}
+
+func (f fullReductionDiscount) name() string {
+	return fmt.Sprintf("每满%g减%g", f.threshold, f.off)
+}
+
+func (f fullReductionDiscount) discount(amount float64) float64 {
+	return math.Floor(amount/f.threshold) * f.off
+}
+
+// bestOfDiscount 从多个策略中选择优惠最大的一个，本身也是一个策略
+type bestOfDiscount struct {
+	strategies []discountStrategy
+}
+
+func (b bestOfDiscount) name() string { return "最优惠" }
+
+func (b bestOfDiscount) discount(amount float64) float64 {
+	best := 0.0
+	for _, s := range b.strategies {
+		if d := s.discount(amount); d > best {
+			best = d
+		}
+	}
+	return best
+}
+
+// round2 保留两位小数
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,8 @@
+package main
+
+// discountStrategy 策略接口
+// 每种优惠规则都是一个策略，输入订单金额，返回优惠金额
+type discountStrategy interface {
+	name() string
+	discount(amount float64) float64
+}
@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestDiscountStrategies(t *testing.T) {
+	tests := []struct {
+		strategy discountStrategy
+		amount   float64
+		want     float64
+	}{
+		{noDiscount{}, 100, 100},
+		{percentageDiscount{rate: 0.8}, 250, 200},
+		{percentageDiscount{rate: 0.95}, 33.33, 31.66},
+		{fullReductionDiscount{threshold: 100, off: 20}, 99, 99},
+		{fullReductionDiscount{threshold: 100, off: 20}, 250, 210},
+		{fullReductionDiscount{threshold: 10, off: 50}, 10, 0}, // 优惠不超过订单金额
+		{bestOfDiscount{strategies: []discountStrategy{
+			percentageDiscount{rate: 0.8},
+			fullReductionDiscount{threshold: 100, off: 20},
+		}}, 99, 79.2},
+		{bestOfDiscount{}, 50, 50},
+	}
+	for _, tt := range tests {
+		if got := newCheckout(tt.strategy).payable(tt.amount); got != tt.want {
+			t.Errorf("%s: payable(%v) = %v, want %v", tt.strategy.name(), tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestCheckoutSetStrategy(t *testing.T) {
+	c := newCheckout(nil)
+	if got := c.payable(100); got != 100 {
+		t.Fatalf("default payable = %v, want 100", got)
+	}
+	c.setStrategy(percentageDiscount{rate: 0.5})
+	if got := c.payable(100); got != 50 {
+		t.Fatalf("payable after setStrategy = %v, want 50", got)
+	}
+}
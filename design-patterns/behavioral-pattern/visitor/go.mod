@@ -0,0 +1,3 @@
+module visitor-pattern
+
+go 1.21
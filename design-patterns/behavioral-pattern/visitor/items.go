@@ -0,0 +1,26 @@
+package main
+
+// physicalItem 实物商品，需要物流配送
+type physicalItem struct {
+	sku    string
+	price  float64
+	weight float64 // 千克
+}
+
+func (i *physicalItem) accept(v itemVisitor) { v.visitPhysical(i) }
+
+// digitalItem 数字商品，例如电子书、软件许可
+type digitalItem struct {
+	sku   string
+	price float64
+}
+
+func (i *digitalItem) accept(v itemVisitor) { v.visitDigital(i) }
+
+// serviceItem 服务，例如安装、延保
+type serviceItem struct {
+	name  string
+	price float64
+}
+
+func (i *serviceItem) accept(v itemVisitor) { v.visitService(i) }
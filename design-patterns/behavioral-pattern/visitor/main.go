@@ -0,0 +1,27 @@
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("访问者模式示例")
+	fmt.Println("==============")
+
+	items := []orderItem{
+		&physicalItem{sku: "KEYBOARD", price: 299, weight: 1.2},
+		&physicalItem{sku: "MOUSE", price: 99, weight: 0.3},
+		&digitalItem{sku: "EBOOK-GO", price: 49},
+		&serviceItem{name: "延保一年", price: 60},
+	}
+
+	subtotal := &subtotalVisitor{}
+	tax := &taxVisitor{}
+	shipping := &shippingVisitor{}
+	for _, v := range []itemVisitor{subtotal, tax, shipping} {
+		visitAll(items, v)
+	}
+
+	fmt.Printf("商品总价: %.2f\n", subtotal.total)
+	fmt.Printf("税费: %.2f\n", tax.total)
+	fmt.Printf("运费: %.2f (总重 %.1f千克)\n", shipping.fee(), shipping.weight)
+	fmt.Printf("应付: %.2f\n", subtotal.total+tax.total+shipping.fee())
+}
@@ -0,0 +1,14 @@
+package main
+
+// itemVisitor 访问者接口，每种商品类型对应一个visit方法
+type itemVisitor interface {
+	visitPhysical(i *physicalItem)
+	visitDigital(i *digitalItem)
+	visitService(i *serviceItem)
+}
+
+// orderItem 元素接口
+// accept 把自己交给访问者，由Go的静态类型选择对应的visit方法（双分派）
+type orderItem interface {
+	accept(v itemVisitor)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestTaxVisitor(t *testing.T) {
+	items := []orderItem{
+		&physicalItem{price: 100},
+		&digitalItem{price: 100},
+		&serviceItem{price: 50},
+	}
+	v := &taxVisitor{}
+	visitAll(items, v)
+	if !almostEqual(v.total, 13+6+3) {
+		t.Fatalf("tax = %v, want 22", v.total)
+	}
+}
+
+func TestShippingVisitor(t *testing.T) {
+	tests := []struct {
+		items []orderItem
+		want  float64
+	}{
+		{nil, 0},
+		{[]orderItem{&digitalItem{price: 10}, &serviceItem{price: 10}}, 0},
+		{[]orderItem{&physicalItem{weight: 0.3}}, 10},
+		{[]orderItem{&physicalItem{weight: 1}}, 10},
+		{[]orderItem{&physicalItem{weight: 1.2}, &physicalItem{weight: 0.3}}, 15},
+		{[]orderItem{&physicalItem{weight: 3.5}, &digitalItem{}}, 25},
+	}
+	for i, tt := range tests {
+		v := &shippingVisitor{}
+		visitAll(tt.items, v)
+		if got := v.fee(); got != tt.want {
+			t.Errorf("case %d: fee = %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+// countingVisitor 统计每种商品被访问的次数，验证accept分派到了正确的方法
+type countingVisitor struct {
+	physical, digital, service int
+}
+
+func (c *countingVisitor) visitPhysical(i *physicalItem) { c.physical++ }
+func (c *countingVisitor) visitDigital(i *digitalItem)   { c.digital++ }
+func (c *countingVisitor) visitService(i *serviceItem)   { c.service++ }
+
+func TestAcceptDispatch(t *testing.T) {
+	items := []orderItem{&physicalItem{}, &digitalItem{}, &physicalItem{}, &serviceItem{}}
+	v := &countingVisitor{}
+	visitAll(items, v)
+	if v.physical != 2 || v.digital != 1 || v.service != 1 {
+		t.Fatalf("counts = %+v, want {2 1 1}", *v)
+	}
+}
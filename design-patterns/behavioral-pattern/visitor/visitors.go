@@ -0,0 +1,45 @@
+package main
+
+import "math"
+
+// taxVisitor 计算税费：实物13%，数字商品和服务6%
+type taxVisitor struct {
+	total float64
+}
+
+func (t *taxVisitor) visitPhysical(i *physicalItem) { t.total += i.price * 0.13 }
+func (t *taxVisitor) visitDigital(i *digitalItem)   { t.total += i.price * 0.06 }
+func (t *taxVisitor) visitService(i *serviceItem)   { t.total += i.price * 0.06 }
+
+// shippingVisitor 计算运费：实物首重1千克10元，续重每千克5元；其他商品不需要配送
+type shippingVisitor struct {
+	weight float64
+}
+
+func (s *shippingVisitor) visitPhysical(i *physicalItem) { s.weight += i.weight }
+func (s *shippingVisitor) visitDigital(i *digitalItem)   {}
+func (s *shippingVisitor) visitService(i *serviceItem)   {}
+
+// fee 返回整单运费，不足1千克按1千克计算
+func (s *shippingVisitor) fee() float64 {
+	if s.weight == 0 {
+		return 0
+	}
+	return 10 + math.Max(0, math.Ceil(s.weight)-1)*5
+}
+
+// subtotalVisitor 计算商品总价
+type subtotalVisitor struct {
+	total float64
+}
+
+func (s *subtotalVisitor) visitPhysical(i *physicalItem) { s.total += i.price }
+func (s *subtotalVisitor) visitDigital(i *digitalItem)   { s.total += i.price }
+func (s *subtotalVisitor) visitService(i *serviceItem)   { s.total += i.price }
+
+// visitAll 让访问者依次访问所有商品
+func visitAll(items []orderItem, v itemVisitor) {
+	for _, i := range items {
+		i.accept(v)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// domesticFactory 国内业务的依赖族
+type domesticFactory struct{}
+
+func (domesticFactory) createValidator() CustomerValidator { return domesticValidator{} }
+func (domesticFactory) createPayment() PaymentProcessor    { return alipay{} }
+func (domesticFactory) createTimeProvider() TimeProvider {
+	return zoneClock{loc: time.FixedZone("CST", 8*3600)}
+}
+
+// domesticValidator 国内客户ID以CN-开头
+type domesticValidator struct{}
+
+func (domesticValidator) ValidateCustomer(customerID string) error {
+	if !strings.HasPrefix(customerID, "CN-") {
+		return fmt.Errorf("customer %s is not a domestic customer", customerID)
+	}
+	return nil
+}
+
+// alipay 以人民币结算
+type alipay struct{}
+
+func (alipay) ProcessPayment(customerID string, amount float64) (*PaymentResponse, error) {
+	return &PaymentResponse{Success: true, Message: fmt.Sprintf("Alipay charged ¥%.2f", amount)}, nil
+}
+
+// zoneClock 返回指定时区的当前时间
+type zoneClock struct {
+	loc *time.Location
+}
+
+func (c zoneClock) Now() time.Time {
+	return time.Now().In(c.loc)
+}
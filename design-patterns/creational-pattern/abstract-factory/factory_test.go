@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestDomesticFamily(t *testing.T) {
+	s := newOrderService(domesticFactory{})
+
+	order, msg, err := s.CreateOrder("CN-1001", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg != "Alipay charged ¥100.00" {
+		t.Errorf("message = %q", msg)
+	}
+	if _, offset := order.CreatedAt.Zone(); offset != 8*3600 {
+		t.Errorf("zone offset = %d, want +8h", offset)
+	}
+
+	if _, _, err := s.CreateOrder("INTL-2001", 100); err == nil {
+		t.Error("domestic service accepted an overseas customer")
+	}
+}
+
+func TestOverseasFamily(t *testing.T) {
+	s := newOrderService(overseasFactory{})
+
+	order, msg, err := s.CreateOrder("INTL-2001", 720)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg != "Card charged $100.00" {
+		t.Errorf("message = %q", msg)
+	}
+	if order.CreatedAt.Location().String() != "UTC" {
+		t.Errorf("location = %s, want UTC", order.CreatedAt.Location())
+	}
+
+	if _, _, err := s.CreateOrder("CN-1001", 100); err == nil {
+		t.Error("overseas service accepted a domestic customer")
+	}
+}
+
+func TestOrderIDsIncrement(t *testing.T) {
+	s := newOrderService(domesticFactory{})
+	a, _, _ := s.CreateOrder("CN-1", 1)
+	b, _, _ := s.CreateOrder("CN-2", 1)
+	if a.ID != "ORDER-1" || b.ID != "ORDER-2" {
+		t.Errorf("ids = %s, %s", a.ID, b.ID)
+	}
+}
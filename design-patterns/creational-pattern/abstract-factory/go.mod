@@ -0,0 +1,3 @@
+module abstract-factory-pattern
+
+go 1.21
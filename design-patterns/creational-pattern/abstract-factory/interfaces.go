@@ -0,0 +1,31 @@
+package main
+
+import "time"
+
+// 产品接口，与mock-demo中ModernOrderService的依赖保持一致
+
+type PaymentResponse struct {
+	Success bool
+	Message string
+}
+
+type CustomerValidator interface {
+	ValidateCustomer(customerID string) error
+}
+
+type PaymentProcessor interface {
+	ProcessPayment(customerID string, amount float64) (*PaymentResponse, error)
+}
+
+type TimeProvider interface {
+	Now() time.Time
+}
+
+// regionFactory 抽象工厂
+// 每个地区提供一整套相互匹配的依赖：客户校验规则、支付渠道和所在时区，
+// 保证同一个订单服务不会混用不同地区的组件
+type regionFactory interface {
+	createValidator() CustomerValidator
+	createPayment() PaymentProcessor
+	createTimeProvider() TimeProvider
+}
@@ -0,0 +1,24 @@
+package main
+
+import "fmt"
+
+func main() {
+	factories := map[string]regionFactory{
+		"国内": domesticFactory{},
+		"海外": overseasFactory{},
+	}
+
+	for _, region := range []string{"国内", "海外"} {
+		service := newOrderService(factories[region])
+		fmt.Printf("\n[%s订单服务]\n", region)
+
+		for _, customerID := range []string{"CN-1001", "INTL-2001"} {
+			order, msg, err := service.CreateOrder(customerID, 720)
+			if err != nil {
+				fmt.Printf("%s: 下单失败: %v\n", customerID, err)
+				continue
+			}
+			fmt.Printf("%s: %s, %s, 时区 %s\n", customerID, order.ID, msg, order.CreatedAt.Location())
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Order 订单
+type Order struct {
+	ID         string
+	CustomerID string
+	Amount     float64
+	CreatedAt  time.Time
+	Status     string
+}
+
+// orderService 订单服务，所有依赖都来自同一个地区工厂
+type orderService struct {
+	validator CustomerValidator
+	payment   PaymentProcessor
+	clock     TimeProvider
+	nextID    int
+}
+
+func newOrderService(f regionFactory) *orderService {
+	return &orderService{
+		validator: f.createValidator(),
+		payment:   f.createPayment(),
+		clock:     f.createTimeProvider(),
+	}
+}
+
+func (s *orderService) CreateOrder(customerID string, amount float64) (*Order, string, error) {
+	if err := s.validator.ValidateCustomer(customerID); err != nil {
+		return nil, "", fmt.Errorf("customer validation failed: %w", err)
+	}
+	resp, err := s.payment.ProcessPayment(customerID, amount)
+	if err != nil {
+		return nil, "", fmt.Errorf("payment failed: %w", err)
+	}
+	if !resp.Success {
+		return nil, "", fmt.Errorf("payment rejected: %s", resp.Message)
+	}
+
+	s.nextID++
+	return &Order{
+		ID:         fmt.Sprintf("ORDER-%d", s.nextID),
+		CustomerID: customerID,
+		Amount:     amount,
+		CreatedAt:  s.clock.Now(),
+		Status:     "PAID",
+	}, resp.Message, nil
+}
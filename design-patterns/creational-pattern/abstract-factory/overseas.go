@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// overseasFactory 海外业务的依赖族
+type overseasFactory struct{}
+
+func (overseasFactory) createValidator() CustomerValidator { return overseasValidator{} }
+func (overseasFactory) createPayment() PaymentProcessor    { return cardGateway{exchangeRate: 7.2} }
+func (overseasFactory) createTimeProvider() TimeProvider   { return zoneClock{loc: time.UTC} }
+
+// overseasValidator 海外客户ID以INTL-开头
+type overseasValidator struct{}
+
+func (overseasValidator) ValidateCustomer(customerID string) error {
+	if !strings.HasPrefix(customerID, "INTL-") {
+		return fmt.Errorf("customer %s is not an overseas customer", customerID)
+	}
+	return nil
+}
+
+// cardGateway 国际信用卡，金额按汇率换算成美元结算
+type cardGateway struct {
+	exchangeRate float64
+}
+
+func (c cardGateway) ProcessPayment(customerID string, amount float64) (*PaymentResponse, error) {
+	return &PaymentResponse{Success: true, Message: fmt.Sprintf("Card charged $%.2f", amount/c.exchangeRate)}, nil
+}
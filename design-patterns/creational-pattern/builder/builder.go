@@ -0,0 +1,11 @@
+package main
+
+// orderBuilder 生成器接口
+// 每个步骤返回生成器本身以便链式调用，校验错误统一在build时返回
+type orderBuilder interface {
+	customer(id string) orderBuilder
+	addItem(sku string, quantity int, price float64) orderBuilder
+	coupon(code string) orderBuilder
+	shipTo(address string) orderBuilder
+	build() (*Order, error)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStandardOrderBuilder(t *testing.T) {
+	fixed := time.Date(2023, 12, 25, 10, 0, 0, 0, time.UTC)
+	b := newStandardOrderBuilder()
+	b.now = func() time.Time { return fixed }
+
+	order, err := b.customer("CUSTOMER-123").
+		addItem("A", 2, 60).
+		addItem("B", 1, 30).
+		coupon("SAVE10").
+		shipTo("上海").
+		build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Amount != 140 || order.Discount != 10 {
+		t.Errorf("amount = %.2f, discount = %.2f, want 140 and 10", order.Amount, order.Discount)
+	}
+	if order.Status != "PENDING" || !order.CreatedAt.Equal(fixed) {
+		t.Errorf("status = %s, createdAt = %v", order.Status, order.CreatedAt)
+	}
+}
+
+func TestStandardOrderBuilderErrors(t *testing.T) {
+	_, err := newStandardOrderBuilder().addItem("A", -1, 10).coupon("BOGUS").build()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"invalid quantity", "customer is required", "at least one item", "shipping address", "unknown coupon"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+}
+
+func TestCouponMinimum(t *testing.T) {
+	_, err := newStandardOrderBuilder().customer("C").addItem("A", 1, 100).coupon("SAVE50").shipTo("x").build()
+	if err == nil || !strings.Contains(err.Error(), "minimum") {
+		t.Fatalf("err = %v, want minimum amount error", err)
+	}
+}
+
+func TestBuildDoesNotShareItems(t *testing.T) {
+	b := newStandardOrderBuilder()
+	b.customer("C").addItem("A", 1, 10).shipTo("x")
+	first, _ := b.build()
+	b.addItem("B", 1, 20)
+	second, _ := b.build()
+
+	if len(first.Items) != 1 || len(second.Items) != 2 {
+		t.Fatalf("items = %d and %d, want 1 and 2", len(first.Items), len(second.Items))
+	}
+}
+
+func TestGiftOrderBuilder(t *testing.T) {
+	order, err := newGiftOrderBuilder("生日快乐").
+		customer("C").
+		addItem("A", 2, 50).
+		shipTo("x").
+		build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Amount != 100+2*giftWrapFee {
+		t.Errorf("amount = %.2f, want %.2f", order.Amount, 100+2*giftWrapFee)
+	}
+	if order.Note != "礼品: 生日快乐" {
+		t.Errorf("note = %q", order.Note)
+	}
+}
+
+func TestDirector(t *testing.T) {
+	standard, err := (&orderDirector{builder: newStandardOrderBuilder()}).buildCoffeeBox("C", "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gift, err := (&orderDirector{builder: newGiftOrderBuilder("hi")}).buildCoffeeBox("C", "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if standard.Amount != 171 {
+		t.Errorf("standard amount = %.2f, want 171", standard.Amount)
+	}
+	if gift.Amount != standard.Amount+3*giftWrapFee {
+		t.Errorf("gift amount = %.2f, want %.2f", gift.Amount, standard.Amount+3*giftWrapFee)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// coupons 优惠券：满minAmount减off
+var coupons = map[string]struct{ minAmount, off float64 }{
+	"SAVE10":  {minAmount: 100, off: 10},
+	"SAVE50":  {minAmount: 300, off: 50},
+	"NEWUSER": {minAmount: 0, off: 5},
+}
+
+// standardOrderBuilder 普通订单生成器
+// self 指向最外层的生成器，礼品生成器嵌入本类型后链式调用仍然返回礼品生成器
+type standardOrderBuilder struct {
+	self       orderBuilder
+	order      Order
+	couponCode string
+	errs       []error
+	now        func() time.Time
+}
+
+func newStandardOrderBuilder() *standardOrderBuilder {
+	b := &standardOrderBuilder{now: time.Now}
+	b.self = b
+	return b
+}
+
+func (b *standardOrderBuilder) customer(id string) orderBuilder {
+	b.order.CustomerID = id
+	return b.self
+}
+
+func (b *standardOrderBuilder) addItem(sku string, quantity int, price float64) orderBuilder {
+	if quantity <= 0 || price < 0 {
+		b.errs = append(b.errs, fmt.Errorf("item %s: invalid quantity %d or price %.2f", sku, quantity, price))
+		return b.self
+	}
+	b.order.Items = append(b.order.Items, Item{SKU: sku, Quantity: quantity, Price: price})
+	return b.self
+}
+
+func (b *standardOrderBuilder) coupon(code string) orderBuilder {
+	b.couponCode = code
+	return b.self
+}
+
+func (b *standardOrderBuilder) shipTo(address string) orderBuilder {
+	b.order.Shipping = address
+	return b.self
+}
+
+// build 校验并计算金额，返回一个新的订单
+func (b *standardOrderBuilder) build() (*Order, error) {
+	errs := append([]error(nil), b.errs...)
+	if b.order.CustomerID == "" {
+		errs = append(errs, errors.New("customer is required"))
+	}
+	if len(b.order.Items) == 0 {
+		errs = append(errs, errors.New("at least one item is required"))
+	}
+	if b.order.Shipping == "" {
+		errs = append(errs, errors.New("shipping address is required"))
+	}
+
+	var subtotal float64
+	for _, it := range b.order.Items {
+		subtotal += float64(it.Quantity) * it.Price
+	}
+
+	var discount float64
+	if b.couponCode != "" {
+		c, ok := coupons[b.couponCode]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("unknown coupon %q", b.couponCode))
+		case subtotal < c.minAmount:
+			errs = append(errs, fmt.Errorf("coupon %s requires a minimum of %.2f", b.couponCode, c.minAmount))
+		default:
+			discount = c.off
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	order := b.order
+	order.Items = append([]Item(nil), b.order.Items...)
+	order.Discount = discount
+	order.Amount = subtotal - discount
+	order.CreatedAt = b.now()
+	order.Status = "PENDING"
+	return &order, nil
+}
+
+// giftOrderBuilder 礼品订单生成器
+// 在普通订单的基础上增加礼品包装费，并附上祝福语
+type giftOrderBuilder struct {
+	*standardOrderBuilder
+	message string
+}
+
+// giftWrapFee 每件商品的礼品包装费
+const giftWrapFee = 8.0
+
+func newGiftOrderBuilder(message string) *giftOrderBuilder {
+	g := &giftOrderBuilder{standardOrderBuilder: newStandardOrderBuilder(), message: message}
+	g.self = g
+	return g
+}
+
+func (g *giftOrderBuilder) build() (*Order, error) {
+	order, err := g.standardOrderBuilder.build()
+	if err != nil {
+		return nil, err
+	}
+	var count int
+	for _, it := range order.Items {
+		count += it.Quantity
+	}
+	order.Amount += float64(count) * giftWrapFee
+	order.Note = "礼品: " + g.message
+	return order, nil
+}
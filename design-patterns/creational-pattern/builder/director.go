@@ -0,0 +1,18 @@
+package main
+
+// orderDirector 主管，封装常用订单的构建步骤
+// 同样的步骤交给不同的生成器，可以得到普通订单或礼品订单
+type orderDirector struct {
+	builder orderBuilder
+}
+
+// buildCoffeeBox 每月咖啡订阅盒：两袋咖啡豆和一个滤杯
+func (d *orderDirector) buildCoffeeBox(customerID, address string) (*Order, error) {
+	return d.builder.
+		customer(customerID).
+		addItem("COFFEE-BEAN", 2, 68).
+		addItem("DRIPPER", 1, 45).
+		coupon("SAVE10").
+		shipTo(address).
+		build()
+}
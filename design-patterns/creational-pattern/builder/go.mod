@@ -0,0 +1,3 @@
+module builder-pattern
+
+go 1.21
@@ -0,0 +1,37 @@
+package main
+
+import "fmt"
+
+func main() {
+	// 直接使用生成器
+	order, err := newStandardOrderBuilder().
+		customer("CUSTOMER-123").
+		addItem("BOOK-GO", 1, 89).
+		addItem("PEN", 3, 5.5).
+		coupon("NEWUSER").
+		shipTo("上海市浦东新区").
+		build()
+	printOrder(order, err)
+
+	// 缺少必填项时一次性返回所有错误
+	_, err = newStandardOrderBuilder().addItem("PEN", 0, 5.5).coupon("SAVE50").build()
+	printOrder(nil, err)
+
+	// 主管 + 不同的生成器
+	standard := &orderDirector{builder: newStandardOrderBuilder()}
+	printOrder(standard.buildCoffeeBox("CUSTOMER-123", "北京市海淀区"))
+
+	gift := &orderDirector{builder: newGiftOrderBuilder("生日快乐")}
+	printOrder(gift.buildCoffeeBox("CUSTOMER-456", "杭州市西湖区"))
+}
+
+func printOrder(order *Order, err error) {
+	if err != nil {
+		fmt.Printf("\n构建失败:\n%v\n", err)
+		return
+	}
+	fmt.Printf("\n客户: %s\n明细: %v\n优惠: %.2f\n金额: %.2f\n地址: %s\n", order.CustomerID, order.Items, order.Discount, order.Amount, order.Shipping)
+	if order.Note != "" {
+		fmt.Printf("备注: %s\n", order.Note)
+	}
+}
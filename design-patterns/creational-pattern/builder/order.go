@@ -0,0 +1,24 @@
+package main
+
+import "time"
+
+// Order 订单，字段沿用mock-demo中的Order，并增加了明细、优惠和配送信息
+type Order struct {
+	ID         string
+	CustomerID string
+	Amount     float64
+	CreatedAt  time.Time
+	Status     string
+
+	Items    []Item
+	Discount float64
+	Shipping string
+	Note     string
+}
+
+// Item 订单明细
+type Item struct {
+	SKU      string
+	Quantity int
+	Price    float64
+}
@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+// newPaymentProcessor 工厂方法
+// 调用方只依赖PaymentProcessor接口，新增支付方式时只需要修改这里
+func newPaymentProcessor(method string) (PaymentProcessor, error) {
+	switch method {
+	case "alipay":
+		return &alipay{limit: 50000}, nil
+	case "wechat":
+		return &wechatPay{limit: 10000}, nil
+	case "card":
+		return &creditCard{feeRate: 0.006}, nil
+	}
+	return nil, fmt.Errorf("unsupported payment method %q", method)
+}
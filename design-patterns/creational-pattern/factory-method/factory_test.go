@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestNewPaymentProcessor(t *testing.T) {
+	tests := []struct {
+		method      string
+		amount      float64
+		wantSuccess bool
+		wantMessage string
+	}{
+		{"alipay", 100.50, true, "支付宝支付 100.50 成功"},
+		{"alipay", 60000, false, "支付宝单笔限额 50000.00"},
+		{"wechat", 10000, true, "微信支付 10000.00 成功"},
+		{"wechat", 10000.01, false, "微信支付单笔限额 10000.00"},
+		{"card", 1000, true, "信用卡支付 1006.00 成功（手续费 6.00）"},
+	}
+	for _, tt := range tests {
+		p, err := newPaymentProcessor(tt.method)
+		if err != nil {
+			t.Fatalf("newPaymentProcessor(%q): %v", tt.method, err)
+		}
+		resp, err := p.ProcessPayment("CUSTOMER-123", tt.amount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Success != tt.wantSuccess || resp.Message != tt.wantMessage {
+			t.Errorf("%s %.2f = %+v, want success=%v message=%q", tt.method, tt.amount, resp, tt.wantSuccess, tt.wantMessage)
+		}
+	}
+}
+
+func TestNewPaymentProcessorUnknown(t *testing.T) {
+	if _, err := newPaymentProcessor("paypal"); err == nil {
+		t.Error("expected error for unsupported method")
+	}
+}
@@ -0,0 +1,3 @@
+module factory-method-pattern
+
+go 1.21
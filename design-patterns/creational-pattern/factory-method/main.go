@@ -0,0 +1,29 @@
+package main
+
+import "fmt"
+
+func main() {
+	payments := []struct {
+		method string
+		amount float64
+	}{
+		{"alipay", 100.50},
+		{"wechat", 20000},
+		{"card", 1000},
+		{"paypal", 10},
+	}
+
+	for _, p := range payments {
+		processor, err := newPaymentProcessor(p.method)
+		if err != nil {
+			fmt.Printf("%s: 创建失败: %v\n", p.method, err)
+			continue
+		}
+		resp, err := processor.ProcessPayment("CUSTOMER-123", p.amount)
+		if err != nil {
+			fmt.Printf("%s: 支付出错: %v\n", p.method, err)
+			continue
+		}
+		fmt.Printf("%s: success=%v, %s\n", p.method, resp.Success, resp.Message)
+	}
+}
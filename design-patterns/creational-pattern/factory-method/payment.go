@@ -0,0 +1,13 @@
+package main
+
+// PaymentResponse 支付结果，与mock-demo中ModernOrderService使用的结构相同
+type PaymentResponse struct {
+	Success bool
+	Message string
+}
+
+// PaymentProcessor 产品接口
+// 工厂方法返回的所有支付方式都实现这个接口
+type PaymentProcessor interface {
+	ProcessPayment(customerID string, amount float64) (*PaymentResponse, error)
+}
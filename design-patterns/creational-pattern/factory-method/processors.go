@@ -0,0 +1,37 @@
+package main
+
+import "fmt"
+
+// alipay 支付宝，单笔限额5万元
+type alipay struct {
+	limit float64
+}
+
+func (a *alipay) ProcessPayment(customerID string, amount float64) (*PaymentResponse, error) {
+	if amount > a.limit {
+		return &PaymentResponse{Success: false, Message: fmt.Sprintf("支付宝单笔限额 %.2f", a.limit)}, nil
+	}
+	return &PaymentResponse{Success: true, Message: fmt.Sprintf("支付宝支付 %.2f 成功", amount)}, nil
+}
+
+// wechatPay 微信支付，单笔限额1万元
+type wechatPay struct {
+	limit float64
+}
+
+func (w *wechatPay) ProcessPayment(customerID string, amount float64) (*PaymentResponse, error) {
+	if amount > w.limit {
+		return &PaymentResponse{Success: false, Message: fmt.Sprintf("微信支付单笔限额 %.2f", w.limit)}, nil
+	}
+	return &PaymentResponse{Success: true, Message: fmt.Sprintf("微信支付 %.2f 成功", amount)}, nil
+}
+
+// creditCard 信用卡，收取手续费
+type creditCard struct {
+	feeRate float64
+}
+
+func (c *creditCard) ProcessPayment(customerID string, amount float64) (*PaymentResponse, error) {
+	fee := amount * c.feeRate
+	return &PaymentResponse{Success: true, Message: fmt.Sprintf("信用卡支付 %.2f 成功（手续费 %.2f）", amount+fee, fee)}, nil
+}
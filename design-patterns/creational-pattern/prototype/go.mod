@@ -0,0 +1,3 @@
+module prototype-pattern
+
+go 1.21
@@ -0,0 +1,38 @@
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("原型模式示例")
+	fmt.Println("============")
+
+	registry := newTemplateRegistry()
+
+	// 每月定期采购的办公用品模板
+	registry.register("office-monthly", &Order{
+		Amount: 320,
+		Items: []Item{
+			{SKU: "PAPER-A4", Quantity: 10, Price: 25},
+			{SKU: "PEN-BLACK", Quantity: 20, Price: 3.5},
+		},
+		Shipping: &Address{City: "上海", Street: "世纪大道100号"},
+		Tags:     map[string]string{"category": "office"},
+	})
+
+	first, _ := registry.newOrder("office-monthly", "CUSTOMER-123")
+	second, _ := registry.newOrder("office-monthly", "CUSTOMER-456")
+
+	// 修改第二个订单不会影响第一个订单和模板
+	second.Items[0].Quantity = 50
+	second.Shipping.City = "北京"
+	second.Tags["urgent"] = "true"
+
+	for _, o := range []*Order{first, second} {
+		fmt.Printf("\n%s 客户: %s\n明细: %v\n地址: %s %s\n标签: %v\n",
+			o.ID, o.CustomerID, o.Items, o.Shipping.City, o.Shipping.Street, o.Tags)
+	}
+
+	if _, err := registry.newOrder("unknown", "CUSTOMER-789"); err != nil {
+		fmt.Println("\n创建失败:", err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import "time"
+
+// Address 收货地址
+type Address struct {
+	City   string
+	Street string
+}
+
+// Item 订单明细
+type Item struct {
+	SKU      string
+	Quantity int
+	Price    float64
+}
+
+// Order 订单，字段沿用mock-demo中的Order，并增加了引用类型字段：
+// 切片、map和指针在浅拷贝时会被共享，clone必须逐一复制
+type Order struct {
+	ID         string
+	CustomerID string
+	Amount     float64
+	CreatedAt  time.Time
+	Status     string
+
+	Items    []Item
+	Shipping *Address
+	Tags     map[string]string
+}
+
+// clone 深拷贝订单
+func (o *Order) clone() prototype {
+	c := *o
+	c.Items = append([]Item(nil), o.Items...)
+	if o.Shipping != nil {
+		addr := *o.Shipping
+		c.Shipping = &addr
+	}
+	if o.Tags != nil {
+		c.Tags = make(map[string]string, len(o.Tags))
+		for k, v := range o.Tags {
+			c.Tags[k] = v
+		}
+	}
+	return &c
+}
@@ -0,0 +1,7 @@
+package main
+
+// prototype 原型接口
+// 实现者负责复制自身，调用方不需要知道具体类型和内部结构
+type prototype interface {
+	clone() prototype
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestOrder() *Order {
+	return &Order{
+		ID:       "ORDER-1",
+		Amount:   100,
+		Items:    []Item{{SKU: "A", Quantity: 1, Price: 100}},
+		Shipping: &Address{City: "上海", Street: "世纪大道"},
+		Tags:     map[string]string{"k": "v"},
+	}
+}
+
+func TestCloneIsDeep(t *testing.T) {
+	orig := newTestOrder()
+	c := orig.clone().(*Order)
+
+	if !reflect.DeepEqual(orig, c) {
+		t.Fatalf("clone differs from original:\n%+v\n%+v", orig, c)
+	}
+
+	c.Items[0].Quantity = 5
+	c.Items = append(c.Items, Item{SKU: "B"})
+	c.Shipping.City = "北京"
+	c.Tags["k"] = "changed"
+
+	if !reflect.DeepEqual(orig, newTestOrder()) {
+		t.Fatalf("modifying the clone changed the original: %+v", orig)
+	}
+}
+
+func TestCloneNilFields(t *testing.T) {
+	c := (&Order{ID: "X"}).clone().(*Order)
+	if c.Shipping != nil || c.Tags != nil || c.Items != nil {
+		t.Errorf("nil fields should stay nil: %+v", c)
+	}
+}
+
+func TestTemplateRegistry(t *testing.T) {
+	fixed := time.Date(2023, 12, 25, 10, 0, 0, 0, time.UTC)
+	r := newTemplateRegistry()
+	r.now = func() time.Time { return fixed }
+
+	tmpl := newTestOrder()
+	r.register("t", tmpl)
+	tmpl.Items[0].SKU = "CHANGED" // 注册后修改传入的订单不影响模板
+
+	a, err := r.newOrder("t", "C1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := r.newOrder("t", "C2")
+
+	if a.ID != "ORDER-1" || b.ID != "ORDER-2" {
+		t.Errorf("ids = %s, %s", a.ID, b.ID)
+	}
+	if a.CustomerID != "C1" || a.Status != "PENDING" || !a.CreatedAt.Equal(fixed) {
+		t.Errorf("order fields not filled: %+v", a)
+	}
+	if a.Items[0].SKU != "A" {
+		t.Errorf("template was affected by later modification: %v", a.Items)
+	}
+
+	b.Tags["k"] = "b"
+	if a.Tags["k"] != "v" {
+		t.Errorf("orders created from the same template share state")
+	}
+
+	if _, err := r.newOrder("missing", "C"); err == nil {
+		t.Error("expected error for unknown template")
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// templateRegistry 订单模板注册表
+// 保存预先配置好的订单原型，创建新订单时复制原型再填入客户信息
+type templateRegistry struct {
+	templates map[string]*Order
+	nextID    int
+	now       func() time.Time
+}
+
+func newTemplateRegistry() *templateRegistry {
+	return &templateRegistry{templates: make(map[string]*Order), now: time.Now}
+}
+
+// register 注册模板，保存的是模板的副本，之后修改传入的订单不会影响模板
+func (r *templateRegistry) register(name string, tmpl *Order) {
+	r.templates[name] = tmpl.clone().(*Order)
+}
+
+// newOrder 复制模板并填入订单ID、客户和创建时间
+func (r *templateRegistry) newOrder(name, customerID string) (*Order, error) {
+	tmpl, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template %q not found", name)
+	}
+	r.nextID++
+	order := tmpl.clone().(*Order)
+	order.ID = fmt.Sprintf("ORDER-%d", r.nextID)
+	order.CustomerID = customerID
+	order.CreatedAt = r.now()
+	order.Status = "PENDING"
+	return order, nil
+}
@@ -0,0 +1,3 @@
+module singleton-pattern
+
+go 1.21
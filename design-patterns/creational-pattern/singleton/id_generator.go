@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// IDGenerator 与mock-demo中ModernOrderService依赖的接口相同
+type IDGenerator interface {
+	GenerateOrderID() string
+}
+
+// orderIDGenerator 全局唯一的订单ID生成器
+// 整个进程只能有一个实例，否则不同实例的序列号会重复
+type orderIDGenerator struct {
+	prefix string
+	seq    atomic.Int64
+}
+
+func (g *orderIDGenerator) GenerateOrderID() string {
+	return fmt.Sprintf("%s-%06d", g.prefix, g.seq.Add(1))
+}
+
+var (
+	once      sync.Once
+	instance  *orderIDGenerator
+	initCount atomic.Int32 // 记录初始化次数，用于验证只初始化了一次
+)
+
+// initIDGenerator 初始化函数，只会执行一次
+// 与basic/synconce中的initDatabase一样，用sleep模拟耗时的初始化
+func initIDGenerator() {
+	initCount.Add(1)
+	time.Sleep(100 * time.Millisecond)
+	instance = &orderIDGenerator{prefix: "ORDER-" + time.Now().Format("20060102")}
+}
+
+// getIDGenerator 获取单例（线程安全）
+// 初始化期间并发调用的goroutine会阻塞，直到初始化完成后拿到同一个实例
+func getIDGenerator() IDGenerator {
+	once.Do(initIDGenerator)
+	return instance
+}
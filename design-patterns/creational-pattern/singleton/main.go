@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+func main() {
+	fmt.Println("单例模式示例")
+	fmt.Println("============")
+
+	var wg sync.WaitGroup
+	ids := make([]string, 5)
+
+	// 多个goroutine同时获取ID生成器并生成订单ID
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i] = getIDGenerator().GenerateOrderID()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, id := range ids {
+		fmt.Printf("Goroutine %d 生成订单ID: %s\n", i, id)
+	}
+	fmt.Printf("初始化次数: %d\n", initCount.Load())
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGetIDGeneratorConcurrent(t *testing.T) {
+	const goroutines = 50
+	const perGoroutine = 100
+
+	var wg sync.WaitGroup
+	instances := make([]IDGenerator, goroutines)
+	results := make(chan string, goroutines*perGoroutine)
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			g := getIDGenerator()
+			instances[i] = g
+			for j := 0; j < perGoroutine; j++ {
+				results <- g.GenerateOrderID()
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	for i, g := range instances {
+		if g != instances[0] {
+			t.Fatalf("goroutine %d got a different instance", i)
+		}
+	}
+	if n := initCount.Load(); n != 1 {
+		t.Fatalf("initialized %d times, want 1", n)
+	}
+
+	seen := make(map[string]bool)
+	for id := range results {
+		if seen[id] {
+			t.Fatalf("duplicate id %s", id)
+		}
+		if !strings.HasPrefix(id, "ORDER-") {
+			t.Fatalf("id %s has wrong prefix", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != goroutines*perGoroutine {
+		t.Fatalf("generated %d ids, want %d", len(seen), goroutines*perGoroutine)
+	}
+}
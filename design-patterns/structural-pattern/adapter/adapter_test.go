@@ -0,0 +1,83 @@
+package main
+
+import "testing"
+
+// fakeCGI 返回固定输出的CGI程序，用于测试解析逻辑
+type fakeCGI string
+
+func (f fakeCGI) serveCGI(env map[string]string) string {
+	return string(f)
+}
+
+func TestCGIAdapter(t *testing.T) {
+	a := &cgiAdapter{app: &cgiApplication{}}
+	tests := []struct {
+		url, method string
+		wantCode    int
+		wantBody    string
+	}{
+		{"/legacy/report", "GET", 200, "Report Generated"},
+		{"/legacy/report", "POST", 404, "No Such Page"},
+		{"/other", "GET", 404, "No Such Page"},
+	}
+	for _, tt := range tests {
+		code, body := a.handleRequest(tt.url, tt.method)
+		if code != tt.wantCode || body != tt.wantBody {
+			t.Errorf("%s %s = (%d, %q), want (%d, %q)", tt.method, tt.url, code, body, tt.wantCode, tt.wantBody)
+		}
+	}
+}
+
+func TestCGIAdapterParsing(t *testing.T) {
+	tests := []struct {
+		out      string
+		wantCode int
+		wantBody string
+	}{
+		{"Status: 201 Created\r\n\r\nCreated", 201, "Created"},
+		{"Content-Type: text/plain\r\nstatus: 302 Found\r\n\r\n", 302, ""},
+		{"Content-Type: text/plain\r\n\r\nno status header", 200, "no status header"},
+		{"Status: abc\r\n\r\nbroken", 502, "Bad Gateway"},
+		{"no header separator", 502, "Bad Gateway"},
+	}
+	for _, tt := range tests {
+		a := &cgiAdapter{app: fakeCGI(tt.out)}
+		code, body := a.handleRequest("/", "GET")
+		if code != tt.wantCode || body != tt.wantBody {
+			t.Errorf("output %q = (%d, %q), want (%d, %q)", tt.out, code, body, tt.wantCode, tt.wantBody)
+		}
+	}
+}
+
+func TestHTTPHandlerAdapter(t *testing.T) {
+	a := &httpHandlerAdapter{handler: newStatusHandler()}
+	tests := []struct {
+		url, method string
+		wantCode    int
+		wantBody    string
+	}{
+		{"/app/status", "GET", 200, "Ok"},
+		{"/app/status", "POST", 405, "Method Not Allowed"},
+		{"/missing", "GET", 404, "404 page not found"},
+	}
+	for _, tt := range tests {
+		code, body := a.handleRequest(tt.url, tt.method)
+		if code != tt.wantCode || body != tt.wantBody {
+			t.Errorf("%s %s = (%d, %q), want (%d, %q)", tt.method, tt.url, code, body, tt.wantCode, tt.wantBody)
+		}
+	}
+}
+
+func TestNginxWithAdapters(t *testing.T) {
+	// 两种完全不同的后端，对Nginx来说都是server
+	backends := []server{
+		&cgiAdapter{app: &cgiApplication{}},
+		&httpHandlerAdapter{handler: newStatusHandler()},
+	}
+	for _, b := range backends {
+		n := &Nginx{backend: b}
+		if code, _ := n.handleRequest("/nowhere", "GET"); code != 404 {
+			t.Errorf("%T: code = %d, want 404", b, code)
+		}
+	}
+}
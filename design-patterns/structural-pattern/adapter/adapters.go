@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+)
+
+// cgiAdapter 把CGI程序适配成server
+// 把(url, method)转换成CGI环境变量，再从CGI输出中解析状态码和响应体
+type cgiAdapter struct {
+	app cgiProgram
+}
+
+func (a *cgiAdapter) handleRequest(url, method string) (int, string) {
+	out := a.app.serveCGI(map[string]string{
+		"REQUEST_METHOD": method,
+		"PATH_INFO":      url,
+	})
+
+	header, body, ok := strings.Cut(out, "\r\n\r\n")
+	if !ok {
+		return 502, "Bad Gateway"
+	}
+	for _, line := range strings.Split(header, "\r\n") {
+		name, value, _ := strings.Cut(line, ":")
+		if !strings.EqualFold(name, "Status") {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			break
+		}
+		code, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return 502, "Bad Gateway"
+		}
+		return code, body
+	}
+	// CGI规范：没有Status头时默认为200
+	return 200, body
+}
+
+// httpHandlerAdapter 把标准库的http.Handler适配成server
+// 构造一个内存中的http.Request，用ResponseRecorder收集响应
+type httpHandlerAdapter struct {
+	handler http.Handler
+}
+
+func (a *httpHandlerAdapter) handleRequest(url, method string) (int, string) {
+	rec := httptest.NewRecorder()
+	a.handler.ServeHTTP(rec, httptest.NewRequest(method, url, nil))
+	return rec.Code, strings.TrimSuffix(rec.Body.String(), "\n")
+}
@@ -0,0 +1,3 @@
+module adapter-pattern
+
+go 1.21
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// cgiProgram 遗留的CGI接口，与server不兼容
+// 通过环境变量接收请求，输出"Status: 200 OK\r\n\r\n<body>"格式的文本
+type cgiProgram interface {
+	serveCGI(env map[string]string) string
+}
+
+// cgiApplication 遗留的CGI程序
+type cgiApplication struct {
+}
+
+func (c *cgiApplication) serveCGI(env map[string]string) string {
+	if env["REQUEST_METHOD"] == "GET" && env["PATH_INFO"] == "/legacy/report" {
+		return "Status: 200 OK\r\nContent-Type: text/plain\r\n\r\nReport Generated"
+	}
+	return "Status: 404 Not Found\r\n\r\nNo Such Page"
+}
+
+// newStatusHandler 标准库的http.Handler，接口同样与server不兼容
+func newStatusHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/app/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprint(w, "Ok")
+	})
+	return mux
+}
@@ -0,0 +1,26 @@
+package main
+
+import "fmt"
+
+func main() {
+	// 遗留CGI程序通过cgiAdapter接入Nginx
+	cgiServer := &Nginx{backend: &cgiAdapter{app: &cgiApplication{}}}
+	// 标准库http.Handler通过httpHandlerAdapter接入Nginx
+	httpServer := &Nginx{backend: &httpHandlerAdapter{handler: newStatusHandler()}}
+
+	requests := []struct {
+		name   string
+		nginx  *Nginx
+		url    string
+		method string
+	}{
+		{"CGI", cgiServer, "/legacy/report", "GET"},
+		{"CGI", cgiServer, "/legacy/unknown", "GET"},
+		{"http.Handler", httpServer, "/app/status", "GET"},
+		{"http.Handler", httpServer, "/app/status", "POST"},
+	}
+	for _, req := range requests {
+		httpCode, body := req.nginx.handleRequest(req.url, req.method)
+		fmt.Printf("\nBackend: %s\nUrl: %s\nHttpCode: %d\nBody: %s\n", req.name, req.url, httpCode, body)
+	}
+}
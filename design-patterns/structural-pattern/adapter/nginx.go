@@ -0,0 +1,10 @@
+package main
+
+// Nginx 客户端，把请求转发给实现了server接口的后端
+type Nginx struct {
+	backend server
+}
+
+func (n *Nginx) handleRequest(url, method string) (int, string) {
+	return n.backend.handleRequest(url, method)
+}
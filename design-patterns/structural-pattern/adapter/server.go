@@ -0,0 +1,7 @@
+package main
+
+// server 目标接口
+// Nginx只认识这个接口，所有后端都需要适配成server才能接入
+type server interface {
+	handleRequest(string, string) (int, string)
+}
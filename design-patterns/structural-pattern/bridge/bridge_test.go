@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// failingSink 总是写入失败
+type failingSink struct{}
+
+func (failingSink) write(line string) error {
+	return errors.New("disk full")
+}
+
+func TestAccessLogger(t *testing.T) {
+	sink := &memorySink{}
+	a := newAccessLogger(sink)
+	a.logRequest("10.0.0.1", "/app/status", "GET", 200)
+
+	want := []string{`10.0.0.1 "GET /app/status" 200`}
+	if !reflect.DeepEqual(sink.lines, want) {
+		t.Fatalf("lines = %v, want %v", sink.lines, want)
+	}
+}
+
+func TestErrorLoggerFiltersLevel(t *testing.T) {
+	sink := &memorySink{}
+	e := newErrorLogger(sink, levelWarn)
+	e.logError(levelDebug, "debug")
+	e.logError(levelInfo, "info")
+	e.logError(levelWarn, "warn")
+	e.logError(levelError, "error")
+
+	want := []string{"[warn] warn", "[error] error"}
+	if !reflect.DeepEqual(sink.lines, want) {
+		t.Fatalf("lines = %v, want %v", sink.lines, want)
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	newAccessLogger(&writerSink{w: &buf}).logRequest("10.0.0.1", "/", "GET", 404)
+	if got := buf.String(); got != "10.0.0.1 \"GET /\" 404\n" {
+		t.Fatalf("output = %q", got)
+	}
+}
+
+func TestBufferedSink(t *testing.T) {
+	memory := &memorySink{}
+	b := &bufferedSink{next: memory, size: 2}
+
+	b.write("a")
+	if len(memory.lines) != 0 {
+		t.Fatalf("line written before buffer is full")
+	}
+	b.write("b")
+	if !reflect.DeepEqual(memory.lines, []string{"a", "b"}) {
+		t.Fatalf("lines = %v", memory.lines)
+	}
+	b.write("c")
+	if err := b.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(memory.lines, []string{"a", "b", "c"}) {
+		t.Fatalf("lines after flush = %v", memory.lines)
+	}
+}
+
+func TestSinkErrorsPropagate(t *testing.T) {
+	if err := newAccessLogger(failingSink{}).logRequest("", "/", "GET", 200); err == nil {
+		t.Error("expected error from access logger")
+	}
+	b := &bufferedSink{next: failingSink{}, size: 1}
+	if err := newErrorLogger(b, levelDebug).logError(levelError, "x"); err == nil {
+		t.Error("expected error from buffered sink")
+	}
+}
@@ -0,0 +1,3 @@
+module bridge-pattern
+
+go 1.21
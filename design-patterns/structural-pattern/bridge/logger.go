@@ -0,0 +1,52 @@
+package main
+
+import "fmt"
+
+// logger 抽象部分，持有对实现部分logSink的引用（桥）
+type logger struct {
+	sink logSink
+}
+
+func (l *logger) log(format string, args ...any) error {
+	return l.sink.write(fmt.Sprintf(format, args...))
+}
+
+// accessLogger 扩展抽象：nginx的access_log
+type accessLogger struct {
+	logger
+}
+
+func newAccessLogger(sink logSink) *accessLogger {
+	return &accessLogger{logger{sink: sink}}
+}
+
+func (a *accessLogger) logRequest(client, url, method string, code int) error {
+	return a.log(`%s "%s %s" %d`, client, method, url, code)
+}
+
+// 日志级别
+const (
+	levelDebug = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// errorLogger 扩展抽象：nginx的error_log，低于minLevel的日志会被丢弃
+type errorLogger struct {
+	logger
+	minLevel int
+}
+
+func newErrorLogger(sink logSink, minLevel int) *errorLogger {
+	return &errorLogger{logger: logger{sink: sink}, minLevel: minLevel}
+}
+
+func (e *errorLogger) logError(level int, msg string) error {
+	if level < e.minLevel {
+		return nil
+	}
+	return e.log("[%s] %s", levelNames[level], msg)
+}
@@ -0,0 +1,27 @@
+package main
+
+import "os"
+
+func main() {
+	// 同一种抽象可以搭配不同的实现
+	stdout := &writerSink{w: os.Stdout}
+	memory := &memorySink{}
+
+	access := newAccessLogger(stdout)
+	access.logRequest("192.168.1.10", "/app/status", "GET", 200)
+	access.logRequest("192.168.1.11", "/create/user", "POST", 201)
+
+	// 不同的抽象也可以共享同一个实现
+	errLog := newErrorLogger(stdout, levelWarn)
+	errLog.logError(levelInfo, "worker process started") // 被过滤
+	errLog.logError(levelError, "upstream timed out while reading response header")
+
+	// 缓冲写入内存，凑满3行才真正写入
+	buffered := &bufferedSink{next: memory, size: 3}
+	bufferedAccess := newAccessLogger(buffered)
+	bufferedAccess.logRequest("10.0.0.1", "/app/status", "GET", 200)
+	bufferedAccess.logRequest("10.0.0.2", "/app/status", "GET", 200)
+	access.log("buffered lines in memory: %d", len(memory.lines))
+	bufferedAccess.logRequest("10.0.0.3", "/app/status", "GET", 200)
+	access.log("buffered lines in memory: %d", len(memory.lines))
+}
@@ -0,0 +1,7 @@
+package main
+
+// logSink 实现部分的接口：日志写到哪里
+// 抽象部分（写什么日志）通过这个接口与实现部分连接，两边可以独立变化
+type logSink interface {
+	write(line string) error
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// memorySink 把日志保存在内存中，便于测试和调试
+type memorySink struct {
+	lines []string
+}
+
+func (s *memorySink) write(line string) error {
+	s.lines = append(s.lines, line)
+	return nil
+}
+
+// writerSink 把日志写到任意io.Writer，例如os.Stdout或文件
+type writerSink struct {
+	w io.Writer
+}
+
+func (s *writerSink) write(line string) error {
+	_, err := fmt.Fprintln(s.w, line)
+	return err
+}
+
+// bufferedSink 缓冲若干行后批量写入下游，模拟写远程日志服务
+type bufferedSink struct {
+	next   logSink
+	size   int
+	buffer []string
+}
+
+func (s *bufferedSink) write(line string) error {
+	s.buffer = append(s.buffer, line)
+	if len(s.buffer) < s.size {
+		return nil
+	}
+	return s.flush()
+}
+
+// flush 把缓冲区中的所有行写入下游
+func (s *bufferedSink) flush() error {
+	var errs []error
+	for _, line := range s.buffer {
+		if err := s.next.write(line); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.buffer = s.buffer[:0]
+	return errors.Join(errs...)
+}
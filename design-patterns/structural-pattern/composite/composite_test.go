@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestTree() location {
+	return newLocationGroup("",
+		newLocationGroup("/app",
+			&endpoint{path: "/status", method: "GET", code: 200, body: "Ok"},
+		),
+		newLocationGroup("/api",
+			newLocationGroup("/v2",
+				&endpoint{path: "/user", method: "POST", code: 201, body: "User Created"},
+				&endpoint{path: "/user", method: "GET", code: 200, body: "User List"},
+			),
+		),
+	)
+}
+
+func TestEndpoint(t *testing.T) {
+	e := &endpoint{path: "/status", method: "GET", code: 200, body: "Ok"}
+	if !e.match("/status") || e.match("/status/x") {
+		t.Error("endpoint should only match its exact path")
+	}
+	if code, _ := e.handleRequest("/status", "POST"); code != 405 {
+		t.Errorf("wrong method code = %d, want 405", code)
+	}
+	if got := e.routes("/app"); !reflect.DeepEqual(got, []string{"GET /app/status"}) {
+		t.Errorf("routes = %v", got)
+	}
+}
+
+func TestLocationGroupMatch(t *testing.T) {
+	g := newLocationGroup("/api")
+	tests := map[string]bool{
+		"/api":     true,
+		"/api/v1":  true,
+		"/apix":    false,
+		"/app/api": false,
+		"/api/":    true,
+		"/":        false,
+	}
+	for url, want := range tests {
+		if got := g.match(url); got != want {
+			t.Errorf("match(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
+
+func TestLocationTreeHandleRequest(t *testing.T) {
+	root := newTestTree()
+	tests := []struct {
+		url, method string
+		wantCode    int
+		wantBody    string
+	}{
+		{"/app/status", "GET", 200, "Ok"},
+		{"/api/v2/user", "POST", 201, "User Created"},
+		{"/api/v2/user", "GET", 200, "User List"},
+		{"/api/v2/user", "DELETE", 405, "Method Not Allowed"},
+		{"/api/v1/user", "GET", 404, "Not Found"},
+		{"/apix/v2/user", "GET", 404, "Not Found"},
+	}
+	for _, tt := range tests {
+		code, body := root.handleRequest(tt.url, tt.method)
+		if code != tt.wantCode || body != tt.wantBody {
+			t.Errorf("%s %s = (%d, %q), want (%d, %q)", tt.method, tt.url, code, body, tt.wantCode, tt.wantBody)
+		}
+	}
+}
+
+func TestLocationTreeRoutes(t *testing.T) {
+	want := []string{"GET /app/status", "POST /api/v2/user", "GET /api/v2/user"}
+	if got := newTestTree().routes(""); !reflect.DeepEqual(got, want) {
+		t.Errorf("routes = %v, want %v", got, want)
+	}
+}
+
+func TestLocationGroupAdd(t *testing.T) {
+	g := newLocationGroup("/api")
+	g.add(&endpoint{path: "/ping", method: "GET", code: 200, body: "pong"})
+	if code, body := g.handleRequest("/api/ping", "GET"); code != 200 || body != "pong" {
+		t.Errorf("got (%d, %q)", code, body)
+	}
+}
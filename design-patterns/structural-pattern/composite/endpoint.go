@@ -0,0 +1,24 @@
+package main
+
+// endpoint 叶子节点，对应一个具体的接口
+type endpoint struct {
+	path   string
+	method string
+	code   int
+	body   string
+}
+
+func (e *endpoint) match(url string) bool {
+	return url == e.path
+}
+
+func (e *endpoint) handleRequest(url, method string) (int, string) {
+	if method != e.method {
+		return 405, "Method Not Allowed"
+	}
+	return e.code, e.body
+}
+
+func (e *endpoint) routes(prefix string) []string {
+	return []string{e.method + " " + prefix + e.path}
+}
@@ -0,0 +1,3 @@
+module composite-pattern
+
+go 1.21
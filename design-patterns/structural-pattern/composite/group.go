@@ -0,0 +1,54 @@
+package main
+
+import "strings"
+
+// locationGroup 组合节点，对应nginx配置中可以嵌套的location块
+// 把去掉自身前缀的URL交给第一个匹配的子节点处理
+type locationGroup struct {
+	prefix   string
+	children []location
+}
+
+func newLocationGroup(prefix string, children ...location) *locationGroup {
+	return &locationGroup{prefix: prefix, children: children}
+}
+
+// add 添加子节点，子节点可以是endpoint，也可以是另一个locationGroup
+func (g *locationGroup) add(children ...location) *locationGroup {
+	g.children = append(g.children, children...)
+	return g
+}
+
+func (g *locationGroup) match(url string) bool {
+	if !strings.HasPrefix(url, g.prefix) {
+		return false
+	}
+	// 前缀需要在路径分隔处结束，/api 不应该匹配 /apix
+	rest := url[len(g.prefix):]
+	return rest == "" || strings.HasPrefix(rest, "/") || strings.HasSuffix(g.prefix, "/")
+}
+
+// handleRequest 同一路径可以有多个子节点（例如GET和POST各一个），
+// 子节点返回405时继续尝试下一个匹配的子节点
+func (g *locationGroup) handleRequest(url, method string) (int, string) {
+	rest := strings.TrimPrefix(url, g.prefix)
+	code, body := 404, "Not Found"
+	for _, child := range g.children {
+		if !child.match(rest) {
+			continue
+		}
+		code, body = child.handleRequest(rest, method)
+		if code != 405 {
+			return code, body
+		}
+	}
+	return code, body
+}
+
+func (g *locationGroup) routes(prefix string) []string {
+	var all []string
+	for _, child := range g.children {
+		all = append(all, child.routes(prefix+g.prefix)...)
+	}
+	return all
+}
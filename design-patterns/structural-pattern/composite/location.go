@@ -0,0 +1,13 @@
+package main
+
+// location 组件接口
+// 叶子节点（单个接口）和组合节点（location块）都实现这个接口，
+// 客户端可以用同样的方式对待一个接口和一整棵路由树
+type location interface {
+	// match 判断URL是否属于这个节点
+	match(url string) bool
+	// handleRequest 处理请求，url是相对于父节点的路径
+	handleRequest(url, method string) (int, string)
+	// routes 列出节点下的所有路由，prefix为父节点的完整前缀
+	routes(prefix string) []string
+}
@@ -0,0 +1,48 @@
+package main
+
+import "fmt"
+
+func main() {
+	// 构建路由树：
+	// /
+	// ├── /app
+	// │   └── GET /status
+	// └── /api
+	//     ├── /v1
+	//     │   └── POST /user
+	//     └── /v2
+	//         ├── POST /user
+	//         └── GET /user
+	root := newLocationGroup("").add(
+		newLocationGroup("/app",
+			&endpoint{path: "/status", method: "GET", code: 200, body: "Ok"},
+		),
+		newLocationGroup("/api",
+			newLocationGroup("/v1",
+				&endpoint{path: "/user", method: "POST", code: 201, body: "User Created"},
+			),
+			newLocationGroup("/v2",
+				&endpoint{path: "/user", method: "POST", code: 201, body: "User Created (v2)"},
+				&endpoint{path: "/user", method: "GET", code: 200, body: "User List"},
+			),
+		),
+	)
+
+	fmt.Println("路由列表:")
+	for _, r := range root.routes("") {
+		fmt.Println("  " + r)
+	}
+
+	requests := []struct{ url, method string }{
+		{"/app/status", "GET"},
+		{"/api/v1/user", "POST"},
+		{"/api/v2/user", "POST"},
+		{"/api/v2/user", "GET"},
+		{"/api/v1/user", "GET"},
+		{"/api/v3/user", "GET"},
+	}
+	for _, req := range requests {
+		httpCode, body := root.handleRequest(req.url, req.method)
+		fmt.Printf("\nUrl: %s %s\nHttpCode: %d\nBody: %s\n", req.method, req.url, httpCode, body)
+	}
+}
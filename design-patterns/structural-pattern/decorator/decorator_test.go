@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// flakyServer 前failures次返回503，之后返回200
+type flakyServer struct {
+	failures int
+	calls    int
+}
+
+func (s *flakyServer) handleRequest(url, method string) (int, string) {
+	s.calls++
+	if s.calls <= s.failures {
+		return 503, "Service Unavailable"
+	}
+	return 200, "Ok"
+}
+
+func TestLoggingDecorator(t *testing.T) {
+	d := &loggingDecorator{wrapped: &Application{}}
+	d.handleRequest("/app/status", "GET")
+	d.handleRequest("/create/user", "GET")
+
+	want := []string{"GET /app/status 200", "GET /create/user 404"}
+	if !reflect.DeepEqual(d.logs, want) {
+		t.Fatalf("logs = %v, want %v", d.logs, want)
+	}
+}
+
+func TestRetryDecorator(t *testing.T) {
+	tests := []struct {
+		failures, maxRetries int
+		wantCode, wantCalls  int
+	}{
+		{failures: 0, maxRetries: 2, wantCode: 200, wantCalls: 1},
+		{failures: 2, maxRetries: 2, wantCode: 200, wantCalls: 3},
+		{failures: 5, maxRetries: 2, wantCode: 503, wantCalls: 3},
+	}
+	for _, tt := range tests {
+		backend := &flakyServer{failures: tt.failures}
+		d := &retryDecorator{wrapped: backend, maxRetries: tt.maxRetries}
+		code, _ := d.handleRequest("/app/status", "GET")
+		if code != tt.wantCode || backend.calls != tt.wantCalls {
+			t.Errorf("failures=%d: got code %d after %d calls, want %d after %d",
+				tt.failures, code, backend.calls, tt.wantCode, tt.wantCalls)
+		}
+	}
+}
+
+func TestJSONDecorator(t *testing.T) {
+	d := &jsonDecorator{wrapped: &Application{}}
+	code, body := d.handleRequest("/create/user", "POST")
+	if code != 201 || body != `{"code":201,"data":"User Created"}` {
+		t.Fatalf("got (%d, %s)", code, body)
+	}
+}
+
+func TestStackedDecorators(t *testing.T) {
+	backend := &flakyServer{failures: 1}
+	logger := &loggingDecorator{wrapped: &retryDecorator{wrapped: backend, maxRetries: 1}}
+	d := &jsonDecorator{wrapped: logger}
+
+	code, body := d.handleRequest("/app/status", "GET")
+	if code != 200 || body != `{"code":200,"data":"Ok"}` {
+		t.Fatalf("got (%d, %s)", code, body)
+	}
+	// 重试在日志装饰器内部，日志只记录最终结果
+	if !reflect.DeepEqual(logger.logs, []string{"GET /app/status 200"}) {
+		t.Fatalf("logs = %v", logger.logs)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// loggingDecorator 日志装饰器
+// 在请求处理后记录一行访问日志，不改变请求和响应
+type loggingDecorator struct {
+	wrapped server
+	logs    []string
+}
+
+func (d *loggingDecorator) handleRequest(url, method string) (int, string) {
+	code, body := d.wrapped.handleRequest(url, method)
+	d.logs = append(d.logs, fmt.Sprintf("%s %s %d", method, url, code))
+	return code, body
+}
+
+// retryDecorator 重试装饰器
+// 被装饰对象返回5xx时最多重试maxRetries次
+type retryDecorator struct {
+	wrapped    server
+	maxRetries int
+}
+
+func (d *retryDecorator) handleRequest(url, method string) (int, string) {
+	code, body := d.wrapped.handleRequest(url, method)
+	for i := 0; i < d.maxRetries && code >= 500; i++ {
+		code, body = d.wrapped.handleRequest(url, method)
+	}
+	return code, body
+}
+
+// jsonDecorator JSON装饰器
+// 把响应体包装成 {"code":200,"data":"Ok"} 格式的JSON
+type jsonDecorator struct {
+	wrapped server
+}
+
+func (d *jsonDecorator) handleRequest(url, method string) (int, string) {
+	code, body := d.wrapped.handleRequest(url, method)
+	out, err := json.Marshal(struct {
+		Code int    `json:"code"`
+		Data string `json:"data"`
+	}{code, body})
+	if err != nil {
+		return 500, err.Error()
+	}
+	return code, string(out)
+}
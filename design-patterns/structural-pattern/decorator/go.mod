@@ -0,0 +1,3 @@
+module decorator-pattern
+
+go 1.21
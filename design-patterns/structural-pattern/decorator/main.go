@@ -0,0 +1,27 @@
+package main
+
+import "fmt"
+
+func main() {
+	// 基础组件
+	app := &Application{}
+
+	// 层层装饰：重试 -> 日志 -> JSON
+	logger := &loggingDecorator{wrapped: &retryDecorator{wrapped: app, maxRetries: 2}}
+	decorated := &jsonDecorator{wrapped: logger}
+
+	requests := []struct{ url, method string }{
+		{"/app/status", "GET"},
+		{"/create/user", "POST"},
+		{"/create/user", "GET"},
+	}
+	for _, req := range requests {
+		httpCode, body := decorated.handleRequest(req.url, req.method)
+		fmt.Printf("\nUrl: %s\nHttpCode: %d\nBody: %s\n", req.url, httpCode, body)
+	}
+
+	fmt.Println("\n访问日志:")
+	for _, line := range logger.logs {
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,7 @@
+package main
+
+// server 定义了服务的接口
+// 被装饰的对象和装饰器都必须实现这个接口，所以装饰器可以层层嵌套
+type server interface {
+	handleRequest(string, string) (int, string)
+}
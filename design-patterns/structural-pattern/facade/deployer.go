@@ -0,0 +1,8 @@
+package main
+
+// siteDeployer 门面对外提供的简单接口
+// 客户端只需要知道域名和后端地址，不需要了解证书、上游、配置文件和重载的细节
+type siteDeployer interface {
+	deploySite(domain string, backends []string) error
+	removeSite(domain string) error
+}
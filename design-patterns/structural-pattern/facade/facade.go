@@ -0,0 +1,51 @@
+package main
+
+import "fmt"
+
+// nginxFacade 门面，实现了siteDeployer接口
+// 按正确的顺序协调各个子系统，并在失败时回滚已经完成的步骤
+type nginxFacade struct {
+	upstreams *upstreamRegistry
+	certs     *certManager
+	configs   *configStore
+	process   *processManager
+}
+
+func newNginxFacade() *nginxFacade {
+	return &nginxFacade{
+		upstreams: newUpstreamRegistry(),
+		certs:     newCertManager(),
+		configs:   newConfigStore(),
+		process:   &processManager{},
+	}
+}
+
+// deploySite 部署一个HTTPS站点：注册上游 -> 签发证书 -> 写入配置 -> 重载
+func (f *nginxFacade) deploySite(domain string, backends []string) error {
+	upstream := domain + "_backend"
+	if err := f.upstreams.register(upstream, backends); err != nil {
+		return fmt.Errorf("deploy %s: %w", domain, err)
+	}
+
+	cert, err := f.certs.issue(domain)
+	if err != nil {
+		// 回滚已经注册的上游
+		f.upstreams.unregister(upstream)
+		return fmt.Errorf("deploy %s: %w", domain, err)
+	}
+
+	f.configs.write(domain, upstream, cert)
+	f.process.reload()
+	return nil
+}
+
+// removeSite 下线站点：删除配置 -> 重载 -> 吊销证书 -> 注销上游
+func (f *nginxFacade) removeSite(domain string) error {
+	if !f.configs.remove(domain) {
+		return fmt.Errorf("remove %s: site not found", domain)
+	}
+	f.process.reload()
+	f.certs.revoke(domain)
+	f.upstreams.unregister(domain + "_backend")
+	return nil
+}
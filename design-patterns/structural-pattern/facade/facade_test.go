@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeploySite(t *testing.T) {
+	f := newNginxFacade()
+	if err := f.deploySite("shop.example.com", []string{"10.0.0.1:8080"}); err != nil {
+		t.Fatal(err)
+	}
+
+	conf, ok := f.configs.files["shop.example.com.conf"]
+	if !ok {
+		t.Fatal("config file not written")
+	}
+	for _, want := range []string{"server_name shop.example.com;", "proxy_pass http://shop.example.com_backend;", "ssl_certificate shop.example.com-1001.pem;"} {
+		if !strings.Contains(conf, want) {
+			t.Errorf("config missing %q:\n%s", want, conf)
+		}
+	}
+	if got := f.upstreams.upstreams["shop.example.com_backend"]; len(got) != 1 {
+		t.Errorf("upstream backends = %v", got)
+	}
+	if f.process.reloads != 1 {
+		t.Errorf("reloads = %d, want 1", f.process.reloads)
+	}
+}
+
+func TestDeploySiteRollsBackOnCertFailure(t *testing.T) {
+	f := newNginxFacade()
+	err := f.deploySite("localhost", []string{"127.0.0.1:8080"})
+	if err == nil {
+		t.Fatal("expected error for unqualified domain")
+	}
+	if len(f.upstreams.upstreams) != 0 {
+		t.Errorf("upstream not rolled back: %v", f.upstreams.upstreams)
+	}
+	if len(f.configs.files) != 0 || f.process.reloads != 0 {
+		t.Errorf("config written or reloaded after failure")
+	}
+}
+
+func TestDeploySiteWithoutBackends(t *testing.T) {
+	f := newNginxFacade()
+	if err := f.deploySite("shop.example.com", nil); err == nil {
+		t.Fatal("expected error for empty backends")
+	}
+	if len(f.certs.certs) != 0 {
+		t.Errorf("certificate issued although upstream registration failed")
+	}
+}
+
+func TestRemoveSite(t *testing.T) {
+	f := newNginxFacade()
+	if err := f.deploySite("shop.example.com", []string{"10.0.0.1:8080"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.removeSite("shop.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.configs.files) != 0 || len(f.certs.certs) != 0 || len(f.upstreams.upstreams) != 0 {
+		t.Errorf("site not fully removed")
+	}
+	if f.process.reloads != 2 {
+		t.Errorf("reloads = %d, want 2", f.process.reloads)
+	}
+	if err := f.removeSite("shop.example.com"); err == nil {
+		t.Error("expected error when removing a missing site")
+	}
+}
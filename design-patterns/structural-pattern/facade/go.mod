@@ -0,0 +1,3 @@
+module facade-pattern
+
+go 1.21
@@ -0,0 +1,26 @@
+package main
+
+import "fmt"
+
+func main() {
+	facade := newNginxFacade()
+	var deployer siteDeployer = facade
+
+	// 部署成功
+	if err := deployer.deploySite("shop.example.com", []string{"10.0.0.1:8080", "10.0.0.2:8080"}); err != nil {
+		fmt.Println("部署失败:", err)
+	}
+	fmt.Printf("\n%s", facade.configs.files["shop.example.com.conf"])
+
+	// 证书签发失败，已注册的上游会被回滚
+	if err := deployer.deploySite("localhost", []string{"127.0.0.1:8080"}); err != nil {
+		fmt.Println("\n部署失败:", err)
+	}
+
+	// 下线站点
+	if err := deployer.removeSite("shop.example.com"); err != nil {
+		fmt.Println("下线失败:", err)
+	}
+	fmt.Printf("\n站点数: %d, 上游数: %d, 证书数: %d, 重载次数: %d\n",
+		len(facade.configs.files), len(facade.upstreams.upstreams), len(facade.certs.certs), facade.process.reloads)
+}
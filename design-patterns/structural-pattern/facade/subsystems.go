@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ============= 子系统：上游注册表 =============
+
+// upstreamRegistry 管理每个站点的上游后端
+type upstreamRegistry struct {
+	upstreams map[string][]string
+}
+
+func newUpstreamRegistry() *upstreamRegistry {
+	return &upstreamRegistry{upstreams: make(map[string][]string)}
+}
+
+func (r *upstreamRegistry) register(name string, backends []string) error {
+	if len(backends) == 0 {
+		return errors.New("upstream: no backend")
+	}
+	r.upstreams[name] = backends
+	return nil
+}
+
+func (r *upstreamRegistry) unregister(name string) {
+	delete(r.upstreams, name)
+}
+
+// ============= 子系统：证书管理 =============
+
+// certificate TLS证书
+type certificate struct {
+	domain string
+	serial int
+}
+
+// certManager 为域名签发TLS证书
+type certManager struct {
+	nextSerial int
+	certs      map[string]certificate
+}
+
+func newCertManager() *certManager {
+	return &certManager{nextSerial: 1000, certs: make(map[string]certificate)}
+}
+
+func (m *certManager) issue(domain string) (certificate, error) {
+	if !strings.Contains(domain, ".") {
+		return certificate{}, fmt.Errorf("cert: %q is not a fully qualified domain", domain)
+	}
+	m.nextSerial++
+	c := certificate{domain: domain, serial: m.nextSerial}
+	m.certs[domain] = c
+	return c, nil
+}
+
+func (m *certManager) revoke(domain string) {
+	delete(m.certs, domain)
+}
+
+// ============= 子系统：配置文件 =============
+
+// configStore 生成并保存nginx的server块配置
+type configStore struct {
+	files map[string]string
+}
+
+func newConfigStore() *configStore {
+	return &configStore{files: make(map[string]string)}
+}
+
+func (s *configStore) write(domain, upstream string, cert certificate) {
+	s.files[domain+".conf"] = fmt.Sprintf(
+		"server {\n    listen 443 ssl;\n    server_name %s;\n    ssl_certificate %s-%d.pem;\n    location / {\n        proxy_pass http://%s;\n    }\n}\n",
+		domain, cert.domain, cert.serial, upstream)
+}
+
+func (s *configStore) remove(domain string) bool {
+	name := domain + ".conf"
+	_, ok := s.files[name]
+	delete(s.files, name)
+	return ok
+}
+
+// ============= 子系统：进程管理 =============
+
+// processManager 通知nginx进程重新加载配置
+type processManager struct {
+	reloads int
+}
+
+func (p *processManager) reload() {
+	p.reloads++
+}
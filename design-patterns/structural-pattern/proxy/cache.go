@@ -0,0 +1,312 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheRoute 按URL前缀设置缓存时间，TTL为0表示该路由不缓存
+type cacheRoute struct {
+	Prefix string
+	TTL    time.Duration
+}
+
+// cacheOptions 缓存配置
+// 命中的第一条路由决定TTL，没有路由命中时使用DefaultTTL；
+// MaxEntries 为缓存条目上限，超过后按LRU淘汰，0表示不限制
+type cacheOptions struct {
+	DefaultTTL time.Duration
+	Routes     []cacheRoute
+	MaxEntries int
+}
+
+// CacheStats 缓存统计
+// Collapsed 为等待同一个key的进行中请求而没有访问后端的次数
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Collapsed int64
+	Evictions int64
+	Entries   int
+}
+
+// HitRatio 命中率，合并的请求也算作命中
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Collapsed + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.Collapsed) / float64(total)
+}
+
+// cacheEntry header为上游的响应头，直接调用handleRequest时为nil
+type cacheEntry struct {
+	key      string
+	code     int
+	header   http.Header
+	body     string
+	expireAt time.Time
+}
+
+// inflightCall 一个正在访问后端的请求，同一个key的并发请求等待它的结果
+// shared为false时结果不能共享（响应体太大或者响应是私有的），等待的请求自己访问后端
+type inflightCall struct {
+	done   chan struct{}
+	code   int
+	header http.Header
+	body   string
+	shared bool
+}
+
+// maxCachedBody 可以缓存的响应体的最大长度，更大的响应既不缓存，也不共享给合并的请求
+const maxCachedBody = 1 << 20
+
+// responseCache 响应缓存
+// 只缓存GET请求的2xx响应，缓存key由请求方法和URL组成；
+// 同一个key的并发未命中请求会合并成一次后端调用；
+// 带有Authorization或Cookie的请求既不缓存也不合并，响应为no-store、private或者带有Set-Cookie时不缓存也不共享
+type responseCache struct {
+	mu       sync.Mutex
+	opts     cacheOptions
+	clock    Clock
+	lru      *list.List // 队头为最近使用的条目
+	entries  map[string]*list.Element
+	inflight map[string]*inflightCall
+	purges   uint64 // 每次清除加1，访问后端期间发生过清除的结果不写入缓存
+	stats    CacheStats
+}
+
+func newResponseCache(opts cacheOptions, clock Clock) *responseCache {
+	return &responseCache{
+		opts:     opts,
+		clock:    clock,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		inflight: make(map[string]*inflightCall),
+	}
+}
+
+// cacheKey 由请求方法和URL组成缓存key
+func cacheKey(url, method string) string {
+	return method + " " + url
+}
+
+// ttlFor 返回URL对应的缓存时间
+func (c *responseCache) ttlFor(url string) time.Duration {
+	for _, r := range c.opts.Routes {
+		if strings.HasPrefix(url, r.Prefix) {
+			return r.TTL
+		}
+	}
+	return c.opts.DefaultTTL
+}
+
+// middleware 实现Middleware
+func (c *responseCache) middleware(next server) server {
+	return serverFunc(func(url, method string) (int, string) {
+		ttl := c.ttlFor(url)
+		x := exchangeOf(next)
+		if method != "GET" || ttl <= 0 || x.private() {
+			return next.handleRequest(url, method)
+		}
+		key := cacheKey(url, method)
+
+		c.mu.Lock()
+		if e, ok := c.lookup(key); ok {
+			c.stats.Hits++
+			c.mu.Unlock()
+			x.setResponseHeader(e.header)
+			return e.code, e.body
+		}
+		if call, ok := c.inflight[key]; ok {
+			c.stats.Collapsed++
+			c.mu.Unlock()
+			<-call.done
+			if !call.shared {
+				return next.handleRequest(url, method)
+			}
+			x.setResponseHeader(call.header)
+			return call.code, call.body
+		}
+		c.stats.Misses++
+		// 后端panic时等待的请求得到502，而不是一直阻塞
+		call := &inflightCall{done: make(chan struct{}), code: 502, body: "Bad Gateway", shared: true}
+		c.inflight[key] = call
+		purges := c.purges
+		c.mu.Unlock()
+
+		defer func() {
+			c.mu.Lock()
+			if c.inflight[key] == call {
+				delete(c.inflight, key)
+			}
+			c.mu.Unlock()
+			close(call.done)
+		}()
+
+		code, body := next.handleRequest(url, method)
+		header := x.responseHeader()
+		// httpProxy只保存了超过maxCachedBody的响应体的开头
+		shared := len(body) <= maxCachedBody && !privateResponse(header)
+		call.code, call.header, call.body, call.shared = code, header, body, shared
+
+		c.mu.Lock()
+		if code >= 200 && code < 300 && shared && c.purges == purges {
+			c.store(&cacheEntry{key: key, code: code, header: header, body: body, expireAt: c.clock.Now().Add(ttl)})
+		}
+		c.mu.Unlock()
+
+		return code, body
+	})
+}
+
+// privateResponse 响应不允许共享缓存保存：Cache-Control为no-store或private，或者设置了Cookie
+func privateResponse(h http.Header) bool {
+	if h.Get("Set-Cookie") != "" {
+		return true
+	}
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if strings.EqualFold(name, "no-store") || strings.EqualFold(name, "private") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookup 查找未过期的条目并标记为最近使用，调用方必须持有c.mu
+func (c *responseCache) lookup(key string) (*cacheEntry, bool) {
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !c.clock.Now().Before(e.expireAt) {
+		c.remove(el)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return e, true
+}
+
+// store 写入条目，超过上限时淘汰最久未使用的条目，调用方必须持有c.mu
+func (c *responseCache) store(e *cacheEntry) {
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	for c.opts.MaxEntries > 0 && c.lru.Len() > c.opts.MaxEntries {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *responseCache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+}
+
+// purge 删除指定请求的缓存，返回是否存在
+// 清除时正在访问后端的请求不再写入缓存，之后到达的请求也不再等待它，而是重新访问后端
+func (c *responseCache) purge(url, method string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(url, method)
+	c.purges++
+	delete(c.inflight, key)
+	el, ok := c.entries[key]
+	if ok {
+		c.remove(el)
+	}
+	return ok
+}
+
+// purgePrefix 删除URL以prefix开头的所有缓存，返回删除的条目数
+func (c *responseCache) purgePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.purges++
+	for key := range c.inflight {
+		if _, url, _ := strings.Cut(key, " "); strings.HasPrefix(url, prefix) {
+			delete(c.inflight, key)
+		}
+	}
+	n := 0
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		e := el.Value.(*cacheEntry)
+		if _, url, _ := strings.Cut(e.key, " "); strings.HasPrefix(url, prefix) {
+			c.remove(el)
+			n++
+		}
+		el = next
+	}
+	return n
+}
+
+// purgeAll 清空缓存
+func (c *responseCache) purgeAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.purges++
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.inflight = make(map[string]*inflightCall)
+}
+
+// snapshot 返回当前的统计信息
+func (c *responseCache) snapshot() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.stats
+	s.Entries = c.lru.Len()
+	return s
+}
+
+// ============= 配置 =============
+
+// CacheConfig 缓存配置
+type CacheConfig struct {
+	TTL        string             `json:"ttl"`
+	MaxEntries int                `json:"maxEntries,omitempty"`
+	Routes     []CacheRouteConfig `json:"routes,omitempty"`
+}
+
+// CacheRouteConfig 路由缓存配置，ttl为"0"表示不缓存
+type CacheRouteConfig struct {
+	Prefix string `json:"prefix"`
+	TTL    string `json:"ttl"`
+}
+
+// newResponseCacheFromConfig 根据配置创建响应缓存
+func newResponseCacheFromConfig(cfg CacheConfig, clock Clock) (*responseCache, error) {
+	opts := cacheOptions{MaxEntries: cfg.MaxEntries}
+	if cfg.MaxEntries < 0 {
+		return nil, fmt.Errorf("cache: maxEntries must not be negative")
+	}
+
+	var err error
+	if opts.DefaultTTL, err = time.ParseDuration(cfg.TTL); err != nil {
+		return nil, fmt.Errorf("cache: invalid ttl %q: %w", cfg.TTL, err)
+	}
+	for _, r := range cfg.Routes {
+		ttl, err := time.ParseDuration(r.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("cache: invalid ttl %q for route %s: %w", r.TTL, r.Prefix, err)
+		}
+		opts.Routes = append(opts.Routes, cacheRoute{Prefix: r.Prefix, TTL: ttl})
+	}
+	return newResponseCache(opts, clock), nil
+}
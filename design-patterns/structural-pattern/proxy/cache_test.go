@@ -0,0 +1,351 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer 统计每个请求到达后端的次数，并发安全
+type countingServer struct {
+	calls atomic.Int64
+	code  int
+	delay time.Duration
+}
+
+func (s *countingServer) handleRequest(url, method string) (int, string) {
+	s.calls.Add(1)
+	time.Sleep(s.delay)
+	return s.code, method + " " + url
+}
+
+func TestResponseCacheHitAndExpiry(t *testing.T) {
+	clock := newFakeClock()
+	c := newResponseCache(cacheOptions{DefaultTTL: time.Minute}, clock)
+	backend := &countingServer{code: 200}
+	h := c.middleware(backend)
+
+	h.handleRequest("/app/status", "GET")
+	code, body := h.handleRequest("/app/status", "GET")
+	if code != 200 || body != "GET /app/status" {
+		t.Fatalf("cached response = (%d, %q)", code, body)
+	}
+	if backend.calls.Load() != 1 {
+		t.Fatalf("backend calls = %d, want 1", backend.calls.Load())
+	}
+
+	clock.Advance(time.Minute)
+	h.handleRequest("/app/status", "GET")
+	if backend.calls.Load() != 2 {
+		t.Fatalf("backend calls after expiry = %d, want 2", backend.calls.Load())
+	}
+
+	s := c.snapshot()
+	if s.Hits != 1 || s.Misses != 2 || s.Entries != 1 {
+		t.Fatalf("stats = %+v, want 1 hit, 2 misses, 1 entry", s)
+	}
+}
+
+func TestResponseCacheOnlyGETAndSuccess(t *testing.T) {
+	c := newResponseCache(cacheOptions{DefaultTTL: time.Minute}, newFakeClock())
+	backend := &countingServer{code: 201}
+	h := c.middleware(backend)
+
+	h.handleRequest("/create/user", "POST")
+	h.handleRequest("/create/user", "POST")
+	if backend.calls.Load() != 2 {
+		t.Fatalf("POST backend calls = %d, want 2", backend.calls.Load())
+	}
+
+	backend.code = 404
+	h.handleRequest("/missing", "GET")
+	h.handleRequest("/missing", "GET")
+	if backend.calls.Load() != 4 {
+		t.Fatalf("404 backend calls = %d, want 4", backend.calls.Load())
+	}
+	if s := c.snapshot(); s.Entries != 0 {
+		t.Fatalf("entries = %d, want 0", s.Entries)
+	}
+}
+
+func TestResponseCachePerRouteTTL(t *testing.T) {
+	clock := newFakeClock()
+	c := newResponseCache(cacheOptions{
+		DefaultTTL: time.Minute,
+		Routes: []cacheRoute{
+			{Prefix: "/app/status", TTL: 5 * time.Second},
+			{Prefix: "/private", TTL: 0},
+		},
+	}, clock)
+	backend := &countingServer{code: 200}
+	h := c.middleware(backend)
+
+	h.handleRequest("/app/status", "GET")
+	h.handleRequest("/app/other", "GET")
+	h.handleRequest("/private/data", "GET")
+	h.handleRequest("/private/data", "GET")
+	if backend.calls.Load() != 4 {
+		t.Fatalf("backend calls = %d, want 4 (/private must not be cached)", backend.calls.Load())
+	}
+
+	clock.Advance(10 * time.Second)
+	h.handleRequest("/app/status", "GET") // 路由TTL 5秒，已过期
+	h.handleRequest("/app/other", "GET")  // 默认TTL 1分钟，仍然命中
+	if backend.calls.Load() != 5 {
+		t.Fatalf("backend calls = %d, want 5", backend.calls.Load())
+	}
+}
+
+func TestResponseCacheLRU(t *testing.T) {
+	c := newResponseCache(cacheOptions{DefaultTTL: time.Minute, MaxEntries: 2}, newFakeClock())
+	backend := &countingServer{code: 200}
+	h := c.middleware(backend)
+
+	h.handleRequest("/a", "GET")
+	h.handleRequest("/b", "GET")
+	h.handleRequest("/a", "GET") // a成为最近使用
+	h.handleRequest("/c", "GET") // 淘汰b
+
+	before := backend.calls.Load()
+	h.handleRequest("/a", "GET")
+	h.handleRequest("/c", "GET")
+	if backend.calls.Load() != before {
+		t.Fatalf("a and c should still be cached")
+	}
+	h.handleRequest("/b", "GET")
+	if backend.calls.Load() != before+1 {
+		t.Fatalf("b should have been evicted")
+	}
+
+	if s := c.snapshot(); s.Evictions != 2 || s.Entries != 2 {
+		t.Fatalf("stats = %+v, want 2 evictions and 2 entries", s)
+	}
+}
+
+func TestResponseCacheCollapsesConcurrentMisses(t *testing.T) {
+	c := newResponseCache(cacheOptions{DefaultTTL: time.Minute}, systemClock{})
+	backend := &countingServer{code: 200, delay: 50 * time.Millisecond}
+	h := c.middleware(backend)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if code, body := h.handleRequest("/app/status", "GET"); code != 200 || body != "GET /app/status" {
+				t.Errorf("response = (%d, %q)", code, body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if backend.calls.Load() != 1 {
+		t.Fatalf("backend calls = %d, want 1", backend.calls.Load())
+	}
+	s := c.snapshot()
+	if s.Misses != 1 || s.Hits+s.Collapsed != 19 {
+		t.Fatalf("stats = %+v, want 1 miss and 19 hits or collapsed", s)
+	}
+}
+
+func TestResponseCacheCollapsesErrors(t *testing.T) {
+	c := newResponseCache(cacheOptions{DefaultTTL: time.Minute}, systemClock{})
+	backend := &countingServer{code: 500, delay: 50 * time.Millisecond}
+	h := c.middleware(backend)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if code, _ := h.handleRequest("/app/status", "GET"); code != 500 {
+				t.Errorf("code = %d, want 500", code)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 合并的请求共享错误结果，但错误结果不会被缓存
+	if s := c.snapshot(); s.Entries != 0 {
+		t.Fatalf("entries = %d, want 0", s.Entries)
+	}
+	before := backend.calls.Load()
+	h.handleRequest("/app/status", "GET")
+	if backend.calls.Load() != before+1 {
+		t.Fatalf("error response must not be served from cache")
+	}
+}
+
+func TestResponseCachePurge(t *testing.T) {
+	c := newResponseCache(cacheOptions{DefaultTTL: time.Minute}, newFakeClock())
+	backend := &countingServer{code: 200}
+	h := c.middleware(backend)
+
+	for _, url := range []string{"/app/status", "/app/version", "/user/1", "/user/2"} {
+		h.handleRequest(url, "GET")
+	}
+
+	if !c.purge("/app/status", "GET") {
+		t.Fatal("purge existing entry returned false")
+	}
+	if c.purge("/app/status", "GET") {
+		t.Fatal("purge missing entry returned true")
+	}
+	if n := c.purgePrefix("/user/"); n != 2 {
+		t.Fatalf("purgePrefix removed %d entries, want 2", n)
+	}
+	if s := c.snapshot(); s.Entries != 1 {
+		t.Fatalf("entries = %d, want 1", s.Entries)
+	}
+
+	before := backend.calls.Load()
+	h.handleRequest("/app/status", "GET")
+	if backend.calls.Load() != before+1 {
+		t.Fatal("purged entry served from cache")
+	}
+
+	c.purgeAll()
+	if s := c.snapshot(); s.Entries != 0 {
+		t.Fatalf("entries after purgeAll = %d, want 0", s.Entries)
+	}
+}
+
+// blockingServer 请求到达后通知started，等待release关闭后返回，panic不为nil时panic
+type blockingServer struct {
+	started chan struct{}
+	release chan struct{}
+	panic   any
+}
+
+func (s *blockingServer) handleRequest(url, method string) (int, string) {
+	close(s.started)
+	<-s.release
+	if s.panic != nil {
+		panic(s.panic)
+	}
+	return 200, method + " " + url
+}
+
+func TestResponseCacheLeaderPanic(t *testing.T) {
+	c := newResponseCache(cacheOptions{DefaultTTL: time.Minute}, systemClock{})
+	backend := &blockingServer{started: make(chan struct{}), release: make(chan struct{}), panic: "boom"}
+	h := c.middleware(backend)
+
+	leader := make(chan any)
+	go func() {
+		defer func() { leader <- recover() }()
+		h.handleRequest("/app/status", "GET")
+	}()
+	<-backend.started
+
+	waiter := make(chan int)
+	go func() {
+		code, _ := h.handleRequest("/app/status", "GET")
+		waiter <- code
+	}()
+	for c.snapshot().Collapsed != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(backend.release)
+
+	if r := <-leader; r != "boom" {
+		t.Fatalf("leader recovered %v, want boom", r)
+	}
+	select {
+	case code := <-waiter:
+		if code != 502 {
+			t.Fatalf("collapsed request code = %d, want 502", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("collapsed request still blocked after leader panicked")
+	}
+	if s := c.snapshot(); s.Entries != 0 {
+		t.Fatalf("entries = %d, want 0", s.Entries)
+	}
+}
+
+func TestResponseCachePurgeDuringFetch(t *testing.T) {
+	for name, purge := range map[string]func(c *responseCache){
+		"purge":       func(c *responseCache) { c.purge("/app/status", "GET") },
+		"purgePrefix": func(c *responseCache) { c.purgePrefix("/app/") },
+		"purgeAll":    func(c *responseCache) { c.purgeAll() },
+	} {
+		c := newResponseCache(cacheOptions{DefaultTTL: time.Minute}, newFakeClock())
+		backend := &blockingServer{started: make(chan struct{}), release: make(chan struct{})}
+		h := c.middleware(backend)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			h.handleRequest("/app/status", "GET")
+		}()
+		<-backend.started
+		purge(c)
+		close(backend.release)
+		<-done
+
+		// 清除之前开始的请求返回的是旧数据，不能写回缓存
+		if s := c.snapshot(); s.Entries != 0 {
+			t.Errorf("%s: entries = %d, want 0", name, s.Entries)
+		}
+
+		// 清除之后到达的请求不再等待旧的请求
+		c = newResponseCache(cacheOptions{DefaultTTL: time.Minute}, newFakeClock())
+		backend = &blockingServer{started: make(chan struct{}), release: make(chan struct{})}
+		h = c.middleware(backend)
+		go h.handleRequest("/app/status", "GET")
+		<-backend.started
+		purge(c)
+		fresh := &countingServer{code: 200}
+		c.middleware(fresh).handleRequest("/app/status", "GET")
+		close(backend.release)
+		if fresh.calls.Load() != 1 {
+			t.Errorf("%s: request after purge collapsed into the stale fetch", name)
+		}
+	}
+}
+
+func TestCacheStatsHitRatio(t *testing.T) {
+	if r := (CacheStats{}).HitRatio(); r != 0 {
+		t.Errorf("empty ratio = %v, want 0", r)
+	}
+	if r := (CacheStats{Hits: 2, Collapsed: 1, Misses: 1}).HitRatio(); r != 0.75 {
+		t.Errorf("ratio = %v, want 0.75", r)
+	}
+}
+
+func TestNewNginxFromConfigWithCache(t *testing.T) {
+	n, err := newNginxFromConfig(ProxyConfig{
+		Cache: &CacheConfig{
+			TTL:        "1m",
+			MaxEntries: 10,
+			Routes:     []CacheRouteConfig{{Prefix: "/create", TTL: "0s"}},
+		},
+		Middlewares: []MiddlewareSpec{
+			{Name: "access", Rules: []accessRule{{Prefix: "/admin", Allow: false}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n.handleRequest("/app/status", "GET")
+	n.handleRequest("/app/status", "GET")
+	n.handleRequest("/admin", "GET")
+	if s := n.cache.snapshot(); s.Hits != 1 || s.Misses != 1 {
+		t.Fatalf("stats = %+v, want 1 hit and 1 miss", s)
+	}
+
+	invalid := []CacheConfig{
+		{TTL: "soon"},
+		{TTL: "1m", MaxEntries: -1},
+		{TTL: "1m", Routes: []CacheRouteConfig{{Prefix: "/a", TTL: "later"}}},
+	}
+	for _, c := range invalid {
+		c := c
+		if _, err := newNginxFromConfig(ProxyConfig{Cache: &c}); err == nil {
+			t.Errorf("newNginxFromConfig(%+v) expected error", c)
+		}
+	}
+}
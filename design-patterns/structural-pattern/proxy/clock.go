@@ -0,0 +1,16 @@
+package main
+
+import "time"
+
+// Clock 时间来源
+// 限流、缓存、熔断等依赖时间的组件都通过Clock获取当前时间，便于在测试中注入假时钟
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock 使用系统时间的Clock实现
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
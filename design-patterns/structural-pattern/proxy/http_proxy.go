@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// httpProxy 将Nginx作为真实的HTTP反向代理运行，实现了http.Handler接口
+// 请求先按客户端IP经过Nginx的限流器，再经过Nginx的中间件链（访问控制、改写、缓存、熔断等），
+// 最后由Nginx的上游组按负载均衡策略选出后端，通过httputil.ReverseProxy转发；
+// 中间件链没有走到上游组时（拒绝、熔断、缓存命中），返回中间件给出的状态码和响应体，
+// 响应头是缓存保存的上游响应头，中间件自己生成的响应使用text/plain
+type httpProxy struct {
+	nginx *Nginx
+	pool  *upstreamPool
+	proxy *httputil.ReverseProxy
+}
+
+// forwardTarget 中间件链改写后的请求URI和选出的后端，通过请求的ctx交给ReverseProxy
+type forwardTarget struct {
+	backend *url.URL
+	uri     *url.URL
+}
+
+type forwardTargetKey struct{}
+
+// exchange HTTP请求在中间件链中携带的请求头和响应头
+// 末端把上游的响应头写入response，缓存命中时缓存把保存的响应头写入response
+type exchange struct {
+	request  http.Header
+	response http.Header
+}
+
+// private 请求带有身份信息，响应可能因人而异，不能缓存也不能与其他请求合并
+func (x *exchange) private() bool {
+	return x != nil && (x.request.Get("Authorization") != "" || x.request.Get("Cookie") != "")
+}
+
+// responseHeader 返回上游的响应头，直接调用handleRequest时返回nil
+func (x *exchange) responseHeader() http.Header {
+	if x == nil {
+		return nil
+	}
+	return x.response
+}
+
+// setResponseHeader 设置中间件链返回的响应对应的响应头
+func (x *exchange) setResponseHeader(h http.Header) {
+	if x != nil {
+		x.response = h.Clone()
+	}
+}
+
+// newHTTPProxy 创建把请求转发到n的上游组的反向代理，上游组中的后端必须都有URL
+func newHTTPProxy(n *Nginx) (*httpProxy, error) {
+	pool, ok := n.upstream.(*upstreamPool)
+	if !ok {
+		return nil, fmt.Errorf("http proxy: nginx has no upstream pool")
+	}
+	for _, b := range pool.backends {
+		if b.target == nil {
+			return nil, fmt.Errorf("http proxy: backend %s has no url", b.name)
+		}
+	}
+
+	p := &httpProxy{nginx: n, pool: pool}
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			t := pr.In.Context().Value(forwardTargetKey{}).(forwardTarget)
+			pr.Out.URL.Path, pr.Out.URL.RawPath, pr.Out.URL.RawQuery = t.uri.Path, t.uri.RawPath, t.uri.RawQuery
+			pr.SetURL(t.backend)
+			// 与nginx的$proxy_add_x_forwarded_for一致：保留已有的X-Forwarded-For并追加客户端地址
+			pr.Out.Header["X-Forwarded-For"] = pr.In.Header["X-Forwarded-For"]
+			pr.SetXForwarded()
+		},
+		// 立即刷新响应，保证流式响应不会被缓冲
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		},
+	}
+	return p, nil
+}
+
+// ServeHTTP 实现http.Handler接口
+func (p *httpProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	client := clientIP(r)
+	if ok, retry := p.nginx.allow(r.URL.Path, r.Method, client); !ok {
+		w.Header().Set("Retry-After", retryAfterSeconds(retry))
+		http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		return
+	}
+
+	// 中间件本身不保存请求相关的状态，每个请求用自己的末端重新组装中间件链
+	x := &exchange{request: r.Header}
+	f := &forwarder{proxy: p, w: w, r: r, client: client, x: x}
+	code, body := chainExchange(f, x, p.nginx.middlewares...).handleRequest(r.URL.RequestURI(), r.Method)
+	if !f.wrote {
+		h := w.Header()
+		if x.response != nil {
+			for k, v := range x.response {
+				h[k] = v
+			}
+		} else {
+			h.Set("Content-Type", "text/plain; charset=utf-8")
+			h.Set("X-Content-Type-Options", "nosniff")
+		}
+		w.WriteHeader(code)
+		io.WriteString(w, body)
+	}
+}
+
+// forwarder 中间件链的末端，从上游组选出后端并把原始请求转发过去
+// 返回的状态码交给熔断、日志等中间件，GET请求的响应体交给缓存
+type forwarder struct {
+	proxy  *httpProxy
+	w      http.ResponseWriter
+	r      *http.Request
+	client string
+	x      *exchange
+	wrote  bool // 响应已经写给客户端
+}
+
+func (f *forwarder) handleRequest(uri, method string) (int, string) {
+	target, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return 400, "Bad Request"
+	}
+	b, code, body := f.proxy.pool.pick(uri, method, f.client)
+	if b == nil {
+		return code, body
+	}
+	b.active.Add(1)
+	defer b.active.Add(-1)
+	b.served.Add(1)
+
+	cw := &captureWriter{ResponseWriter: f.w, x: f.x, code: http.StatusOK, capture: method == "GET"}
+	ctx := context.WithValue(f.r.Context(), forwardTargetKey{}, forwardTarget{backend: b.target, uri: target})
+	f.proxy.proxy.ServeHTTP(cw, f.r.WithContext(ctx))
+	f.wrote = true
+	return cw.code, cw.body.String()
+}
+
+// captureWriter 转发响应的同时记录状态码和响应头，capture为true时最多保存maxCachedBody+1字节的响应体，
+// 超过maxCachedBody的响应体不会被缓存，多保存的一个字节让缓存能够识别出来
+type captureWriter struct {
+	http.ResponseWriter
+	x       *exchange
+	code    int
+	capture bool
+	body    bytes.Buffer
+}
+
+func (c *captureWriter) WriteHeader(code int) {
+	if code >= 200 {
+		c.code = code
+		c.x.setResponseHeader(c.Header())
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *captureWriter) Write(p []byte) (int, error) {
+	if c.capture && c.body.Len() <= maxCachedBody {
+		c.body.Write(p[:min(len(p), maxCachedBody+1-c.body.Len())])
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// Unwrap 让http.ResponseController（ReverseProxy用它刷新响应）找到原始的ResponseWriter
+func (c *captureWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// ============= HTTP后端 =============
+
+// httpServer 通过HTTP访问后端的server实现
+// 上游组用它对HTTP后端做健康检查，直接调用upstreamPool.handleRequest时也通过它转发
+type httpServer struct {
+	base   *url.URL
+	client *http.Client
+}
+
+func (s *httpServer) handleRequest(uri, method string) (int, string) {
+	u, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return 400, "Bad Request"
+	}
+	target := s.base.JoinPath(u.Path)
+	target.RawQuery = u.RawQuery
+
+	req, err := http.NewRequest(method, target.String(), nil)
+	if err != nil {
+		return 400, "Bad Request"
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 502, "Bad Gateway"
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 502, "Bad Gateway"
+	}
+	return resp.StatusCode, string(body)
+}
+
+// newHTTPBackend 创建地址为rawURL的HTTP后端，httpProxy把请求转发到这个地址
+func newHTTPBackend(name, rawURL string, weight int) (*backend, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("upstream: invalid url %q for backend %s: %w", rawURL, name, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("upstream: url %q for backend %s must be absolute", rawURL, name)
+	}
+	b := newBackend(name, &httpServer{base: u, client: &http.Client{Timeout: 10 * time.Second}}, weight)
+	b.target = u
+	return b, nil
+}
+
+// clientIP 从RemoteAddr中提取客户端IP
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// retryAfterSeconds 将等待时间转换为Retry-After头的秒数，向上取整且至少为1秒
+func retryAfterSeconds(d time.Duration) string {
+	s := int(math.Ceil(d.Seconds()))
+	if s < 1 {
+		s = 1
+	}
+	return strconv.Itoa(s)
+}
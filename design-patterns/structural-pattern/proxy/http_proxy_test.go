@@ -0,0 +1,513 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestPool 创建由upstreams组成的上游组，后端按顺序命名为a、b、c……
+func newTestPool(t *testing.T, strategy string, key KeyFunc, upstreams ...string) *upstreamPool {
+	t.Helper()
+	backends := make([]*backend, len(upstreams))
+	for i, u := range upstreams {
+		b, err := newHTTPBackend(string(rune('a'+i)), u, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backends[i] = b
+	}
+	pool, err := newUpstreamPool(strategy, key, healthCheckConfig{}, backends...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+func mustHTTPProxy(t *testing.T, n *Nginx) *httpProxy {
+	t.Helper()
+	p, err := newHTTPProxy(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// serveHTTP 以remoteAddr为客户端地址发送请求，返回响应
+func serveHTTP(p http.Handler, method, target, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	return rec
+}
+
+// countingUpstream 返回自己的名字和请求的URI，并统计收到的请求数
+func countingUpstream(name string, calls *atomic.Int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		io.WriteString(w, name+" "+r.URL.RequestURI())
+	}))
+}
+
+func TestHTTPProxyForwardsRequest(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Upstream", "a")
+		w.Header().Set("X-Echo-Header", r.Header.Get("X-Custom"))
+		w.Header().Set("X-Echo-Forwarded-For", r.Header.Get("X-Forwarded-For"))
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+	}))
+	defer upstream.Close()
+
+	p := mustHTTPProxy(t, newNginxWithUpstream(newTestPool(t, "roundRobin", nil, upstream.URL), nil, nil))
+	front := httptest.NewServer(p)
+	defer front.Close()
+
+	req, _ := http.NewRequest("POST", front.URL+"/create/user?name=tom", strings.NewReader("payload"))
+	req.Header.Set("X-Custom", "hello")
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("status = %d, want 201", resp.StatusCode)
+	}
+	if got := string(body); got != "POST /create/user?name=tom payload" {
+		t.Errorf("body = %q", got)
+	}
+	if got := resp.Header.Get("X-Upstream"); got != "a" {
+		t.Errorf("response header X-Upstream = %q, want a", got)
+	}
+	if got := resp.Header.Get("X-Echo-Header"); got != "hello" {
+		t.Errorf("request header not forwarded, got %q", got)
+	}
+	if got := resp.Header.Get("X-Echo-Forwarded-For"); got != "10.0.0.1, 127.0.0.1" {
+		t.Errorf("X-Forwarded-For = %q, want %q", got, "10.0.0.1, 127.0.0.1")
+	}
+}
+
+func TestHTTPProxyUsesUpstreamPool(t *testing.T) {
+	var calls atomic.Int64
+	a, b := countingUpstream("a", &calls), countingUpstream("b", &calls)
+	defer a.Close()
+	defer b.Close()
+
+	pool := newTestPool(t, "roundRobin", nil, a.URL, b.URL)
+	p := mustHTTPProxy(t, newNginxWithUpstream(pool, nil, nil))
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, serveHTTP(p, "GET", "/app/status", "192.0.2.1:1234").Body.String())
+	}
+	if strings.Join(got, ",") != "a /app/status,b /app/status,a /app/status,b /app/status" {
+		t.Errorf("responses = %v, want a and b in turn", got)
+	}
+
+	// 被健康检查摘除的后端不再收到请求
+	pool.backends[1].healthy.Store(false)
+	for i := 0; i < 2; i++ {
+		if body := serveHTTP(p, "GET", "/app/status", "192.0.2.1:1234").Body.String(); body != "a /app/status" {
+			t.Errorf("body with b ejected = %q, want a", body)
+		}
+	}
+	pool.backends[0].healthy.Store(false)
+	if rec := serveHTTP(p, "GET", "/app/status", "192.0.2.1:1234"); rec.Code != http.StatusBadGateway {
+		t.Errorf("status with no healthy backend = %d, want 502", rec.Code)
+	}
+	if a, b := pool.backends[0].served.Load(), pool.backends[1].served.Load(); a != 4 || b != 2 {
+		t.Errorf("served = (%d, %d), want (4, 2)", a, b)
+	}
+}
+
+func TestHTTPProxyHashByClient(t *testing.T) {
+	var calls atomic.Int64
+	upstreams := make([]string, 3)
+	for i := range upstreams {
+		srv := countingUpstream(string(rune('a'+i)), &calls)
+		defer srv.Close()
+		upstreams[i] = srv.URL
+	}
+	p := mustHTTPProxy(t, newNginxWithUpstream(newTestPool(t, "consistentHash", keyByClient, upstreams...), nil, nil))
+
+	// 同一个客户端的请求总是落到同一个后端，不同客户端分散到各个后端
+	seen := make(map[string]bool)
+	for i := 0; i < 30; i++ {
+		addr := "192.0.2." + strconv.Itoa(i) + ":1234"
+		first := serveHTTP(p, "GET", "/app/status", addr).Body.String()
+		again := serveHTTP(p, "GET", "/other", addr).Body.String()
+		if first[:1] != again[:1] {
+			t.Fatalf("client %s went to %q then %q", addr, first, again)
+		}
+		seen[first[:1]] = true
+	}
+	if len(seen) != 3 {
+		t.Fatalf("clients spread over %d backends, want 3", len(seen))
+	}
+}
+
+func TestHTTPProxyMiddlewareChain(t *testing.T) {
+	var calls atomic.Int64
+	upstream := countingUpstream("a", &calls)
+	defer upstream.Close()
+
+	middlewares, err := buildMiddlewares([]MiddlewareSpec{
+		{Name: "access", Rules: []accessRule{{Prefix: "/admin", Allow: false}}},
+		{Name: "rewrite", Rewrites: []rewriteRule{{From: "/v1/", To: "/"}}},
+		{Name: "cache", Params: map[string]string{"ttl": "1m"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := mustHTTPProxy(t, newNginxWithUpstream(newTestPool(t, "roundRobin", nil, upstream.URL), nil, nil, middlewares...))
+
+	// 访问控制拒绝的请求不会到达后端
+	if rec := serveHTTP(p, "GET", "/admin/users", "192.0.2.1:1234"); rec.Code != http.StatusForbidden {
+		t.Fatalf("denied status = %d, want 403", rec.Code)
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("upstream calls after deny = %d, want 0", calls.Load())
+	}
+
+	// 改写之后再转发，查询参数保留
+	rec := serveHTTP(p, "GET", "/v1/app/status?verbose=1", "192.0.2.1:1234")
+	if rec.Code != http.StatusOK || rec.Body.String() != "a /app/status?verbose=1" {
+		t.Fatalf("rewritten response = (%d, %q)", rec.Code, rec.Body.String())
+	}
+
+	// 第二次由缓存返回，不访问后端
+	rec = serveHTTP(p, "GET", "/v1/app/status?verbose=1", "192.0.2.1:1234")
+	if rec.Code != http.StatusOK || rec.Body.String() != "a /app/status?verbose=1" {
+		t.Fatalf("cached response = (%d, %q)", rec.Code, rec.Body.String())
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("upstream calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestHTTPProxyResponseHeaders(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"status":"ok"}`)
+	}))
+	defer upstream.Close()
+
+	// 中间件链能通过exchange看到请求头，末端把上游的响应头交给中间件
+	var seen string
+	var header http.Header
+	probe := func(next server) server {
+		return serverFunc(func(url, method string) (int, string) {
+			x := exchangeOf(next)
+			seen = x.request.Get("X-Custom")
+			code, body := next.handleRequest(url, method)
+			header = x.responseHeader()
+			return code, body
+		})
+	}
+	p := mustHTTPProxy(t, newNginxWithUpstream(newTestPool(t, "roundRobin", nil, upstream.URL), nil, nil, probe))
+	req := httptest.NewRequest("GET", "/status", nil)
+	req.Header.Set("X-Custom", "hello")
+	p.ServeHTTP(httptest.NewRecorder(), req)
+	if seen != "hello" || header.Get("Content-Type") != "application/json" {
+		t.Errorf("exchange request header = %q, response Content-Type = %q", seen, header.Get("Content-Type"))
+	}
+
+	// 中间件自己生成的响应使用text/plain
+	deny := mustHTTPProxy(t, newNginxWithUpstream(newTestPool(t, "roundRobin", nil, upstream.URL), nil, nil,
+		accessControlMiddleware([]accessRule{{Prefix: "/", Allow: false}})))
+	rec := serveHTTP(deny, "GET", "/status", "192.0.2.1:1234")
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("denied response = (%d, %q)", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestHTTPProxyCacheHeaders(t *testing.T) {
+	var calls atomic.Int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch {
+		case r.Header.Get("Authorization") != "":
+			w.Header().Set("Cache-Control", "private, no-store")
+			io.WriteString(w, "secret of "+r.Header.Get("Authorization"))
+		case r.URL.Path == "/private":
+			w.Header().Set("Cache-Control", "private")
+			io.WriteString(w, "private")
+		case r.URL.Path == "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "alice"})
+			io.WriteString(w, "welcome")
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "max-age=60")
+			io.WriteString(w, `{"status":"ok"}`)
+		}
+	}))
+	defer upstream.Close()
+
+	cache := newResponseCache(cacheOptions{DefaultTTL: time.Minute}, newFakeClock())
+	p := mustHTTPProxy(t, newNginxWithUpstream(newTestPool(t, "roundRobin", nil, upstream.URL), nil, nil, cache.middleware))
+	get := func(target, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 带身份信息的请求不缓存，其他客户端拿不到它的响应
+	if rec := get("/status", "alice"); rec.Body.String() != "secret of alice" {
+		t.Fatalf("alice body = %q", rec.Body.String())
+	}
+	if rec := get("/status", ""); rec.Body.String() != `{"status":"ok"}` {
+		t.Fatalf("bob body = %q, alice's response leaked", rec.Body.String())
+	}
+
+	// 缓存命中时返回上游的响应头
+	rec := get("/status", "")
+	if rec.Body.String() != `{"status":"ok"}` || calls.Load() != 2 {
+		t.Fatalf("cached response = %q after %d upstream calls, want 2", rec.Body.String(), calls.Load())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("cached Content-Type = %q, want application/json", got)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "max-age=60" {
+		t.Errorf("cached Cache-Control = %q, want max-age=60", got)
+	}
+
+	// private和Set-Cookie的响应不缓存
+	for _, path := range []string{"/private", "/login"} {
+		before := calls.Load()
+		get(path, "")
+		rec := get(path, "")
+		if calls.Load() != before+2 {
+			t.Errorf("%s cached, upstream calls = %d, want %d", path, calls.Load(), before+2)
+		}
+		if path == "/login" && rec.Header().Get("Set-Cookie") == "" {
+			t.Errorf("%s lost Set-Cookie", path)
+		}
+	}
+}
+
+func TestHTTPProxyCollapsedPrivateResponse(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n == 1 {
+			<-release
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, strconv.FormatInt(n, 10))
+	}))
+	defer upstream.Close()
+
+	cache := newResponseCache(cacheOptions{DefaultTTL: time.Minute}, newFakeClock())
+	p := mustHTTPProxy(t, newNginxWithUpstream(newTestPool(t, "roundRobin", nil, upstream.URL), nil, nil, cache.middleware))
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- serveHTTP(p, "GET", "/status", "192.0.2.1:1234") }()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan *httptest.ResponseRecorder)
+	go func() { second <- serveHTTP(p, "GET", "/status", "192.0.2.2:1234") }()
+	for cache.snapshot().Collapsed == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if rec := <-first; rec.Body.String() != "1" {
+		t.Fatalf("leader body = %q, want 1", rec.Body.String())
+	}
+	// no-store的响应不共享给等待的请求，它自己访问后端
+	rec := <-second
+	if rec.Body.String() != "2" || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("waiter response = (%q, %q), want its own response", rec.Body.String(), rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestHTTPProxyCircuitBreaker(t *testing.T) {
+	var calls atomic.Int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer upstream.Close()
+
+	clock := newFakeClock()
+	breaker := newCircuitBreaker(2, 10*time.Second, clock)
+	p := mustHTTPProxy(t, newNginxWithUpstream(newTestPool(t, "roundRobin", nil, upstream.URL), nil, nil, breaker.middleware))
+
+	for i := 0; i < 2; i++ {
+		if rec := serveHTTP(p, "GET", "/app/status", "192.0.2.1:1234"); rec.Code != http.StatusInternalServerError {
+			t.Fatalf("request %d status = %d, want 500", i, rec.Code)
+		}
+	}
+	// 上游连续失败后熔断打开，请求不再到达后端
+	if rec := serveHTTP(p, "GET", "/app/status", "192.0.2.1:1234"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("open breaker status = %d, want 503", rec.Code)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("upstream calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestHTTPProxyRateLimit(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "Ok")
+	}))
+	defer upstream.Close()
+
+	clock := newFakeClock()
+	limiter := newFixedWindowLimiter(1, time.Minute, clock)
+	clock.Advance(20 * time.Second)
+
+	p := mustHTTPProxy(t, newNginxWithUpstream(newTestPool(t, "roundRobin", nil, upstream.URL), limiter, keyByClient))
+
+	if rec := serveHTTP(p, "GET", "/app/status", "192.0.2.1:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", rec.Code)
+	}
+	rec := serveHTTP(p, "GET", "/app/status", "192.0.2.1:5678")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "40" {
+		t.Errorf("Retry-After = %q, want 40", got)
+	}
+	// 按客户端限流，其他客户端不受影响
+	if rec := serveHTTP(p, "GET", "/app/status", "192.0.2.2:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("other client status = %d, want 200", rec.Code)
+	}
+}
+
+func TestHTTPProxyStreamsResponse(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "first\n")
+		w.(http.Flusher).Flush()
+		<-release
+		io.WriteString(w, "second\n")
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	p := mustHTTPProxy(t, newNginxWithUpstream(newTestPool(t, "roundRobin", nil, upstream.URL), nil, nil))
+	front := httptest.NewServer(p)
+	defer front.Close()
+
+	resp, err := http.Get(front.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// 上游还没有写完时，客户端就应该能读到第一行
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "first\n" {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+}
+
+func TestHTTPProxyLargeResponseNotCached(t *testing.T) {
+	var calls atomic.Int64
+	large := strings.Repeat("x", maxCachedBody+10)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		io.WriteString(w, large)
+	}))
+	defer upstream.Close()
+
+	p := mustHTTPProxy(t, newNginxWithUpstream(newTestPool(t, "roundRobin", nil, upstream.URL), nil, nil, cachingMiddleware(time.Minute)))
+	for i := 0; i < 2; i++ {
+		if rec := serveHTTP(p, "GET", "/download", "192.0.2.1:1234"); rec.Body.Len() != len(large) {
+			t.Fatalf("request %d body length = %d, want %d", i, rec.Body.Len(), len(large))
+		}
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("upstream calls = %d, want 2 (large responses must not be cached)", calls.Load())
+	}
+}
+
+func TestHTTPProxyBadGateway(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	addr := upstream.URL
+	upstream.Close()
+
+	p := mustHTTPProxy(t, newNginxWithUpstream(newTestPool(t, "roundRobin", nil, addr), nil, nil))
+	if rec := serveHTTP(p, "GET", "/app/status", "192.0.2.1:1234"); rec.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want 502", rec.Code)
+	}
+}
+
+func TestHTTPBackendHealthCheck(t *testing.T) {
+	var down atomic.Bool
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() || r.URL.Path != "/app/status" {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "Ok")
+	}))
+	defer upstream.Close()
+
+	pool, err := newUpstreamPoolFromConfig(UpstreamConfig{
+		Strategy:    "roundRobin",
+		Backends:    []BackendConfig{{Name: "a", URL: upstream.URL}},
+		HealthCheck: &HealthCheckConfig{FailThreshold: 1, RiseThreshold: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, body := pool.handleRequest("/app/status", "GET"); code != 200 || body != "Ok" {
+		t.Fatalf("handleRequest = (%d, %q), want (200, Ok)", code, body)
+	}
+	down.Store(true)
+	if changed := pool.checkHealth(); len(changed) != 1 || len(pool.healthyBackends()) != 0 {
+		t.Fatalf("http backend not ejected, healthy = %v", pool.healthyBackends())
+	}
+}
+
+func TestNewHTTPProxyErrors(t *testing.T) {
+	if _, err := newHTTPProxy(newNginx(nil, nil)); err == nil {
+		t.Error("expected error for nginx without upstream pool")
+	}
+	pool, err := newUpstreamPool("roundRobin", nil, healthCheckConfig{}, newBackend("a", &Application{}, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newHTTPProxy(newNginxWithUpstream(pool, nil, nil)); err == nil {
+		t.Error("expected error for backend without url")
+	}
+	for _, u := range []string{"localhost:8080", "http://%zz", "/app"} {
+		if _, err := newHTTPBackend("a", u, 1); err == nil {
+			t.Errorf("newHTTPBackend(%q) expected error", u)
+		}
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                       "1",
+		300 * time.Millisecond:  "1",
+		1500 * time.Millisecond: "2",
+		40 * time.Second:        "40",
+	}
+	for d, want := range tests {
+		if got := retryAfterSeconds(d); got != want {
+			t.Errorf("retryAfterSeconds(%v) = %s, want %s", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"fmt"
+	"hash/crc32"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// backend 上游组中的一个后端
+type backend struct {
+	name   string
+	server server
+	weight int
+	target *url.URL // HTTP后端的地址，httpProxy把请求转发到这里；Application等进程内后端为nil
+
+	healthy   atomic.Bool
+	active    atomic.Int64 // 正在处理的请求数
+	served    atomic.Int64 // 累计处理的请求数
+	successes int          // 连续健康检查成功次数，只在健康检查中访问
+	failures  int          // 连续健康检查失败次数，只在健康检查中访问
+}
+
+func newBackend(name string, s server, weight int) *backend {
+	if weight <= 0 {
+		weight = 1
+	}
+	b := &backend{name: name, server: s, weight: weight}
+	b.healthy.Store(true)
+	return b
+}
+
+// Balancer 负载均衡策略
+// Pick 从健康的后端中选出一个处理请求，没有可用后端时返回nil
+type Balancer interface {
+	Pick(key string) *backend
+}
+
+// ============= 轮询 =============
+
+type roundRobinBalancer struct {
+	backends []*backend
+	next     atomic.Uint64
+}
+
+func newRoundRobinBalancer(backends []*backend) Balancer {
+	return &roundRobinBalancer{backends: backends}
+}
+
+func (r *roundRobinBalancer) Pick(key string) *backend {
+	n := uint64(len(r.backends))
+	for i := uint64(0); i < n; i++ {
+		b := r.backends[(r.next.Add(1)-1)%n]
+		if b.healthy.Load() {
+			return b
+		}
+	}
+	return nil
+}
+
+// ============= 加权轮询 =============
+
+// weightedRoundRobinBalancer 平滑加权轮询（与nginx的实现相同）
+// 每次选择时所有后端的currentWeight加上自身权重，选出最大者后减去总权重，
+// 这样权重为{5,1,1}时选出的序列是 a a b a c a a，而不是 a a a a a b c
+type weightedRoundRobinBalancer struct {
+	mu             sync.Mutex
+	backends       []*backend
+	currentWeights []int
+}
+
+func newWeightedRoundRobinBalancer(backends []*backend) Balancer {
+	return &weightedRoundRobinBalancer{
+		backends:       backends,
+		currentWeights: make([]int, len(backends)),
+	}
+}
+
+func (w *weightedRoundRobinBalancer) Pick(key string) *backend {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	best, total := -1, 0
+	for i, b := range w.backends {
+		if !b.healthy.Load() {
+			continue
+		}
+		w.currentWeights[i] += b.weight
+		total += b.weight
+		if best == -1 || w.currentWeights[i] > w.currentWeights[best] {
+			best = i
+		}
+	}
+	if best == -1 {
+		return nil
+	}
+	w.currentWeights[best] -= total
+	return w.backends[best]
+}
+
+// ============= 最少连接 =============
+
+// leastConnectionsBalancer 选择正在处理请求数最少的后端，
+// 按权重折算，连接数相同时选择靠前的后端
+type leastConnectionsBalancer struct {
+	backends []*backend
+}
+
+func newLeastConnectionsBalancer(backends []*backend) Balancer {
+	return &leastConnectionsBalancer{backends: backends}
+}
+
+func (l *leastConnectionsBalancer) Pick(key string) *backend {
+	var best *backend
+	for _, b := range l.backends {
+		if !b.healthy.Load() {
+			continue
+		}
+		// 比较 active/weight，交叉相乘避免浮点运算
+		if best == nil || b.active.Load()*int64(best.weight) < best.active.Load()*int64(b.weight) {
+			best = b
+		}
+	}
+	return best
+}
+
+// ============= 一致性哈希 =============
+
+type ringNode struct {
+	hash    uint32
+	backend *backend
+}
+
+// consistentHashBalancer 一致性哈希
+// 每个后端按权重在哈希环上放置多个虚拟节点，同一个key总是落到同一个后端；
+// 后端被摘除时只有落在它上面的key会迁移到环上的下一个健康后端
+type consistentHashBalancer struct {
+	ring []ringNode
+}
+
+// virtualNodes 每单位权重对应的虚拟节点数
+const virtualNodes = 100
+
+func newConsistentHashBalancer(backends []*backend) Balancer {
+	c := &consistentHashBalancer{}
+	for _, b := range backends {
+		for i := 0; i < virtualNodes*b.weight; i++ {
+			h := crc32.ChecksumIEEE([]byte(b.name + "#" + strconv.Itoa(i)))
+			c.ring = append(c.ring, ringNode{hash: h, backend: b})
+		}
+	}
+	sort.Slice(c.ring, func(i, j int) bool { return c.ring[i].hash < c.ring[j].hash })
+	return c
+}
+
+func (c *consistentHashBalancer) Pick(key string) *backend {
+	if len(c.ring) == 0 {
+		return nil
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	start := sort.Search(len(c.ring), func(i int) bool { return c.ring[i].hash >= h })
+	for i := 0; i < len(c.ring); i++ {
+		node := c.ring[(start+i)%len(c.ring)]
+		if node.backend.healthy.Load() {
+			return node.backend
+		}
+	}
+	return nil
+}
+
+// balancerFactories 策略名称到构造函数的映射
+var balancerFactories = map[string]func([]*backend) Balancer{
+	"roundRobin":         newRoundRobinBalancer,
+	"weightedRoundRobin": newWeightedRoundRobinBalancer,
+	"leastConnections":   newLeastConnectionsBalancer,
+	"consistentHash":     newConsistentHashBalancer,
+}
+
+// ============= 上游组 =============
+
+// healthCheckConfig 主动健康检查配置
+// 连续失败FailThreshold次后摘除后端，摘除后连续成功RiseThreshold次再恢复
+type healthCheckConfig struct {
+	URL           string
+	Method        string
+	Interval      time.Duration
+	FailThreshold int
+	RiseThreshold int
+}
+
+// upstreamPool 上游组，实现了server接口
+// 按负载均衡策略把请求分发给健康的后端，可以直接放在Nginx中间件链的末端
+type upstreamPool struct {
+	backends    []*backend
+	balancer    Balancer
+	key         KeyFunc
+	hashed      bool // 策略是否使用key选择后端
+	healthCheck healthCheckConfig
+
+	checkMu sync.Mutex
+	mu      sync.Mutex // 保护stop和done
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// newUpstreamPool 创建上游组
+// strategy 为负载均衡策略名称，key 决定一致性哈希使用的key
+func newUpstreamPool(strategy string, key KeyFunc, hc healthCheckConfig, backends ...*backend) (*upstreamPool, error) {
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("upstream: no backend")
+	}
+	factory, ok := balancerFactories[strategy]
+	if !ok {
+		return nil, fmt.Errorf("upstream: unknown strategy %q", strategy)
+	}
+	if key == nil {
+		key = keyByURL
+	}
+	if hc.URL == "" {
+		hc.URL = "/app/status"
+	}
+	if hc.Method == "" {
+		hc.Method = "GET"
+	}
+	if hc.FailThreshold <= 0 {
+		hc.FailThreshold = 3
+	}
+	if hc.RiseThreshold <= 0 {
+		hc.RiseThreshold = 2
+	}
+	return &upstreamPool{
+		backends:    backends,
+		balancer:    factory(backends),
+		key:         key,
+		hashed:      strategy == "consistentHash",
+		healthCheck: hc,
+	}, nil
+}
+
+// handleRequest 选出一个后端并转发请求，没有健康的后端时返回502
+// server接口中没有客户端信息，按client哈希时返回400，这种策略只用于httpProxy
+func (p *upstreamPool) handleRequest(url, method string) (int, string) {
+	b, code, body := p.pick(url, method, "")
+	if b == nil {
+		return code, body
+	}
+	b.active.Add(1)
+	defer b.active.Add(-1)
+	b.served.Add(1)
+	return b.server.handleRequest(url, method)
+}
+
+// pick 按负载均衡策略选出后端，client为客户端标识，没有客户端信息时为空字符串
+// 选不出后端时返回nil以及应该返回给客户端的状态码和响应体：
+// 一致性哈希取不到key时（按client哈希但没有客户端信息）返回400，而不是所有请求落到同一个后端
+func (p *upstreamPool) pick(url, method, client string) (*backend, int, string) {
+	key := p.key(url, method, client)
+	if p.hashed && key == "" {
+		return nil, 400, "Missing Client"
+	}
+	b := p.balancer.Pick(key)
+	if b == nil {
+		return nil, 502, "No Healthy Upstream"
+	}
+	return b, 0, ""
+}
+
+// checkHealth 对所有后端执行一轮健康检查
+// 2xx响应视为成功，状态变化时返回发生变化的后端
+func (p *upstreamPool) checkHealth() []*backend {
+	p.checkMu.Lock()
+	defer p.checkMu.Unlock()
+
+	var changed []*backend
+	for _, b := range p.backends {
+		code, _ := b.server.handleRequest(p.healthCheck.URL, p.healthCheck.Method)
+		if code >= 200 && code < 300 {
+			b.failures = 0
+			b.successes++
+			if !b.healthy.Load() && b.successes >= p.healthCheck.RiseThreshold {
+				b.healthy.Store(true)
+				changed = append(changed, b)
+			}
+			continue
+		}
+		b.successes = 0
+		b.failures++
+		if b.healthy.Load() && b.failures >= p.healthCheck.FailThreshold {
+			b.healthy.Store(false)
+			changed = append(changed, b)
+		}
+	}
+	return changed
+}
+
+// startHealthChecks 按Interval周期性执行健康检查，直到调用stopHealthChecks
+// 可以和stopHealthChecks并发调用，已经启动时不做任何事
+func (p *upstreamPool) startHealthChecks() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil || p.healthCheck.Interval <= 0 {
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	p.stop, p.done = stop, done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(p.healthCheck.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.checkHealth()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopHealthChecks 停止健康检查并等待后台goroutine退出
+func (p *upstreamPool) stopHealthChecks() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+	p.stop, p.done = nil, nil
+}
+
+// healthyBackends 返回当前健康的后端名称
+func (p *upstreamPool) healthyBackends() []string {
+	var names []string
+	for _, b := range p.backends {
+		if b.healthy.Load() {
+			names = append(names, b.name)
+		}
+	}
+	return names
+}
+
+// ============= 配置 =============
+
+// UpstreamConfig 上游组配置
+// Strategy 可选 roundRobin、weightedRoundRobin、leastConnections、consistentHash
+// Key 为一致性哈希使用的key，可选 url（默认）、method、client，client只在httpProxy中可用
+type UpstreamConfig struct {
+	Strategy    string             `json:"strategy"`
+	Key         string             `json:"key,omitempty"`
+	Backends    []BackendConfig    `json:"backends"`
+	HealthCheck *HealthCheckConfig `json:"healthCheck,omitempty"`
+}
+
+// HealthCheckConfig 健康检查配置，未设置Interval时不会启动后台检查
+type HealthCheckConfig struct {
+	URL           string `json:"url,omitempty"`
+	Method        string `json:"method,omitempty"`
+	Interval      string `json:"interval,omitempty"`
+	FailThreshold int    `json:"failThreshold,omitempty"`
+	RiseThreshold int    `json:"riseThreshold,omitempty"`
+}
+
+// BackendConfig 后端配置
+// URL 不为空时后端是这个地址上的HTTP服务器，否则是一个独立的Application实例
+type BackendConfig struct {
+	Name   string `json:"name"`
+	URL    string `json:"url,omitempty"`
+	Weight int    `json:"weight,omitempty"`
+}
+
+// newUpstreamPoolFromConfig 根据配置创建上游组
+func newUpstreamPoolFromConfig(cfg UpstreamConfig) (*upstreamPool, error) {
+	var key KeyFunc
+	if cfg.Key != "" {
+		var ok bool
+		if key, ok = keyFuncs[cfg.Key]; !ok {
+			return nil, fmt.Errorf("upstream: unknown key %q", cfg.Key)
+		}
+	}
+
+	var hc healthCheckConfig
+	if cfg.HealthCheck != nil {
+		hc = healthCheckConfig{
+			URL:           cfg.HealthCheck.URL,
+			Method:        cfg.HealthCheck.Method,
+			FailThreshold: cfg.HealthCheck.FailThreshold,
+			RiseThreshold: cfg.HealthCheck.RiseThreshold,
+		}
+		if cfg.HealthCheck.Interval != "" {
+			d, err := time.ParseDuration(cfg.HealthCheck.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("upstream: invalid health check interval %q: %w", cfg.HealthCheck.Interval, err)
+			}
+			hc.Interval = d
+		}
+	}
+
+	backends := make([]*backend, 0, len(cfg.Backends))
+	for _, bc := range cfg.Backends {
+		if bc.URL == "" {
+			backends = append(backends, newBackend(bc.Name, &Application{}, bc.Weight))
+			continue
+		}
+		b, err := newHTTPBackend(bc.Name, bc.URL, bc.Weight)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, b)
+	}
+	return newUpstreamPool(cfg.Strategy, key, hc, backends...)
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// switchableServer 可以切换健康状态的后端
+type switchableServer struct {
+	mu   sync.Mutex
+	down bool
+}
+
+func (s *switchableServer) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func (s *switchableServer) handleRequest(url, method string) (int, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return 503, "Service Unavailable"
+	}
+	return 200, "Ok"
+}
+
+func newTestBackends(weights ...int) []*backend {
+	backends := make([]*backend, len(weights))
+	for i, w := range weights {
+		backends[i] = newBackend(string(rune('a'+i)), &switchableServer{}, w)
+	}
+	return backends
+}
+
+// pickSequence 连续选择n次，返回选中的后端名称序列
+func pickSequence(b Balancer, n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		if picked := b.Pick(""); picked != nil {
+			sb.WriteString(picked.name)
+		} else {
+			sb.WriteString("-")
+		}
+	}
+	return sb.String()
+}
+
+func TestRoundRobinBalancer(t *testing.T) {
+	backends := newTestBackends(1, 1, 1)
+	b := newRoundRobinBalancer(backends)
+
+	if got := pickSequence(b, 6); got != "abcabc" {
+		t.Fatalf("sequence = %s, want abcabc", got)
+	}
+
+	backends[1].healthy.Store(false)
+	if got := pickSequence(b, 4); got != "acac" {
+		t.Fatalf("sequence with b ejected = %s, want acac", got)
+	}
+}
+
+func TestWeightedRoundRobinBalancer(t *testing.T) {
+	backends := newTestBackends(5, 1, 1)
+	b := newWeightedRoundRobinBalancer(backends)
+
+	// 平滑加权轮询不会连续选中同一个高权重后端5次
+	if got := pickSequence(b, 7); got != "aabacaa" {
+		t.Fatalf("sequence = %s, want aabacaa", got)
+	}
+
+	backends[0].healthy.Store(false)
+	if got := pickSequence(b, 4); got != "bcbc" && got != "cbcb" {
+		t.Fatalf("sequence with a ejected = %s, want alternating b and c", got)
+	}
+}
+
+func TestLeastConnectionsBalancer(t *testing.T) {
+	backends := newTestBackends(1, 1, 2)
+	b := newLeastConnectionsBalancer(backends)
+
+	backends[0].active.Store(3)
+	backends[1].active.Store(1)
+	backends[2].active.Store(3) // 权重为2，折算后为1.5
+	if got := b.Pick(""); got.name != "b" {
+		t.Fatalf("picked %s, want b", got.name)
+	}
+
+	backends[1].active.Store(5)
+	if got := b.Pick(""); got.name != "c" {
+		t.Fatalf("picked %s, want c", got.name)
+	}
+
+	backends[2].healthy.Store(false)
+	if got := b.Pick(""); got.name != "a" {
+		t.Fatalf("picked %s, want a", got.name)
+	}
+}
+
+func TestConsistentHashBalancer(t *testing.T) {
+	backends := newTestBackends(1, 1, 1)
+	b := newConsistentHashBalancer(backends)
+
+	keys := make([]string, 300)
+	before := make(map[string]*backend)
+	counts := make(map[string]int)
+	for i := range keys {
+		keys[i] = "/user/" + strconv.Itoa(i)
+		before[keys[i]] = b.Pick(keys[i])
+		counts[before[keys[i]].name]++
+	}
+
+	// 同一个key总是选中同一个后端
+	for _, k := range keys {
+		if b.Pick(k) != before[k] {
+			t.Fatalf("key %s moved without topology change", k)
+		}
+	}
+	// 每个后端都分到了一部分key
+	for _, backend := range backends {
+		if counts[backend.name] == 0 {
+			t.Fatalf("backend %s received no keys: %v", backend.name, counts)
+		}
+	}
+
+	// 摘除b后，只有原来落在b上的key会迁移
+	backends[1].healthy.Store(false)
+	for _, k := range keys {
+		after := b.Pick(k)
+		if before[k].name != "b" && after != before[k] {
+			t.Fatalf("key %s moved from %s to %s although its backend is healthy", k, before[k].name, after.name)
+		}
+		if after.name == "b" {
+			t.Fatalf("key %s routed to ejected backend", k)
+		}
+	}
+
+	// b恢复后，key回到原来的后端
+	backends[1].healthy.Store(true)
+	for _, k := range keys {
+		if b.Pick(k) != before[k] {
+			t.Fatalf("key %s did not return to %s", k, before[k].name)
+		}
+	}
+}
+
+func TestBalancersNoHealthyBackend(t *testing.T) {
+	for name, factory := range balancerFactories {
+		backends := newTestBackends(1, 1)
+		for _, b := range backends {
+			b.healthy.Store(false)
+		}
+		if got := factory(backends).Pick("k"); got != nil {
+			t.Errorf("%s picked %s, want nil", name, got.name)
+		}
+	}
+}
+
+func TestUpstreamPoolHealthCheck(t *testing.T) {
+	servers := []*switchableServer{{}, {}}
+	pool, err := newUpstreamPool("roundRobin", nil, healthCheckConfig{FailThreshold: 2, RiseThreshold: 2},
+		newBackend("a", servers[0], 1),
+		newBackend("b", servers[1], 1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	servers[1].setDown(true)
+
+	// 连续失败未达到阈值前不摘除
+	if changed := pool.checkHealth(); len(changed) != 0 {
+		t.Fatalf("changed after 1 failure = %d, want 0", len(changed))
+	}
+	if changed := pool.checkHealth(); len(changed) != 1 || changed[0].name != "b" {
+		t.Fatalf("expected b to be ejected, changed = %v", changed)
+	}
+	if got := strings.Join(pool.healthyBackends(), ","); got != "a" {
+		t.Fatalf("healthy = %s, want a", got)
+	}
+	for i := 0; i < 4; i++ {
+		if code, _ := pool.handleRequest("/app/status", "GET"); code != 200 {
+			t.Fatalf("request %d code = %d, want 200 (ejected backend must not receive traffic)", i, code)
+		}
+	}
+
+	// 恢复后连续成功达到阈值才重新加入
+	servers[1].setDown(false)
+	pool.checkHealth()
+	if got := strings.Join(pool.healthyBackends(), ","); got != "a" {
+		t.Fatalf("healthy after 1 success = %s, want a", got)
+	}
+	pool.checkHealth()
+	if got := strings.Join(pool.healthyBackends(), ","); got != "a,b" {
+		t.Fatalf("healthy after 2 successes = %s, want a,b", got)
+	}
+
+	// 全部摘除后返回502
+	servers[0].setDown(true)
+	servers[1].setDown(true)
+	pool.checkHealth()
+	pool.checkHealth()
+	if code, _ := pool.handleRequest("/app/status", "GET"); code != 502 {
+		t.Fatalf("code with no healthy backend = %d, want 502", code)
+	}
+}
+
+func TestUpstreamPoolBackgroundHealthCheck(t *testing.T) {
+	srv := &switchableServer{}
+	pool, err := newUpstreamPool("roundRobin", nil,
+		healthCheckConfig{Interval: time.Millisecond, FailThreshold: 1, RiseThreshold: 1},
+		newBackend("a", srv, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.startHealthChecks()
+	defer pool.stopHealthChecks()
+
+	srv.setDown(true)
+	waitFor(t, func() bool { return len(pool.healthyBackends()) == 0 })
+	srv.setDown(false)
+	waitFor(t, func() bool { return len(pool.healthyBackends()) == 1 })
+}
+
+// waitFor 轮询等待条件成立，超时后测试失败
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestUpstreamPoolHashByClient(t *testing.T) {
+	pool, err := newUpstreamPool("consistentHash", keyByClient, healthCheckConfig{}, newTestBackends(1, 1, 1)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// server接口中没有客户端信息，不能所有请求都落到同一个后端
+	if code, _ := pool.handleRequest("/app/status", "GET"); code != 400 {
+		t.Fatalf("code without client = %d, want 400", code)
+	}
+
+	// 同一个客户端总是落到同一个后端，不同客户端分散到各个后端
+	picked := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		client := "192.0.2." + strconv.Itoa(i)
+		first, _, _ := pool.pick("/app/status", "GET", client)
+		again, _, _ := pool.pick("/other", "POST", client)
+		if first == nil || first != again {
+			t.Fatalf("client %s picked %v then %v", client, first, again)
+		}
+		picked[first.name] = true
+	}
+	if len(picked) != 3 {
+		t.Fatalf("clients spread over %d backends, want 3", len(picked))
+	}
+
+	// 其他策略不使用key，没有客户端信息也可以转发
+	rr, err := newUpstreamPool("roundRobin", keyByClient, healthCheckConfig{}, newTestBackends(1)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := rr.handleRequest("/app/status", "GET"); code != 200 {
+		t.Fatalf("roundRobin code = %d, want 200", code)
+	}
+}
+
+func TestUpstreamPoolStartStopConcurrent(t *testing.T) {
+	pool, err := newUpstreamPool("roundRobin", nil, healthCheckConfig{Interval: time.Millisecond}, newTestBackends(1)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			pool.startHealthChecks()
+		}()
+		go func() {
+			defer wg.Done()
+			pool.stopHealthChecks()
+		}()
+	}
+	wg.Wait()
+	pool.stopHealthChecks()
+	if pool.stop != nil || pool.done != nil {
+		t.Fatal("health checks still running after stopHealthChecks")
+	}
+}
+
+func TestUpstreamPoolConcurrent(t *testing.T) {
+	for name := range balancerFactories {
+		t.Run(name, func(t *testing.T) {
+			pool, err := newUpstreamPool(name, nil, healthCheckConfig{}, newTestBackends(3, 2, 1)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					for j := 0; j < 50; j++ {
+						pool.handleRequest("/app/status/"+strconv.Itoa(j), "GET")
+						if j%10 == 0 {
+							pool.checkHealth()
+						}
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			var total int64
+			for _, b := range pool.backends {
+				total += b.served.Load()
+				if b.active.Load() != 0 {
+					t.Errorf("backend %s active = %d after all requests finished", b.name, b.active.Load())
+				}
+			}
+			if total != 500 {
+				t.Fatalf("served = %d, want 500", total)
+			}
+		})
+	}
+}
+
+func TestNewNginxFromConfigWithUpstream(t *testing.T) {
+	cfg := ProxyConfig{
+		Upstream: &UpstreamConfig{
+			Strategy: "weightedRoundRobin",
+			Backends: []BackendConfig{{Name: "app-1", Weight: 2}, {Name: "app-2"}},
+		},
+	}
+	n, err := newNginxFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.close()
+
+	for i := 0; i < 3; i++ {
+		if code, _ := n.handleRequest("/app/status", "GET"); code != 200 {
+			t.Fatalf("code = %d, want 200", code)
+		}
+	}
+	pool := n.upstream.(*upstreamPool)
+	if a, b := pool.backends[0].served.Load(), pool.backends[1].served.Load(); a != 2 || b != 1 {
+		t.Fatalf("served = (%d, %d), want (2, 1)", a, b)
+	}
+
+	invalid := []UpstreamConfig{
+		{Strategy: "random", Backends: []BackendConfig{{Name: "a"}}},
+		{Strategy: "roundRobin"},
+		{Strategy: "consistentHash", Key: "header", Backends: []BackendConfig{{Name: "a"}}},
+		{Strategy: "roundRobin", Backends: []BackendConfig{{Name: "a"}}, HealthCheck: &HealthCheckConfig{Interval: "often"}},
+		{Strategy: "roundRobin", Backends: []BackendConfig{{Name: "a", URL: "localhost:8080"}}},
+	}
+	for _, u := range invalid {
+		u := u
+		if _, err := newNginxFromConfig(ProxyConfig{Upstream: &u}); err == nil {
+			t.Errorf("newNginxFromConfig(%+v) expected error", u)
+		}
+	}
+}
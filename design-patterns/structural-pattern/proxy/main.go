@@ -1,6 +1,14 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+)
 
 func main() {
 	// 创建Nginx代理服务器
@@ -27,4 +35,171 @@ func main() {
 	// 错误的请求方法 - 应该返回404
 	httpCode, body = nginxServer.handleRequest(createuserURL, "GET")
 	fmt.Printf("\nUrl: %s\nHttpCode: %d\nBody: %s\n", createuserURL, httpCode, body)
+
+	// 通过配置组合中间件链
+	fmt.Println("\n=== 中间件链示例 ===")
+	middlewareChainExample()
+
+	// 作为真实的HTTP反向代理运行
+	fmt.Println("\n=== 反向代理示例 ===")
+	reverseProxyExample()
+
+	// 多个后端之间的负载均衡
+	fmt.Println("\n=== 负载均衡示例 ===")
+	loadBalancingExample()
+
+	// 响应缓存
+	fmt.Println("\n=== 响应缓存示例 ===")
+	cachingExample()
+}
+
+// middlewareChainExample 演示通过配置组合中间件链
+func middlewareChainExample() {
+	cfgJSON := `{
+		"rateLimit": {"algorithm": "tokenBucket", "key": "url", "rate": 1, "burst": 5},
+		"cache": {"ttl": "30s", "maxEntries": 100},
+		"middlewares": [
+			{"name": "logging"},
+			{"name": "access", "rules": [{"prefix": "/admin", "allow": false}]},
+			{"name": "rewrite", "rewrites": [{"from": "/v1/", "to": "/"}]},
+			{"name": "circuitBreaker", "threshold": 3, "params": {"openTimeout": "5s"}}
+		]
+	}`
+
+	cfg, err := loadProxyConfig(strings.NewReader(cfgJSON))
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	nginxServer, err := newNginxFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("创建代理失败: %v", err)
+	}
+
+	for _, req := range []struct{ url, method string }{
+		{"/v1/app/status", "GET"},
+		{"/admin/users", "GET"},
+		{"/v1/create/user", "POST"},
+	} {
+		httpCode, body := nginxServer.handleRequest(req.url, req.method)
+		fmt.Printf("\nUrl: %s\nHttpCode: %d\nBody: %s\n", req.url, httpCode, body)
+	}
+}
+
+// reverseProxyExample 演示Nginx作为真实的HTTP反向代理运行
+func reverseProxyExample() {
+	// 两个上游服务器，响应中带上自己的名字
+	newUpstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s: %s %s (X-Forwarded-For: %s)", name, r.Method, r.URL.Path, r.Header.Get("X-Forwarded-For"))
+		}))
+	}
+	upstreamA, upstreamB := newUpstream("upstream-a"), newUpstream("upstream-b")
+	defer upstreamA.Close()
+	defer upstreamB.Close()
+
+	// 每个客户端每分钟最多4个请求，/admin被访问控制拒绝，请求在两个上游服务器之间轮询
+	nginxServer, err := newNginxFromConfig(ProxyConfig{
+		RateLimit: &RateLimitConfig{Algorithm: "fixedWindow", Key: "client", Limit: 4, Window: "1m"},
+		Upstream: &UpstreamConfig{
+			Strategy: "roundRobin",
+			Backends: []BackendConfig{{Name: "upstream-a", URL: upstreamA.URL}, {Name: "upstream-b", URL: upstreamB.URL}},
+		},
+		Middlewares: []MiddlewareSpec{
+			{Name: "access", Rules: []accessRule{{Prefix: "/admin", Allow: false}}},
+		},
+	})
+	if err != nil {
+		log.Fatalf("创建代理失败: %v", err)
+	}
+	defer nginxServer.close()
+	proxy, err := newHTTPProxy(nginxServer)
+	if err != nil {
+		log.Fatalf("创建反向代理失败: %v", err)
+	}
+	front := httptest.NewServer(proxy)
+	defer front.Close()
+
+	for _, path := range []string{"/app/status", "/app/status", "/admin", "/app/status", "/app/status"} {
+		resp, err := http.Get(front.URL + path)
+		if err != nil {
+			log.Fatalf("请求失败: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("\nUrl: %s\nHttpCode: %d\nRetry-After: %s\nBody: %s\n",
+			path, resp.StatusCode, resp.Header.Get("Retry-After"), strings.TrimSpace(string(body)))
+	}
+}
+
+// loadBalancingExample 演示上游组的负载均衡和健康检查
+func loadBalancingExample() {
+	// app-3 可以被手动"宕机"，用于演示摘除和恢复
+	var app3Down atomic.Bool
+	app3 := serverFunc(func(url, method string) (int, string) {
+		if app3Down.Load() {
+			return 503, "Service Unavailable"
+		}
+		return (&Application{}).handleRequest(url, method)
+	})
+
+	pool, err := newUpstreamPool("weightedRoundRobin", nil, healthCheckConfig{FailThreshold: 1, RiseThreshold: 1},
+		newBackend("app-1", &Application{}, 3),
+		newBackend("app-2", &Application{}, 1),
+		newBackend("app-3", app3, 1),
+	)
+	if err != nil {
+		log.Fatalf("创建上游组失败: %v", err)
+	}
+	nginxServer := newNginxWithUpstream(pool, nil, nil)
+
+	sendRequests := func(title string) {
+		for _, b := range pool.backends {
+			b.served.Store(0)
+		}
+		for i := 0; i < 10; i++ {
+			nginxServer.handleRequest("/app/status", "GET")
+		}
+		fmt.Printf("\n%s（健康后端: %v）\n", title, pool.healthyBackends())
+		for _, b := range pool.backends {
+			fmt.Printf("  %s (weight=%d): %d\n", b.name, b.weight, b.served.Load())
+		}
+	}
+
+	sendRequests("加权轮询分发10个请求")
+
+	app3Down.Store(true)
+	pool.checkHealth()
+	sendRequests("app-3宕机并被摘除后")
+
+	app3Down.Store(false)
+	pool.checkHealth()
+	sendRequests("app-3恢复后")
+}
+
+// cachingExample 演示响应缓存的命中统计和清除
+func cachingExample() {
+	cfg := ProxyConfig{
+		Cache: &CacheConfig{
+			TTL:        "1m",
+			MaxEntries: 100,
+			Routes:     []CacheRouteConfig{{Prefix: "/app/status", TTL: "5s"}},
+		},
+	}
+	nginxServer, err := newNginxFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("创建代理失败: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		nginxServer.handleRequest("/app/status", "GET")
+	}
+	nginxServer.handleRequest("/create/user", "POST")
+
+	s := nginxServer.cache.snapshot()
+	fmt.Printf("\n命中: %d, 未命中: %d, 条目数: %d, 命中率: %.2f\n", s.Hits, s.Misses, s.Entries, s.HitRatio())
+
+	nginxServer.cache.purge("/app/status", "GET")
+	nginxServer.handleRequest("/app/status", "GET")
+	s = nginxServer.cache.snapshot()
+	fmt.Printf("清除后再次请求 - 命中: %d, 未命中: %d\n", s.Hits, s.Misses)
 }
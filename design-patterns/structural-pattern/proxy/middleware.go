@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Middleware 中间件
+// 接收下一个server，返回包装后的server，所有中间件本身也都实现了server接口；
+// httpProxy为每个请求重新包装，计数、缓存等状态应该在创建中间件时分配，而不是在包装时；
+// httpProxy包装时next上附带了当前请求的exchange，需要请求头或响应头的中间件通过exchangeOf(next)取得
+type Middleware func(next server) server
+
+// serverFunc 函数适配器，让普通函数也能实现server接口
+type serverFunc func(url, method string) (int, string)
+
+func (f serverFunc) handleRequest(url, method string) (int, string) {
+	return f(url, method)
+}
+
+// chain 将中间件按顺序组装到目标server前面
+// 第一个中间件位于最外层，最先处理请求
+func chain(target server, middlewares ...Middleware) server {
+	return chainExchange(target, nil, middlewares...)
+}
+
+// chainExchange 与chain相同，x不为空时附加到每一层上，每个中间件都能从next取得x
+func chainExchange(target server, x *exchange, middlewares ...Middleware) server {
+	wrap := func(s server) server {
+		if x == nil {
+			return s
+		}
+		return exchangeServer{server: s, x: x}
+	}
+	h := wrap(target)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = wrap(middlewares[i](h))
+	}
+	return h
+}
+
+// exchangeServer 附带了当前请求的exchange的server
+type exchangeServer struct {
+	server
+	x *exchange
+}
+
+// exchangeOf 返回s上附带的exchange，直接调用handleRequest时没有exchange，返回nil
+func exchangeOf(s server) *exchange {
+	if e, ok := s.(exchangeServer); ok {
+		return e.x
+	}
+	return nil
+}
+
+// ============= 访问控制 =============
+
+// accessRule 访问控制规则
+// Prefix 匹配URL前缀，Methods为空时表示匹配所有方法
+type accessRule struct {
+	Prefix  string   `json:"prefix"`
+	Methods []string `json:"methods,omitempty"`
+	Allow   bool     `json:"allow"`
+}
+
+func (r accessRule) match(url, method string) bool {
+	if !strings.HasPrefix(url, r.Prefix) {
+		return false
+	}
+	if len(r.Methods) == 0 {
+		return true
+	}
+	for _, m := range r.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// accessControlMiddleware 按顺序匹配规则，命中的第一条规则决定是否放行
+// 没有规则命中时默认放行
+func accessControlMiddleware(rules []accessRule) Middleware {
+	return func(next server) server {
+		return serverFunc(func(url, method string) (int, string) {
+			for _, r := range rules {
+				if r.match(url, method) {
+					if !r.Allow {
+						return 403, "Forbidden"
+					}
+					break
+				}
+			}
+			return next.handleRequest(url, method)
+		})
+	}
+}
+
+// ============= 日志 =============
+
+// loggingMiddleware 记录每个请求的方法、URL、状态码和耗时
+func loggingMiddleware(logger *log.Logger) Middleware {
+	return func(next server) server {
+		return serverFunc(func(url, method string) (int, string) {
+			start := time.Now()
+			code, body := next.handleRequest(url, method)
+			logger.Printf("%s %s -> %d (%v)", method, url, code, time.Since(start))
+			return code, body
+		})
+	}
+}
+
+// ============= 缓存 =============
+
+// cachingMiddleware 创建带TTL的响应缓存中间件
+// 需要按路由设置TTL、限制条目数或者清除缓存时使用ProxyConfig.Cache
+func cachingMiddleware(ttl time.Duration) Middleware {
+	return newResponseCache(cacheOptions{DefaultTTL: ttl}, systemClock{}).middleware
+}
+
+// ============= 请求改写 =============
+
+// rewriteRule 将以From开头的URL前缀替换为To
+type rewriteRule struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// rewriteMiddleware 使用第一条匹配的规则改写URL后再转发
+func rewriteMiddleware(rules []rewriteRule) Middleware {
+	return func(next server) server {
+		return serverFunc(func(url, method string) (int, string) {
+			for _, r := range rules {
+				if strings.HasPrefix(url, r.From) {
+					url = r.To + strings.TrimPrefix(url, r.From)
+					break
+				}
+			}
+			return next.handleRequest(url, method)
+		})
+	}
+}
+
+// ============= 熔断 =============
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case stateClosed:
+		return "closed"
+	case stateOpen:
+		return "open"
+	case stateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// circuitBreaker 熔断器
+// 连续失败（5xx）达到阈值后打开，打开期间直接返回503；
+// 超过openTimeout后进入半开状态放行一个探测请求，成功则关闭，失败则重新打开
+type circuitBreaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	openTimeout      time.Duration
+	clock            Clock
+
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(failureThreshold int, openTimeout time.Duration, clock Clock) *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		clock:            clock,
+	}
+}
+
+// allow 判断当前请求是否可以通过熔断器
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if b.clock.Now().Sub(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = stateHalfOpen
+		b.probing = true
+		return true
+	case stateHalfOpen:
+		// 半开状态只允许一个探测请求
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// record 记录请求结果并更新熔断器状态
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.state = stateClosed
+		b.failures = 0
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.failureThreshold {
+		b.state = stateOpen
+		b.openedAt = b.clock.Now()
+		b.probing = false
+	}
+}
+
+func (b *circuitBreaker) currentState() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *circuitBreaker) middleware(next server) server {
+	return serverFunc(func(url, method string) (int, string) {
+		if !b.allow() {
+			return 503, "Service Unavailable"
+		}
+		code, body := next.handleRequest(url, method)
+		b.record(code < 500)
+		return code, body
+	})
+}
+
+// circuitBreakerMiddleware 创建熔断中间件
+func circuitBreakerMiddleware(failureThreshold int, openTimeout time.Duration) Middleware {
+	return newCircuitBreaker(failureThreshold, openTimeout, systemClock{}).middleware
+}
+
+// ============= 配置 =============
+
+// middlewareFactory 根据配置创建中间件
+type middlewareFactory func(spec MiddlewareSpec) (Middleware, error)
+
+// middlewareRegistry 中间件名称到工厂函数的映射
+var middlewareRegistry = map[string]middlewareFactory{
+	"access": func(spec MiddlewareSpec) (Middleware, error) {
+		return accessControlMiddleware(spec.Rules), nil
+	},
+	"logging": func(spec MiddlewareSpec) (Middleware, error) {
+		return loggingMiddleware(log.Default()), nil
+	},
+	"cache": func(spec MiddlewareSpec) (Middleware, error) {
+		ttl, err := spec.duration("ttl", time.Minute)
+		if err != nil {
+			return nil, err
+		}
+		return cachingMiddleware(ttl), nil
+	},
+	"rewrite": func(spec MiddlewareSpec) (Middleware, error) {
+		return rewriteMiddleware(spec.Rewrites), nil
+	},
+	"circuitBreaker": func(spec MiddlewareSpec) (Middleware, error) {
+		timeout, err := spec.duration("openTimeout", 10*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		threshold := spec.Threshold
+		if threshold <= 0 {
+			threshold = 5
+		}
+		return circuitBreakerMiddleware(threshold, timeout), nil
+	},
+}
+
+// MiddlewareSpec 单个中间件的配置
+type MiddlewareSpec struct {
+	Name      string            `json:"name"`
+	Rules     []accessRule      `json:"rules,omitempty"`
+	Rewrites  []rewriteRule     `json:"rewrites,omitempty"`
+	Threshold int               `json:"threshold,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+}
+
+// duration 读取Params中的时间参数，不存在时返回默认值
+func (s MiddlewareSpec) duration(key string, def time.Duration) (time.Duration, error) {
+	v, ok := s.Params[key]
+	if !ok {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("middleware %s: invalid %s %q: %w", s.Name, key, v, err)
+	}
+	return d, nil
+}
+
+// buildMiddlewares 根据配置按顺序创建中间件
+func buildMiddlewares(specs []MiddlewareSpec) ([]Middleware, error) {
+	middlewares := make([]Middleware, 0, len(specs))
+	for _, spec := range specs {
+		factory, ok := middlewareRegistry[spec.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %q", spec.Name)
+		}
+		mw, err := factory(spec)
+		if err != nil {
+			return nil, err
+		}
+		middlewares = append(middlewares, mw)
+	}
+	return middlewares, nil
+}
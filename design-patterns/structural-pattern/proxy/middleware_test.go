@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingServer 记录收到的请求，并返回预设的响应
+type recordingServer struct {
+	calls []string
+	code  int
+	body  string
+}
+
+func (s *recordingServer) handleRequest(url, method string) (int, string) {
+	s.calls = append(s.calls, method+" "+url)
+	return s.code, s.body
+}
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next server) server {
+			return serverFunc(func(url, method string) (int, string) {
+				order = append(order, name)
+				return next.handleRequest(url, method)
+			})
+		}
+	}
+
+	backend := &recordingServer{code: 200, body: "Ok"}
+	h := chain(backend, mark("a"), mark("b"), mark("c"))
+	h.handleRequest("/x", "GET")
+
+	if got := strings.Join(order, ","); got != "a,b,c" {
+		t.Fatalf("middleware order = %s, want a,b,c", got)
+	}
+	if len(backend.calls) != 1 {
+		t.Fatalf("backend called %d times, want 1", len(backend.calls))
+	}
+}
+
+func TestAccessControlMiddleware(t *testing.T) {
+	rules := []accessRule{
+		{Prefix: "/admin/health", Allow: true},
+		{Prefix: "/admin", Allow: false},
+		{Prefix: "/create", Methods: []string{"DELETE"}, Allow: false},
+	}
+	backend := &recordingServer{code: 200, body: "Ok"}
+	h := accessControlMiddleware(rules)(backend)
+
+	tests := []struct {
+		url, method string
+		want        int
+	}{
+		{"/admin/health", "GET", 200},
+		{"/admin/users", "GET", 403},
+		{"/create/user", "POST", 200},
+		{"/create/user", "delete", 403},
+		{"/app/status", "GET", 200},
+	}
+	for _, tt := range tests {
+		if code, _ := h.handleRequest(tt.url, tt.method); code != tt.want {
+			t.Errorf("%s %s: code = %d, want %d", tt.method, tt.url, code, tt.want)
+		}
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	backend := &recordingServer{code: 201, body: "User Created"}
+	h := loggingMiddleware(log.New(&buf, "", 0))(backend)
+
+	code, body := h.handleRequest("/create/user", "POST")
+	if code != 201 || body != "User Created" {
+		t.Fatalf("got (%d, %q), want (201, %q)", code, body, "User Created")
+	}
+	if !strings.Contains(buf.String(), "POST /create/user -> 201") {
+		t.Fatalf("log output = %q", buf.String())
+	}
+}
+
+func TestCachingMiddleware(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := newResponseCache(cacheOptions{DefaultTTL: time.Minute}, clock)
+
+	backend := &recordingServer{code: 200, body: "Ok"}
+	h := c.middleware(backend)
+
+	h.handleRequest("/app/status", "GET")
+	h.handleRequest("/app/status", "GET")
+	if len(backend.calls) != 1 {
+		t.Fatalf("backend called %d times, want 1 (second GET should hit cache)", len(backend.calls))
+	}
+
+	// 非GET请求不缓存
+	h.handleRequest("/app/status", "POST")
+	h.handleRequest("/app/status", "POST")
+	if len(backend.calls) != 3 {
+		t.Fatalf("backend called %d times, want 3", len(backend.calls))
+	}
+
+	// 过期后重新请求后端
+	clock.Advance(2 * time.Minute)
+	h.handleRequest("/app/status", "GET")
+	if len(backend.calls) != 4 {
+		t.Fatalf("backend called %d times, want 4 after expiry", len(backend.calls))
+	}
+}
+
+func TestCachingMiddlewareSkipsErrors(t *testing.T) {
+	backend := &recordingServer{code: 404, body: "Not Ok"}
+	h := cachingMiddleware(time.Minute)(backend)
+
+	h.handleRequest("/missing", "GET")
+	h.handleRequest("/missing", "GET")
+	if len(backend.calls) != 2 {
+		t.Fatalf("backend called %d times, want 2 (errors must not be cached)", len(backend.calls))
+	}
+}
+
+func TestRewriteMiddleware(t *testing.T) {
+	backend := &recordingServer{code: 200, body: "Ok"}
+	h := rewriteMiddleware([]rewriteRule{
+		{From: "/v1/", To: "/"},
+		{From: "/legacy", To: "/app"},
+	})(backend)
+
+	h.handleRequest("/v1/app/status", "GET")
+	h.handleRequest("/legacy/status", "GET")
+	h.handleRequest("/other", "GET")
+
+	want := []string{"GET /app/status", "GET /app/status", "GET /other"}
+	for i, w := range want {
+		if backend.calls[i] != w {
+			t.Errorf("call %d = %q, want %q", i, backend.calls[i], w)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := newCircuitBreaker(2, 10*time.Second, clock)
+
+	backend := &recordingServer{code: 500, body: "boom"}
+	h := b.middleware(backend)
+
+	// 连续两次失败后熔断打开
+	h.handleRequest("/app/status", "GET")
+	h.handleRequest("/app/status", "GET")
+	if b.currentState() != stateOpen {
+		t.Fatalf("state = %v, want open", b.currentState())
+	}
+	if code, _ := h.handleRequest("/app/status", "GET"); code != 503 {
+		t.Fatalf("open breaker code = %d, want 503", code)
+	}
+	if len(backend.calls) != 2 {
+		t.Fatalf("backend called %d times, want 2", len(backend.calls))
+	}
+
+	// 超时后半开，探测失败重新打开
+	clock.Advance(11 * time.Second)
+	h.handleRequest("/app/status", "GET")
+	if b.currentState() != stateOpen {
+		t.Fatalf("state after failed probe = %v, want open", b.currentState())
+	}
+
+	// 再次超时后探测成功，熔断关闭
+	clock.Advance(11 * time.Second)
+	backend.code = 200
+	if code, _ := h.handleRequest("/app/status", "GET"); code != 200 {
+		t.Fatalf("probe code = %d, want 200", code)
+	}
+	if b.currentState() != stateClosed {
+		t.Fatalf("state after successful probe = %v, want closed", b.currentState())
+	}
+}
+
+func TestNewNginxFromConfig(t *testing.T) {
+	cfg, err := loadProxyConfig(strings.NewReader(`{
+		"rateLimit": {"algorithm": "fixedWindow", "limit": 1, "window": "1m"},
+		"middlewares": [
+			{"name": "access", "rules": [{"prefix": "/admin", "allow": false}]},
+			{"name": "rewrite", "rewrites": [{"from": "/v1/", "to": "/"}]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := newNginxFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code, _ := n.handleRequest("/v1/app/status", "GET"); code != 200 {
+		t.Errorf("rewritten request code = %d, want 200", code)
+	}
+	if code, _ := n.handleRequest("/admin", "GET"); code != 403 {
+		t.Errorf("admin request code = %d, want 403", code)
+	}
+	if code, body := n.handleRequest("/v1/app/status", "GET"); code != 403 || body != "Not Allowed" {
+		t.Errorf("rate limited request = (%d, %q), want (403, Not Allowed)", code, body)
+	}
+}
+
+func TestBuildMiddlewaresErrors(t *testing.T) {
+	if _, err := buildMiddlewares([]MiddlewareSpec{{Name: "unknown"}}); err == nil {
+		t.Error("expected error for unknown middleware")
+	}
+	if _, err := buildMiddlewares([]MiddlewareSpec{{Name: "cache", Params: map[string]string{"ttl": "soon"}}}); err == nil {
+		t.Error("expected error for invalid ttl")
+	}
+	if _, err := buildMiddlewares([]MiddlewareSpec{{Name: "circuitBreaker", Params: map[string]string{"openTimeout": "soon"}}}); err == nil {
+		t.Error("expected error for invalid openTimeout")
+	}
+}
@@ -1,40 +1,136 @@
 package main
 
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
 // Nginx 代理类，实现了server接口
-// 提供访问控制、限流等功能
+// 提供访问控制、限流等功能，具体行为由中间件链组合而成
 type Nginx struct {
-	application       *Application
-	maxAllowedRequest int
-	rateLimiter       map[string]int
+	upstream    server
+	middlewares []Middleware
+	handler     server
+	limiter     RateLimiter
+	limitKey    KeyFunc
+	cache       *responseCache
+}
+
+// ProxyConfig 代理配置
+// RateLimit 不为空时在中间件链之前启用限流
+// Upstream 不为空时请求转发给上游组，否则转发给单个Application
+// Cache 不为空时在中间件链最内层启用响应缓存，限流、访问控制等检查仍然作用于缓存命中的请求
+type ProxyConfig struct {
+	RateLimit   *RateLimitConfig `json:"rateLimit,omitempty"`
+	Upstream    *UpstreamConfig  `json:"upstream,omitempty"`
+	Cache       *CacheConfig     `json:"cache,omitempty"`
+	Middlewares []MiddlewareSpec `json:"middlewares"`
 }
 
 // newNginxServer 创建新的Nginx代理实例
+// 默认每个URL每分钟最多放行2个请求
 func newNginxServer() *Nginx {
-	return &Nginx{
-		application:       &Application{},
-		maxAllowedRequest: 2,
-		rateLimiter:       make(map[string]int),
+	limiter := newFixedWindowLimiter(2, time.Minute, systemClock{})
+	return newNginx(limiter, keyByURL)
+}
+
+// newNginx 创建使用指定限流器和中间件链的Nginx代理实例，请求最终转发给一个Application
+// limiter 不为空时请求先经过限流，再进入中间件链
+func newNginx(limiter RateLimiter, limitKey KeyFunc, middlewares ...Middleware) *Nginx {
+	return newNginxWithUpstream(&Application{}, limiter, limitKey, middlewares...)
+}
+
+// newNginxWithUpstream 创建请求最终转发给upstream的Nginx代理实例
+// upstream 可以是单个Application，也可以是上游组
+func newNginxWithUpstream(upstream server, limiter RateLimiter, limitKey KeyFunc, middlewares ...Middleware) *Nginx {
+	n := &Nginx{
+		upstream:    upstream,
+		middlewares: middlewares,
+		limiter:     limiter,
+		limitKey:    limitKey,
 	}
+	n.handler = chain(n.upstream, middlewares...)
+	return n
 }
 
-// handleRequest 处理客户端请求
-// 先进行限流检查，然后转发给真实的应用服务器
-func (n *Nginx) handleRequest(url, method string) (int, string) {
-	allowed := n.checkRateLimiting(url)
-	if !allowed {
-		return 403, "Not Allowed"
+// allow 限流检查，client为客户端标识，没有客户端信息时为空字符串
+// limitKey取不到key时（例如按client限流但没有客户端信息）拒绝请求
+func (n *Nginx) allow(url, method, client string) (bool, time.Duration) {
+	if n.limiter == nil {
+		return true, 0
+	}
+	key := n.limitKey(url, method, client)
+	if key == "" {
+		return false, 0
+	}
+	return n.limiter.Allow(key)
+}
+
+// newNginxFromConfig 根据配置创建Nginx代理实例
+func newNginxFromConfig(cfg ProxyConfig) (*Nginx, error) {
+	middlewares, err := buildMiddlewares(cfg.Middlewares)
+	if err != nil {
+		return nil, err
+	}
+
+	var limiter RateLimiter
+	var key KeyFunc
+	if cfg.RateLimit != nil {
+		if limiter, key, err = newRateLimiter(*cfg.RateLimit, systemClock{}); err != nil {
+			return nil, err
+		}
+	}
+
+	var cache *responseCache
+	if cfg.Cache != nil {
+		if cache, err = newResponseCacheFromConfig(*cfg.Cache, systemClock{}); err != nil {
+			return nil, err
+		}
+		middlewares = append(middlewares, cache.middleware)
+	}
+
+	var upstream server = &Application{}
+	if cfg.Upstream != nil {
+		pool, err := newUpstreamPoolFromConfig(*cfg.Upstream)
+		if err != nil {
+			return nil, err
+		}
+		pool.startHealthChecks()
+		upstream = pool
 	}
-	return n.application.handleRequest(url, method)
+
+	n := newNginxWithUpstream(upstream, limiter, key, middlewares...)
+	n.cache = cache
+	return n, nil
 }
 
-// checkRateLimiting 检查请求是否超过限流阈值
-func (n *Nginx) checkRateLimiting(url string) bool {
-	if n.rateLimiter[url] == 0 {
-		n.rateLimiter[url] = 1
+// close 停止上游组的后台健康检查
+func (n *Nginx) close() {
+	if pool, ok := n.upstream.(*upstreamPool); ok {
+		pool.stopHealthChecks()
 	}
-	if n.rateLimiter[url] > n.maxAllowedRequest {
-		return false
+}
+
+// loadProxyConfig 从JSON读取代理配置
+func loadProxyConfig(r io.Reader) (ProxyConfig, error) {
+	var cfg ProxyConfig
+	if err := json.NewDecoder(r).Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("decode proxy config: %w", err)
+	}
+	return cfg, nil
+}
+
+// localClient 直接调用handleRequest的请求的客户端标识
+// server接口中没有客户端信息，这些请求都来自同一个进程内的调用方，按client限流时共享同一个配额
+const localClient = "local"
+
+// handleRequest 处理客户端请求
+// 请求先经过限流，再依次经过中间件链，最后转发给真实的应用服务器
+func (n *Nginx) handleRequest(url, method string) (int, string) {
+	if ok, _ := n.allow(url, method, localClient); !ok {
+		return 403, "Not Allowed"
 	}
-	n.rateLimiter[url] = n.rateLimiter[url] + 1
-	return true
+	return n.handler.handleRequest(url, method)
 }
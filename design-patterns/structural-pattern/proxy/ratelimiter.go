@@ -0,0 +1,338 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter 限流器接口
+// Allow 判断key对应的请求是否放行，被拒绝时返回建议的重试等待时间
+// 所有实现都是并发安全的；空闲的key在之后的Allow中被清理，
+// 清理只删除与新key状态相同的条目，不影响限流结果
+type RateLimiter interface {
+	Allow(key string) (bool, time.Duration)
+}
+
+// KeyFunc 从请求中提取限流的key
+// client 为客户端标识：httpProxy中是客户端IP，直接调用Nginx.handleRequest时是localClient；
+// 返回空字符串表示请求中没有所需的信息，这样的请求直接拒绝，而不是所有请求共享同一个key
+type KeyFunc func(url, method, client string) string
+
+// keyByURL 按URL限流
+func keyByURL(url, method, client string) string { return url }
+
+// keyByMethod 按请求方法限流
+func keyByMethod(url, method, client string) string { return method }
+
+// keyByClient 按客户端限流
+func keyByClient(url, method, client string) string { return client }
+
+// keyFuncs 配置中可用的key类型
+var keyFuncs = map[string]KeyFunc{
+	"url":    keyByURL,
+	"method": keyByMethod,
+	"client": keyByClient,
+}
+
+// ============= 令牌桶 =============
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// tokenBucketLimiter 令牌桶算法
+// 桶容量为burst，每秒以rate的速度补充令牌，每个请求消耗一个令牌，允许突发流量
+type tokenBucketLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	clock     Clock
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newTokenBucketLimiter(rate float64, burst int, clock Clock) *tokenBucketLimiter {
+	return &tokenBucketLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		clock:   clock,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+func (l *tokenBucketLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	// 按流逝的时间补充令牌
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, secondsToDuration((1 - b.tokens) / l.rate)
+}
+
+// sweep 每隔装满一桶所需的时间删除已经装满的桶，调用方必须持有l.mu
+func (l *tokenBucketLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep).Seconds()*l.rate < l.burst {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// ============= 漏桶 =============
+
+type leakyBucket struct {
+	level float64
+	last  time.Time
+}
+
+// leakyBucketLimiter 漏桶算法
+// 每个请求向桶中注入一个单位的水，桶以rate的速度匀速漏水，水满（capacity）时拒绝请求
+type leakyBucketLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	capacity  float64
+	clock     Clock
+	buckets   map[string]*leakyBucket
+	lastSweep time.Time
+}
+
+func newLeakyBucketLimiter(rate float64, capacity int, clock Clock) *leakyBucketLimiter {
+	return &leakyBucketLimiter{
+		rate:     rate,
+		capacity: float64(capacity),
+		clock:    clock,
+		buckets:  make(map[string]*leakyBucket),
+	}
+}
+
+func (l *leakyBucketLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &leakyBucket{last: now}
+		l.buckets[key] = b
+	}
+
+	// 按流逝的时间漏水
+	b.level = math.Max(0, b.level-now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.level+1 <= l.capacity {
+		b.level++
+		return true, 0
+	}
+	return false, secondsToDuration((b.level + 1 - l.capacity) / l.rate)
+}
+
+// sweep 每隔漏空一桶所需的时间删除已经漏空的桶，调用方必须持有l.mu
+func (l *leakyBucketLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep).Seconds()*l.rate < l.capacity {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.level <= now.Sub(b.last).Seconds()*l.rate {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// ============= 固定窗口 =============
+
+type fixedWindow struct {
+	start time.Time
+	count int
+}
+
+// fixedWindowLimiter 固定窗口计数
+// 时间按window切分成固定窗口，每个窗口内最多放行limit个请求，窗口切换时计数清零
+type fixedWindowLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	clock     Clock
+	windows   map[string]*fixedWindow
+	lastSweep time.Time
+}
+
+func newFixedWindowLimiter(limit int, window time.Duration, clock Clock) *fixedWindowLimiter {
+	return &fixedWindowLimiter{
+		limit:   limit,
+		window:  window,
+		clock:   clock,
+		windows: make(map[string]*fixedWindow),
+	}
+}
+
+func (l *fixedWindowLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	start := now.Truncate(l.window)
+	l.sweep(start)
+	w, ok := l.windows[key]
+	if !ok || !w.start.Equal(start) {
+		w = &fixedWindow{start: start}
+		l.windows[key] = w
+	}
+
+	if w.count < l.limit {
+		w.count++
+		return true, 0
+	}
+	return false, start.Add(l.window).Sub(now)
+}
+
+// sweep 进入新窗口时删除之前窗口的计数，调用方必须持有l.mu
+func (l *fixedWindowLimiter) sweep(start time.Time) {
+	if l.lastSweep.Equal(start) {
+		return
+	}
+	l.lastSweep = start
+	for key, w := range l.windows {
+		if !w.start.Equal(start) {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// ============= 滑动窗口日志 =============
+
+// slidingWindowLogLimiter 滑动窗口日志
+// 记录每个放行请求的时间戳，任意长度为window的时间段内最多放行limit个请求
+type slidingWindowLogLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	clock     Clock
+	logs      map[string][]time.Time
+	lastSweep time.Time
+}
+
+func newSlidingWindowLogLimiter(limit int, window time.Duration, clock Clock) *slidingWindowLogLimiter {
+	return &slidingWindowLogLimiter{
+		limit:  limit,
+		window: window,
+		clock:  clock,
+		logs:   make(map[string][]time.Time),
+	}
+}
+
+func (l *slidingWindowLogLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	boundary := now.Add(-l.window)
+	l.sweep(now, boundary)
+
+	// 丢弃窗口之外的记录
+	entries := l.logs[key]
+	i := 0
+	for i < len(entries) && !entries[i].After(boundary) {
+		i++
+	}
+	entries = entries[i:]
+
+	if len(entries) < l.limit {
+		l.logs[key] = append(entries, now)
+		return true, 0
+	}
+	l.logs[key] = entries
+	return false, entries[0].Add(l.window).Sub(now)
+}
+
+// sweep 每隔一个窗口删除最后一条记录也已经滑出窗口的key，调用方必须持有l.mu
+func (l *slidingWindowLogLimiter) sweep(now, boundary time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, entries := range l.logs {
+		if len(entries) == 0 || !entries[len(entries)-1].After(boundary) {
+			delete(l.logs, key)
+		}
+	}
+}
+
+// secondsToDuration 将秒数转换为Duration，向上取整到纳秒
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+// ============= 配置 =============
+
+// RateLimitConfig 限流配置
+// Algorithm 可选 tokenBucket、leakyBucket、fixedWindow、slidingWindowLog
+// 令牌桶和漏桶使用 Rate（每秒）和 Burst（桶容量），窗口类算法使用 Limit 和 Window
+// Key 可选 url、method、client，默认为 url
+type RateLimitConfig struct {
+	Algorithm string  `json:"algorithm"`
+	Key       string  `json:"key,omitempty"`
+	Rate      float64 `json:"rate,omitempty"`
+	Burst     int     `json:"burst,omitempty"`
+	Limit     int     `json:"limit,omitempty"`
+	Window    string  `json:"window,omitempty"`
+}
+
+// newRateLimiter 根据配置创建限流器和对应的KeyFunc
+func newRateLimiter(cfg RateLimitConfig, clock Clock) (RateLimiter, KeyFunc, error) {
+	keyName := cfg.Key
+	if keyName == "" {
+		keyName = "url"
+	}
+	keyFunc, ok := keyFuncs[keyName]
+	if !ok {
+		return nil, nil, fmt.Errorf("rate limit: unknown key %q", cfg.Key)
+	}
+
+	switch cfg.Algorithm {
+	case "tokenBucket", "leakyBucket":
+		if cfg.Rate <= 0 || cfg.Burst <= 0 {
+			return nil, nil, fmt.Errorf("rate limit %s: rate and burst must be positive", cfg.Algorithm)
+		}
+		if cfg.Algorithm == "tokenBucket" {
+			return newTokenBucketLimiter(cfg.Rate, cfg.Burst, clock), keyFunc, nil
+		}
+		return newLeakyBucketLimiter(cfg.Rate, cfg.Burst, clock), keyFunc, nil
+	case "fixedWindow", "slidingWindowLog":
+		window, err := time.ParseDuration(cfg.Window)
+		if err != nil {
+			return nil, nil, fmt.Errorf("rate limit %s: invalid window %q: %w", cfg.Algorithm, cfg.Window, err)
+		}
+		if cfg.Limit <= 0 || window <= 0 {
+			return nil, nil, fmt.Errorf("rate limit %s: limit and window must be positive", cfg.Algorithm)
+		}
+		if cfg.Algorithm == "fixedWindow" {
+			return newFixedWindowLimiter(cfg.Limit, window, clock), keyFunc, nil
+		}
+		return newSlidingWindowLogLimiter(cfg.Limit, window, clock), keyFunc, nil
+	}
+	return nil, nil, fmt.Errorf("rate limit: unknown algorithm %q", cfg.Algorithm)
+}
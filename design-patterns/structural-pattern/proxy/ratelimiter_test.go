@@ -0,0 +1,251 @@
+package main
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock 可手动推进的时钟，用于确定性测试
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// expectAllow 连续调用Allow并检查每次的结果
+func expectAllow(t *testing.T, l RateLimiter, key string, want ...bool) {
+	t.Helper()
+	for i, w := range want {
+		if got, _ := l.Allow(key); got != w {
+			t.Fatalf("call %d for %q: allowed = %v, want %v", i, key, got, w)
+		}
+	}
+}
+
+func TestTokenBucketLimiter(t *testing.T) {
+	clock := newFakeClock()
+	l := newTokenBucketLimiter(2, 3, clock) // 每秒2个令牌，桶容量3
+
+	// 允许突发3个请求
+	expectAllow(t, l, "a", true, true, true, false)
+
+	// 拒绝时返回补充一个令牌所需的时间
+	if ok, retry := l.Allow("a"); ok || retry != 500*time.Millisecond {
+		t.Fatalf("Allow = (%v, %v), want (false, 500ms)", ok, retry)
+	}
+
+	// 0.5秒补充1个令牌
+	clock.Advance(500 * time.Millisecond)
+	expectAllow(t, l, "a", true, false)
+
+	// 长时间空闲后令牌数不超过桶容量
+	clock.Advance(time.Hour)
+	expectAllow(t, l, "a", true, true, true, false)
+
+	// 不同key互不影响
+	expectAllow(t, l, "b", true, true, true, false)
+}
+
+func TestLeakyBucketLimiter(t *testing.T) {
+	clock := newFakeClock()
+	l := newLeakyBucketLimiter(1, 2, clock) // 每秒漏出1个，容量2
+
+	expectAllow(t, l, "a", true, true, false)
+	if ok, retry := l.Allow("a"); ok || retry != time.Second {
+		t.Fatalf("Allow = (%v, %v), want (false, 1s)", ok, retry)
+	}
+
+	clock.Advance(time.Second)
+	expectAllow(t, l, "a", true, false)
+
+	clock.Advance(2 * time.Second)
+	expectAllow(t, l, "a", true, true, false)
+}
+
+func TestFixedWindowLimiter(t *testing.T) {
+	clock := newFakeClock()
+	l := newFixedWindowLimiter(2, time.Minute, clock)
+
+	expectAllow(t, l, "/app/status", true, true, false)
+
+	clock.Advance(45 * time.Second)
+	if ok, retry := l.Allow("/app/status"); ok || retry != 15*time.Second {
+		t.Fatalf("Allow = (%v, %v), want (false, 15s)", ok, retry)
+	}
+
+	// 进入下一个窗口后计数清零
+	clock.Advance(15 * time.Second)
+	expectAllow(t, l, "/app/status", true, true, false)
+}
+
+func TestSlidingWindowLogLimiter(t *testing.T) {
+	clock := newFakeClock()
+	l := newSlidingWindowLogLimiter(2, time.Minute, clock)
+
+	expectAllow(t, l, "a", true)
+	clock.Advance(40 * time.Second)
+	expectAllow(t, l, "a", true, false)
+
+	// 固定窗口在此时已经重置，滑动窗口仍然包含40秒前的请求
+	clock.Advance(10 * time.Second)
+	if ok, retry := l.Allow("a"); ok || retry != 10*time.Second {
+		t.Fatalf("Allow = (%v, %v), want (false, 10s)", ok, retry)
+	}
+
+	// 第一个请求滑出窗口
+	clock.Advance(10 * time.Second)
+	expectAllow(t, l, "a", true, false)
+}
+
+func TestNewRateLimiter(t *testing.T) {
+	clock := newFakeClock()
+	valid := []RateLimitConfig{
+		{Algorithm: "tokenBucket", Rate: 1, Burst: 1},
+		{Algorithm: "leakyBucket", Rate: 1, Burst: 1, Key: "method"},
+		{Algorithm: "fixedWindow", Limit: 1, Window: "1s", Key: "client"},
+		{Algorithm: "slidingWindowLog", Limit: 1, Window: "1s", Key: "url"},
+	}
+	for _, cfg := range valid {
+		if _, _, err := newRateLimiter(cfg, clock); err != nil {
+			t.Errorf("newRateLimiter(%+v) error: %v", cfg, err)
+		}
+	}
+
+	invalid := []RateLimitConfig{
+		{Algorithm: "unknown"},
+		{Algorithm: "tokenBucket", Rate: 0, Burst: 1},
+		{Algorithm: "fixedWindow", Limit: 1, Window: "soon"},
+		{Algorithm: "slidingWindowLog", Limit: 0, Window: "1s"},
+		{Algorithm: "fixedWindow", Limit: 1, Window: "1s", Key: "header"},
+	}
+	for _, cfg := range invalid {
+		if _, _, err := newRateLimiter(cfg, clock); err == nil {
+			t.Errorf("newRateLimiter(%+v) expected error", cfg)
+		}
+	}
+}
+
+func TestKeyFuncs(t *testing.T) {
+	clock := newFakeClock()
+	l := newFixedWindowLimiter(1, time.Minute, clock)
+	h := newNginx(l, keyByMethod)
+
+	if code, _ := h.handleRequest("/app/status", "GET"); code != 200 {
+		t.Fatalf("first GET code = %d, want 200", code)
+	}
+	// 按方法限流时，不同URL共享同一个GET配额
+	if code, _ := h.handleRequest("/create/user", "GET"); code != 403 {
+		t.Fatalf("second GET code = %d, want 403", code)
+	}
+	if code, _ := h.handleRequest("/create/user", "POST"); code != 201 {
+		t.Fatalf("POST code = %d, want 201", code)
+	}
+
+	// 直接调用handleRequest的请求都来自同一个调用方，按client限流时共享同一个配额
+	n := newNginx(newFixedWindowLimiter(1, time.Minute, clock), keyByClient)
+	if code, _ := n.handleRequest("/app/status", "GET"); code != 200 {
+		t.Fatalf("first local request code = %d, want 200", code)
+	}
+	if code, _ := n.handleRequest("/create/user", "POST"); code != 403 {
+		t.Fatalf("second local request code = %d, want 403", code)
+	}
+
+	// KeyFunc取不到key时拒绝请求
+	none := newNginx(newFixedWindowLimiter(100, time.Minute, clock), func(url, method, client string) string { return "" })
+	if code, _ := none.handleRequest("/app/status", "GET"); code != 403 {
+		t.Fatalf("request without key code = %d, want 403", code)
+	}
+}
+
+// TestRateLimitersEvictIdleKeys 空闲的key被清理，清理后限流结果不变
+func TestRateLimitersEvictIdleKeys(t *testing.T) {
+	clock := newFakeClock()
+	tokens := newTokenBucketLimiter(1, 2, clock)
+	leaky := newLeakyBucketLimiter(1, 2, clock)
+	fixed := newFixedWindowLimiter(2, time.Minute, clock)
+	sliding := newSlidingWindowLogLimiter(2, time.Minute, clock)
+	limiters := map[string]RateLimiter{
+		"tokenBucket":      tokens,
+		"leakyBucket":      leaky,
+		"fixedWindow":      fixed,
+		"slidingWindowLog": sliding,
+	}
+	size := map[string]func() int{
+		"tokenBucket":      func() int { return len(tokens.buckets) },
+		"leakyBucket":      func() int { return len(leaky.buckets) },
+		"fixedWindow":      func() int { return len(fixed.windows) },
+		"slidingWindowLog": func() int { return len(sliding.logs) },
+	}
+
+	for name, l := range limiters {
+		for i := 0; i < 100; i++ {
+			l.Allow("client-" + strconv.Itoa(i))
+		}
+		expectAllow(t, l, "busy", true, true, false)
+		if n := size[name](); n != 101 {
+			t.Fatalf("%s: keys = %d, want 101", name, n)
+		}
+	}
+
+	// 一个窗口（或者装满、漏空一桶的时间）之后，只剩下这次请求的key
+	clock.Advance(2 * time.Minute)
+	for name, l := range limiters {
+		expectAllow(t, l, "busy", true, true, false)
+		if n := size[name](); n != 1 {
+			t.Errorf("%s: keys after idle = %d, want 1", name, n)
+		}
+	}
+}
+
+// TestRateLimitersConcurrent 在 go test -race 下验证并发安全，
+// 并检查并发请求下放行的数量不超过配额
+func TestRateLimitersConcurrent(t *testing.T) {
+	clock := newFakeClock()
+	limiters := map[string]RateLimiter{
+		"tokenBucket":      newTokenBucketLimiter(1, 50, clock),
+		"leakyBucket":      newLeakyBucketLimiter(1, 50, clock),
+		"fixedWindow":      newFixedWindowLimiter(50, time.Minute, clock),
+		"slidingWindowLog": newSlidingWindowLogLimiter(50, time.Minute, clock),
+	}
+
+	for name, l := range limiters {
+		t.Run(name, func(t *testing.T) {
+			var allowed int64
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 20; j++ {
+						if ok, _ := l.Allow("shared"); ok {
+							atomic.AddInt64(&allowed, 1)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			if allowed != 50 {
+				t.Fatalf("allowed = %d, want 50", allowed)
+			}
+		})
+	}
+}